@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/bootstrap"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// apply reconciles the database with a bootstrap file, e.g.
+//
+//	xoidc_server apply -f bootstrap.yaml -dry-run
+func apply(s *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	path := fs.String("f", "", "bootstrap file")
+	prune := fs.Bool("prune", false, "delete the scopes and clients missing from the file in the namespaces it declares, and revoke the admin flag of users it does not name")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+	_ = fs.Parse(args)
+	if *path == "" {
+		return errors.New("apply: -f is required")
+	}
+
+	ctx := context.Background()
+	plan, err := planBootstrap(ctx, s, *path, *prune)
+	if err != nil {
+		return err
+	}
+	err = plan.Write(os.Stdout)
+	if err != nil || *dryRun {
+		return err
+	}
+	return s.ApplyBootstrap(ctx, plan)
+}
+
+func planBootstrap(ctx context.Context, s *storage.Storage, path string, prune bool) (*bootstrap.Plan, error) {
+	cfg, err := bootstrap.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.PlanBootstrap(ctx, cfg, prune)
+}
+
+// bootstrapAtStartup applies the file of XOIDC_BOOTSTRAP_FILE before serving,
+// XOIDC_BOOTSTRAP_PRUNE=true prunes like apply -prune
+func bootstrapAtStartup(s *storage.Storage) error {
+	path := os.Getenv("XOIDC_BOOTSTRAP_FILE")
+	if path == "" {
+		return nil
+	}
+	ctx := context.Background()
+	plan, err := planBootstrap(ctx, s, path, os.Getenv("XOIDC_BOOTSTRAP_PRUNE") == "true")
+	if err != nil {
+		return err
+	}
+	for _, c := range plan.Changes {
+		log.Infof("bootstrap: %s", c)
+	}
+	return s.ApplyBootstrap(ctx, plan)
+}
@@ -1,22 +1,40 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/zltl/xoidc/server/internal/pkg/api"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+	"github.com/zltl/xoidc/server/internal/pkg/clientreg"
 	"github.com/zltl/xoidc/server/internal/pkg/exampleop"
+	"github.com/zltl/xoidc/server/internal/pkg/mailer"
+	"github.com/zltl/xoidc/server/internal/pkg/ratelimit"
+	"github.com/zltl/xoidc/server/internal/pkg/scim"
+	"github.com/zltl/xoidc/server/internal/pkg/sms"
 	"github.com/zltl/xoidc/server/internal/pkg/storage"
+	"github.com/zltl/xoidc/server/pkg/password"
 	"golang.org/x/exp/slog"
 )
 
 func main() {
 	//we will run on :9998
 	port := "9998"
+	// with XOIDC_TLS_CERT_FILE and XOIDC_TLS_KEY_FILE we serve https, which
+	// mutual TLS clients need
+	certFile, keyFile := os.Getenv("XOIDC_TLS_CERT_FILE"), os.Getenv("XOIDC_TLS_KEY_FILE")
+	scheme := "http"
+	if certFile != "" {
+		scheme = "https"
+	}
 	//which gives us the issuer: http://localhost:9998/
-	issuer := fmt.Sprintf("http://localhost:%s/", port)
+	issuer := fmt.Sprintf("%s://localhost:%s/", scheme, port)
 
 	log.SetFormatter(&log.TextFormatter{
 		DisableQuote:  true,
@@ -42,6 +60,39 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// new password hashes use these argon2id params, e.g. "m=65536,t=3,p=4".
+	// existing hashes with weaker params are rehashed on the next login.
+	if params := os.Getenv("XOIDC_ARGON2_PARAMS"); params != "" {
+		password.DefaultParams, err = password.ParseParams(params)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// passwords of this list are rejected, one password or SHA-1 hash per line
+	if path := os.Getenv("XOIDC_BREACHED_PASSWORDS"); path != "" {
+		storage.BreachedPasswords, err = password.LoadBreachedList(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("loaded %d breached passwords", storage.BreachedPasswords.Len())
+	}
+
+	// subcommands like import-users work on the database and exit
+	if len(os.Args) > 1 {
+		log.SetLevel(log.WarnLevel)
+		err = runCommand(storage, os.Args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	err = bootstrapAtStartup(storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	logger := slog.New(
 		slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 			AddSource: true,
@@ -49,20 +100,146 @@ func main() {
 		}),
 	)
 
-	router := exampleop.SetupServer(issuer, storage, logger, false)
+	// rate limit counters are kept in memory, which is fine for a single node.
+	// set XOIDC_RATELIMIT_STORE=postgres to share them between several nodes.
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if os.Getenv("XOIDC_RATELIMIT_STORE") == "postgres" {
+		limitStore = ratelimit.StoreFunc(storage.IncrRateLimit)
+		go func() {
+			for range time.Tick(time.Minute) {
+				err := storage.DeleteExpiredRateLimits(context.Background())
+				if err != nil {
+					log.Errorf("DeleteExpiredRateLimits: %v", err)
+				}
+			}
+		}()
+	}
+	go func() {
+		for range time.Tick(time.Hour) {
+			err := storage.DeleteExpiredUserSessions(context.Background())
+			if err != nil {
+				log.Errorf("DeleteExpiredUserSessions: %v", err)
+			}
+		}
+	}()
+	// the jtis of used DPoP proofs are kept until the proofs expire
+	go func() {
+		for range time.Tick(time.Minute) {
+			err := storage.DeleteExpiredDPoPProofs(context.Background())
+			if err != nil {
+				log.Errorf("DeleteExpiredDPoPProofs: %v", err)
+			}
+		}
+	}()
+	go func() {
+		for range time.Tick(time.Hour) {
+			err := storage.DeleteExpiredBackchannelAuthRequests(context.Background())
+			if err != nil {
+				log.Errorf("DeleteExpiredBackchannelAuthRequests: %v", err)
+			}
+		}
+	}()
+
+	// XOIDC_RATELIMITS changes the limits of the rules by name,
+	// e.g. "token:client=600/1m,login:ip=30/1m,device:ip=off"
+	limits := exampleop.DefaultRateLimits()
+	if s := os.Getenv("XOIDC_RATELIMITS"); s != "" {
+		limits, err = ratelimit.Configure(limits, s)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	limiter := ratelimit.New(limitStore, limits...)
+
+	// mails are sent with XOIDC_SMTP_ADDR, written to XOIDC_MAIL_FILE or logged
+	var mail mailer.Mailer = &mailer.FileMailer{Path: os.Getenv("XOIDC_MAIL_FILE")}
+	if addr := os.Getenv("XOIDC_SMTP_ADDR"); addr != "" {
+		mail = &mailer.SMTPMailer{
+			Addr:     addr,
+			From:     os.Getenv("XOIDC_SMTP_FROM"),
+			Username: os.Getenv("XOIDC_SMTP_USERNAME"),
+			Password: os.Getenv("XOIDC_SMTP_PASSWORD"),
+		}
+	}
+
+	// tls_client_auth clients need a certificate of these CAs
+	var clientCAs *x509.CertPool
+	if path := os.Getenv("XOIDC_TLS_CLIENT_CA_FILE"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			log.Fatalf("no certificates in %s", path)
+		}
+	}
+
+	// the client certificate header is only taken from the proxies of
+	// XOIDC_CLIENT_CERT_PROXIES, e.g. "10.0.0.1,192.168.0.0/16"
+	certProxies, err := exampleop.ParseProxies(os.Getenv("XOIDC_CLIENT_CERT_PROXIES"))
+	if err != nil {
+		log.Fatalf("XOIDC_CLIENT_CERT_PROXIES: %v", err)
+	}
+	if os.Getenv("XOIDC_CLIENT_CERT_HEADER") != "" && len(certProxies) == 0 {
+		log.Warn("XOIDC_CLIENT_CERT_HEADER is ignored without XOIDC_CLIENT_CERT_PROXIES")
+	}
+
+	// the approval links of backchannel authentication requests are posted to
+	// XOIDC_CIBA_WEBHOOK, which delivers them to the devices of the users, or logged
+	var notifier ciba.Notifier = ciba.LogNotifier{}
+	if hook := os.Getenv("XOIDC_CIBA_WEBHOOK"); hook != "" {
+		notifier = &ciba.WebhookNotifier{URL: hook, Token: os.Getenv("XOIDC_CIBA_WEBHOOK_TOKEN")}
+	}
+
+	// the links sent to users, e.g. to reset the password, are signed with the
+	// secret of XOIDC_LINK_KEY_FILE or XOIDC_LINK_KEY, e.g. openssl rand -base64 32
+	linkKey, err := exampleop.LoadLinkKey(os.Getenv("XOIDC_LINK_KEY"), os.Getenv("XOIDC_LINK_KEY_FILE"))
+	if err != nil {
+		log.Fatalf("XOIDC_LINK_KEY: %v", err)
+	}
+
+	router := exampleop.SetupServer(issuer, storage, logger, false, exampleop.ServerConfig{
+		Limiter: limiter,
+		Mailer:  mail,
+		// no sms gateway yet, the codes are logged
+		SMS: sms.LogSender{},
+		// register a client with the redirect uri <issuer>account/callback for the account portal
+		AccountClientID:     os.Getenv("XOIDC_ACCOUNT_CLIENT_ID"),
+		AccountClientSecret: os.Getenv("XOIDC_ACCOUNT_CLIENT_SECRET"),
+		// the namespaces allow it with PUT /api/oidc/namespaces/{namespace_id}/client_registration_policy
+		ClientRegistration: clientreg.NewServer(storage, issuer+"register"),
+		// DPoP proofs must have a nonce of the server unless XOIDC_DPOP_NONCE=off
+		RequireDPoPNonce: os.Getenv("XOIDC_DPOP_NONCE") != "off",
+		ClientCAs:        clientCAs,
+		// behind a TLS terminating proxy the header with the client certificate,
+		// e.g. X-SSL-Client-Cert, set it only if the proxy overwrites the header
+		ClientCertificateHeader:  os.Getenv("XOIDC_CLIENT_CERT_HEADER"),
+		ClientCertificateProxies: certProxies,
+		Notifier:                 notifier,
+		LinkKey:                  linkKey,
+	})
 	h := api.Handler{
 		Store: storage,
 	}
 	router.Route("/api/oidc", h.Serve)
+	// provisioning with the tokens of POST /api/oidc/clients/{client_id}/scim_tokens
+	router.Route("/scim/v2", scim.NewServer(storage, issuer+"scim/v2").Serve)
 
 	server := &http.Server{
 		Addr:    ":" + port,
 		Handler: router,
+		// the certificates are verified per client, requests without one are fine
+		TLSConfig: &tls.Config{ClientAuth: tls.RequestClientCert},
 	}
 
-	log.Printf("server listening on http://localhost:%s/", port)
+	log.Printf("server listening on %s", issuer)
 	log.Println("press ctrl+c to stop")
-	err = server.ListenAndServe()
+	if certFile != "" {
+		err = server.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+	"github.com/zltl/xoidc/server/internal/pkg/transfer"
+)
+
+// commands run against the database instead of serving, e.g.
+//
+//	xoidc_server import-users -namespace <id> -dry-run users.csv
+//	xoidc_server export-users -namespace <id> -format jsonl -o users.jsonl
+//	xoidc_server import-clients clients.yaml
+//	xoidc_server export-clients -format yaml
+//	xoidc_server apply -f bootstrap.yaml -prune
+var commands = map[string]func(s *storage.Storage, args []string) error{
+	"import-users":   importUsers,
+	"export-users":   exportUsers,
+	"import-clients": importClients,
+	"export-clients": exportClients,
+	"apply":          apply,
+}
+
+func runCommand(s *storage.Storage, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return cmd(s, args[1:])
+}
+
+// openInput opens the file of an import, - is stdin
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// createOutput creates the file of an export, - is stdout
+func createOutput(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return os.Stdout, nil
+	}
+	return os.Create(path)
+}
+
+// formatOf is the -format flag, else the format of the file extension
+func formatOf(flagValue, path, fallback string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if f := transfer.FormatOf(path); f != "" {
+		return f
+	}
+	return fallback
+}
+
+// printResult prints the rows of an import as JSON, failed rows fail the command
+func printResult(result *transfer.Result) error {
+	e := json.NewEncoder(os.Stdout)
+	e.SetIndent("", "  ")
+	err := e.Encode(result)
+	if err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", result.Failed, len(result.Rows))
+	}
+	return nil
+}
+
+func importUsers(s *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("import-users", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "namespace id of the users")
+	format := fs.String("format", "", "csv or jsonl, by default by the file extension")
+	dryRun := fs.Bool("dry-run", false, "report what would change without changing it")
+	fs.Parse(args)
+	ns, err := uuid.Parse(*namespace)
+	if err != nil {
+		return fmt.Errorf("-namespace: %w", err)
+	}
+	in, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	result, err := s.ImportUsers(context.Background(), ns, in, formatOf(*format, fs.Arg(0), transfer.FormatCSV), *dryRun)
+	if err != nil {
+		return err
+	}
+	return printResult(result)
+}
+
+func exportUsers(s *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("export-users", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "namespace id of the users")
+	format := fs.String("format", "", "csv or jsonl, by default by the file extension")
+	output := fs.String("o", "-", "file to write")
+	hashes := fs.Bool("password-hashes", false, "export the password hashes")
+	fs.Parse(args)
+	ns, err := uuid.Parse(*namespace)
+	if err != nil {
+		return fmt.Errorf("-namespace: %w", err)
+	}
+	out, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	uw, err := transfer.NewUserWriter(out, formatOf(*format, *output, transfer.FormatCSV))
+	if err != nil {
+		out.Close()
+		return err
+	}
+	err = s.ExportUsers(context.Background(), ns, uw, *hashes)
+	return errors.Join(err, out.Close())
+}
+
+func importClients(s *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("import-clients", flag.ExitOnError)
+	format := fs.String("format", "", "json or yaml, by default by the file extension")
+	dryRun := fs.Bool("dry-run", false, "report what would change without changing it")
+	fs.Parse(args)
+	in, err := openInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	clients, err := transfer.ReadClients(in, formatOf(*format, fs.Arg(0), transfer.FormatYAML))
+	if err != nil {
+		return err
+	}
+	result, err := s.ImportClients(context.Background(), clients, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printResult(result)
+}
+
+func exportClients(s *storage.Storage, args []string) error {
+	fs := flag.NewFlagSet("export-clients", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "only the clients of this user namespace")
+	format := fs.String("format", "", "json or yaml, by default by the file extension")
+	output := fs.String("o", "-", "file to write")
+	secrets := fs.Bool("secrets", false, "export the client secrets")
+	fs.Parse(args)
+	ns := uuid.Nil
+	if *namespace != "" {
+		var err error
+		ns, err = uuid.Parse(*namespace)
+		if err != nil {
+			return fmt.Errorf("-namespace: %w", err)
+		}
+	}
+	clients, err := s.ExportClients(context.Background(), ns, *secrets)
+	if err != nil {
+		return err
+	}
+	out, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	err = transfer.WriteClients(out, formatOf(*format, *output, transfer.FormatYAML), clients)
+	return errors.Join(err, out.Close())
+}
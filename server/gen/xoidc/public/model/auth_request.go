@@ -13,11 +13,18 @@ import (
 )
 
 type AuthRequest struct {
-	ID           uuid.UUID `sql:"primary_key"`
-	CreationDate time.Time
-	Done         bool
-	AuthTime     time.Time
-	Content      string
-	NamespaceID  uuid.UUID
-	UserID       uuid.UUID
+	ID                   uuid.UUID `sql:"primary_key"`
+	CreationDate         time.Time
+	Done                 bool
+	AuthTime             time.Time
+	Content              string
+	NamespaceID          uuid.UUID
+	UserID               uuid.UUID
+	Amr                  string
+	Audience             string
+	Resources            string
+	RequestURI           string
+	AuthorizationDetails string
+	DetailsApproved      bool
+	SessionID            uuid.UUID
 }
@@ -9,22 +9,54 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 type Client struct {
-	ID                             uuid.UUID
-	Secret                         string
-	RedirectUris                   string
-	ApplicationType                int32
-	AuthMethod                     string
-	ResponseTypes                  string
-	AccessTokenType                int32
-	DevMode                        bool
-	IDTokenUserInfoClaimsAssertion bool `sql:"primary_key"`
-	ClockSkew                      string
-	PostLogoutRedirectURIGlobs     string
-	RedirectURIGlobs               string
-	UserNamespaceID                uuid.UUID
-	GrantTypes                     string
-	Name                           string
+	ID                                    uuid.UUID `sql:"primary_key"`
+	Secret                                string
+	RedirectUris                          string
+	ApplicationType                       int32
+	AuthMethod                            string
+	ResponseTypes                         string
+	AccessTokenType                       int32
+	DevMode                               bool
+	IDTokenUserInfoClaimsAssertion        bool
+	ClockSkew                             string
+	PostLogoutRedirectURIGlobs            string
+	RedirectURIGlobs                      string
+	UserNamespaceID                       uuid.UUID
+	GrantTypes                            string
+	Name                                  string
+	CreateTime                            time.Time
+	RegistrationTokenHash                 string
+	RegistrationMetadata                  string
+	AccessTokenLifetime                   string
+	IDTokenLifetime                       string
+	RefreshTokenLifetime                  string
+	RefreshTokenIdleLifetime              string
+	RefreshTokens                         string
+	AllowedScopes                         string
+	DefaultScopes                         string
+	Audiences                             string
+	DisallowedScopes                      string
+	Resources                             string
+	RequirePushedAuthorizationRequests    bool
+	ResponseModes                         string
+	AuthorizationEncryptedResponseAlg     string
+	AuthorizationEncryptedResponseEnc     string
+	Jwks                                  string
+	DpopBoundAccessTokens                 bool
+	TLSClientAuthSubjectDn                string
+	TLSClientAuthSanDNS                   string
+	TLSClientAuthSanURI                   string
+	TLSClientAuthSanIP                    string
+	TLSClientAuthSanEmail                 string
+	TLSClientCertificateBoundAccessTokens bool
+	BackchannelTokenDeliveryMode          string
+	BackchannelClientNotificationEndpoint string
+	FrontchannelLogoutURI                 string
+	FrontchannelLogoutSessionRequired     bool
+	BackchannelLogoutURI                  string
+	BackchannelLogoutSessionRequired      bool
 }
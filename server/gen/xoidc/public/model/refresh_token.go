@@ -12,13 +12,17 @@ import (
 )
 
 type RefreshToken struct {
-	ID            string `sql:"primary_key"`
-	Token         string
-	AuthTime      time.Time
-	Amr           string
-	Audience      string
-	UserID        string
-	ApplicationID string
-	Expiration    time.Time
-	Scopes        string
+	ID                   string `sql:"primary_key"`
+	Token                string
+	AuthTime             time.Time
+	Amr                  string
+	Audience             string
+	UserID               string
+	ApplicationID        string
+	Expiration           time.Time
+	Scopes               string
+	AbsoluteExpiration   time.Time
+	Jkt                  string
+	AuthorizationDetails string
+	SessionID            string
 }
@@ -13,11 +13,14 @@ import (
 )
 
 type Token struct {
-	ID             string `sql:"primary_key"`
-	Audience       string
-	Expiration     time.Time
-	Scopes         string
-	ApplicationID  uuid.UUID
-	Subject        uuid.UUID
-	RefreshTokenID uuid.UUID
+	ID                   string `sql:"primary_key"`
+	Audience             string
+	Expiration           time.Time
+	Scopes               string
+	ApplicationID        uuid.UUID
+	Subject              uuid.UUID
+	RefreshTokenID       uuid.UUID
+	Jkt                  string
+	X5tS256              string
+	AuthorizationDetails string
 }
@@ -35,4 +35,11 @@ type User struct {
 	UpdatedAt           time.Time
 	NamespaceID         uuid.UUID
 	ID                  uuid.UUID
+	PasswordChangeTime  time.Time
+	Status              string
+	DirectoryID         *uuid.UUID
+	Groups              string
+	ExternalID          string
+	CreateTime          time.Time
+	IsAdmin             bool
 }
@@ -17,13 +17,20 @@ type authRequestTable struct {
 	postgres.Table
 
 	// Columns
-	ID           postgres.ColumnString
-	CreationDate postgres.ColumnTimestampz
-	Done         postgres.ColumnBool
-	AuthTime     postgres.ColumnTimestampz
-	Content      postgres.ColumnString
-	NamespaceID  postgres.ColumnString
-	UserID       postgres.ColumnString
+	ID                   postgres.ColumnString
+	CreationDate         postgres.ColumnTimestampz
+	Done                 postgres.ColumnBool
+	AuthTime             postgres.ColumnTimestampz
+	Content              postgres.ColumnString
+	NamespaceID          postgres.ColumnString
+	UserID               postgres.ColumnString
+	Amr                  postgres.ColumnString
+	Audience             postgres.ColumnString
+	Resources            postgres.ColumnString
+	RequestURI           postgres.ColumnString
+	AuthorizationDetails postgres.ColumnString
+	DetailsApproved      postgres.ColumnBool
+	SessionID            postgres.ColumnString
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -64,28 +71,42 @@ func newAuthRequestTable(schemaName, tableName, alias string) *AuthRequestTable
 
 func newAuthRequestTableImpl(schemaName, tableName, alias string) authRequestTable {
 	var (
-		IDColumn           = postgres.StringColumn("id")
-		CreationDateColumn = postgres.TimestampzColumn("creation_date")
-		DoneColumn         = postgres.BoolColumn("done")
-		AuthTimeColumn     = postgres.TimestampzColumn("auth_time")
-		ContentColumn      = postgres.StringColumn("content")
-		NamespaceIDColumn  = postgres.StringColumn("namespace_id")
-		UserIDColumn       = postgres.StringColumn("user_id")
-		allColumns         = postgres.ColumnList{IDColumn, CreationDateColumn, DoneColumn, AuthTimeColumn, ContentColumn, NamespaceIDColumn, UserIDColumn}
-		mutableColumns     = postgres.ColumnList{CreationDateColumn, DoneColumn, AuthTimeColumn, ContentColumn, NamespaceIDColumn, UserIDColumn}
+		IDColumn                   = postgres.StringColumn("id")
+		CreationDateColumn         = postgres.TimestampzColumn("creation_date")
+		DoneColumn                 = postgres.BoolColumn("done")
+		AuthTimeColumn             = postgres.TimestampzColumn("auth_time")
+		ContentColumn              = postgres.StringColumn("content")
+		NamespaceIDColumn          = postgres.StringColumn("namespace_id")
+		UserIDColumn               = postgres.StringColumn("user_id")
+		AmrColumn                  = postgres.StringColumn("amr")
+		AudienceColumn             = postgres.StringColumn("audience")
+		ResourcesColumn            = postgres.StringColumn("resources")
+		RequestURIColumn           = postgres.StringColumn("request_uri")
+		AuthorizationDetailsColumn = postgres.StringColumn("authorization_details")
+		DetailsApprovedColumn      = postgres.BoolColumn("details_approved")
+		SessionIDColumn            = postgres.StringColumn("session_id")
+		allColumns                 = postgres.ColumnList{IDColumn, CreationDateColumn, DoneColumn, AuthTimeColumn, ContentColumn, NamespaceIDColumn, UserIDColumn, AmrColumn, AudienceColumn, ResourcesColumn, RequestURIColumn, AuthorizationDetailsColumn, DetailsApprovedColumn, SessionIDColumn}
+		mutableColumns             = postgres.ColumnList{CreationDateColumn, DoneColumn, AuthTimeColumn, ContentColumn, NamespaceIDColumn, UserIDColumn, AmrColumn, AudienceColumn, ResourcesColumn, RequestURIColumn, AuthorizationDetailsColumn, DetailsApprovedColumn, SessionIDColumn}
 	)
 
 	return authRequestTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:           IDColumn,
-		CreationDate: CreationDateColumn,
-		Done:         DoneColumn,
-		AuthTime:     AuthTimeColumn,
-		Content:      ContentColumn,
-		NamespaceID:  NamespaceIDColumn,
-		UserID:       UserIDColumn,
+		ID:                   IDColumn,
+		CreationDate:         CreationDateColumn,
+		Done:                 DoneColumn,
+		AuthTime:             AuthTimeColumn,
+		Content:              ContentColumn,
+		NamespaceID:          NamespaceIDColumn,
+		UserID:               UserIDColumn,
+		Amr:                  AmrColumn,
+		Audience:             AudienceColumn,
+		Resources:            ResourcesColumn,
+		RequestURI:           RequestURIColumn,
+		AuthorizationDetails: AuthorizationDetailsColumn,
+		DetailsApproved:      DetailsApprovedColumn,
+		SessionID:            SessionIDColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -17,21 +17,52 @@ type clientTable struct {
 	postgres.Table
 
 	// Columns
-	ID                             postgres.ColumnString
-	Secret                         postgres.ColumnString
-	RedirectUris                   postgres.ColumnString
-	ApplicationType                postgres.ColumnInteger
-	AuthMethod                     postgres.ColumnString
-	ResponseTypes                  postgres.ColumnString
-	AccessTokenType                postgres.ColumnInteger
-	DevMode                        postgres.ColumnBool
-	IDTokenUserInfoClaimsAssertion postgres.ColumnBool
-	ClockSkew                      postgres.ColumnInterval
-	PostLogoutRedirectURIGlobs     postgres.ColumnString
-	RedirectURIGlobs               postgres.ColumnString
-	UserNamespaceID                postgres.ColumnString
-	GrantTypes                     postgres.ColumnString
-	Name                           postgres.ColumnString
+	ID                                    postgres.ColumnString
+	Secret                                postgres.ColumnString
+	RedirectUris                          postgres.ColumnString
+	ApplicationType                       postgres.ColumnInteger
+	AuthMethod                            postgres.ColumnString
+	ResponseTypes                         postgres.ColumnString
+	AccessTokenType                       postgres.ColumnInteger
+	DevMode                               postgres.ColumnBool
+	IDTokenUserInfoClaimsAssertion        postgres.ColumnBool
+	ClockSkew                             postgres.ColumnInterval
+	PostLogoutRedirectURIGlobs            postgres.ColumnString
+	RedirectURIGlobs                      postgres.ColumnString
+	UserNamespaceID                       postgres.ColumnString
+	GrantTypes                            postgres.ColumnString
+	Name                                  postgres.ColumnString
+	CreateTime                            postgres.ColumnTimestampz
+	RegistrationTokenHash                 postgres.ColumnString
+	RegistrationMetadata                  postgres.ColumnString
+	AccessTokenLifetime                   postgres.ColumnInterval
+	IDTokenLifetime                       postgres.ColumnInterval
+	RefreshTokenLifetime                  postgres.ColumnInterval
+	RefreshTokenIdleLifetime              postgres.ColumnInterval
+	RefreshTokens                         postgres.ColumnString
+	AllowedScopes                         postgres.ColumnString
+	DefaultScopes                         postgres.ColumnString
+	Audiences                             postgres.ColumnString
+	DisallowedScopes                      postgres.ColumnString
+	Resources                             postgres.ColumnString
+	RequirePushedAuthorizationRequests    postgres.ColumnBool
+	ResponseModes                         postgres.ColumnString
+	AuthorizationEncryptedResponseAlg     postgres.ColumnString
+	AuthorizationEncryptedResponseEnc     postgres.ColumnString
+	Jwks                                  postgres.ColumnString
+	DpopBoundAccessTokens                 postgres.ColumnBool
+	TLSClientAuthSubjectDn                postgres.ColumnString
+	TLSClientAuthSanDNS                   postgres.ColumnString
+	TLSClientAuthSanURI                   postgres.ColumnString
+	TLSClientAuthSanIP                    postgres.ColumnString
+	TLSClientAuthSanEmail                 postgres.ColumnString
+	TLSClientCertificateBoundAccessTokens postgres.ColumnBool
+	BackchannelTokenDeliveryMode          postgres.ColumnString
+	BackchannelClientNotificationEndpoint postgres.ColumnString
+	FrontchannelLogoutURI                 postgres.ColumnString
+	FrontchannelLogoutSessionRequired     postgres.ColumnBool
+	BackchannelLogoutURI                  postgres.ColumnString
+	BackchannelLogoutSessionRequired      postgres.ColumnBool
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -72,44 +103,106 @@ func newClientTable(schemaName, tableName, alias string) *ClientTable {
 
 func newClientTableImpl(schemaName, tableName, alias string) clientTable {
 	var (
-		IDColumn                             = postgres.StringColumn("id")
-		SecretColumn                         = postgres.StringColumn("secret")
-		RedirectUrisColumn                   = postgres.StringColumn("redirect_uris")
-		ApplicationTypeColumn                = postgres.IntegerColumn("application_type")
-		AuthMethodColumn                     = postgres.StringColumn("auth_method")
-		ResponseTypesColumn                  = postgres.StringColumn("response_types")
-		AccessTokenTypeColumn                = postgres.IntegerColumn("access_token_type")
-		DevModeColumn                        = postgres.BoolColumn("dev_mode")
-		IDTokenUserInfoClaimsAssertionColumn = postgres.BoolColumn("id_token_user_info_claims_assertion")
-		ClockSkewColumn                      = postgres.IntervalColumn("clock_skew")
-		PostLogoutRedirectURIGlobsColumn     = postgres.StringColumn("post_logout_redirect_uri_globs")
-		RedirectURIGlobsColumn               = postgres.StringColumn("redirect_uri_globs")
-		UserNamespaceIDColumn                = postgres.StringColumn("user_namespace_id")
-		GrantTypesColumn                     = postgres.StringColumn("grant_types")
-		NameColumn                           = postgres.StringColumn("name")
-		allColumns                           = postgres.ColumnList{IDColumn, SecretColumn, RedirectUrisColumn, ApplicationTypeColumn, AuthMethodColumn, ResponseTypesColumn, AccessTokenTypeColumn, DevModeColumn, IDTokenUserInfoClaimsAssertionColumn, ClockSkewColumn, PostLogoutRedirectURIGlobsColumn, RedirectURIGlobsColumn, UserNamespaceIDColumn, GrantTypesColumn, NameColumn}
-		mutableColumns                       = postgres.ColumnList{IDColumn, SecretColumn, RedirectUrisColumn, ApplicationTypeColumn, AuthMethodColumn, ResponseTypesColumn, AccessTokenTypeColumn, DevModeColumn, ClockSkewColumn, PostLogoutRedirectURIGlobsColumn, RedirectURIGlobsColumn, UserNamespaceIDColumn, GrantTypesColumn, NameColumn}
+		IDColumn                                    = postgres.StringColumn("id")
+		SecretColumn                                = postgres.StringColumn("secret")
+		RedirectUrisColumn                          = postgres.StringColumn("redirect_uris")
+		ApplicationTypeColumn                       = postgres.IntegerColumn("application_type")
+		AuthMethodColumn                            = postgres.StringColumn("auth_method")
+		ResponseTypesColumn                         = postgres.StringColumn("response_types")
+		AccessTokenTypeColumn                       = postgres.IntegerColumn("access_token_type")
+		DevModeColumn                               = postgres.BoolColumn("dev_mode")
+		IDTokenUserInfoClaimsAssertionColumn        = postgres.BoolColumn("id_token_user_info_claims_assertion")
+		ClockSkewColumn                             = postgres.IntervalColumn("clock_skew")
+		PostLogoutRedirectURIGlobsColumn            = postgres.StringColumn("post_logout_redirect_uri_globs")
+		RedirectURIGlobsColumn                      = postgres.StringColumn("redirect_uri_globs")
+		UserNamespaceIDColumn                       = postgres.StringColumn("user_namespace_id")
+		GrantTypesColumn                            = postgres.StringColumn("grant_types")
+		NameColumn                                  = postgres.StringColumn("name")
+		CreateTimeColumn                            = postgres.TimestampzColumn("create_time")
+		RegistrationTokenHashColumn                 = postgres.StringColumn("registration_token_hash")
+		RegistrationMetadataColumn                  = postgres.StringColumn("registration_metadata")
+		AccessTokenLifetimeColumn                   = postgres.IntervalColumn("access_token_lifetime")
+		IDTokenLifetimeColumn                       = postgres.IntervalColumn("id_token_lifetime")
+		RefreshTokenLifetimeColumn                  = postgres.IntervalColumn("refresh_token_lifetime")
+		RefreshTokenIdleLifetimeColumn              = postgres.IntervalColumn("refresh_token_idle_lifetime")
+		RefreshTokensColumn                         = postgres.StringColumn("refresh_tokens")
+		AllowedScopesColumn                         = postgres.StringColumn("allowed_scopes")
+		DefaultScopesColumn                         = postgres.StringColumn("default_scopes")
+		AudiencesColumn                             = postgres.StringColumn("audiences")
+		DisallowedScopesColumn                      = postgres.StringColumn("disallowed_scopes")
+		ResourcesColumn                             = postgres.StringColumn("resources")
+		RequirePushedAuthorizationRequestsColumn    = postgres.BoolColumn("require_pushed_authorization_requests")
+		ResponseModesColumn                         = postgres.StringColumn("response_modes")
+		AuthorizationEncryptedResponseAlgColumn     = postgres.StringColumn("authorization_encrypted_response_alg")
+		AuthorizationEncryptedResponseEncColumn     = postgres.StringColumn("authorization_encrypted_response_enc")
+		JwksColumn                                  = postgres.StringColumn("jwks")
+		DpopBoundAccessTokensColumn                 = postgres.BoolColumn("dpop_bound_access_tokens")
+		TLSClientAuthSubjectDnColumn                = postgres.StringColumn("tls_client_auth_subject_dn")
+		TLSClientAuthSanDNSColumn                   = postgres.StringColumn("tls_client_auth_san_dns")
+		TLSClientAuthSanURIColumn                   = postgres.StringColumn("tls_client_auth_san_uri")
+		TLSClientAuthSanIPColumn                    = postgres.StringColumn("tls_client_auth_san_ip")
+		TLSClientAuthSanEmailColumn                 = postgres.StringColumn("tls_client_auth_san_email")
+		TLSClientCertificateBoundAccessTokensColumn = postgres.BoolColumn("tls_client_certificate_bound_access_tokens")
+		BackchannelTokenDeliveryModeColumn          = postgres.StringColumn("backchannel_token_delivery_mode")
+		BackchannelClientNotificationEndpointColumn = postgres.StringColumn("backchannel_client_notification_endpoint")
+		FrontchannelLogoutURIColumn                 = postgres.StringColumn("frontchannel_logout_uri")
+		FrontchannelLogoutSessionRequiredColumn     = postgres.BoolColumn("frontchannel_logout_session_required")
+		BackchannelLogoutURIColumn                  = postgres.StringColumn("backchannel_logout_uri")
+		BackchannelLogoutSessionRequiredColumn      = postgres.BoolColumn("backchannel_logout_session_required")
+		allColumns                                  = postgres.ColumnList{IDColumn, SecretColumn, RedirectUrisColumn, ApplicationTypeColumn, AuthMethodColumn, ResponseTypesColumn, AccessTokenTypeColumn, DevModeColumn, IDTokenUserInfoClaimsAssertionColumn, ClockSkewColumn, PostLogoutRedirectURIGlobsColumn, RedirectURIGlobsColumn, UserNamespaceIDColumn, GrantTypesColumn, NameColumn, CreateTimeColumn, RegistrationTokenHashColumn, RegistrationMetadataColumn, AccessTokenLifetimeColumn, IDTokenLifetimeColumn, RefreshTokenLifetimeColumn, RefreshTokenIdleLifetimeColumn, RefreshTokensColumn, AllowedScopesColumn, DefaultScopesColumn, AudiencesColumn, DisallowedScopesColumn, ResourcesColumn, RequirePushedAuthorizationRequestsColumn, ResponseModesColumn, AuthorizationEncryptedResponseAlgColumn, AuthorizationEncryptedResponseEncColumn, JwksColumn, DpopBoundAccessTokensColumn, TLSClientAuthSubjectDnColumn, TLSClientAuthSanDNSColumn, TLSClientAuthSanURIColumn, TLSClientAuthSanIPColumn, TLSClientAuthSanEmailColumn, TLSClientCertificateBoundAccessTokensColumn, BackchannelTokenDeliveryModeColumn, BackchannelClientNotificationEndpointColumn, FrontchannelLogoutURIColumn, FrontchannelLogoutSessionRequiredColumn, BackchannelLogoutURIColumn, BackchannelLogoutSessionRequiredColumn}
+		mutableColumns                              = postgres.ColumnList{SecretColumn, RedirectUrisColumn, ApplicationTypeColumn, AuthMethodColumn, ResponseTypesColumn, AccessTokenTypeColumn, DevModeColumn, IDTokenUserInfoClaimsAssertionColumn, ClockSkewColumn, PostLogoutRedirectURIGlobsColumn, RedirectURIGlobsColumn, UserNamespaceIDColumn, GrantTypesColumn, NameColumn, CreateTimeColumn, RegistrationTokenHashColumn, RegistrationMetadataColumn, AccessTokenLifetimeColumn, IDTokenLifetimeColumn, RefreshTokenLifetimeColumn, RefreshTokenIdleLifetimeColumn, RefreshTokensColumn, AllowedScopesColumn, DefaultScopesColumn, AudiencesColumn, DisallowedScopesColumn, ResourcesColumn, RequirePushedAuthorizationRequestsColumn, ResponseModesColumn, AuthorizationEncryptedResponseAlgColumn, AuthorizationEncryptedResponseEncColumn, JwksColumn, DpopBoundAccessTokensColumn, TLSClientAuthSubjectDnColumn, TLSClientAuthSanDNSColumn, TLSClientAuthSanURIColumn, TLSClientAuthSanIPColumn, TLSClientAuthSanEmailColumn, TLSClientCertificateBoundAccessTokensColumn, BackchannelTokenDeliveryModeColumn, BackchannelClientNotificationEndpointColumn, FrontchannelLogoutURIColumn, FrontchannelLogoutSessionRequiredColumn, BackchannelLogoutURIColumn, BackchannelLogoutSessionRequiredColumn}
 	)
 
 	return clientTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:                             IDColumn,
-		Secret:                         SecretColumn,
-		RedirectUris:                   RedirectUrisColumn,
-		ApplicationType:                ApplicationTypeColumn,
-		AuthMethod:                     AuthMethodColumn,
-		ResponseTypes:                  ResponseTypesColumn,
-		AccessTokenType:                AccessTokenTypeColumn,
-		DevMode:                        DevModeColumn,
-		IDTokenUserInfoClaimsAssertion: IDTokenUserInfoClaimsAssertionColumn,
-		ClockSkew:                      ClockSkewColumn,
-		PostLogoutRedirectURIGlobs:     PostLogoutRedirectURIGlobsColumn,
-		RedirectURIGlobs:               RedirectURIGlobsColumn,
-		UserNamespaceID:                UserNamespaceIDColumn,
-		GrantTypes:                     GrantTypesColumn,
-		Name:                           NameColumn,
+		ID:                                    IDColumn,
+		Secret:                                SecretColumn,
+		RedirectUris:                          RedirectUrisColumn,
+		ApplicationType:                       ApplicationTypeColumn,
+		AuthMethod:                            AuthMethodColumn,
+		ResponseTypes:                         ResponseTypesColumn,
+		AccessTokenType:                       AccessTokenTypeColumn,
+		DevMode:                               DevModeColumn,
+		IDTokenUserInfoClaimsAssertion:        IDTokenUserInfoClaimsAssertionColumn,
+		ClockSkew:                             ClockSkewColumn,
+		PostLogoutRedirectURIGlobs:            PostLogoutRedirectURIGlobsColumn,
+		RedirectURIGlobs:                      RedirectURIGlobsColumn,
+		UserNamespaceID:                       UserNamespaceIDColumn,
+		GrantTypes:                            GrantTypesColumn,
+		Name:                                  NameColumn,
+		CreateTime:                            CreateTimeColumn,
+		RegistrationTokenHash:                 RegistrationTokenHashColumn,
+		RegistrationMetadata:                  RegistrationMetadataColumn,
+		AccessTokenLifetime:                   AccessTokenLifetimeColumn,
+		IDTokenLifetime:                       IDTokenLifetimeColumn,
+		RefreshTokenLifetime:                  RefreshTokenLifetimeColumn,
+		RefreshTokenIdleLifetime:              RefreshTokenIdleLifetimeColumn,
+		RefreshTokens:                         RefreshTokensColumn,
+		AllowedScopes:                         AllowedScopesColumn,
+		DefaultScopes:                         DefaultScopesColumn,
+		Audiences:                             AudiencesColumn,
+		DisallowedScopes:                      DisallowedScopesColumn,
+		Resources:                             ResourcesColumn,
+		RequirePushedAuthorizationRequests:    RequirePushedAuthorizationRequestsColumn,
+		ResponseModes:                         ResponseModesColumn,
+		AuthorizationEncryptedResponseAlg:     AuthorizationEncryptedResponseAlgColumn,
+		AuthorizationEncryptedResponseEnc:     AuthorizationEncryptedResponseEncColumn,
+		Jwks:                                  JwksColumn,
+		DpopBoundAccessTokens:                 DpopBoundAccessTokensColumn,
+		TLSClientAuthSubjectDn:                TLSClientAuthSubjectDnColumn,
+		TLSClientAuthSanDNS:                   TLSClientAuthSanDNSColumn,
+		TLSClientAuthSanURI:                   TLSClientAuthSanURIColumn,
+		TLSClientAuthSanIP:                    TLSClientAuthSanIPColumn,
+		TLSClientAuthSanEmail:                 TLSClientAuthSanEmailColumn,
+		TLSClientCertificateBoundAccessTokens: TLSClientCertificateBoundAccessTokensColumn,
+		BackchannelTokenDeliveryMode:          BackchannelTokenDeliveryModeColumn,
+		BackchannelClientNotificationEndpoint: BackchannelClientNotificationEndpointColumn,
+		FrontchannelLogoutURI:                 FrontchannelLogoutURIColumn,
+		FrontchannelLogoutSessionRequired:     FrontchannelLogoutSessionRequiredColumn,
+		BackchannelLogoutURI:                  BackchannelLogoutURIColumn,
+		BackchannelLogoutSessionRequired:      BackchannelLogoutSessionRequiredColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -17,15 +17,19 @@ type refreshTokenTable struct {
 	postgres.Table
 
 	// Columns
-	ID            postgres.ColumnString
-	Token         postgres.ColumnString
-	AuthTime      postgres.ColumnTimestamp
-	Amr           postgres.ColumnString
-	Audience      postgres.ColumnString
-	UserID        postgres.ColumnString
-	ApplicationID postgres.ColumnString
-	Expiration    postgres.ColumnTimestamp
-	Scopes        postgres.ColumnString
+	ID                   postgres.ColumnString
+	Token                postgres.ColumnString
+	AuthTime             postgres.ColumnTimestamp
+	Amr                  postgres.ColumnString
+	Audience             postgres.ColumnString
+	UserID               postgres.ColumnString
+	ApplicationID        postgres.ColumnString
+	Expiration           postgres.ColumnTimestamp
+	Scopes               postgres.ColumnString
+	AbsoluteExpiration   postgres.ColumnTimestamp
+	Jkt                  postgres.ColumnString
+	AuthorizationDetails postgres.ColumnString
+	SessionID            postgres.ColumnString
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -66,32 +70,40 @@ func newRefreshTokenTable(schemaName, tableName, alias string) *RefreshTokenTabl
 
 func newRefreshTokenTableImpl(schemaName, tableName, alias string) refreshTokenTable {
 	var (
-		IDColumn            = postgres.StringColumn("id")
-		TokenColumn         = postgres.StringColumn("token")
-		AuthTimeColumn      = postgres.TimestampColumn("auth_time")
-		AmrColumn           = postgres.StringColumn("amr")
-		AudienceColumn      = postgres.StringColumn("audience")
-		UserIDColumn        = postgres.StringColumn("user_id")
-		ApplicationIDColumn = postgres.StringColumn("application_id")
-		ExpirationColumn    = postgres.TimestampColumn("expiration")
-		ScopesColumn        = postgres.StringColumn("scopes")
-		allColumns          = postgres.ColumnList{IDColumn, TokenColumn, AuthTimeColumn, AmrColumn, AudienceColumn, UserIDColumn, ApplicationIDColumn, ExpirationColumn, ScopesColumn}
-		mutableColumns      = postgres.ColumnList{TokenColumn, AuthTimeColumn, AmrColumn, AudienceColumn, UserIDColumn, ApplicationIDColumn, ExpirationColumn, ScopesColumn}
+		IDColumn                   = postgres.StringColumn("id")
+		TokenColumn                = postgres.StringColumn("token")
+		AuthTimeColumn             = postgres.TimestampColumn("auth_time")
+		AmrColumn                  = postgres.StringColumn("amr")
+		AudienceColumn             = postgres.StringColumn("audience")
+		UserIDColumn               = postgres.StringColumn("user_id")
+		ApplicationIDColumn        = postgres.StringColumn("application_id")
+		ExpirationColumn           = postgres.TimestampColumn("expiration")
+		ScopesColumn               = postgres.StringColumn("scopes")
+		AbsoluteExpirationColumn   = postgres.TimestampColumn("absolute_expiration")
+		JktColumn                  = postgres.StringColumn("jkt")
+		AuthorizationDetailsColumn = postgres.StringColumn("authorization_details")
+		SessionIDColumn            = postgres.StringColumn("session_id")
+		allColumns                 = postgres.ColumnList{IDColumn, TokenColumn, AuthTimeColumn, AmrColumn, AudienceColumn, UserIDColumn, ApplicationIDColumn, ExpirationColumn, ScopesColumn, AbsoluteExpirationColumn, JktColumn, AuthorizationDetailsColumn, SessionIDColumn}
+		mutableColumns             = postgres.ColumnList{TokenColumn, AuthTimeColumn, AmrColumn, AudienceColumn, UserIDColumn, ApplicationIDColumn, ExpirationColumn, ScopesColumn, AbsoluteExpirationColumn, JktColumn, AuthorizationDetailsColumn, SessionIDColumn}
 	)
 
 	return refreshTokenTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:            IDColumn,
-		Token:         TokenColumn,
-		AuthTime:      AuthTimeColumn,
-		Amr:           AmrColumn,
-		Audience:      AudienceColumn,
-		UserID:        UserIDColumn,
-		ApplicationID: ApplicationIDColumn,
-		Expiration:    ExpirationColumn,
-		Scopes:        ScopesColumn,
+		ID:                   IDColumn,
+		Token:                TokenColumn,
+		AuthTime:             AuthTimeColumn,
+		Amr:                  AmrColumn,
+		Audience:             AudienceColumn,
+		UserID:               UserIDColumn,
+		ApplicationID:        ApplicationIDColumn,
+		Expiration:           ExpirationColumn,
+		Scopes:               ScopesColumn,
+		AbsoluteExpiration:   AbsoluteExpirationColumn,
+		Jkt:                  JktColumn,
+		AuthorizationDetails: AuthorizationDetailsColumn,
+		SessionID:            SessionIDColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
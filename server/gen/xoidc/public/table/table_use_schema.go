@@ -10,10 +10,38 @@ package table
 // UseSchema sets a new schema name for all generated table SQL builder types. It is recommended to invoke
 // this method only once at the beginning of the program.
 func UseSchema(schema string) {
+	APIResource = APIResource.FromSchema(schema)
 	AuthRequest = AuthRequest.FromSchema(schema)
+	AuthorizationDetailsType = AuthorizationDetailsType.FromSchema(schema)
+	BackchannelAuthRequest = BackchannelAuthRequest.FromSchema(schema)
+	BackchannelLogout = BackchannelLogout.FromSchema(schema)
 	Client = Client.FromSchema(schema)
+	ClientRegistrationPolicy = ClientRegistrationPolicy.FromSchema(schema)
 	CodeRequestID = CodeRequestID.FromSchema(schema)
+	Consent = Consent.FromSchema(schema)
+	Directory = Directory.FromSchema(schema)
+	DpopProof = DpopProof.FromSchema(schema)
+	IdentityProvider = IdentityProvider.FromSchema(schema)
+	InitialAccessToken = InitialAccessToken.FromSchema(schema)
+	LoginFailure = LoginFailure.FromSchema(schema)
+	Namespace = Namespace.FromSchema(schema)
+	PasswordHistory = PasswordHistory.FromSchema(schema)
+	PasswordPolicy = PasswordPolicy.FromSchema(schema)
+	PasswordReset = PasswordReset.FromSchema(schema)
+	RateLimitCounter = RateLimitCounter.FromSchema(schema)
 	RefreshToken = RefreshToken.FromSchema(schema)
+	RegistrationPolicy = RegistrationPolicy.FromSchema(schema)
+	ScimToken = ScimToken.FromSchema(schema)
+	Scope = Scope.FromSchema(schema)
 	Token = Token.FromSchema(schema)
+	TokenPolicy = TokenPolicy.FromSchema(schema)
 	User = User.FromSchema(schema)
+	UserGroup = UserGroup.FromSchema(schema)
+	UserGroupMember = UserGroupMember.FromSchema(schema)
+	UserIdentity = UserIdentity.FromSchema(schema)
+	UserSession = UserSession.FromSchema(schema)
+	UserSessionClient = UserSessionClient.FromSchema(schema)
+	Verification = Verification.FromSchema(schema)
+	WebauthnCredential = WebauthnCredential.FromSchema(schema)
+	WebauthnSession = WebauthnSession.FromSchema(schema)
 }
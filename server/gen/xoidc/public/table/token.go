@@ -17,13 +17,16 @@ type tokenTable struct {
 	postgres.Table
 
 	// Columns
-	ID             postgres.ColumnString
-	Audience       postgres.ColumnString
-	Expiration     postgres.ColumnTimestamp
-	Scopes         postgres.ColumnString
-	ApplicationID  postgres.ColumnString
-	Subject        postgres.ColumnString
-	RefreshTokenID postgres.ColumnString
+	ID                   postgres.ColumnString
+	Audience             postgres.ColumnString
+	Expiration           postgres.ColumnTimestamp
+	Scopes               postgres.ColumnString
+	ApplicationID        postgres.ColumnString
+	Subject              postgres.ColumnString
+	RefreshTokenID       postgres.ColumnString
+	Jkt                  postgres.ColumnString
+	X5tS256              postgres.ColumnString
+	AuthorizationDetails postgres.ColumnString
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -64,28 +67,34 @@ func newTokenTable(schemaName, tableName, alias string) *TokenTable {
 
 func newTokenTableImpl(schemaName, tableName, alias string) tokenTable {
 	var (
-		IDColumn             = postgres.StringColumn("id")
-		AudienceColumn       = postgres.StringColumn("audience")
-		ExpirationColumn     = postgres.TimestampColumn("expiration")
-		ScopesColumn         = postgres.StringColumn("scopes")
-		ApplicationIDColumn  = postgres.StringColumn("application_id")
-		SubjectColumn        = postgres.StringColumn("subject")
-		RefreshTokenIDColumn = postgres.StringColumn("refresh_token_id")
-		allColumns           = postgres.ColumnList{IDColumn, AudienceColumn, ExpirationColumn, ScopesColumn, ApplicationIDColumn, SubjectColumn, RefreshTokenIDColumn}
-		mutableColumns       = postgres.ColumnList{AudienceColumn, ExpirationColumn, ScopesColumn, ApplicationIDColumn, SubjectColumn, RefreshTokenIDColumn}
+		IDColumn                   = postgres.StringColumn("id")
+		AudienceColumn             = postgres.StringColumn("audience")
+		ExpirationColumn           = postgres.TimestampColumn("expiration")
+		ScopesColumn               = postgres.StringColumn("scopes")
+		ApplicationIDColumn        = postgres.StringColumn("application_id")
+		SubjectColumn              = postgres.StringColumn("subject")
+		RefreshTokenIDColumn       = postgres.StringColumn("refresh_token_id")
+		JktColumn                  = postgres.StringColumn("jkt")
+		X5tS256Column              = postgres.StringColumn("x5t_s256")
+		AuthorizationDetailsColumn = postgres.StringColumn("authorization_details")
+		allColumns                 = postgres.ColumnList{IDColumn, AudienceColumn, ExpirationColumn, ScopesColumn, ApplicationIDColumn, SubjectColumn, RefreshTokenIDColumn, JktColumn, X5tS256Column, AuthorizationDetailsColumn}
+		mutableColumns             = postgres.ColumnList{AudienceColumn, ExpirationColumn, ScopesColumn, ApplicationIDColumn, SubjectColumn, RefreshTokenIDColumn, JktColumn, X5tS256Column, AuthorizationDetailsColumn}
 	)
 
 	return tokenTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		ID:             IDColumn,
-		Audience:       AudienceColumn,
-		Expiration:     ExpirationColumn,
-		Scopes:         ScopesColumn,
-		ApplicationID:  ApplicationIDColumn,
-		Subject:        SubjectColumn,
-		RefreshTokenID: RefreshTokenIDColumn,
+		ID:                   IDColumn,
+		Audience:             AudienceColumn,
+		Expiration:           ExpirationColumn,
+		Scopes:               ScopesColumn,
+		ApplicationID:        ApplicationIDColumn,
+		Subject:              SubjectColumn,
+		RefreshTokenID:       RefreshTokenIDColumn,
+		Jkt:                  JktColumn,
+		X5tS256:              X5tS256Column,
+		AuthorizationDetails: AuthorizationDetailsColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -39,6 +39,13 @@ type userTable struct {
 	UpdatedAt           postgres.ColumnTimestampz
 	NamespaceID         postgres.ColumnString
 	ID                  postgres.ColumnString
+	PasswordChangeTime  postgres.ColumnTimestampz
+	Status              postgres.ColumnString
+	DirectoryID         postgres.ColumnString
+	Groups              postgres.ColumnString
+	ExternalID          postgres.ColumnString
+	CreateTime          postgres.ColumnTimestampz
+	IsAdmin             postgres.ColumnBool
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -101,8 +108,15 @@ func newUserTableImpl(schemaName, tableName, alias string) userTable {
 		UpdatedAtColumn           = postgres.TimestampzColumn("updated_at")
 		NamespaceIDColumn         = postgres.StringColumn("namespace_id")
 		IDColumn                  = postgres.StringColumn("id")
-		allColumns                = postgres.ColumnList{UsernameColumn, PasswordColumn, NicknameColumn, GivenNameColumn, FamilyNameColumn, MiddleNameColumn, PreferredUsernameColumn, ProfileColumn, PictureColumn, WebsiteColumn, EmailColumn, EmailVerifiedColumn, GenderColumn, BirthdateColumn, ZoneinfoColumn, LocaleColumn, PhoneNumberColumn, PhoneNumberVerifiedColumn, AddressColumn, UpdatedAtColumn, NamespaceIDColumn, IDColumn}
-		mutableColumns            = postgres.ColumnList{UsernameColumn, PasswordColumn, NicknameColumn, GivenNameColumn, FamilyNameColumn, MiddleNameColumn, PreferredUsernameColumn, ProfileColumn, PictureColumn, WebsiteColumn, EmailColumn, EmailVerifiedColumn, GenderColumn, BirthdateColumn, ZoneinfoColumn, LocaleColumn, PhoneNumberColumn, PhoneNumberVerifiedColumn, AddressColumn, UpdatedAtColumn, NamespaceIDColumn, IDColumn}
+		PasswordChangeTimeColumn  = postgres.TimestampzColumn("password_change_time")
+		StatusColumn              = postgres.StringColumn("status")
+		DirectoryIDColumn         = postgres.StringColumn("directory_id")
+		GroupsColumn              = postgres.StringColumn("groups")
+		ExternalIDColumn          = postgres.StringColumn("external_id")
+		CreateTimeColumn          = postgres.TimestampzColumn("create_time")
+		IsAdminColumn             = postgres.BoolColumn("is_admin")
+		allColumns                = postgres.ColumnList{UsernameColumn, PasswordColumn, NicknameColumn, GivenNameColumn, FamilyNameColumn, MiddleNameColumn, PreferredUsernameColumn, ProfileColumn, PictureColumn, WebsiteColumn, EmailColumn, EmailVerifiedColumn, GenderColumn, BirthdateColumn, ZoneinfoColumn, LocaleColumn, PhoneNumberColumn, PhoneNumberVerifiedColumn, AddressColumn, UpdatedAtColumn, NamespaceIDColumn, IDColumn, PasswordChangeTimeColumn, StatusColumn, DirectoryIDColumn, GroupsColumn, ExternalIDColumn, CreateTimeColumn, IsAdminColumn}
+		mutableColumns            = postgres.ColumnList{UsernameColumn, PasswordColumn, NicknameColumn, GivenNameColumn, FamilyNameColumn, MiddleNameColumn, PreferredUsernameColumn, ProfileColumn, PictureColumn, WebsiteColumn, EmailColumn, EmailVerifiedColumn, GenderColumn, BirthdateColumn, ZoneinfoColumn, LocaleColumn, PhoneNumberColumn, PhoneNumberVerifiedColumn, AddressColumn, UpdatedAtColumn, NamespaceIDColumn, IDColumn, PasswordChangeTimeColumn, StatusColumn, DirectoryIDColumn, GroupsColumn, ExternalIDColumn, CreateTimeColumn, IsAdminColumn}
 	)
 
 	return userTable{
@@ -131,6 +145,13 @@ func newUserTableImpl(schemaName, tableName, alias string) userTable {
 		UpdatedAt:           UpdatedAtColumn,
 		NamespaceID:         NamespaceIDColumn,
 		ID:                  IDColumn,
+		PasswordChangeTime:  PasswordChangeTimeColumn,
+		Status:              StatusColumn,
+		DirectoryID:         DirectoryIDColumn,
+		Groups:              GroupsColumn,
+		ExternalID:          ExternalIDColumn,
+		CreateTime:          CreateTimeColumn,
+		IsAdmin:             IsAdminColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
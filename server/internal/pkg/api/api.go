@@ -20,6 +20,51 @@ func (h *Handler) Serve(r chi.Router) {
 	r.Get("/clients", h.handleGetClientList)
 	r.Post("/clients", h.handlePostClient)
 	r.Get("/clients/{client_id}", h.handleGetClient)
+	r.Post("/users/{user_id}/unlock", h.handleUnlockUser)
+	r.Put("/users/{user_id}/password", h.handlePutUserPassword)
+	r.Put("/users/{user_id}/verified", h.handlePutUserVerified)
+	r.Post("/users/{user_id}/approve", h.handleApproveUser)
+	r.Post("/users/{user_id}/reject", h.handleRejectUser)
+	r.Get("/namespaces/{namespace_id}/password_policy", h.handleGetPasswordPolicy)
+	r.Put("/namespaces/{namespace_id}/password_policy", h.handlePutPasswordPolicy)
+	r.Get("/namespaces/{namespace_id}/token_policy", h.handleGetTokenPolicy)
+	r.Put("/namespaces/{namespace_id}/token_policy", h.handlePutTokenPolicy)
+	r.Get("/namespaces/{namespace_id}/registration_policy", h.handleGetRegistrationPolicy)
+	r.Put("/namespaces/{namespace_id}/registration_policy", h.handlePutRegistrationPolicy)
+	r.Get("/namespaces/{namespace_id}/registrations", h.handleGetRegistrations)
+	r.Get("/namespaces/{namespace_id}/identity_providers", h.handleGetIdentityProviders)
+	r.Post("/namespaces/{namespace_id}/identity_providers", h.handlePostIdentityProvider)
+	r.Get("/identity_providers/{provider_id}", h.handleGetIdentityProvider)
+	r.Put("/identity_providers/{provider_id}", h.handlePutIdentityProvider)
+	r.Delete("/identity_providers/{provider_id}", h.handleDeleteIdentityProvider)
+	r.Get("/namespaces/{namespace_id}/directories", h.handleGetDirectories)
+	r.Post("/namespaces/{namespace_id}/directories", h.handlePostDirectory)
+	r.Get("/directories/{directory_id}", h.handleGetDirectory)
+	r.Put("/directories/{directory_id}", h.handlePutDirectory)
+	r.Delete("/directories/{directory_id}", h.handleDeleteDirectory)
+	r.Post("/directories/{directory_id}/test", h.handleTestDirectory)
+	r.Get("/namespaces/{namespace_id}/api_resources", h.handleGetAPIResources)
+	r.Post("/namespaces/{namespace_id}/api_resources", h.handlePostAPIResource)
+	r.Get("/api_resources/{resource_id}", h.handleGetAPIResource)
+	r.Put("/api_resources/{resource_id}", h.handlePutAPIResource)
+	r.Delete("/api_resources/{resource_id}", h.handleDeleteAPIResource)
+	r.Get("/namespaces/{namespace_id}/authorization_details_types", h.handleGetAuthorizationDetailsTypes)
+	r.Post("/namespaces/{namespace_id}/authorization_details_types", h.handlePostAuthorizationDetailsType)
+	r.Get("/authorization_details_types/{type_id}", h.handleGetAuthorizationDetailsType)
+	r.Put("/authorization_details_types/{type_id}", h.handlePutAuthorizationDetailsType)
+	r.Delete("/authorization_details_types/{type_id}", h.handleDeleteAuthorizationDetailsType)
+	r.Get("/clients/{client_id}/scim_tokens", h.handleGetSCIMTokens)
+	r.Post("/clients/{client_id}/scim_tokens", h.handlePostSCIMToken)
+	r.Delete("/clients/{client_id}/scim_tokens/{token_id}", h.handleDeleteSCIMToken)
+	r.Post("/namespaces/{namespace_id}/users/import", h.handleImportUsers)
+	r.Get("/namespaces/{namespace_id}/users/export", h.handleExportUsers)
+	r.Post("/clients/import", h.handleImportClients)
+	r.Get("/clients/export", h.handleExportClients)
+	r.Get("/namespaces/{namespace_id}/client_registration_policy", h.handleGetClientRegistrationPolicy)
+	r.Put("/namespaces/{namespace_id}/client_registration_policy", h.handlePutClientRegistrationPolicy)
+	r.Get("/namespaces/{namespace_id}/initial_access_tokens", h.handleGetInitialAccessTokens)
+	r.Post("/namespaces/{namespace_id}/initial_access_tokens", h.handlePostInitialAccessToken)
+	r.Delete("/namespaces/{namespace_id}/initial_access_tokens/{token_id}", h.handleDeleteInitialAccessToken)
 	// r.Get("/", h.index)
 }
 
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// list the api resources of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/api_resources
+func (h *Handler) handleGetAPIResources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	resources, err := h.Store.ListAPIResources(ctx, namespace)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.APIResourceListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		APIResources: []m.APIResource{},
+	}
+	for _, a := range resources {
+		res.APIResources = append(res.APIResources, m.APIResourceDB2View(a))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// register an api resource in a namespace
+// POST /api/oidc/namespaces/{namespace_id}/api_resources
+func (h *Handler) handlePostAPIResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var a m.APIResource
+	if err := h.decodeJSON(ctx, r, &a); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	a.ID = uuid.Nil
+	a.NamespaceID = namespace
+	h.saveAPIResource(w, r, &a)
+}
+
+// get an api resource
+// GET /api/oidc/api_resources/{resource_id}
+func (h *Handler) handleGetAPIResource(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "resource_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	a, err := h.Store.GetAPIResource(r.Context(), id)
+	if err != nil {
+		h.apiResourceError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.APIResourceResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		APIResource: m.APIResourceDB2View(a),
+	})
+}
+
+// update an api resource
+// PUT /api/oidc/api_resources/{resource_id}
+func (h *Handler) handlePutAPIResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "resource_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	old, err := h.Store.GetAPIResource(ctx, id)
+	if err != nil {
+		h.apiResourceError(w, r, err)
+		return
+	}
+	var a m.APIResource
+	if err := h.decodeJSON(ctx, r, &a); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	a.ID = id
+	a.NamespaceID = old.NamespaceID
+	h.saveAPIResource(w, r, &a)
+}
+
+// delete an api resource, clients listing it can not request it anymore
+// DELETE /api/oidc/api_resources/{resource_id}
+func (h *Handler) handleDeleteAPIResource(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "resource_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.DeleteAPIResource(r.Context(), id)
+	if err != nil {
+		h.apiResourceError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
+
+func (h *Handler) saveAPIResource(w http.ResponseWriter, r *http.Request, a *m.APIResource) {
+	resource := a.View2DB()
+	err := h.Store.SaveAPIResource(r.Context(), resource)
+	if err != nil {
+		h.apiResourceError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.APIResourceResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		APIResource: m.APIResourceDB2View(resource),
+	})
+}
+
+func (h *Handler) apiResourceError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage.ErrAPIResourceNotFound) {
+		h.R(w, r, http.StatusNotFound, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusBadRequest, m.Response{
+		Status: m.ErrFailed,
+		Msg:    err.Error(),
+	})
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// list the authorization details types of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/authorization_details_types
+func (h *Handler) handleGetAuthorizationDetailsTypes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	types, err := h.Store.ListAuthorizationDetailsTypes(ctx, namespace)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.AuthorizationDetailsTypeListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		AuthorizationDetailsTypes: []m.AuthorizationDetailsType{},
+	}
+	for _, t := range types {
+		res.AuthorizationDetailsTypes = append(res.AuthorizationDetailsTypes, m.AuthorizationDetailsTypeDB2View(t))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// register an authorization details type in a namespace
+// POST /api/oidc/namespaces/{namespace_id}/authorization_details_types
+func (h *Handler) handlePostAuthorizationDetailsType(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var t m.AuthorizationDetailsType
+	if err := h.decodeJSON(ctx, r, &t); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	t.ID = uuid.Nil
+	t.NamespaceID = namespace
+	h.saveAuthorizationDetailsType(w, r, &t)
+}
+
+// get an authorization details type
+// GET /api/oidc/authorization_details_types/{type_id}
+func (h *Handler) handleGetAuthorizationDetailsType(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "type_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	t, err := h.Store.GetAuthorizationDetailsType(r.Context(), id)
+	if err != nil {
+		h.authorizationDetailsTypeError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.AuthorizationDetailsTypeResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		AuthorizationDetailsType: m.AuthorizationDetailsTypeDB2View(t),
+	})
+}
+
+// update an authorization details type
+// PUT /api/oidc/authorization_details_types/{type_id}
+func (h *Handler) handlePutAuthorizationDetailsType(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "type_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	old, err := h.Store.GetAuthorizationDetailsType(ctx, id)
+	if err != nil {
+		h.authorizationDetailsTypeError(w, r, err)
+		return
+	}
+	var t m.AuthorizationDetailsType
+	if err := h.decodeJSON(ctx, r, &t); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	t.ID = id
+	t.NamespaceID = old.NamespaceID
+	h.saveAuthorizationDetailsType(w, r, &t)
+}
+
+// delete an authorization details type, clients can not request it anymore
+// DELETE /api/oidc/authorization_details_types/{type_id}
+func (h *Handler) handleDeleteAuthorizationDetailsType(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "type_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.DeleteAuthorizationDetailsType(r.Context(), id)
+	if err != nil {
+		h.authorizationDetailsTypeError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
+
+func (h *Handler) saveAuthorizationDetailsType(w http.ResponseWriter, r *http.Request, t *m.AuthorizationDetailsType) {
+	detailsType := t.View2DB()
+	err := h.Store.SaveAuthorizationDetailsType(r.Context(), detailsType)
+	if err != nil {
+		h.authorizationDetailsTypeError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.AuthorizationDetailsTypeResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		AuthorizationDetailsType: m.AuthorizationDetailsTypeDB2View(detailsType),
+	})
+}
+
+func (h *Handler) authorizationDetailsTypeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage.ErrAuthorizationDetailsTypeNotFound) {
+		h.R(w, r, http.StatusNotFound, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusBadRequest, m.Response{
+		Status: m.ErrFailed,
+		Msg:    err.Error(),
+	})
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// get the dynamic client registration policy of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/client_registration_policy
+func (h *Handler) handleGetClientRegistrationPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	policy, err := h.Store.DynamicRegistrationPolicy(ctx, namespace)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.ClientRegistrationPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: m.ClientRegistrationPolicyDB2View(policy),
+	})
+}
+
+// set the dynamic client registration policy of a namespace
+// PUT /api/oidc/namespaces/{namespace_id}/client_registration_policy
+func (h *Handler) handlePutClientRegistrationPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var policy m.ClientRegistrationPolicy
+	if err := h.decodeJSON(ctx, r, &policy); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if policy.SoftwareStatementJWKS != "" && !json.Valid([]byte(policy.SoftwareStatementJWKS)) {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    "software_statement_jwks is not a JSON web key set",
+		})
+		return
+	}
+	err = h.Store.SetDynamicRegistrationPolicy(ctx, namespace, policy.View2DB())
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.ClientRegistrationPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: policy,
+	})
+}
+
+// list the initial access tokens of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/initial_access_tokens
+func (h *Handler) handleGetInitialAccessTokens(w http.ResponseWriter, r *http.Request) {
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	tokens, err := h.Store.ListInitialAccessTokens(r.Context(), namespace)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.InitialAccessTokenListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Tokens: []m.InitialAccessToken{},
+	}
+	for _, t := range tokens {
+		res.Tokens = append(res.Tokens, m.InitialAccessTokenDB2View(t))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// issue an initial access token registering clients in a namespace,
+// the token is only in this response
+// POST /api/oidc/namespaces/{namespace_id}/initial_access_tokens
+func (h *Handler) handlePostInitialAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var t m.InitialAccessToken
+	if err := h.decodeJSON(ctx, r, &t); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	now := time.Now()
+	var expireTime time.Time
+	if t.ExpiresIn > 0 {
+		expireTime = now.Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	id, token, err := h.Store.CreateInitialAccessToken(ctx, namespace, t.Description, expireTime)
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	v := m.InitialAccessToken{
+		ID:          id,
+		NamespaceID: namespace,
+		Description: t.Description,
+		Token:       token,
+		CreateTime:  now,
+	}
+	if !expireTime.IsZero() {
+		v.ExpireTime = &expireTime
+	}
+	h.R(w, r, http.StatusOK, m.InitialAccessTokenResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Token: v,
+	})
+}
+
+// revoke an initial access token, clients registered with it stay
+// DELETE /api/oidc/namespaces/{namespace_id}/initial_access_tokens/{token_id}
+func (h *Handler) handleDeleteInitialAccessToken(w http.ResponseWriter, r *http.Request) {
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "token_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.DeleteInitialAccessToken(r.Context(), namespace, id)
+	if errors.Is(err, storage.ErrInitialAccessTokenNotFound) {
+		h.R(w, r, http.StatusNotFound, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/directory"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// list the directories of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/directories
+func (h *Handler) handleGetDirectories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	directories, err := h.Store.ListDirectories(ctx, namespace, false)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.DirectoryListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Directories: []m.Directory{},
+	}
+	for _, d := range directories {
+		res.Directories = append(res.Directories, m.DirectoryDB2View(d))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// add a directory to a namespace
+// POST /api/oidc/namespaces/{namespace_id}/directories
+func (h *Handler) handlePostDirectory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var d m.Directory
+	if err := h.decodeJSON(ctx, r, &d); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if d.Name == "" || d.URL == "" || d.BaseDN == "" {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    "name, url and base_dn are required",
+		})
+		return
+	}
+	if d.Kind == "" {
+		d.Kind = directory.KindLDAP
+	}
+	d.ID = uuid.Nil
+	d.NamespaceID = namespace
+	h.saveDirectory(w, r, &d)
+}
+
+// get a directory
+// GET /api/oidc/directories/{directory_id}
+func (h *Handler) handleGetDirectory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "directory_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	d, err := h.Store.GetDirectory(r.Context(), id)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.DirectoryResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Directory: m.DirectoryDB2View(d),
+	})
+}
+
+// update a directory, an empty bind_password keeps the old one
+// PUT /api/oidc/directories/{directory_id}
+func (h *Handler) handlePutDirectory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "directory_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	old, err := h.Store.GetDirectory(ctx, id)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	var d m.Directory
+	if err := h.decodeJSON(ctx, r, &d); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if d.Kind == "" {
+		d.Kind = old.Kind
+	}
+	d.ID = id
+	d.NamespaceID = old.NamespaceID
+	h.saveDirectory(w, r, &d)
+}
+
+// delete a directory, its users keep their accounts without password
+// DELETE /api/oidc/directories/{directory_id}
+func (h *Handler) handleDeleteDirectory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "directory_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.DeleteDirectory(r.Context(), id)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
+
+// try a login against a directory, nothing is stored
+// POST /api/oidc/directories/{directory_id}/test
+func (h *Handler) handleTestDirectory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "directory_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var test m.DirectoryTest
+	if err := h.decodeJSON(ctx, r, &test); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	d, err := h.Store.GetDirectory(ctx, id)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	a, err := directory.New(d)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	entry, err := a.Authenticate(ctx, test.Username, test.Password)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.DirectoryTestResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Entry: m.DirectoryEntryView(entry),
+	})
+}
+
+func (h *Handler) saveDirectory(w http.ResponseWriter, r *http.Request, d *m.Directory) {
+	dir := d.View2DB()
+	err := h.Store.SaveDirectory(r.Context(), dir)
+	if err != nil {
+		h.directoryError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.DirectoryResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Directory: m.DirectoryDB2View(dir),
+	})
+}
+
+func (h *Handler) directoryError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage.ErrDirectoryNotFound) {
+		h.R(w, r, http.StatusNotFound, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusBadRequest, m.Response{
+		Status: m.ErrFailed,
+		Msg:    err.Error(),
+	})
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// list the upstream identity providers of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/identity_providers
+func (h *Handler) handleGetIdentityProviders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	providers, err := h.Store.ListIdentityProviders(ctx, namespace, false)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.IdentityProviderListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Providers: []m.IdentityProvider{},
+	}
+	for _, p := range providers {
+		res.Providers = append(res.Providers, m.IdentityProviderDB2View(p))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// add an upstream identity provider to a namespace
+// POST /api/oidc/namespaces/{namespace_id}/identity_providers
+func (h *Handler) handlePostIdentityProvider(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var provider m.IdentityProvider
+	if err := h.decodeJSON(ctx, r, &provider); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if provider.Name == "" || provider.Issuer == "" || provider.ClientID == "" {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    "name, issuer and client_id are required",
+		})
+		return
+	}
+	provider.ID = uuid.Nil
+	provider.NamespaceID = namespace
+	h.saveIdentityProvider(w, r, &provider)
+}
+
+// get an upstream identity provider
+// GET /api/oidc/identity_providers/{provider_id}
+func (h *Handler) handleGetIdentityProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "provider_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	provider, err := h.Store.GetIdentityProvider(r.Context(), id)
+	if err != nil {
+		h.identityProviderError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.IdentityProviderResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Provider: m.IdentityProviderDB2View(provider),
+	})
+}
+
+// update an upstream identity provider, an empty client_secret keeps the old one
+// PUT /api/oidc/identity_providers/{provider_id}
+func (h *Handler) handlePutIdentityProvider(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := uuid.Parse(chi.URLParam(r, "provider_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	old, err := h.Store.GetIdentityProvider(ctx, id)
+	if err != nil {
+		h.identityProviderError(w, r, err)
+		return
+	}
+	var provider m.IdentityProvider
+	if err := h.decodeJSON(ctx, r, &provider); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	provider.ID = id
+	provider.NamespaceID = old.NamespaceID
+	h.saveIdentityProvider(w, r, &provider)
+}
+
+// delete an upstream identity provider and the identities linked with it
+// DELETE /api/oidc/identity_providers/{provider_id}
+func (h *Handler) handleDeleteIdentityProvider(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "provider_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.DeleteIdentityProvider(r.Context(), id)
+	if err != nil {
+		h.identityProviderError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
+
+func (h *Handler) saveIdentityProvider(w http.ResponseWriter, r *http.Request, provider *m.IdentityProvider) {
+	p := provider.View2DB()
+	err := h.Store.SaveIdentityProvider(r.Context(), p)
+	if err != nil {
+		h.identityProviderError(w, r, err)
+		return
+	}
+	h.R(w, r, http.StatusOK, m.IdentityProviderResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Provider: m.IdentityProviderDB2View(p),
+	})
+}
+
+func (h *Handler) identityProviderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage.ErrProviderNotFound) {
+		h.R(w, r, http.StatusNotFound, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusBadRequest, m.Response{
+		Status: m.ErrFailed,
+		Msg:    err.Error(),
+	})
+}
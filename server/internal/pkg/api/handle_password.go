@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/pkg/password"
+
+	"github.com/sirupsen/logrus"
+)
+
+// get the password policy of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/password_policy
+func (h *Handler) handleGetPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	policy, err := h.Store.GetPasswordPolicy(ctx, namespace)
+	if err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.PasswordPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: m.PasswordPolicyDB2View(policy),
+	})
+}
+
+// set the password policy of a namespace
+// PUT /api/oidc/namespaces/{namespace_id}/password_policy
+func (h *Handler) handlePutPasswordPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var policy m.PasswordPolicy
+	if err := h.decodeJSON(ctx, r, &policy); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if policy.MinLength < 1 || policy.MaxAgeDays < 0 || policy.History < 0 {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    "min_length must be positive, max_age_days and history must not be negative",
+		})
+		return
+	}
+	err = h.Store.SetPasswordPolicy(ctx, namespace, policy.View2DB())
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.PasswordPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: policy,
+	})
+}
+
+// set the password of a user, the policy of the user's namespace applies
+// PUT /api/oidc/users/{user_id}/password
+func (h *Handler) handlePutUserPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var req m.SetPasswordRequest
+	if err := h.decodeJSON(ctx, r, &req); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.SetUserPassword(ctx, userID, req.Password)
+	if err != nil {
+		code, status := passwordError(err)
+		h.R(w, r, code, m.Response{
+			Status: status,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
+
+// passwordError tells policy violations from internal errors
+func passwordError(err error) (int, string) {
+	var perr *password.PolicyError
+	if errors.As(err, &perr) || errors.Is(err, password.ErrReused) {
+		return http.StatusBadRequest, m.ErrInvalidParams
+	}
+	logrus.Error(err)
+	return http.StatusInternalServerError, m.ErrFailed
+}
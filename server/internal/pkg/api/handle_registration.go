@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// get the registration policy of a namespace
+// GET /api/oidc/namespaces/{namespace_id}/registration_policy
+func (h *Handler) handleGetRegistrationPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	policy, err := h.Store.GetRegistrationPolicy(ctx, namespace)
+	if err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.RegistrationPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: m.RegistrationPolicyDB2View(policy),
+	})
+}
+
+// set the registration policy of a namespace
+// PUT /api/oidc/namespaces/{namespace_id}/registration_policy
+func (h *Handler) handlePutRegistrationPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var policy m.RegistrationPolicy
+	if err := h.decodeJSON(ctx, r, &policy); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	for _, f := range policy.RequiredFields {
+		if !knownRegistrationField(f) {
+			h.R(w, r, http.StatusBadRequest, m.Response{
+				Status: m.ErrInvalidParams,
+				Msg:    "unknown required field " + f,
+			})
+			return
+		}
+	}
+	err = h.Store.SetRegistrationPolicy(ctx, namespace, policy.View2DB())
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.RegistrationPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: policy,
+	})
+}
+
+func knownRegistrationField(f string) bool {
+	for _, known := range storage.RegistrationFields {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// list the self registered users waiting for approval
+// GET /api/oidc/namespaces/{namespace_id}/registrations
+func (h *Handler) handleGetRegistrations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	users, err := h.Store.ListPendingUsers(ctx, namespace)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.PendingUserListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Users: []m.PendingUser{},
+	}
+	for _, u := range users {
+		res.Users = append(res.Users, m.PendingUserDB2View(u))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// approve a self registered user
+// POST /api/oidc/users/{user_id}/approve
+func (h *Handler) handleApproveUser(w http.ResponseWriter, r *http.Request) {
+	h.decideRegistration(w, r, h.Store.ApproveUser)
+}
+
+// reject a self registered user, the user is deleted
+// POST /api/oidc/users/{user_id}/reject
+func (h *Handler) handleRejectUser(w http.ResponseWriter, r *http.Request) {
+	h.decideRegistration(w, r, h.Store.RejectUser)
+}
+
+func (h *Handler) decideRegistration(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, userID uuid.UUID) error) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = decide(r.Context(), userID)
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
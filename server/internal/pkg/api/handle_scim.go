@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// list the SCIM provisioning tokens of a client
+// GET /api/oidc/clients/{client_id}/scim_tokens
+func (h *Handler) handleGetSCIMTokens(w http.ResponseWriter, r *http.Request) {
+	clientID, err := uuid.Parse(chi.URLParam(r, "client_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	tokens, err := h.Store.ListSCIMTokens(r.Context(), clientID)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	res := m.SCIMTokenListResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Tokens: []m.SCIMToken{},
+	}
+	for _, t := range tokens {
+		res.Tokens = append(res.Tokens, m.SCIMTokenDB2View(t))
+	}
+	h.R(w, r, http.StatusOK, res)
+}
+
+// issue a SCIM provisioning token for the user namespace of a client,
+// the token is only in this response
+// POST /api/oidc/clients/{client_id}/scim_tokens
+func (h *Handler) handlePostSCIMToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	clientID, err := uuid.Parse(chi.URLParam(r, "client_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var t m.SCIMToken
+	if err := h.decodeJSON(ctx, r, &t); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	id, token, err := h.Store.CreateSCIMToken(ctx, clientID, t.Description)
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.SCIMTokenResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Token: m.SCIMToken{
+			ID:          id,
+			ClientID:    clientID,
+			Description: t.Description,
+			Token:       token,
+			CreateTime:  time.Now(),
+		},
+	})
+}
+
+// revoke a SCIM provisioning token
+// DELETE /api/oidc/clients/{client_id}/scim_tokens/{token_id}
+func (h *Handler) handleDeleteSCIMToken(w http.ResponseWriter, r *http.Request) {
+	clientID, err := uuid.Parse(chi.URLParam(r, "client_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "token_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.DeleteSCIMToken(r.Context(), clientID, id)
+	if errors.Is(err, storage.ErrSCIMTokenNotFound) {
+		h.R(w, r, http.StatusNotFound, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.Response{
+		Status: m.Success,
+		Msg:    "success",
+	})
+}
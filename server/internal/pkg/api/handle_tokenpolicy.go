@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+
+	"github.com/sirupsen/logrus"
+)
+
+// get the token policy of a namespace, the defaults of its clients' token lifetimes
+// GET /api/oidc/namespaces/{namespace_id}/token_policy
+func (h *Handler) handleGetTokenPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	policy, err := h.Store.GetTokenPolicy(ctx, namespace)
+	if err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.TokenPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: m.TokenPolicyDB2View(*policy),
+	})
+}
+
+// set the token policy of a namespace, empty lifetimes are the defaults
+// PUT /api/oidc/namespaces/{namespace_id}/token_policy
+func (h *Handler) handlePutTokenPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	var view m.TokenPolicy
+	if err := h.decodeJSON(ctx, r, &view); err != nil {
+		logrus.Error(err)
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	policy, err := view.View2DB()
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	err = h.Store.SetTokenPolicy(ctx, namespace, policy)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	policy, err = h.Store.GetTokenPolicy(ctx, namespace)
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.TokenPolicyResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Policy: m.TokenPolicyDB2View(*policy),
+	})
+}
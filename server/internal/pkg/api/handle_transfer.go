@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/m"
+	"github.com/zltl/xoidc/server/internal/pkg/transfer"
+
+	"github.com/sirupsen/logrus"
+)
+
+// import files may be this large
+const maxImportSize = 64 << 20
+
+// import users into a namespace from a CSV or JSON Lines body, upserted by username
+// POST /api/oidc/namespaces/{namespace_id}/users/import?format=csv|jsonl&dry_run=true
+func (h *Handler) handleImportUsers(w http.ResponseWriter, r *http.Request) {
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = transfer.FormatCSV
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	result, err := h.Store.ImportUsers(r.Context(), namespace, body, format, dryRun)
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.ImportResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Result: result,
+	})
+}
+
+// export the users of a namespace as CSV or JSON Lines, with password hashes only if asked for
+// GET /api/oidc/namespaces/{namespace_id}/users/export?format=csv|jsonl&password_hashes=true
+func (h *Handler) handleExportUsers(w http.ResponseWriter, r *http.Request) {
+	namespace, err := uuid.Parse(chi.URLParam(r, "namespace_id"))
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = transfer.FormatCSV
+	}
+	uw, err := transfer.NewUserWriter(w, format)
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	w.Header().Set("Content-Type", transfer.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="users.`+format+`"`)
+	err = h.Store.ExportUsers(r.Context(), namespace, uw, r.URL.Query().Get("password_hashes") == "true")
+	if err != nil {
+		// the status is sent with the first user, a broken file is all that is left
+		logrus.Error(err)
+	}
+}
+
+// import clients from a JSON or YAML list, upserted by id, or by name in the user namespace
+// POST /api/oidc/clients/import?format=json|yaml&dry_run=true
+func (h *Handler) handleImportClients(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+	clients, err := transfer.ReadClients(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidRequest,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	result, err := h.Store.ImportClients(r.Context(), clients, r.URL.Query().Get("dry_run") == "true")
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	h.R(w, r, http.StatusOK, m.ImportResponse{
+		Response: m.Response{
+			Status: m.Success,
+		},
+		Result: result,
+	})
+}
+
+// export the clients of a user namespace, or of all, with secrets only if asked for
+// GET /api/oidc/clients/export?format=json|yaml&namespace_id=...&secrets=true
+func (h *Handler) handleExportClients(w http.ResponseWriter, r *http.Request) {
+	namespace := uuid.Nil
+	if s := r.URL.Query().Get("namespace_id"); s != "" {
+		var err error
+		namespace, err = uuid.Parse(s)
+		if err != nil {
+			h.R(w, r, http.StatusBadRequest, m.Response{
+				Status: m.ErrInvalidParams,
+				Msg:    err.Error(),
+			})
+			return
+		}
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+	if format != transfer.FormatJSON && format != transfer.FormatYAML {
+		h.R(w, r, http.StatusBadRequest, m.Response{
+			Status: m.ErrInvalidParams,
+			Msg:    "format must be json or yaml",
+		})
+		return
+	}
+	clients, err := h.Store.ExportClients(r.Context(), namespace, r.URL.Query().Get("secrets") == "true")
+	if err != nil {
+		h.R(w, r, http.StatusInternalServerError, m.Response{
+			Status: m.ErrFailed,
+			Msg:    err.Error(),
+		})
+		return
+	}
+	w.Header().Set("Content-Type", transfer.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="clients.`+format+`"`)
+	err = transfer.WriteClients(w, format, clients)
+	if err != nil {
+		logrus.Error(err)
+	}
+}
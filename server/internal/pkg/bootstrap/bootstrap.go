@@ -0,0 +1,171 @@
+// Package bootstrap declares namespaces with their policies, scopes, clients
+// and admin users in a YAML file, which the storage reconciles into the database
+package bootstrap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"github.com/zltl/xoidc/server/internal/pkg/transfer"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the bootstrap file
+type Config struct {
+	Namespaces []*Namespace `yaml:"namespaces"`
+}
+
+// Namespace is reconciled as a whole, except for the users of it the file does not name
+type Namespace struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+	// the policies are kept if not given
+	PasswordPolicy     *PasswordPolicy     `yaml:"password_policy,omitempty"`
+	RegistrationPolicy *RegistrationPolicy `yaml:"registration_policy,omitempty"`
+	Scopes             []*Scope            `yaml:"scopes,omitempty"`
+	// Clients get the namespace as their user namespace
+	Clients []*clientmeta.Client `yaml:"clients,omitempty"`
+	Users   []*User              `yaml:"users,omitempty"`
+}
+
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min_length" json:"min_length"`
+	RequireUpper  bool `yaml:"require_upper" json:"require_upper"`
+	RequireLower  bool `yaml:"require_lower" json:"require_lower"`
+	RequireDigit  bool `yaml:"require_digit" json:"require_digit"`
+	RequireSymbol bool `yaml:"require_symbol" json:"require_symbol"`
+	MaxAgeDays    int  `yaml:"max_age_days" json:"max_age_days"`
+	History       int  `yaml:"history" json:"history"`
+	CheckBreached bool `yaml:"check_breached" json:"check_breached"`
+}
+
+type RegistrationPolicy struct {
+	Enabled                  bool     `yaml:"enabled" json:"enabled"`
+	RequiredFields           []string `yaml:"required_fields" json:"required_fields"`
+	EmailAllowDomains        []string `yaml:"email_allow_domains" json:"email_allow_domains"`
+	EmailDenyDomains         []string `yaml:"email_deny_domains" json:"email_deny_domains"`
+	RequireEmailVerification bool     `yaml:"require_email_verification" json:"require_email_verification"`
+	RequireApproval          bool     `yaml:"require_approval" json:"require_approval"`
+}
+
+// Scope is a scope the clients of the namespace may request
+type Scope struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description"`
+}
+
+// User is created with its password if missing, the password of existing users is kept
+type User struct {
+	transfer.User `yaml:",inline"`
+	Admin         bool `yaml:"admin,omitempty" json:"admin"`
+}
+
+// ${NAME} is replaced by the environment variable, e.g. for secrets
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Parse reads a bootstrap file. Unset environment variables are errors, an empty
+// secret is not what anybody meant.
+func Parse(r io.Reader) (*Config, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	b = envRef.ReplaceAllFunc(b, func(ref []byte) []byte {
+		name := string(envRef.FindSubmatch(ref)[1])
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return []byte(v)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("environment variables not set: %v", missing)
+	}
+
+	cfg := &Config{}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	err = d.Decode(cfg)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return cfg, cfg.Validate()
+}
+
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+func (c *Config) Validate() error {
+	ids := map[string]bool{}
+	names := map[string]bool{}
+	for _, ns := range c.Namespaces {
+		id, err := uuid.Parse(ns.ID)
+		if err != nil {
+			return fmt.Errorf("namespace %q: id: %w", ns.Name, err)
+		}
+		ns.ID = id.String()
+		if ns.Name == "" {
+			return fmt.Errorf("namespace %s: name is required", ns.ID)
+		}
+		if ids[ns.ID] || names[ns.Name] {
+			return fmt.Errorf("namespace %s: declared twice", ns.Name)
+		}
+		ids[ns.ID], names[ns.Name] = true, true
+		err = ns.validate()
+		if err != nil {
+			return fmt.Errorf("namespace %s: %w", ns.Name, err)
+		}
+	}
+	return nil
+}
+
+func (ns *Namespace) validate() error {
+	scopes := map[string]bool{}
+	for _, s := range ns.Scopes {
+		if s.Name == "" {
+			return errors.New("scope without name")
+		}
+		if scopes[s.Name] {
+			return fmt.Errorf("scope %s: declared twice", s.Name)
+		}
+		scopes[s.Name] = true
+	}
+	clients := map[string]bool{}
+	for _, c := range ns.Clients {
+		if c.UserNamespaceID != "" && c.UserNamespaceID != ns.ID {
+			return fmt.Errorf("client %s: user_namespace_id is the namespace it is declared in", c.Key())
+		}
+		c.UserNamespaceID = ns.ID
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("client %s: %w", c.Key(), err)
+		}
+		if clients[c.Key()] {
+			return fmt.Errorf("client %s: declared twice", c.Key())
+		}
+		clients[c.Key()] = true
+	}
+	users := map[string]bool{}
+	for _, u := range ns.Users {
+		if err := u.Validate(); err != nil {
+			return fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		if users[u.Username] {
+			return fmt.Errorf("user %s: declared twice", u.Username)
+		}
+		users[u.Username] = true
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package bootstrap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const example = `
+namespaces:
+  - id: 7D4E8C0E-1B7A-4C55-9C7E-0B0B4C2F1E11
+    name: default
+    password_policy:
+      min_length: 12
+    scopes:
+      - name: orders:read
+    clients:
+      - name: web
+        secret: ${WEB_SECRET}
+        redirect_uris: [https://app.example.com/callback]
+        application_type: web
+        auth_method: client_secret_basic
+        response_types: [code]
+        grant_types: [authorization_code]
+        access_token_type: bearer
+    users:
+      - username: admin
+        password_hash: $2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy
+        admin: true
+`
+
+func TestParse(t *testing.T) {
+	t.Setenv("WEB_SECRET", "s3cret")
+	cfg, err := Parse(strings.NewReader(example))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := cfg.Namespaces[0]
+	if ns.ID != "7d4e8c0e-1b7a-4c55-9c7e-0b0b4c2f1e11" || ns.PasswordPolicy.MinLength != 12 {
+		t.Errorf("namespace: %+v", ns)
+	}
+	c := ns.Clients[0]
+	if c.Secret != "s3cret" || c.UserNamespaceID != ns.ID {
+		t.Errorf("client: %+v", c)
+	}
+	u := ns.Users[0]
+	if !u.Admin || !strings.HasPrefix(u.PasswordHash, "$2a$10$") {
+		t.Errorf("user: %+v", u)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(strings.NewReader(example))
+	if err == nil || !strings.Contains(err.Error(), "WEB_SECRET") {
+		t.Errorf("missing environment variable: %v", err)
+	}
+	for _, input := range []string{
+		"namespaces:\n  - id: x\n    name: a\n",
+		"namespaces:\n  - id: 7d4e8c0e-1b7a-4c55-9c7e-0b0b4c2f1e11\n    name: a\n    unknown: 1\n",
+		"namespaces:\n  - id: 7d4e8c0e-1b7a-4c55-9c7e-0b0b4c2f1e11\n    name: a\n    scopes: [{name: s}, {name: s}]\n",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("accepted:\n%s", input)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := &RegistrationPolicy{Enabled: true, RequiredFields: []string{}}
+	b := &RegistrationPolicy{Enabled: true, RequireApproval: true}
+	if got := Diff(a, b); !reflect.DeepEqual(got, []string{"require_approval"}) {
+		t.Errorf("got %v", got)
+	}
+	if got := Diff(a, a); len(got) != 0 {
+		t.Errorf("got %v", got)
+	}
+}
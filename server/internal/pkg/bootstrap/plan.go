@@ -0,0 +1,151 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+// actions of changes
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// kinds of resources
+const (
+	KindNamespace          = "namespace"
+	KindPasswordPolicy     = "password_policy"
+	KindRegistrationPolicy = "registration_policy"
+	KindScope              = "scope"
+	KindClient             = "client"
+	KindUser               = "user"
+)
+
+// Change is one step of a plan
+type Change struct {
+	Action    string `json:"action"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	// Fields are the changed fields of updates
+	Fields []string `json:"fields,omitempty"`
+
+	Run func(ctx context.Context, tx *sql.Tx) error `json:"-"`
+}
+
+func (c *Change) String() string {
+	sign := map[string]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}[c.Action]
+	s := fmt.Sprintf("%s %s %s/%s", sign, c.Kind, c.Namespace, c.Key)
+	if len(c.Fields) > 0 {
+		s += " (" + strings.Join(c.Fields, ", ") + ")"
+	}
+	return s
+}
+
+// Plan is the changes which reconcile the database with a bootstrap file
+type Plan struct {
+	Changes []*Change `json:"changes"`
+}
+
+func (p *Plan) Add(c *Change) {
+	p.Changes = append(p.Changes, c)
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Write prints the plan like a diff
+func (p *Plan) Write(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "no changes")
+		return err
+	}
+	for _, c := range p.Changes {
+		if _, err := fmt.Fprintln(w, c.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply runs the changes in order in the transaction and stops at the first
+// error, the caller commits the whole plan or rolls it back
+func (p *Plan) Apply(ctx context.Context, tx *sql.Tx) error {
+	for _, c := range p.Changes {
+		if err := c.Run(ctx, tx); err != nil {
+			return fmt.Errorf("%s: %w", c.String(), err)
+		}
+	}
+	return nil
+}
+
+// Diff returns the sorted names of the fields of two structs which differ by
+// their JSON. Empty lists and missing lists are the same.
+func Diff(old, new any) []string {
+	a, b := fields(old), fields(new)
+	var changed []string
+	for k, v := range b {
+		if !reflect.DeepEqual(a[k], v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func fields(v any) map[string]any {
+	b, _ := json.Marshal(v)
+	m := map[string]any{}
+	_ = json.Unmarshal(b, &m)
+	for k, v := range m {
+		if v == nil || reflect.DeepEqual(v, []any{}) {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+const day = 24 * time.Hour
+
+// Policy is the password policy the storage uses
+func (p *PasswordPolicy) Policy() *password.Policy {
+	return &password.Policy{
+		MinLength:     p.MinLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+		MaxAge:        time.Duration(p.MaxAgeDays) * day,
+		History:       p.History,
+		CheckBreached: p.CheckBreached,
+	}
+}
+
+func PasswordPolicyOf(p *password.Policy) *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:     p.MinLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+		MaxAgeDays:    int(p.MaxAge / day),
+		History:       p.History,
+		CheckBreached: p.CheckBreached,
+	}
+}
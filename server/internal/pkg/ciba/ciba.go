@@ -0,0 +1,117 @@
+// Package ciba has the parts of client initiated backchannel authentication
+// (OpenID Connect CIBA core 1.0) outside of the provider: the delivery modes,
+// the notifiers asking users for their approval and the callbacks to clients.
+package ciba
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GrantType is the grant type of the token requests of ciba clients
+const GrantType = "urn:openid:params:grant-type:ciba"
+
+// the token delivery modes of ciba clients
+const (
+	ModePoll = "poll"
+	ModePing = "ping"
+	ModePush = "push"
+)
+
+// Modes are the supported token delivery modes
+func Modes() []string {
+	return []string{ModePoll, ModePing, ModePush}
+}
+
+// ValidMode tells if the mode is a token delivery mode, empty is none
+func ValidMode(mode string) bool {
+	switch mode {
+	case ModePoll, ModePing, ModePush:
+		return true
+	}
+	return false
+}
+
+// Notification asks the user to approve a backchannel authentication request
+type Notification struct {
+	// ID is the auth_req_id of the request
+	ID       string   `json:"id"`
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	Email    string   `json:"email,omitempty"`
+	Phone    string   `json:"phone,omitempty"`
+	Client   string   `json:"client"`
+	Scopes   []string `json:"scopes"`
+	// BindingMessage is shown on both the device of the client and the one of the user
+	BindingMessage string    `json:"binding_message,omitempty"`
+	ApprovalURL    string    `json:"approval_url"`
+	Expiration     time.Time `json:"expiration"`
+}
+
+// Notifier delivers notifications to the device of the user, e.g. by push message
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// LogNotifier only logs the notifications, use it for development and tests
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n *Notification) error {
+	logrus.Infof("backchannel authentication of %s for %s: %s %s", n.Username, n.Client, n.BindingMessage, n.ApprovalURL)
+	return nil
+}
+
+// WebhookNotifier posts the notifications as JSON to a service delivering them to the users
+type WebhookNotifier struct {
+	URL string
+	// Token is sent as bearer token if set
+	Token  string
+	Client *http.Client
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n *Notification) error {
+	return post(ctx, w.Client, w.URL, w.Token, n)
+}
+
+// Callback posts the ping or push callback of a request to the client
+// notification endpoint, authenticated with the client notification token
+func Callback(ctx context.Context, client *http.Client, endpoint, token string, body any) error {
+	return post(ctx, client, endpoint, token, body)
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func post(ctx context.Context, client *http.Client, url, token string, body any) error {
+	if client == nil {
+		client = defaultClient
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s answered %s", url, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ciba
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n := &Notification{ID: "req", Username: "alice", BindingMessage: "W4SCT", ApprovalURL: "https://op/approve"}
+	err := (&WebhookNotifier{URL: srv.URL, Token: "secret"}).Notify(context.Background(), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != "req" || got.BindingMessage != "W4SCT" {
+		t.Errorf("webhook got %+v", got)
+	}
+	if (&WebhookNotifier{URL: srv.URL}).Notify(context.Background(), n) == nil {
+		t.Error("an unauthorized webhook succeeded")
+	}
+}
+
+func TestValidMode(t *testing.T) {
+	for _, mode := range Modes() {
+		if !ValidMode(mode) {
+			t.Errorf("%s is invalid", mode)
+		}
+	}
+	if ValidMode("") || ValidMode("mail") {
+		t.Error("unknown modes are valid")
+	}
+}
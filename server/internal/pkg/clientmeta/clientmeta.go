@@ -0,0 +1,337 @@
+// Package clientmeta is the metadata of clients and its validation, shared by
+// imports, bootstrap files, dynamic registration and discovery
+package clientmeta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+)
+
+// Client is the metadata of a client row, keyed on its id, or on its name in
+// the user namespace if it has no id
+type Client struct {
+	ID   string `json:"id,omitempty" yaml:"id,omitempty"`
+	Name string `json:"name" yaml:"name"`
+	// Secret keeps the secret of existing clients if empty
+	Secret                         string             `json:"secret,omitempty" yaml:"secret,omitempty"`
+	UserNamespaceID                string             `json:"user_namespace_id" yaml:"user_namespace_id"`
+	RedirectURIs                   []string           `json:"redirect_uris" yaml:"redirect_uris"`
+	PostLogoutRedirectURIGlobs     []string           `json:"post_logout_redirect_uri_globs,omitempty" yaml:"post_logout_redirect_uri_globs,omitempty"`
+	RedirectURIGlobs               []string           `json:"redirect_uri_globs,omitempty" yaml:"redirect_uri_globs,omitempty"`
+	ApplicationType                op.ApplicationType `json:"application_type" yaml:"application_type"`
+	AuthMethod                     string             `json:"auth_method" yaml:"auth_method"`
+	ResponseTypes                  []string           `json:"response_types" yaml:"response_types"`
+	GrantTypes                     []string           `json:"grant_types" yaml:"grant_types"`
+	AccessTokenType                op.AccessTokenType `json:"access_token_type" yaml:"access_token_type"`
+	DevMode                        bool               `json:"dev_mode" yaml:"dev_mode"`
+	IDTokenUserinfoClaimsAssertion bool               `json:"id_token_userinfo_claims_assertion" yaml:"id_token_userinfo_claims_assertion"`
+	// ClockSkew is a duration like 5s
+	ClockSkew string `json:"clock_skew,omitempty" yaml:"clock_skew,omitempty"`
+	// token lifetimes are durations too, empty ones inherit the token policy of the user namespace
+	AccessTokenLifetime      string `json:"access_token_lifetime,omitempty" yaml:"access_token_lifetime,omitempty"`
+	IDTokenLifetime          string `json:"id_token_lifetime,omitempty" yaml:"id_token_lifetime,omitempty"`
+	RefreshTokenLifetime     string `json:"refresh_token_lifetime,omitempty" yaml:"refresh_token_lifetime,omitempty"`
+	RefreshTokenIdleLifetime string `json:"refresh_token_idle_lifetime,omitempty" yaml:"refresh_token_idle_lifetime,omitempty"`
+	// RefreshTokens is enabled, disabled or offline_access, empty inherits
+	RefreshTokens string `json:"refresh_tokens,omitempty" yaml:"refresh_tokens,omitempty"`
+	// AllowedScopes are globs of the scopes the client may request besides
+	// the standard ones, empty allows the scopes of the user namespace
+	AllowedScopes []string `json:"allowed_scopes,omitempty" yaml:"allowed_scopes,omitempty"`
+	// DefaultScopes are added to every authorization request
+	DefaultScopes []string `json:"default_scopes,omitempty" yaml:"default_scopes,omitempty"`
+	// Audiences are the APIs the tokens are for besides the client
+	Audiences []string `json:"audiences,omitempty" yaml:"audiences,omitempty"`
+	// DisallowedScopes is downscope, the default, or reject
+	DisallowedScopes string `json:"disallowed_scopes,omitempty" yaml:"disallowed_scopes,omitempty"`
+	// Resources are the identifiers of the api resources the client may request
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// RequirePushedAuthorizationRequests rejects authorization requests not pushed to /par
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty" yaml:"require_pushed_authorization_requests,omitempty"`
+	// ResponseModes the client may request, empty allows query, fragment and form_post
+	ResponseModes []string `json:"response_modes,omitempty" yaml:"response_modes,omitempty"`
+	// jwt authorization responses are encrypted to a key of JWKS if the alg is set
+	AuthorizationEncryptedResponseAlg string `json:"authorization_encrypted_response_alg,omitempty" yaml:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc string `json:"authorization_encrypted_response_enc,omitempty" yaml:"authorization_encrypted_response_enc,omitempty"`
+	// JWKS is the JSON web key set of the public keys of the client
+	JWKS string `json:"jwks,omitempty" yaml:"jwks,omitempty"`
+	// DPoPBoundAccessTokens requires DPoP proofs (RFC 9449) at the token endpoint
+	DPoPBoundAccessTokens bool `json:"dpop_bound_access_tokens,omitempty" yaml:"dpop_bound_access_tokens,omitempty"`
+	// the certificate of tls_client_auth clients (RFC 8705), exactly one of them is set,
+	// self_signed_tls_client_auth clients list the keys of their certificates in JWKS
+	TLSClientAuthSubjectDN string `json:"tls_client_auth_subject_dn,omitempty" yaml:"tls_client_auth_subject_dn,omitempty"`
+	TLSClientAuthSANDNS    string `json:"tls_client_auth_san_dns,omitempty" yaml:"tls_client_auth_san_dns,omitempty"`
+	TLSClientAuthSANURI    string `json:"tls_client_auth_san_uri,omitempty" yaml:"tls_client_auth_san_uri,omitempty"`
+	TLSClientAuthSANIP     string `json:"tls_client_auth_san_ip,omitempty" yaml:"tls_client_auth_san_ip,omitempty"`
+	TLSClientAuthSANEmail  string `json:"tls_client_auth_san_email,omitempty" yaml:"tls_client_auth_san_email,omitempty"`
+	// TLSClientCertificateBoundAccessTokens binds the access tokens to the client certificate
+	TLSClientCertificateBoundAccessTokens bool `json:"tls_client_certificate_bound_access_tokens,omitempty" yaml:"tls_client_certificate_bound_access_tokens,omitempty"`
+	// BackchannelTokenDeliveryMode is poll, ping or push for clients of the ciba grant,
+	// ping and push callbacks go to the BackchannelClientNotificationEndpoint
+	BackchannelTokenDeliveryMode          string `json:"backchannel_token_delivery_mode,omitempty" yaml:"backchannel_token_delivery_mode,omitempty"`
+	BackchannelClientNotificationEndpoint string `json:"backchannel_client_notification_endpoint,omitempty" yaml:"backchannel_client_notification_endpoint,omitempty"`
+	// FrontchannelLogoutURI is rendered in an iframe when a user signs out,
+	// with iss and sid if FrontchannelLogoutSessionRequired
+	FrontchannelLogoutURI             string `json:"frontchannel_logout_uri,omitempty" yaml:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool   `json:"frontchannel_logout_session_required,omitempty" yaml:"frontchannel_logout_session_required,omitempty"`
+	// BackchannelLogoutURI gets the logout tokens of the sessions of the client
+	BackchannelLogoutURI             string `json:"backchannel_logout_uri,omitempty" yaml:"backchannel_logout_uri,omitempty"`
+	BackchannelLogoutSessionRequired bool   `json:"backchannel_logout_session_required,omitempty" yaml:"backchannel_logout_session_required,omitempty"`
+}
+
+var (
+	responseModes = map[string]bool{
+		"query": true, "fragment": true, "form_post": true,
+		"jwt": true, "query.jwt": true, "fragment.jwt": true, "form_post.jwt": true,
+	}
+	keyAlgorithms = map[string]bool{
+		"RSA-OAEP": true, "RSA-OAEP-256": true,
+		"ECDH-ES": true, "ECDH-ES+A128KW": true, "ECDH-ES+A192KW": true, "ECDH-ES+A256KW": true,
+	}
+	contentEncryptions = map[string]bool{
+		"A128CBC-HS256": true, "A192CBC-HS384": true, "A256CBC-HS512": true,
+		"A128GCM": true, "A192GCM": true, "A256GCM": true,
+	}
+)
+
+// JWTResponseModes are the response modes of clients with JARM metadata
+var JWTResponseModes = []string{"query", "fragment", "form_post", "jwt", "query.jwt", "fragment.jwt", "form_post.jwt"}
+
+// KeyAlgorithms are the key management algorithms of encrypted authorization responses
+func KeyAlgorithms() []string {
+	return sortedKeys(keyAlgorithms)
+}
+
+// ContentEncryptions are the content encryptions of encrypted authorization responses
+func ContentEncryptions() []string {
+	return sortedKeys(contentEncryptions)
+}
+
+// SupportedEncryption rejects the alg and enc, empty for the default, of
+// encrypted authorization responses which are not supported
+func SupportedEncryption(alg, enc string) error {
+	if !keyAlgorithms[alg] {
+		return fmt.Errorf("authorization_encrypted_response_alg %s is not supported", alg)
+	}
+	if enc != "" && !contentEncryptions[enc] {
+		return fmt.Errorf("authorization_encrypted_response_enc %s is not supported", enc)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Key names the client in the rows of a result
+func (c *Client) Key() string {
+	if c.ID != "" {
+		return c.ID
+	}
+	return c.Name
+}
+
+func (c *Client) Validate() error {
+	if c.ID != "" {
+		if _, err := uuid.Parse(c.ID); err != nil {
+			return fmt.Errorf("id: %w", err)
+		}
+	}
+	if c.ID == "" && c.Name == "" {
+		return errors.New("id or name is required")
+	}
+	if _, err := uuid.Parse(c.UserNamespaceID); err != nil {
+		return fmt.Errorf("user_namespace_id: %w", err)
+	}
+	for _, f := range c.durations() {
+		if *f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(*f.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if d < 0 && f.name != "clock_skew" {
+			return fmt.Errorf("%s must not be negative", f.name)
+		}
+	}
+	switch c.RefreshTokens {
+	case "", "enabled", "disabled", "offline_access":
+	default:
+		return errors.New("refresh_tokens must be enabled, disabled or offline_access")
+	}
+	switch c.DisallowedScopes {
+	case "", "downscope", "reject":
+	default:
+		return errors.New("disallowed_scopes must be downscope or reject")
+	}
+	for _, g := range c.AllowedScopes {
+		if _, err := path.Match(g, ""); err != nil {
+			return fmt.Errorf("allowed_scopes: %s: %w", g, err)
+		}
+	}
+	for _, r := range c.Resources {
+		if u, err := url.Parse(r); err != nil || !u.IsAbs() || u.Fragment != "" {
+			return fmt.Errorf("resources: %s is not an absolute uri without fragment", r)
+		}
+	}
+	for _, m := range c.ResponseModes {
+		if !responseModes[m] {
+			return fmt.Errorf("response_modes: %s is not supported", m)
+		}
+	}
+	if err := c.validateTLSClientAuth(); err != nil {
+		return err
+	}
+	if err := c.validateBackchannel(); err != nil {
+		return err
+	}
+	if !logoutURI(c.FrontchannelLogoutURI, true) {
+		return errors.New("frontchannel_logout_uri must be an http or https uri without fragment")
+	}
+	// the server posts the logout tokens, in plain text only for development
+	if !logoutURI(c.BackchannelLogoutURI, c.DevMode) {
+		return errors.New("backchannel_logout_uri must be an https uri without fragment")
+	}
+	return c.validateEncryption()
+}
+
+// logoutURI tells if uri may be a front- or back-channel logout uri, empty is none
+func logoutURI(uri string, plain bool) bool {
+	if uri == "" {
+		return true
+	}
+	u, err := url.Parse(uri)
+	return err == nil && (u.Scheme == "https" || plain && u.Scheme == "http") && u.Host != "" && u.Fragment == ""
+}
+
+func (c *Client) validateBackchannel() error {
+	backchannel := false
+	for _, g := range c.GrantTypes {
+		backchannel = backchannel || g == ciba.GrantType
+	}
+	switch c.BackchannelTokenDeliveryMode {
+	case "":
+		if backchannel {
+			return errors.New("the ciba grant type requires backchannel_token_delivery_mode")
+		}
+		return nil
+	case ciba.ModePoll:
+		return nil
+	case ciba.ModePing, ciba.ModePush:
+		// http only for development
+		if u, err := url.Parse(c.BackchannelClientNotificationEndpoint); err != nil || u.Host == "" || u.Scheme != "https" && !(c.DevMode && u.Scheme == "http") {
+			return fmt.Errorf("backchannel_token_delivery_mode %s requires an https backchannel_client_notification_endpoint", c.BackchannelTokenDeliveryMode)
+		}
+		return nil
+	}
+	return errors.New("backchannel_token_delivery_mode must be poll, ping or push")
+}
+
+func (c *Client) validateTLSClientAuth() error {
+	switch c.AuthMethod {
+	case "tls_client_auth":
+		set := 0
+		for _, v := range []string{c.TLSClientAuthSubjectDN, c.TLSClientAuthSANDNS, c.TLSClientAuthSANURI, c.TLSClientAuthSANIP, c.TLSClientAuthSANEmail} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return errors.New("tls_client_auth requires exactly one of tls_client_auth_subject_dn and the tls_client_auth_san values")
+		}
+		if c.TLSClientAuthSANIP != "" && net.ParseIP(c.TLSClientAuthSANIP) == nil {
+			return fmt.Errorf("tls_client_auth_san_ip: %s is not an ip address", c.TLSClientAuthSANIP)
+		}
+	case "self_signed_tls_client_auth":
+		var keys jose.JSONWebKeySet
+		if err := json.Unmarshal([]byte(c.JWKS), &keys); err != nil || len(keys.Keys) == 0 {
+			return errors.New("self_signed_tls_client_auth requires the keys of the certificates in jwks")
+		}
+	}
+	return nil
+}
+
+func (c *Client) validateEncryption() error {
+	var keys jose.JSONWebKeySet
+	if c.JWKS != "" {
+		if err := json.Unmarshal([]byte(c.JWKS), &keys); err != nil {
+			return fmt.Errorf("jwks: %w", err)
+		}
+	}
+	if c.AuthorizationEncryptedResponseAlg == "" {
+		if c.AuthorizationEncryptedResponseEnc != "" {
+			return errors.New("authorization_encrypted_response_enc requires authorization_encrypted_response_alg")
+		}
+		return nil
+	}
+	if err := SupportedEncryption(c.AuthorizationEncryptedResponseAlg, c.AuthorizationEncryptedResponseEnc); err != nil {
+		return err
+	}
+	for _, k := range keys.Keys {
+		if k.Use != "sig" && k.IsPublic() {
+			return nil
+		}
+	}
+	return errors.New("encrypted authorization responses require a public encryption key in jwks")
+}
+
+func (c *Client) durations() []struct {
+	name  string
+	value *string
+} {
+	return []struct {
+		name  string
+		value *string
+	}{
+		{"clock_skew", &c.ClockSkew},
+		{"access_token_lifetime", &c.AccessTokenLifetime},
+		{"id_token_lifetime", &c.IDTokenLifetime},
+		{"refresh_token_lifetime", &c.RefreshTokenLifetime},
+		{"refresh_token_idle_lifetime", &c.RefreshTokenIdleLifetime},
+	}
+}
+
+// Normalize writes the durations and defaults of a valid client like exports do
+func (c *Client) Normalize() {
+	for _, f := range c.durations() {
+		*f.value = FormatDuration(Duration(*f.value))
+	}
+	if c.DisallowedScopes == "downscope" {
+		c.DisallowedScopes = ""
+	}
+}
+
+// Duration parses a duration of a valid client, empty is 0
+func Duration(s string) time.Duration {
+	d, _ := time.ParseDuration(s)
+	return d
+}
+
+// FormatDuration formats a duration of a client, empty for 0
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// ClockSkewDuration is the parsed ClockSkew of a valid client
+func (c *Client) ClockSkewDuration() time.Duration {
+	return Duration(c.ClockSkew)
+}
@@ -0,0 +1,54 @@
+package clientmeta
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestClientValidate(t *testing.T) {
+	for _, c := range []Client{
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), IDTokenLifetime: "1 hour"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), RefreshTokenLifetime: "-1h"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), RefreshTokens: "always"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), DisallowedScopes: "ignore"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), AllowedScopes: []string{"api:["}},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), Resources: []string{"api.example.com"}},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), ResponseModes: []string{"web_message"}},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), AuthorizationEncryptedResponseAlg: "RSA-OAEP-256"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), AuthorizationEncryptedResponseEnc: "A256GCM"},
+		{Name: "m2m", UserNamespaceID: uuid.Nil.String(), AuthMethod: "tls_client_auth"},
+		{Name: "m2m", UserNamespaceID: uuid.Nil.String(), AuthMethod: "tls_client_auth", TLSClientAuthSANIP: "host"},
+		{Name: "m2m", UserNamespaceID: uuid.Nil.String(), AuthMethod: "self_signed_tls_client_auth"},
+		{Name: "agent", UserNamespaceID: uuid.Nil.String(), GrantTypes: []string{"urn:openid:params:grant-type:ciba"}},
+		{Name: "agent", UserNamespaceID: uuid.Nil.String(), BackchannelTokenDeliveryMode: "mail"},
+		{Name: "agent", UserNamespaceID: uuid.Nil.String(), BackchannelTokenDeliveryMode: "ping", BackchannelClientNotificationEndpoint: "http://agent.example.com/cb"},
+		{Name: "agent", UserNamespaceID: uuid.Nil.String(), BackchannelTokenDeliveryMode: "push", BackchannelClientNotificationEndpoint: "http://localhost:8080/cb"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), FrontchannelLogoutURI: "/logout"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), BackchannelLogoutURI: "https://web.example.com/logout#now"},
+		{Name: "web", UserNamespaceID: uuid.Nil.String(), BackchannelLogoutURI: "http://web.example.com/logout"},
+	} {
+		if c.Validate() == nil {
+			t.Errorf("%+v is valid", c)
+		}
+	}
+
+	// http endpoints for development
+	dev := Client{Name: "agent", UserNamespaceID: uuid.Nil.String(), DevMode: true, BackchannelTokenDeliveryMode: "ping", BackchannelClientNotificationEndpoint: "http://localhost:8080/cb"}
+	if err := dev.Validate(); err != nil {
+		t.Errorf("dev mode: %v", err)
+	}
+	dev = Client{Name: "web", UserNamespaceID: uuid.Nil.String(), DevMode: true, BackchannelLogoutURI: "http://localhost:8080/logout"}
+	if err := dev.Validate(); err != nil {
+		t.Errorf("dev mode: %v", err)
+	}
+
+	c := Client{Name: "web", UserNamespaceID: uuid.Nil.String(), ClockSkew: "-5s", AccessTokenLifetime: "600s", IDTokenLifetime: "0s"}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c.Normalize()
+	if c.ClockSkew != "-5s" || c.AccessTokenLifetime != "10m0s" || c.IDTokenLifetime != "" {
+		t.Errorf("normalized to %+v", c)
+	}
+}
@@ -0,0 +1,437 @@
+// Package clientreg implements OAuth 2.0 dynamic client registration (RFC 7591)
+// and its management protocol (RFC 7592)
+package clientreg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"github.com/zltl/xoidc/server/internal/pkg/publichttp"
+)
+
+// ErrInvalidToken is returned by the backend for unknown or expired tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+// Metadata is the client metadata of RFC 7591 and of OpenID Connect dynamic registration
+type Metadata struct {
+	RedirectURIs            []string        `json:"redirect_uris,omitempty"`
+	TokenEndpointAuthMethod string          `json:"token_endpoint_auth_method,omitempty"`
+	GrantTypes              []string        `json:"grant_types,omitempty"`
+	ResponseTypes           []string        `json:"response_types,omitempty"`
+	ApplicationType         string          `json:"application_type,omitempty"`
+	ClientName              string          `json:"client_name,omitempty"`
+	ClientURI               string          `json:"client_uri,omitempty"`
+	LogoURI                 string          `json:"logo_uri,omitempty"`
+	Scope                   string          `json:"scope,omitempty"`
+	Contacts                []string        `json:"contacts,omitempty"`
+	TosURI                  string          `json:"tos_uri,omitempty"`
+	PolicyURI               string          `json:"policy_uri,omitempty"`
+	JwksURI                 string          `json:"jwks_uri,omitempty"`
+	Jwks                    json.RawMessage `json:"jwks,omitempty"`
+	SoftwareID              string          `json:"software_id,omitempty"`
+	SoftwareVersion         string          `json:"software_version,omitempty"`
+	PostLogoutRedirectURIs  []string        `json:"post_logout_redirect_uris,omitempty"`
+	// RequirePushedAuthorizationRequests is the client metadata of RFC 9126
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests,omitempty"`
+	// the client metadata of JARM, clients registering them may request the jwt response modes
+	AuthorizationSignedResponseAlg    string `json:"authorization_signed_response_alg,omitempty"`
+	AuthorizationEncryptedResponseAlg string `json:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc string `json:"authorization_encrypted_response_enc,omitempty"`
+	// DPoPBoundAccessTokens is the client metadata of RFC 9449
+	DPoPBoundAccessTokens bool `json:"dpop_bound_access_tokens,omitempty"`
+	// the client metadata of mutual TLS (RFC 8705)
+	TLSClientAuthSubjectDN                string `json:"tls_client_auth_subject_dn,omitempty"`
+	TLSClientAuthSANDNS                   string `json:"tls_client_auth_san_dns,omitempty"`
+	TLSClientAuthSANURI                   string `json:"tls_client_auth_san_uri,omitempty"`
+	TLSClientAuthSANIP                    string `json:"tls_client_auth_san_ip,omitempty"`
+	TLSClientAuthSANEmail                 string `json:"tls_client_auth_san_email,omitempty"`
+	TLSClientCertificateBoundAccessTokens bool   `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	// the client metadata of CIBA
+	BackchannelTokenDeliveryMode          string `json:"backchannel_token_delivery_mode,omitempty"`
+	BackchannelClientNotificationEndpoint string `json:"backchannel_client_notification_endpoint,omitempty"`
+	// the client metadata of front- and back-channel logout
+	FrontchannelLogoutURI             string `json:"frontchannel_logout_uri,omitempty"`
+	FrontchannelLogoutSessionRequired bool   `json:"frontchannel_logout_session_required,omitempty"`
+	BackchannelLogoutURI              string `json:"backchannel_logout_uri,omitempty"`
+	BackchannelLogoutSessionRequired  bool   `json:"backchannel_logout_session_required,omitempty"`
+	// SoftwareStatement is a signed JWT of metadata, its values win over the plain ones
+	SoftwareStatement string `json:"software_statement,omitempty"`
+}
+
+// Client is a registered client as returned by the endpoints
+type Client struct {
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64  `json:"client_id_issued_at"`
+	ClientSecretExpiresAt   int64  `json:"client_secret_expires_at"`
+	RegistrationAccessToken string `json:"registration_access_token,omitempty"`
+	RegistrationClientURI   string `json:"registration_client_uri,omitempty"`
+	Metadata
+
+	// Namespace is the user namespace of the client
+	Namespace uuid.UUID `json:"-"`
+}
+
+// Policy is what a namespace allows clients registering themselves
+type Policy struct {
+	Enabled bool
+	// RequireInitialAccessToken rejects registrations without a token of the namespace
+	RequireInitialAccessToken bool
+	RequireSoftwareStatement  bool
+	// SoftwareStatementJWKS is the JSON web key set verifying software statements,
+	// statements are rejected without it
+	SoftwareStatementJWKS string
+	// GrantTypes limits the grant types of clients if not empty
+	GrantTypes []string
+	// RedirectURIGlobs limit the redirect uris of clients if not empty
+	RedirectURIGlobs []string
+}
+
+type Backend interface {
+	// InitialAccessTokenNamespace returns the namespace an initial access token registers clients in
+	InitialAccessTokenNamespace(ctx context.Context, token string) (uuid.UUID, error)
+	// DynamicRegistrationPolicy returns the policy of a namespace, disabled if it has none
+	DynamicRegistrationPolicy(ctx context.Context, namespace uuid.UUID) (*Policy, error)
+	// RegisterClient creates a client with a new secret and registration access token
+	RegisterClient(ctx context.Context, namespace uuid.UUID, md *Metadata) (*Client, error)
+	// RegisteredClient returns the client the registration access token manages
+	RegisteredClient(ctx context.Context, clientID, token string) (*Client, error)
+	UpdateRegisteredClient(ctx context.Context, clientID string, md *Metadata) (*Client, error)
+	DeleteRegisteredClient(ctx context.Context, clientID string) error
+}
+
+// Error is an error response of RFC 7591
+type Error struct {
+	Status      int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func invalidMetadata(format string, args ...any) *Error {
+	return &Error{Status: 400, Code: "invalid_client_metadata", Description: fmt.Sprintf(format, args...)}
+}
+
+func invalidRedirectURI(format string, args ...any) *Error {
+	return &Error{Status: 400, Code: "invalid_redirect_uri", Description: fmt.Sprintf(format, args...)}
+}
+
+func invalidSoftwareStatement(format string, args ...any) *Error {
+	return &Error{Status: 400, Code: "invalid_software_statement", Description: fmt.Sprintf(format, args...)}
+}
+
+// what registered clients may use
+var (
+	grantTypes = map[string]bool{
+		string(oidc.GrantTypeCode):              true,
+		string(oidc.GrantTypeImplicit):          true,
+		string(oidc.GrantTypeRefreshToken):      true,
+		string(oidc.GrantTypeClientCredentials): true,
+		string(oidc.GrantTypeDeviceCode):        true,
+		string(oidc.GrantTypeTokenExchange):     true,
+		ciba.GrantType:                          true,
+	}
+	responseTypes = map[string]bool{
+		string(oidc.ResponseTypeCode):        true,
+		string(oidc.ResponseTypeIDToken):     true,
+		string(oidc.ResponseTypeIDTokenOnly): true,
+	}
+	authMethods = map[string]bool{
+		string(oidc.AuthMethodBasic):  true,
+		string(oidc.AuthMethodPost):   true,
+		string(oidc.AuthMethodNone):   true,
+		"tls_client_auth":             true,
+		"self_signed_tls_client_auth": true,
+	}
+)
+
+// Validate fills in the defaults of RFC 7591 and checks the metadata is consistent
+func (md *Metadata) Validate() error {
+	if md.TokenEndpointAuthMethod == "" {
+		md.TokenEndpointAuthMethod = string(oidc.AuthMethodBasic)
+	}
+	if len(md.GrantTypes) == 0 {
+		md.GrantTypes = []string{string(oidc.GrantTypeCode)}
+	}
+	if md.ApplicationType == "" {
+		md.ApplicationType = "web"
+	}
+	if !authMethods[md.TokenEndpointAuthMethod] {
+		return invalidMetadata("token_endpoint_auth_method %s is not supported", md.TokenEndpointAuthMethod)
+	}
+	if err := md.validateTLSClientAuth(); err != nil {
+		return err
+	}
+	if md.ApplicationType != "web" && md.ApplicationType != "native" {
+		return invalidMetadata("application_type %s is not supported", md.ApplicationType)
+	}
+	grants := map[string]bool{}
+	for _, g := range md.GrantTypes {
+		if !grantTypes[g] {
+			return invalidMetadata("grant type %s is not supported", g)
+		}
+		grants[g] = true
+	}
+	redirects := grants[string(oidc.GrantTypeCode)] || grants[string(oidc.GrantTypeImplicit)]
+	if len(md.ResponseTypes) == 0 && grants[string(oidc.GrantTypeCode)] {
+		md.ResponseTypes = []string{string(oidc.ResponseTypeCode)}
+	}
+	for _, t := range md.ResponseTypes {
+		if !responseTypes[t] {
+			return invalidMetadata("response type %s is not supported", t)
+		}
+		// RFC 7591 2.1, the response types need their grant types
+		if t == string(oidc.ResponseTypeCode) && !grants[string(oidc.GrantTypeCode)] {
+			return invalidMetadata("response type code needs the grant type authorization_code")
+		}
+		if t != string(oidc.ResponseTypeCode) && !grants[string(oidc.GrantTypeImplicit)] {
+			return invalidMetadata("response type %s needs the grant type implicit", t)
+		}
+	}
+	if redirects && len(md.RedirectURIs) == 0 {
+		return invalidRedirectURI("redirect_uris are required for redirect based grant types")
+	}
+	for _, uris := range [][]string{md.RedirectURIs, md.PostLogoutRedirectURIs} {
+		for _, uri := range uris {
+			u, err := url.Parse(uri)
+			if err != nil || !u.IsAbs() || u.Fragment != "" {
+				return invalidRedirectURI("%s is not an absolute uri without fragment", uri)
+			}
+			// OpenID Connect dynamic registration 2, web clients use https
+			if md.ApplicationType == "web" && u.Scheme != "https" && u.Hostname() != "localhost" {
+				return invalidRedirectURI("%s: web clients must use https", uri)
+			}
+		}
+	}
+	if uri := md.FrontchannelLogoutURI; uri != "" {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+			return invalidMetadata("%s is not an absolute logout uri without fragment", uri)
+		}
+		if md.ApplicationType == "web" && u.Scheme != "https" && u.Hostname() != "localhost" {
+			return invalidMetadata("%s: web clients must use https", uri)
+		}
+	}
+	// the browser loads the frontchannel uri, the server posts to the backchannel one
+	if md.BackchannelLogoutURI != "" {
+		if err := publichttp.CheckURL(md.BackchannelLogoutURI); err != nil {
+			return invalidMetadata("backchannel_logout_uri must be a public https uri: %v", err)
+		}
+	}
+	if len(md.Jwks) > 0 && md.JwksURI != "" {
+		return invalidMetadata("jwks and jwks_uri are exclusive")
+	}
+	if md.AuthorizationEncryptedResponseEnc != "" && md.AuthorizationEncryptedResponseAlg == "" {
+		return invalidMetadata("authorization_encrypted_response_enc needs authorization_encrypted_response_alg")
+	}
+	if md.AuthorizationEncryptedResponseAlg != "" {
+		if err := clientmeta.SupportedEncryption(md.AuthorizationEncryptedResponseAlg, md.AuthorizationEncryptedResponseEnc); err != nil {
+			return invalidMetadata("%v", err)
+		}
+		if len(md.Jwks) == 0 {
+			return invalidMetadata("encrypted authorization responses need the keys of the client in jwks")
+		}
+	}
+	return md.validateBackchannel(grants[ciba.GrantType])
+}
+
+// validateBackchannel checks the token delivery of ciba clients
+func (md *Metadata) validateBackchannel(grant bool) error {
+	if !grant {
+		if md.BackchannelTokenDeliveryMode != "" {
+			return invalidMetadata("backchannel_token_delivery_mode needs the grant type %s", ciba.GrantType)
+		}
+		return nil
+	}
+	if !ciba.ValidMode(md.BackchannelTokenDeliveryMode) {
+		return invalidMetadata("backchannel_token_delivery_mode must be poll, ping or push")
+	}
+	if md.BackchannelTokenDeliveryMode == ciba.ModePoll {
+		return nil
+	}
+	// the server posts to the endpoint, registered clients must not point it at its own network
+	if err := publichttp.CheckURL(md.BackchannelClientNotificationEndpoint); err != nil {
+		return invalidMetadata("backchannel_client_notification_endpoint must be a public https uri for %s: %v", md.BackchannelTokenDeliveryMode, err)
+	}
+	return nil
+}
+
+// validateTLSClientAuth checks the certificate of the mutual TLS auth methods is registered
+func (md *Metadata) validateTLSClientAuth() error {
+	switch md.TokenEndpointAuthMethod {
+	case "tls_client_auth":
+		set := 0
+		for _, v := range []string{md.TLSClientAuthSubjectDN, md.TLSClientAuthSANDNS, md.TLSClientAuthSANURI, md.TLSClientAuthSANIP, md.TLSClientAuthSANEmail} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return invalidMetadata("tls_client_auth needs exactly one of tls_client_auth_subject_dn and the tls_client_auth_san values")
+		}
+	case "self_signed_tls_client_auth":
+		if len(md.Jwks) == 0 {
+			return invalidMetadata("self_signed_tls_client_auth needs the keys of the certificates in jwks")
+		}
+	}
+	return nil
+}
+
+// ClientMetadata is the client row of the metadata
+func (md *Metadata) ClientMetadata(id string, namespace uuid.UUID, secret string) *clientmeta.Client {
+	applicationType := op.ApplicationTypeWeb
+	if md.ApplicationType == "native" {
+		applicationType = op.ApplicationTypeNative
+	}
+	c := &clientmeta.Client{
+		ID:              id,
+		Name:            md.ClientName,
+		Secret:          secret,
+		UserNamespaceID: namespace.String(),
+		RedirectURIs:    md.RedirectURIs,
+		ApplicationType: applicationType,
+		AuthMethod:      md.TokenEndpointAuthMethod,
+		ResponseTypes:   md.ResponseTypes,
+		GrantTypes:      md.GrantTypes,
+		AccessTokenType: op.AccessTokenTypeBearer,
+		AllowedScopes:   strings.Fields(md.Scope),
+
+		RequirePushedAuthorizationRequests: md.RequirePushedAuthorizationRequests,
+		AuthorizationEncryptedResponseAlg:  md.AuthorizationEncryptedResponseAlg,
+		AuthorizationEncryptedResponseEnc:  md.AuthorizationEncryptedResponseEnc,
+		DPoPBoundAccessTokens:              md.DPoPBoundAccessTokens,
+
+		TLSClientAuthSubjectDN:                md.TLSClientAuthSubjectDN,
+		TLSClientAuthSANDNS:                   md.TLSClientAuthSANDNS,
+		TLSClientAuthSANURI:                   md.TLSClientAuthSANURI,
+		TLSClientAuthSANIP:                    md.TLSClientAuthSANIP,
+		TLSClientAuthSANEmail:                 md.TLSClientAuthSANEmail,
+		TLSClientCertificateBoundAccessTokens: md.TLSClientCertificateBoundAccessTokens,
+		BackchannelTokenDeliveryMode:          md.BackchannelTokenDeliveryMode,
+		BackchannelClientNotificationEndpoint: md.BackchannelClientNotificationEndpoint,
+		FrontchannelLogoutURI:                 md.FrontchannelLogoutURI,
+		FrontchannelLogoutSessionRequired:     md.FrontchannelLogoutSessionRequired,
+		BackchannelLogoutURI:                  md.BackchannelLogoutURI,
+		BackchannelLogoutSessionRequired:      md.BackchannelLogoutSessionRequired,
+	}
+	if len(md.Jwks) > 0 {
+		c.JWKS = string(md.Jwks)
+	}
+	if md.AuthorizationSignedResponseAlg != "" || md.AuthorizationEncryptedResponseAlg != "" {
+		c.ResponseModes = append([]string(nil), clientmeta.JWTResponseModes...)
+	}
+	return c
+}
+
+// Check rejects the grant types and redirect uris of valid metadata the policy does not allow
+func (p *Policy) Check(md *Metadata) error {
+	if len(p.GrantTypes) > 0 {
+		allowed := map[string]bool{}
+		for _, g := range p.GrantTypes {
+			allowed[g] = true
+		}
+		for _, g := range md.GrantTypes {
+			if !allowed[g] {
+				return invalidMetadata("grant type %s is not allowed", g)
+			}
+		}
+	}
+	if len(p.RedirectURIGlobs) == 0 {
+		return nil
+	}
+	for _, uri := range md.RedirectURIs {
+		if !matchAny(p.RedirectURIGlobs, uri) {
+			return invalidRedirectURI("%s is not allowed", uri)
+		}
+	}
+	return nil
+}
+
+func matchAny(globs []string, s string) bool {
+	for _, g := range globs {
+		if ok, _ := path.Match(g, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySoftwareStatement verifies the software statement of the metadata with
+// the keys of the policy and copies its claims over the plain metadata
+func (p *Policy) ApplySoftwareStatement(md *Metadata, now time.Time) error {
+	if md.SoftwareStatement == "" {
+		if p.RequireSoftwareStatement {
+			return invalidSoftwareStatement("a software statement is required")
+		}
+		return nil
+	}
+	jws, err := jose.ParseSigned(md.SoftwareStatement)
+	if err != nil {
+		return invalidSoftwareStatement("%v", err)
+	}
+	if p.SoftwareStatementJWKS == "" {
+		return &Error{Status: 400, Code: "unapproved_software_statement", Description: "software statements are not accepted"}
+	}
+	var jwks jose.JSONWebKeySet
+	err = json.Unmarshal([]byte(p.SoftwareStatementJWKS), &jwks)
+	if err != nil {
+		return fmt.Errorf("software statement keys: %w", err)
+	}
+	keys := jwks.Keys
+	if kid := jws.Signatures[0].Header.KeyID; kid != "" {
+		keys = jwks.Key(kid)
+	}
+	var payload []byte
+	for _, key := range keys {
+		payload, err = jws.Verify(key)
+		if err == nil {
+			break
+		}
+	}
+	if payload == nil {
+		return &Error{Status: 400, Code: "unapproved_software_statement", Description: "the signature is not of a trusted issuer"}
+	}
+
+	var claims struct {
+		Expiry *int64 `json:"exp"`
+	}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return invalidSoftwareStatement("%v", err)
+	}
+	if claims.Expiry != nil && now.Unix() >= *claims.Expiry {
+		return invalidSoftwareStatement("the software statement expired")
+	}
+	// claims win over plain values of the same name, RFC 7591 3.1.1
+	statement := md.SoftwareStatement
+	b, _ := json.Marshal(md)
+	merged := map[string]json.RawMessage{}
+	_ = json.Unmarshal(b, &merged)
+	err = json.Unmarshal(payload, &merged)
+	if err != nil {
+		return invalidSoftwareStatement("%v", err)
+	}
+	b, _ = json.Marshal(merged)
+	*md = Metadata{}
+	err = json.Unmarshal(b, md)
+	if err != nil {
+		return invalidSoftwareStatement("%v", err)
+	}
+	md.SoftwareStatement = statement
+	return nil
+}
@@ -0,0 +1,143 @@
+package clientreg
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+func code(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+func TestValidate(t *testing.T) {
+	md := &Metadata{RedirectURIs: []string{"https://app.example.com/cb"}}
+	if err := md.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if md.TokenEndpointAuthMethod != "client_secret_basic" || md.ApplicationType != "web" ||
+		!reflect.DeepEqual(md.GrantTypes, []string{"authorization_code"}) ||
+		!reflect.DeepEqual(md.ResponseTypes, []string{"code"}) {
+		t.Errorf("defaults: %+v", md)
+	}
+
+	machine := &Metadata{GrantTypes: []string{"client_credentials"}}
+	if err := machine.Validate(); err != nil || len(machine.ResponseTypes) != 0 {
+		t.Errorf("client credentials: %v %+v", err, machine)
+	}
+
+	for _, c := range []struct {
+		md   Metadata
+		code string
+	}{
+		{Metadata{}, "invalid_redirect_uri"},
+		{Metadata{RedirectURIs: []string{"http://app.example.com/cb"}}, "invalid_redirect_uri"},
+		{Metadata{RedirectURIs: []string{"https://app.example.com/cb#x"}}, "invalid_redirect_uri"},
+		{Metadata{RedirectURIs: []string{"https://a/cb"}, ResponseTypes: []string{"id_token"}}, "invalid_client_metadata"},
+		{Metadata{RedirectURIs: []string{"https://a/cb"}, GrantTypes: []string{"password"}}, "invalid_client_metadata"},
+		{Metadata{RedirectURIs: []string{"https://a/cb"}, TokenEndpointAuthMethod: "tls_client_auth"}, "invalid_client_metadata"},
+		{Metadata{RedirectURIs: []string{"https://a/cb"}, TokenEndpointAuthMethod: "self_signed_tls_client_auth"}, "invalid_client_metadata"},
+		{Metadata{RedirectURIs: []string{"https://a/cb"}, TokenEndpointAuthMethod: "tls_client_auth", TLSClientAuthSubjectDN: "CN=a", TLSClientAuthSANDNS: "a"}, "invalid_client_metadata"},
+		{Metadata{RedirectURIs: []string{"https://a/cb"}, TokenEndpointAuthMethod: "tls_client_auth", TLSClientAuthSubjectDN: "CN=a"}, ""},
+		{Metadata{GrantTypes: []string{"urn:openid:params:grant-type:ciba"}}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"urn:openid:params:grant-type:ciba"}, BackchannelTokenDeliveryMode: "ping"}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"client_credentials"}, BackchannelTokenDeliveryMode: "poll"}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"urn:openid:params:grant-type:ciba"}, BackchannelTokenDeliveryMode: "push", BackchannelClientNotificationEndpoint: "https://a/cb"}, ""},
+		{Metadata{GrantTypes: []string{"urn:openid:params:grant-type:ciba"}, BackchannelTokenDeliveryMode: "ping", BackchannelClientNotificationEndpoint: "https://localhost/cb"}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"urn:openid:params:grant-type:ciba"}, BackchannelTokenDeliveryMode: "ping", BackchannelClientNotificationEndpoint: "https://169.254.169.254/cb"}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"client_credentials"}, BackchannelLogoutURI: "http://a/logout"}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"client_credentials"}, FrontchannelLogoutURI: "https://a/logout#x"}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"client_credentials"}, BackchannelLogoutURI: "https://127.0.0.1/logout"}, "invalid_client_metadata"},
+		{Metadata{ApplicationType: "native", GrantTypes: []string{"client_credentials"}, BackchannelLogoutURI: "http://localhost:8080/logout"}, "invalid_client_metadata"},
+		{Metadata{ApplicationType: "native", GrantTypes: []string{"client_credentials"}, FrontchannelLogoutURI: "http://localhost:8080/logout"}, ""},
+		{Metadata{GrantTypes: []string{"client_credentials"}, BackchannelLogoutURI: "https://a/logout", BackchannelLogoutSessionRequired: true}, ""},
+		{Metadata{GrantTypes: []string{"client_credentials"}, AuthorizationEncryptedResponseAlg: "RSA1_5", Jwks: json.RawMessage(`{"keys": []}`)}, "invalid_client_metadata"},
+		{Metadata{GrantTypes: []string{"client_credentials"}, AuthorizationEncryptedResponseAlg: "RSA-OAEP", AuthorizationEncryptedResponseEnc: "A128CTR", Jwks: json.RawMessage(`{"keys": []}`)}, "invalid_client_metadata"},
+	} {
+		if err := c.md.Validate(); code(err) != c.code {
+			t.Errorf("%+v: got %v, want %s", c.md, err, c.code)
+		}
+	}
+	native := &Metadata{ApplicationType: "native", RedirectURIs: []string{"com.example.app:/cb", "http://localhost:1234/cb"}}
+	if err := native.Validate(); err != nil {
+		t.Errorf("native: %v", err)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{
+		GrantTypes:       []string{"authorization_code", "refresh_token"},
+		RedirectURIGlobs: []string{"https://pr-*.preview.example.com/*"},
+	}
+	ok := &Metadata{RedirectURIs: []string{"https://pr-42.preview.example.com/cb"}, GrantTypes: []string{"authorization_code"}}
+	if err := p.Check(ok); err != nil {
+		t.Error(err)
+	}
+	if err := p.Check(&Metadata{RedirectURIs: []string{"https://evil.example.com/cb"}}); code(err) != "invalid_redirect_uri" {
+		t.Errorf("redirect uri accepted: %v", err)
+	}
+	if err := p.Check(&Metadata{GrantTypes: []string{"client_credentials"}}); code(err) != "invalid_client_metadata" {
+		t.Errorf("grant type accepted: %v", err)
+	}
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(claims)
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSoftwareStatement(t *testing.T) {
+	trusted, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &trusted.PublicKey, KeyID: "ci", Algorithm: "RS256", Use: "sig"}}})
+	p := &Policy{RequireSoftwareStatement: true, SoftwareStatementJWKS: string(jwks)}
+	now := time.Now()
+
+	if err := p.ApplySoftwareStatement(&Metadata{}, now); code(err) != "invalid_software_statement" {
+		t.Errorf("missing statement accepted: %v", err)
+	}
+
+	md := &Metadata{
+		ClientName:        "mine",
+		Contacts:          []string{"dev@example.com"},
+		SoftwareStatement: sign(t, trusted, "ci", map[string]any{"iss": "ci", "client_name": "preview", "software_id": "web", "exp": now.Add(time.Hour).Unix()}),
+	}
+	if err := p.ApplySoftwareStatement(md, now); err != nil {
+		t.Fatal(err)
+	}
+	if md.ClientName != "preview" || md.SoftwareID != "web" || len(md.Contacts) != 1 || md.SoftwareStatement == "" {
+		t.Errorf("claims not applied: %+v", md)
+	}
+
+	untrusted := &Metadata{SoftwareStatement: sign(t, other, "ci", map[string]any{"client_name": "x"})}
+	if err := p.ApplySoftwareStatement(untrusted, now); code(err) != "unapproved_software_statement" {
+		t.Errorf("untrusted statement accepted: %v", err)
+	}
+	expired := &Metadata{SoftwareStatement: sign(t, trusted, "", map[string]any{"exp": now.Add(-time.Minute).Unix()})}
+	if err := p.ApplySoftwareStatement(expired, now); code(err) != "invalid_software_statement" {
+		t.Errorf("expired statement accepted: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package clientreg
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const maxMetadataSize = 64 << 10
+
+// Server serves the registration endpoint and the client configuration endpoints
+type Server struct {
+	backend Backend
+	// Endpoint is the absolute url of the registration endpoint,
+	// the configuration endpoint of a client is Endpoint/<client_id>
+	Endpoint string
+}
+
+func NewServer(backend Backend, endpoint string) *Server {
+	return &Server{
+		backend:  backend,
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+// Serve registers POST /register and /register/{client_id}. They live next to
+// the pages under /register/ where users sign up, so they are no subrouter.
+func (s *Server) Serve(r chi.Router) {
+	r.Post("/register", s.handleRegister)
+	r.Get("/register/{client_id}", s.handleGet)
+	r.Put("/register/{client_id}", s.handleUpdate)
+	r.Delete("/register/{client_id}", s.handleDelete)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		logrus.Error(err)
+		e = &Error{Status: http.StatusInternalServerError, Code: "server_error"}
+	}
+	if e.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer error="`+e.Code+`"`)
+	}
+	writeJSON(w, e.Status, e)
+}
+
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token
+}
+
+var (
+	errInvalidToken = &Error{Status: http.StatusUnauthorized, Code: "invalid_token"}
+	errDisabled     = &Error{Status: http.StatusForbidden, Code: "access_denied", Description: "the namespace does not allow dynamic registration"}
+)
+
+func decodeMetadata(w http.ResponseWriter, r *http.Request, v any) error {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMetadataSize)).Decode(v)
+	if err != nil {
+		return invalidMetadata("%v", err)
+	}
+	return nil
+}
+
+// enabledPolicy is the policy of a namespace which allows dynamic registration
+func (s *Server) enabledPolicy(ctx context.Context, namespace uuid.UUID) (*Policy, error) {
+	policy, err := s.backend.DynamicRegistrationPolicy(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.Enabled {
+		return nil, errDisabled
+	}
+	return policy, nil
+}
+
+// check applies the software statement and the policy to the metadata
+func check(p *Policy, md *Metadata) error {
+	err := p.ApplySoftwareStatement(md, time.Now())
+	if err != nil {
+		return err
+	}
+	err = md.Validate()
+	if err != nil {
+		return err
+	}
+	return p.Check(md)
+}
+
+// register a client, in the namespace of the initial access token if there is
+// one, else in the namespace of the namespace_id parameter
+// POST /register
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var (
+		namespace uuid.UUID
+		err       error
+	)
+	token := bearerToken(r)
+	if token != "" {
+		namespace, err = s.backend.InitialAccessTokenNamespace(ctx, token)
+		if errors.Is(err, ErrInvalidToken) {
+			writeError(w, errInvalidToken)
+			return
+		}
+	} else {
+		namespace, err = uuid.Parse(r.URL.Query().Get("namespace_id"))
+		if err != nil {
+			writeError(w, &Error{Status: http.StatusBadRequest, Code: "invalid_request", Description: "an initial access token or namespace_id is required"})
+			return
+		}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	policy, err := s.enabledPolicy(ctx, namespace)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if policy.RequireInitialAccessToken && token == "" {
+		writeError(w, errInvalidToken)
+		return
+	}
+
+	var md Metadata
+	err = decodeMetadata(w, r, &md)
+	if err == nil {
+		err = check(policy, &md)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	client, err := s.backend.RegisterClient(ctx, namespace, &md)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	client.RegistrationClientURI = s.Endpoint + "/" + client.ClientID
+	writeJSON(w, http.StatusCreated, client)
+}
+
+// registered returns the client of the configuration endpoint, which the
+// registration access token must manage, and the policy of its namespace,
+// which must still allow dynamic registration
+func (s *Server) registered(w http.ResponseWriter, r *http.Request) (*Client, *Policy, bool) {
+	client, err := s.backend.RegisteredClient(r.Context(), chi.URLParam(r, "client_id"), bearerToken(r))
+	if errors.Is(err, ErrInvalidToken) {
+		// RFC 7592 2, unknown clients are not told apart from bad tokens
+		writeError(w, errInvalidToken)
+		return nil, nil, false
+	}
+	if err != nil {
+		writeError(w, err)
+		return nil, nil, false
+	}
+	policy, err := s.enabledPolicy(r.Context(), client.Namespace)
+	if err != nil {
+		writeError(w, err)
+		return nil, nil, false
+	}
+	client.RegistrationClientURI = s.Endpoint + "/" + client.ClientID
+	return client, policy, true
+}
+
+// read the configuration of a registered client
+// GET /register/{client_id}
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	client, _, ok := s.registered(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, client)
+}
+
+// replace the metadata of a registered client, RFC 7592 2.2
+// PUT /register/{client_id}
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	client, policy, ok := s.registered(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Metadata
+	}
+	err := decodeMetadata(w, r, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.ClientID != client.ClientID {
+		writeError(w, invalidMetadata("client_id does not match"))
+		return
+	}
+	if req.ClientSecret != "" && subtle.ConstantTimeCompare([]byte(req.ClientSecret), []byte(client.ClientSecret)) != 1 {
+		writeError(w, invalidMetadata("client_secret does not match"))
+		return
+	}
+	err = check(policy, &req.Metadata)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	client, err = s.backend.UpdateRegisteredClient(ctx, client.ClientID, &req.Metadata)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	client.RegistrationClientURI = s.Endpoint + "/" + client.ClientID
+	writeJSON(w, http.StatusOK, client)
+}
+
+// deregister a client, e.g. when its preview environment is torn down
+// DELETE /register/{client_id}
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	client, _, ok := s.registered(w, r)
+	if !ok {
+		return
+	}
+	err := s.backend.DeleteRegisteredClient(r.Context(), client.ClientID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
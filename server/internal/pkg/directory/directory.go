@@ -0,0 +1,151 @@
+// Package directory authenticates the users of a namespace against external
+// directories, like LDAP and Active Directory
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidCredentials is returned for a wrong password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUserNotFound is returned if the directory has no user with the name
+	ErrUserNotFound = errors.New("user not found in directory")
+)
+
+// Directory is the configuration of a directory of a namespace
+type Directory struct {
+	ID          uuid.UUID
+	NamespaceID uuid.UUID
+	Name        string
+	// Kind selects the authenticator, see Register
+	Kind string
+	// Priority orders the directories of a namespace for unknown users, lowest first
+	Priority int
+
+	// URL is ldap://host:389 or ldaps://host:636
+	URL string
+	// StartTLS upgrades ldap:// connections
+	StartTLS           bool
+	InsecureSkipVerify bool
+	// RootCA is a PEM bundle to verify the server with, instead of the system roots
+	RootCA string
+
+	// BindDN and BindPassword of the service account searching users, empty binds anonymously
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the user, {username} is replaced with the escaped login name
+	UserFilter string
+
+	// GroupBaseDN enables the search of groups with GroupFilter,
+	// {dn} and {username} are replaced with the escaped values of the user
+	GroupBaseDN        string
+	GroupFilter        string
+	GroupNameAttribute string
+
+	// AttributeMapping is a JSON object of claims to directory attributes, see ParseAttributeMapping
+	AttributeMapping string
+
+	// SyncUsers creates unknown users at their first login and updates their profile at every login
+	SyncUsers bool
+	Enabled   bool
+}
+
+// Entry is the user found in the directory, mapped to claims
+type Entry struct {
+	DN         string
+	Username   string
+	GivenName  string
+	FamilyName string
+	Nickname   string
+	Email      string
+	Phone      string
+	Groups     []string
+}
+
+// Authenticator checks the password of a directory user
+type Authenticator interface {
+	// Authenticate returns ErrUserNotFound or ErrInvalidCredentials for failed logins,
+	// other errors mean the directory could not answer
+	Authenticate(ctx context.Context, username, password string) (*Entry, error)
+}
+
+// Factory creates the authenticator of a directory
+type Factory func(d *Directory) (Authenticator, error)
+
+var (
+	factoriesLock sync.RWMutex
+	factories     = map[string]Factory{}
+)
+
+// Register adds a kind of directory
+func Register(kind string, f Factory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	factories[kind] = f
+}
+
+// Kinds lists the registered kinds
+func Kinds() []string {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+	var kinds []string
+	for k := range factories {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// New creates the authenticator of the kind of the directory
+func New(d *Directory) (Authenticator, error) {
+	factoriesLock.RLock()
+	f, ok := factories[d.Kind]
+	factoriesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown directory kind %q", d.Kind)
+	}
+	return f(d)
+}
+
+// claims the attribute mapping may set
+var mappedClaims = map[string]bool{
+	"given_name":   true,
+	"family_name":  true,
+	"nickname":     true,
+	"email":        true,
+	"phone_number": true,
+	"groups":       true,
+}
+
+// ParseAttributeMapping merges the JSON object of claims to attributes into
+// the defaults, an empty attribute drops a claim
+func ParseAttributeMapping(s string, defaults map[string]string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for k, v := range defaults {
+		mapping[k] = v
+	}
+	if s == "" {
+		return mapping, nil
+	}
+	var fields map[string]string
+	err := json.Unmarshal([]byte(s), &fields)
+	if err != nil {
+		return nil, fmt.Errorf("attribute mapping: %w", err)
+	}
+	for k, v := range fields {
+		if !mappedClaims[k] {
+			return nil, fmt.Errorf("attribute mapping: unknown claim %q", k)
+		}
+		mapping[k] = v
+	}
+	return mapping, nil
+}
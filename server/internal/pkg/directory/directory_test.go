@@ -0,0 +1,64 @@
+package directory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFillFilter(t *testing.T) {
+	got := fillFilter("(&(uid={username})(member={dn}))", map[string]string{
+		"username": "ann*)(uid=*",
+		"dn":       "cn=ann,dc=example",
+	})
+	want := `(&(uid=ann\2a\29\28uid=\2a)(member=cn=ann,dc=example))`
+	if got != want {
+		t.Errorf("fillFilter = %s, want %s", got, want)
+	}
+}
+
+func TestGroupName(t *testing.T) {
+	for in, want := range map[string]string{
+		"CN=Domain Admins,CN=Users,DC=corp,DC=example": "Domain Admins",
+		"developers": "developers",
+	} {
+		if got := groupName(in); got != want {
+			t.Errorf("groupName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	a, err := New(&Directory{Kind: KindActiveDirectory, URL: "ldaps://dc.corp.example:636",
+		AttributeMapping: `{"nickname": "", "phone_number": "mobile"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := a.(*LDAP)
+	if l.d.UserFilter != "(&(objectClass=user)(sAMAccountName={username}))" || l.tlsConfig.ServerName != "dc.corp.example" {
+		t.Errorf("defaults not applied: %+v", l.d)
+	}
+	if l.mapping["nickname"] != "" || l.mapping["phone_number"] != "mobile" || l.mapping["groups"] != "memberOf" {
+		t.Errorf("mapping = %v", l.mapping)
+	}
+
+	_, err = New(&Directory{Kind: KindLDAP, AttributeMapping: `{"password": "userPassword"}`})
+	if err == nil {
+		t.Error("unknown claim accepted")
+	}
+	_, err = New(&Directory{Kind: "kerberos"})
+	if err == nil {
+		t.Error("unknown kind accepted")
+	}
+}
+
+func TestEmptyPassword(t *testing.T) {
+	a, err := New(&Directory{Kind: KindLDAP, URL: "ldap://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rejected before connecting
+	_, err = a.Authenticate(context.Background(), "ann", "")
+	if err != ErrInvalidCredentials {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	KindLDAP            = "ldap"
+	KindActiveDirectory = "active_directory"
+)
+
+// the default timeout of connecting and of each operation
+const defaultTimeout = 10 * time.Second
+
+// DefaultLDAPMapping maps the inetOrgPerson attributes
+var DefaultLDAPMapping = map[string]string{
+	"given_name":   "givenName",
+	"family_name":  "sn",
+	"nickname":     "displayName",
+	"email":        "mail",
+	"phone_number": "telephoneNumber",
+}
+
+// DefaultActiveDirectoryMapping reads the groups of memberOf
+var DefaultActiveDirectoryMapping = map[string]string{
+	"given_name":   "givenName",
+	"family_name":  "sn",
+	"nickname":     "displayName",
+	"email":        "mail",
+	"phone_number": "telephoneNumber",
+	"groups":       "memberOf",
+}
+
+func init() {
+	Register(KindLDAP, func(d *Directory) (Authenticator, error) {
+		return newLDAP(d, "(uid={username})", "(member={dn})", DefaultLDAPMapping)
+	})
+	Register(KindActiveDirectory, func(d *Directory) (Authenticator, error) {
+		return newLDAP(d, "(&(objectClass=user)(sAMAccountName={username}))", "(member={dn})", DefaultActiveDirectoryMapping)
+	})
+}
+
+// LDAP authenticates with a search by the service account and a bind as the user
+type LDAP struct {
+	d         Directory
+	mapping   map[string]string
+	tlsConfig *tls.Config
+}
+
+func newLDAP(d *Directory, userFilter, groupFilter string, mapping map[string]string) (*LDAP, error) {
+	l := &LDAP{d: *d}
+	if l.d.UserFilter == "" {
+		l.d.UserFilter = userFilter
+	}
+	if l.d.GroupFilter == "" {
+		l.d.GroupFilter = groupFilter
+	}
+	if l.d.GroupNameAttribute == "" {
+		l.d.GroupNameAttribute = "cn"
+	}
+	var err error
+	l.mapping, err = ParseAttributeMapping(d.AttributeMapping, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	l.tlsConfig = &tls.Config{InsecureSkipVerify: d.InsecureSkipVerify}
+	if d.RootCA != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(d.RootCA)) {
+			return nil, errors.New("no certificate in root CA")
+		}
+		l.tlsConfig.RootCAs = pool
+	}
+	if d.StartTLS || strings.HasPrefix(d.URL, "ldaps://") {
+		host := strings.TrimPrefix(strings.TrimPrefix(d.URL, "ldaps://"), "ldap://")
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		l.tlsConfig.ServerName = host
+	}
+	return l, nil
+}
+
+func (l *LDAP) dial(ctx context.Context) (*ldap.Conn, error) {
+	timeout := defaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	conn, err := ldap.DialURL(l.d.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(l.tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+	if l.d.StartTLS {
+		err = conn.StartTLS(l.tlsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the service account, or stays anonymous
+func (l *LDAP) bindService(conn *ldap.Conn) error {
+	if l.d.BindDN == "" {
+		return nil
+	}
+	return conn.Bind(l.d.BindDN, l.d.BindPassword)
+}
+
+func (l *LDAP) Authenticate(ctx context.Context, username, password string) (*Entry, error) {
+	// an empty password would be an unauthenticated bind, which many servers accept
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("directory %s: %w", l.d.Name, err)
+	}
+	defer conn.Close()
+
+	err = l.bindService(conn)
+	if err != nil {
+		return nil, fmt.Errorf("directory %s: service bind: %w", l.d.Name, err)
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		l.d.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fillFilter(l.d.UserFilter, map[string]string{"username": username}),
+		l.attributes(), nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("directory %s: search user: %w", l.d.Name, err)
+	}
+	if res == nil || len(res.Entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+	// a filter matching several users can not tell who logs in
+	if len(res.Entries) > 1 {
+		return nil, fmt.Errorf("directory %s: several users match %q", l.d.Name, username)
+	}
+	user := res.Entries[0]
+
+	err = conn.Bind(user.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("directory %s: bind: %w", l.d.Name, err)
+	}
+
+	entry := l.entry(username, user)
+	if l.d.GroupBaseDN != "" {
+		// the user may not read the groups
+		err = l.bindService(conn)
+		if err != nil {
+			return nil, fmt.Errorf("directory %s: service bind: %w", l.d.Name, err)
+		}
+		groups, err := l.searchGroups(conn, user.DN, username)
+		if err != nil {
+			return nil, err
+		}
+		entry.Groups = append(entry.Groups, groups...)
+	}
+	return entry, nil
+}
+
+func (l *LDAP) attributes() []string {
+	attrs := []string{}
+	for _, a := range l.mapping {
+		if a != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs
+}
+
+func (l *LDAP) entry(username string, user *ldap.Entry) *Entry {
+	get := func(claim string) string {
+		if l.mapping[claim] == "" {
+			return ""
+		}
+		return user.GetAttributeValue(l.mapping[claim])
+	}
+	entry := &Entry{
+		DN:         user.DN,
+		Username:   username,
+		GivenName:  get("given_name"),
+		FamilyName: get("family_name"),
+		Nickname:   get("nickname"),
+		Email:      get("email"),
+		Phone:      get("phone_number"),
+	}
+	if l.mapping["groups"] != "" {
+		for _, g := range user.GetAttributeValues(l.mapping["groups"]) {
+			entry.Groups = append(entry.Groups, groupName(g))
+		}
+	}
+	return entry
+}
+
+func (l *LDAP) searchGroups(conn *ldap.Conn, dn, username string) ([]string, error) {
+	res, err := conn.Search(ldap.NewSearchRequest(
+		l.d.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fillFilter(l.d.GroupFilter, map[string]string{"dn": dn, "username": username}),
+		[]string{l.d.GroupNameAttribute}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("directory %s: search groups: %w", l.d.Name, err)
+	}
+	var groups []string
+	for _, g := range res.Entries {
+		if name := g.GetAttributeValue(l.d.GroupNameAttribute); name != "" {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
+// fillFilter replaces the {name} placeholders with escaped values
+func fillFilter(filter string, values map[string]string) string {
+	for k, v := range values {
+		filter = strings.ReplaceAll(filter, "{"+k+"}", ldap.EscapeFilter(v))
+	}
+	return filter
+}
+
+// groupName is the value of the first RDN of a group DN like memberOf,
+// other values are names already
+func groupName(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return dn
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
@@ -0,0 +1,173 @@
+// Package dpop validates the DPoP proofs (RFC 9449) clients send to bind
+// access tokens to a key they hold.
+package dpop
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// the headers of DPoP requests and responses
+const (
+	Header      = "DPoP"
+	NonceHeader = "DPoP-Nonce"
+	// TokenType is the token_type of DPoP bound access tokens, and the
+	// authorization scheme they are sent with
+	TokenType = "DPoP"
+)
+
+const (
+	// MaxAge is how long after its iat a proof is accepted
+	MaxAge = 5 * time.Minute
+	// clockSkew tolerates proofs of clients whose clock is ahead
+	clockSkew = time.Minute
+)
+
+// ErrInvalidProof rejects a request with an invalid or missing proof
+func ErrInvalidProof(description string) *oidc.Error {
+	return &oidc.Error{
+		ErrorType:   "invalid_dpop_proof",
+		Description: description,
+	}
+}
+
+// ErrUseNonce asks the client to send the proof again with the DPoP-Nonce of the response
+func ErrUseNonce() *oidc.Error {
+	return &oidc.Error{
+		ErrorType:   "use_dpop_nonce",
+		Description: "the DPoP proof must have the DPoP-Nonce of the server",
+	}
+}
+
+var signingAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// SigningAlgorithms are the algorithms proofs may be signed with
+func SigningAlgorithms() []string {
+	return append([]string(nil), signingAlgorithms...)
+}
+
+// Proof is a validated DPoP proof
+type Proof struct {
+	// JKT is the JWK SHA-256 thumbprint of the key of the proof, the cnf.jkt of bound tokens
+	JKT      string
+	JTI      string
+	Nonce    string
+	IssuedAt time.Time
+}
+
+// Expiration is when the proof is not accepted anymore, replay caches keep its jti until then
+func (p *Proof) Expiration() time.Time {
+	return p.IssuedAt.Add(MaxAge + clockSkew)
+}
+
+type claims struct {
+	JTI   string `json:"jti"`
+	HTM   string `json:"htm"`
+	HTU   string `json:"htu"`
+	IAT   int64  `json:"iat"`
+	ATH   string `json:"ath"`
+	Nonce string `json:"nonce"`
+}
+
+// Parse validates the proof of a request with the method to the url, the
+// access token is the one the request presents, empty at the token endpoint
+func Parse(proof, method, uri, accessToken string, now time.Time) (*Proof, error) {
+	if strings.Count(proof, ".") != 2 {
+		return nil, errors.New("the proof is not a compact JWS")
+	}
+	jws, err := jose.ParseSigned(proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, errors.New("the proof must have one signature")
+	}
+	header := jws.Signatures[0].Protected
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != "dpop+jwt" {
+		return nil, errors.New("the typ of the proof must be dpop+jwt")
+	}
+	if !supported(header.Algorithm) {
+		return nil, fmt.Errorf("the alg %s is not supported", header.Algorithm)
+	}
+	key := header.JSONWebKey
+	if key == nil || !key.IsPublic() || !key.Valid() {
+		return nil, errors.New("the proof must have a public jwk")
+	}
+	payload, err := jws.Verify(key)
+	if err != nil {
+		return nil, err
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, err
+	}
+	if c.JTI == "" {
+		return nil, errors.New("the proof has no jti")
+	}
+	if c.HTM != method {
+		return nil, errors.New("the htm of the proof does not match the request")
+	}
+	if !sameURI(c.HTU, uri) {
+		return nil, errors.New("the htu of the proof does not match the request")
+	}
+	iat := time.Unix(c.IAT, 0)
+	if iat.After(now.Add(clockSkew)) || iat.Before(now.Add(-MaxAge)) {
+		return nil, errors.New("the iat of the proof is out of range")
+	}
+	if accessToken != "" && c.ATH != AccessTokenHash(accessToken) {
+		return nil, errors.New("the ath of the proof does not match the access token")
+	}
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &Proof{
+		JKT:      base64.RawURLEncoding.EncodeToString(thumbprint),
+		JTI:      c.JTI,
+		Nonce:    c.Nonce,
+		IssuedAt: iat,
+	}, nil
+}
+
+func supported(alg string) bool {
+	for _, a := range signingAlgorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// sameURI compares the htu of a proof without query and fragment
+func sameURI(htu, uri string) bool {
+	a, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host) && a.Path == b.Path
+}
+
+// AccessTokenHash is the ath of proofs presenting the access token
+func AccessTokenHash(accessToken string) string {
+	h := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,97 @@
+package dpop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, typ string, claims map[string]any) string {
+	t.Helper()
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{EmbedJWK: true}).WithType(jose.ContentType(typ)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(claims)
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	now := time.Now()
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{
+			"jti": "1",
+			"htm": "POST",
+			"htu": "https://op.example.com/oauth/token",
+			"iat": now.Unix(),
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	p, err := Parse(sign(t, key, "dpop+jwt", claims(nil)), "POST", "https://op.example.com/oauth/token?x=1", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := Parse(sign(t, key, "dpop+jwt", claims(map[string]any{"jti": "2"})), "POST", "https://op.example.com/oauth/token", "", now)
+	if p.JKT == "" || again == nil || again.JKT != p.JKT {
+		t.Errorf("thumbprints of the same key differ: %+v %+v", p, again)
+	}
+
+	if _, err := Parse(sign(t, key, "dpop+jwt", claims(map[string]any{"ath": AccessTokenHash("at")})), "POST", "https://op.example.com/oauth/token", "at", now); err != nil {
+		t.Errorf("ath: %v", err)
+	}
+
+	for name, proof := range map[string]string{
+		"typ":   sign(t, key, "JWT", claims(nil)),
+		"htm":   sign(t, key, "dpop+jwt", claims(map[string]any{"htm": "GET"})),
+		"htu":   sign(t, key, "dpop+jwt", claims(map[string]any{"htu": "https://op.example.com/userinfo"})),
+		"old":   sign(t, key, "dpop+jwt", claims(map[string]any{"iat": now.Add(-10 * time.Minute).Unix()})),
+		"early": sign(t, key, "dpop+jwt", claims(map[string]any{"iat": now.Add(10 * time.Minute).Unix()})),
+		"jti":   sign(t, key, "dpop+jwt", claims(map[string]any{"jti": ""})),
+		"ath":   sign(t, key, "dpop+jwt", claims(map[string]any{"ath": AccessTokenHash("other")})),
+	} {
+		if _, err := Parse(proof, "POST", "https://op.example.com/oauth/token", "at", now); err == nil {
+			t.Errorf("%s: the proof was accepted", name)
+		}
+	}
+}
+
+func TestNonces(t *testing.T) {
+	n := NewNonces([]byte("key"))
+	now := time.Now()
+	nonce := n.New(now)
+	if !n.Valid(nonce, now.Add(time.Minute)) {
+		t.Error("fresh nonce is invalid")
+	}
+	if n.Valid(nonce, now.Add(NonceLifetime+time.Second)) {
+		t.Error("expired nonce is valid")
+	}
+	if NewNonces([]byte("other")).Valid(nonce, now) {
+		t.Error("nonce of another key is valid")
+	}
+	tampered := []byte(nonce)
+	tampered[0] ^= 1
+	if n.Valid(string(tampered), now) || n.Valid("", now) {
+		t.Error("tampered nonce is valid")
+	}
+}
@@ -0,0 +1,48 @@
+package dpop
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+)
+
+// NonceLifetime is how long a server nonce is accepted
+const NonceLifetime = 5 * time.Minute
+
+// Nonces issues the server nonces of proofs, they are the time signed with
+// the key, so every node of the server accepts them without shared state
+type Nonces struct {
+	key []byte
+}
+
+func NewNonces(key []byte) *Nonces {
+	return &Nonces{key: key}
+}
+
+func (n *Nonces) New(now time.Time) string {
+	b := make([]byte, 8, 8+sha256.Size)
+	binary.BigEndian.PutUint64(b, uint64(now.Unix()))
+	return base64.RawURLEncoding.EncodeToString(append(b, n.mac(b)...))
+}
+
+// Valid tells if the nonce was issued by New in the last NonceLifetime
+func (n *Nonces) Valid(nonce string, now time.Time) bool {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(b) != 8+sha256.Size {
+		return false
+	}
+	if !hmac.Equal(b[8:], n.mac(b[:8])) {
+		return false
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(b[:8])), 0)
+	return !issued.After(now.Add(clockSkew)) && now.Sub(issued) <= NonceLifetime
+}
+
+func (n *Nonces) mac(b []byte) []byte {
+	m := hmac.New(sha256.New, n.key)
+	m.Write([]byte("dpop-nonce"))
+	m.Write(b)
+	return m.Sum(nil)
+}
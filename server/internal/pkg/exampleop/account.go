@@ -0,0 +1,251 @@
+package exampleop
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/gen/xoidc/public/model"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// the account pages are mounted on pathAccount
+const pathAccount = "/account"
+
+type accountStorage interface {
+	ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword string) error
+	GetUserProfile(ctx context.Context, id uuid.UUID) (*model.User, error)
+	UpdateUserProfile(ctx context.Context, id uuid.UUID, u *model.User) error
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*storage.UserSession, error)
+	DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error
+	ListConsents(ctx context.Context, userID uuid.UUID) ([]*storage.Consent, error)
+	RevokeConsent(ctx context.Context, userID, clientID uuid.UUID) error
+	ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]*storage.UserIdentity, error)
+	UnlinkIdentity(ctx context.Context, userID, providerID uuid.UUID, subject string) error
+}
+
+// account serves the pages a signed in user uses to manage the own account
+type account struct {
+	users    accountStorage
+	passkeys passkeyStorage
+	verifier *verifier
+	webauthn *webauthn.WebAuthn
+	sessions *sessionCookies
+	// portal signs in to the account pages with OIDC, without it the pages
+	// trust the session of the last login of the browser
+	portal *accountPortal
+	router chi.Router
+}
+
+func NewAccount(users accountStorage, passkeys passkeyStorage, verifier *verifier, webAuthn *webauthn.WebAuthn, sessions *sessionCookies, portal *accountPortal) *account {
+	a := &account{
+		users:    users,
+		passkeys: passkeys,
+		verifier: verifier,
+		webauthn: webAuthn,
+		sessions: sessions,
+		portal:   portal,
+	}
+	a.createRouter()
+	return a
+}
+
+func (a *account) createRouter() {
+	a.router = chi.NewRouter()
+	a.router.Get("/", a.indexHandler)
+	if a.portal != nil {
+		a.router.Get("/login", a.portal.loginHandler)
+		a.router.Get("/callback", a.portal.callbackHandler)
+	}
+	a.router.Post("/logout", a.logoutHandler)
+	a.router.Get("/profile", a.profileHandler)
+	a.router.Post("/profile", a.saveProfileHandler)
+	a.router.Get("/sessions", a.sessionsHandler)
+	a.router.Post("/sessions/revoke", a.revokeSessionHandler)
+	a.router.Get("/clients", a.clientsHandler)
+	a.router.Post("/clients/revoke", a.revokeClientHandler)
+	a.router.Get("/identities", a.identitiesHandler)
+	a.router.Post("/identities/unlink", a.unlinkIdentityHandler)
+	a.router.Get("/password", a.passwordHandler)
+	a.router.Post("/password", a.changePasswordHandler)
+	a.router.Get("/contact", a.contactHandler)
+	a.router.Post("/contact/email", a.changeContactHandler(storage.VerifyEmail))
+	a.router.Post("/contact/phone", a.changeContactHandler(storage.VerifyPhone))
+	a.router.Post(pathAccountEmailVerify, a.sendVerificationHandler(storage.VerifyEmail))
+	a.router.Post(pathAccountPhoneVerify, a.sendVerificationHandler(storage.VerifyPhone))
+	a.router.Post(pathAccountPhoneConfirm, a.confirmPhoneHandler)
+	a.router.Get(pathAccountEmailConfirm, a.confirmEmailHandler)
+	a.router.Get("/passkeys", a.passkeysHandler)
+	a.router.Post("/passkeys/begin", a.beginRegistrationHandler)
+	a.router.Post("/passkeys/finish", a.finishRegistrationHandler)
+	a.router.Post("/passkeys/delete", a.deletePasskeyHandler)
+}
+
+// userID returns the user signed in to the account pages.
+// The portal sign in ends with the session of the browser, so revoked sessions are signed out here too.
+func (a *account) userID(r *http.Request) (uuid.UUID, error) {
+	sessionUser, err := a.sessions.UserID(r)
+	if a.portal == nil || err != nil {
+		return sessionUser, err
+	}
+	portalUser, err := a.portal.UserID(r)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if portalUser != sessionUser {
+		return uuid.UUID{}, storage.ErrSessionNotFound
+	}
+	return portalUser, nil
+}
+
+// signIn sends users without a session to the sign in of the portal
+func (a *account) signIn(w http.ResponseWriter, r *http.Request) {
+	if a.portal != nil && r.Method == http.MethodGet {
+		http.Redirect(w, r, "/account/login", http.StatusFound)
+		return
+	}
+	http.Error(w, "please sign in first", http.StatusUnauthorized)
+}
+
+// currentUser loads the user signed in with this browser
+func (a *account) currentUser(r *http.Request) (*storage.PasskeyUser, error) {
+	userID, err := a.userID(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.passkeys.GetPasskeyUser(r.Context(), userID)
+}
+
+func (a *account) passwordHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := a.userID(r); err != nil {
+		a.signIn(w, r)
+		return
+	}
+	renderPassword(w, "/account/password", "", "", "", nil)
+}
+
+func (a *account) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	err = a.users.ChangePassword(r.Context(), userID, r.FormValue("password"), r.FormValue("new_password"))
+	if err != nil {
+		renderPassword(w, "/account/password", "", "", "", err)
+		return
+	}
+	renderPassword(w, "/account/password", "", "", "password changed", nil)
+}
+
+func (a *account) passkeysHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := a.currentUser(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	type passkey struct {
+		ID       string
+		Name     string
+		AMR      string
+		Created  string
+		LastUsed string
+	}
+	data := &struct {
+		Username string
+		Passkeys []passkey
+		Error    string
+	}{
+		Username: user.Username,
+		Error:    r.URL.Query().Get("error"),
+	}
+	for _, c := range user.Credentials {
+		data.Passkeys = append(data.Passkeys, passkey{
+			ID:       c.ID.String(),
+			Name:     c.Name,
+			AMR:      c.AMR(),
+			Created:  c.CreateTime.Format(accountTimeLayout),
+			LastUsed: c.LastUsedTime.Format(accountTimeLayout),
+		})
+	}
+	err = templates.ExecuteTemplate(w, "passkeys", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (a *account) beginRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := a.currentUser(r)
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err)
+		return
+	}
+	// passkeys must be discoverable to be usable without a username
+	creation, session, err := a.webauthn.BeginRegistration(user,
+		webauthn.WithExclusions(user.CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	err = a.passkeys.StoreWebAuthnSession(r.Context(), passkeyRegisterSession(user.ID), session)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, creation)
+}
+
+func (a *account) finishRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	user, err := a.currentUser(r)
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err)
+		return
+	}
+	session, err := a.passkeys.TakeWebAuthnSession(ctx, passkeyRegisterSession(user.ID))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	cred, err := a.webauthn.FinishRegistration(user, *session, r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "passkey"
+	}
+	err = a.passkeys.StorePasskeyCredential(ctx, user.ID, name, cred)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"redirect": "/account/passkeys",
+	})
+}
+
+func (a *account) deletePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	id, err := uuid.Parse(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.passkeys.DeletePasskeyCredential(r.Context(), userID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/account/passkeys", http.StatusFound)
+}
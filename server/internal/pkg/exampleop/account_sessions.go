@@ -0,0 +1,190 @@
+package exampleop
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const accountTimeLayout = "2006-01-02 15:04"
+
+// sessionsHandler lists the browsers the user is signed in with
+func (a *account) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	sessions, err := a.users.ListUserSessions(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	current, _ := a.sessions.SessionID(r)
+	type session struct {
+		ID        string
+		UserAgent string
+		RemoteIP  string
+		AMR       string
+		Created   string
+		LastSeen  string
+		Current   bool
+	}
+	data := &struct {
+		Sessions []session
+	}{}
+	for _, s := range sessions {
+		data.Sessions = append(data.Sessions, session{
+			ID:        s.ID.String(),
+			UserAgent: s.UserAgent,
+			RemoteIP:  s.RemoteIP,
+			AMR:       strings.Join(s.AMR, ", "),
+			Created:   s.CreateTime.Format(accountTimeLayout),
+			LastSeen:  s.LastSeenTime.Format(accountTimeLayout),
+			Current:   s.ID == current,
+		})
+	}
+	err = templates.ExecuteTemplate(w, "sessions", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (a *account) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	id, err := uuid.Parse(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.users.DeleteUserSession(r.Context(), userID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/account/sessions", http.StatusFound)
+}
+
+// clientsHandler lists the clients the user granted access
+func (a *account) clientsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	consents, err := a.users.ListConsents(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type client struct {
+		ID      string
+		Name    string
+		Scopes  string
+		Granted string
+		Updated string
+	}
+	data := &struct {
+		Clients []client
+	}{}
+	for _, c := range consents {
+		name := c.ClientName
+		if name == "" {
+			name = c.ClientID.String()
+		}
+		data.Clients = append(data.Clients, client{
+			ID:      c.ClientID.String(),
+			Name:    name,
+			Scopes:  strings.Join(c.Scopes, " "),
+			Granted: c.CreateTime.Format(accountTimeLayout),
+			Updated: c.UpdateTime.Format(accountTimeLayout),
+		})
+	}
+	err = templates.ExecuteTemplate(w, "clients", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// revokeClientHandler withdraws the consent and the tokens of a client
+func (a *account) revokeClientHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	clientID, err := uuid.Parse(r.FormValue("client_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.users.RevokeConsent(r.Context(), userID, clientID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/account/clients", http.StatusFound)
+}
+
+// identitiesHandler lists the accounts of upstream providers linked to the user
+func (a *account) identitiesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	identities, err := a.users.ListUserIdentities(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type identity struct {
+		ProviderID string
+		Provider   string
+		Subject    string
+		Email      string
+		Linked     string
+		LastLogin  string
+	}
+	data := &struct {
+		Identities []identity
+	}{}
+	for _, i := range identities {
+		data.Identities = append(data.Identities, identity{
+			ProviderID: i.ProviderID.String(),
+			Provider:   i.ProviderName,
+			Subject:    i.Subject,
+			Email:      i.Email,
+			Linked:     i.CreateTime.Format(accountTimeLayout),
+			LastLogin:  i.LastLoginTime.Format(accountTimeLayout),
+		})
+	}
+	err = templates.ExecuteTemplate(w, "identities", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (a *account) unlinkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	providerID, err := uuid.Parse(r.FormValue("provider_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.users.UnlinkIdentity(r.Context(), userID, providerID, r.FormValue("subject"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/account/identities", http.StatusFound)
+}
@@ -0,0 +1,109 @@
+package exampleop
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// fakeSessions keeps the user sessions of the account tests in a map,
+// the other methods of accountStorage are not used
+type fakeSessions struct {
+	accountStorage
+	owners map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeSessions) CreateUserSession(ctx context.Context, userID uuid.UUID, userAgent, remoteIP string, amr []string) (uuid.UUID, error) {
+	id := uuid.New()
+	f.owners[id] = userID
+	return id, nil
+}
+
+func (f *fakeSessions) TouchUserSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	owner, ok := f.owners[id]
+	if !ok {
+		return uuid.Nil, storage.ErrSessionNotFound
+	}
+	return owner, nil
+}
+
+func (f *fakeSessions) DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error {
+	if f.owners[id] == userID {
+		delete(f.owners, id)
+	}
+	return nil
+}
+
+func (f *fakeSessions) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*storage.UserSession, error) {
+	var sessions []*storage.UserSession
+	for id, owner := range f.owners {
+		if owner == userID {
+			sessions = append(sessions, &storage.UserSession{ID: id, UserID: owner, CreateTime: time.Now(), LastSeenTime: time.Now()})
+		}
+	}
+	return sessions, nil
+}
+
+func TestRevokeSession(t *testing.T) {
+	store := &fakeSessions{owners: map[uuid.UUID]uuid.UUID{}}
+	sessions := newSessionCookies(store, sha256.Sum256([]byte("a key of the tests")), true)
+	a := &account{users: store, sessions: sessions}
+	a.createRouter()
+
+	// signIn returns the session cookie of a new browser of the user
+	signIn := func(userID uuid.UUID) (*http.Cookie, uuid.UUID) {
+		id, _ := store.CreateUserSession(context.Background(), userID, "", "", nil)
+		w := httptest.NewRecorder()
+		if err := sessions.handler.SetCookie(w, sessionCookieName, id.String()); err != nil {
+			t.Fatal(err)
+		}
+		return w.Result().Cookies()[0], id
+	}
+	do := func(cookie *http.Cookie, method, path string, form url.Values) int {
+		r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		a.router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	alice, bob := uuid.New(), uuid.New()
+	laptop, _ := signIn(alice)
+	phone, phoneID := signIn(alice)
+	bobs, bobsID := signIn(bob)
+
+	if code := do(phone, http.MethodGet, "/sessions", nil); code != http.StatusOK {
+		t.Fatalf("sessions page: got %d", code)
+	}
+
+	// the sessions of others are not revoked
+	if code := do(laptop, http.MethodPost, "/sessions/revoke", url.Values{"id": {bobsID.String()}}); code != http.StatusFound {
+		t.Fatalf("revoke: got %d", code)
+	}
+	if code := do(bobs, http.MethodGet, "/sessions", nil); code != http.StatusOK {
+		t.Errorf("the session of another user was revoked: got %d", code)
+	}
+
+	// a revoked browser is signed out
+	if code := do(laptop, http.MethodPost, "/sessions/revoke", url.Values{"id": {phoneID.String()}}); code != http.StatusFound {
+		t.Fatalf("revoke: got %d", code)
+	}
+	if code := do(phone, http.MethodGet, "/sessions", nil); code != http.StatusUnauthorized {
+		t.Errorf("revoked session: got %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := do(phone, http.MethodPost, "/sessions/revoke", url.Values{"id": {bobsID.String()}}); code != http.StatusUnauthorized {
+		t.Errorf("revoke with a revoked session: got %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := do(laptop, http.MethodGet, "/sessions", nil); code != http.StatusOK {
+		t.Errorf("the current session was revoked: got %d", code)
+	}
+}
@@ -0,0 +1,44 @@
+package exampleop
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// authorizationDetails passes the authorization_details parameter (RFC 9396)
+// of authorization and token requests to the storage, the library ignores it.
+// Token responses list the details of the issued tokens.
+func authorizationDetails(next http.Handler) http.Handler {
+	endpoints := op.DefaultEndpoints
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case endpoints.Authorization.Relative():
+			if err := r.ParseForm(); err == nil {
+				r = r.WithContext(storage.WithAuthorizationDetails(r.Context(), r.Form.Get("authorization_details")))
+			}
+		case endpoints.Token.Relative():
+			if err := r.ParseForm(); err != nil {
+				break
+			}
+			ctx := storage.WithAuthorizationDetails(r.Context(), r.Form.Get("authorization_details"))
+			buf := &bufferedResponse{header: http.Header{}}
+			next.ServeHTTP(buf, r.WithContext(ctx))
+			if details := storage.IssuedAuthorizationDetails(ctx); buf.status == http.StatusOK && len(details) > 0 {
+				var res map[string]json.RawMessage
+				if json.Unmarshal(buf.body.Bytes(), &res) == nil {
+					res["authorization_details"], _ = json.Marshal(details)
+					body, _ := json.Marshal(res)
+					buf.body.Reset()
+					buf.body.Write(body)
+					buf.header.Del("Content-Length")
+				}
+			}
+			buf.writeTo(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,432 @@
+package exampleop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+	"github.com/zltl/xoidc/server/internal/pkg/publichttp"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+const pathBackchannelAuthorize = "/bc-authorize"
+
+type backchannelStorage interface {
+	BackchannelUser(ctx context.Context, clientID, loginHint, subject string) (*storage.User, error)
+	CreateBackchannelAuthRequest(ctx context.Context, r *storage.BackchannelAuthRequest) error
+	PendingBackchannelAuthRequest(ctx context.Context, id uuid.UUID) (*storage.BackchannelAuthRequest, error)
+	DecideBackchannelAuthRequest(ctx context.Context, id, sessionID uuid.UUID, approve bool) (*storage.BackchannelAuthRequest, error)
+	BackchannelTokenRequest(ctx context.Context, authReqID, clientID string) (*storage.BackchannelAuthRequest, error)
+}
+
+// backchannelClient is the ciba metadata of the clients of the storage
+type backchannelClient interface {
+	BackchannelTokenDeliveryMode() string
+	BackchannelClientNotificationEndpoint() string
+	Registered() bool
+}
+
+// backchannel serves client initiated backchannel authentication (CIBA):
+// clients start it on /bc-authorize, the user approves it on the page of the
+// link the notifier delivers, and the client gets the tokens by poll, ping or push
+type backchannel struct {
+	provider op.OpenIDProvider
+	storage  backchannelStorage
+	notifier ciba.Notifier
+	sessions *sessionCookies
+	links    *signedIDs
+	issuer   string
+	router   chi.Router
+	// public posts the callbacks of registered clients
+	public *http.Client
+
+	issuerInterceptor *op.IssuerInterceptor
+}
+
+func newBackchannel(provider op.OpenIDProvider, store backchannelStorage, notifier ciba.Notifier, sessions *sessionCookies, key [32]byte, issuer string, issuerInterceptor *op.IssuerInterceptor) *backchannel {
+	b := &backchannel{
+		provider: provider,
+		storage:  store,
+		notifier: notifier,
+		sessions: sessions,
+		links:    newSignedIDs(key, "backchannel", storage.ErrBackchannelAuthRequestNotFound),
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		public:   publichttp.Client(10 * time.Second),
+
+		issuerInterceptor: issuerInterceptor,
+	}
+	b.router = chi.NewRouter()
+	b.router.Get("/approve", b.approveHandler)
+	b.router.Post("/approve", issuerInterceptor.HandlerFunc(b.decideHandler))
+	return b
+}
+
+type backchannelResponse struct {
+	AuthReqID string `json:"auth_req_id"`
+	ExpiresIn int    `json:"expires_in"`
+	Interval  int    `json:"interval,omitempty"`
+}
+
+// authorize starts the authentication of the user of the hint and notifies the user
+func (b *backchannel) authorize(w http.ResponseWriter, r *http.Request) {
+	logger := b.provider.Logger()
+	ctx := r.Context()
+	client, err := authenticateClient(r, b.provider)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	if client.AuthMethod() == oidc.AuthMethodNone {
+		op.RequestError(w, r, oidc.ErrInvalidClient().WithDescription("ciba clients must authenticate"), logger)
+		return
+	}
+	if r.Form.Get("request") != "" {
+		op.RequestError(w, r, oidc.ErrRequestNotSupported(), logger)
+		return
+	}
+	subject, err := b.hintSubject(ctx, client.GetID(), r.Form)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	user, err := b.storage.BackchannelUser(ctx, client.GetID(), r.Form.Get("login_hint"), subject)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	bindingMessage := r.Form.Get("binding_message")
+	if len(bindingMessage) > 200 {
+		op.RequestError(w, r, &oidc.Error{ErrorType: "invalid_binding_message", Description: "the binding_message is too long"}, logger)
+		return
+	}
+	req := &storage.BackchannelAuthRequest{
+		ClientID:                uuid.MustParse(client.GetID()),
+		UserID:                  user.ID,
+		Scopes:                  strings.Fields(r.Form.Get("scope")),
+		BindingMessage:          bindingMessage,
+		ClientNotificationToken: r.Form.Get("client_notification_token"),
+	}
+	if expiry := r.Form.Get("requested_expiry"); expiry != "" {
+		seconds, err := strconv.Atoi(expiry)
+		if err != nil || seconds <= 0 {
+			op.RequestError(w, r, oidc.ErrInvalidRequest().WithDescription("requested_expiry must be a positive number of seconds"), logger)
+			return
+		}
+		req.Expiration = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	err = b.storage.CreateBackchannelAuthRequest(ctx, req)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	err = b.notifier.Notify(ctx, &ciba.Notification{
+		ID:             req.ID.String(),
+		UserID:         user.ID.String(),
+		Username:       user.Username,
+		Email:          user.Email,
+		Phone:          user.Phone,
+		Client:         clientName(client),
+		Scopes:         req.Scopes,
+		BindingMessage: req.BindingMessage,
+		ApprovalURL:    b.issuer + "/backchannel/approve?token=" + url.QueryEscape(b.links.Sign(req.ID, req.Expiration)),
+		Expiration:     req.Expiration,
+	})
+	if err != nil {
+		op.RequestError(w, r, oidc.ErrServerError().WithParent(err).WithDescription("unable to notify the user"), logger)
+		return
+	}
+	res := backchannelResponse{
+		AuthReqID: req.ID.String(),
+		ExpiresIn: int(time.Until(req.Expiration).Seconds()),
+	}
+	if backchannelMode(client) != ciba.ModePush {
+		res.Interval = int(req.PollInterval.Seconds())
+	}
+	httphelper.MarshalJSON(w, res)
+}
+
+// hintSubject returns the subject of the id_token_hint, a request has exactly
+// one hint and login_hint_token is not supported
+func (b *backchannel) hintSubject(ctx context.Context, clientID string, form url.Values) (string, error) {
+	hints := 0
+	for _, name := range []string{"login_hint", "id_token_hint", "login_hint_token"} {
+		if form.Get(name) != "" {
+			hints++
+		}
+	}
+	if hints != 1 {
+		return "", oidc.ErrInvalidRequest().WithDescription("exactly one of login_hint and id_token_hint is required")
+	}
+	if form.Get("login_hint_token") != "" {
+		return "", oidc.ErrInvalidRequest().WithDescription("login_hint_token is not supported")
+	}
+	hint := form.Get("id_token_hint")
+	if hint == "" {
+		return "", nil
+	}
+	claims, err := op.VerifyIDTokenHint[*oidc.IDTokenClaims](ctx, hint, b.provider.IDTokenHintVerifier(ctx))
+	if err != nil {
+		return "", oidc.ErrInvalidRequest().WithDescription("the id_token_hint is invalid")
+	}
+	// the hint was issued to the client
+	for _, aud := range claims.GetAudience() {
+		if aud == clientID {
+			return claims.GetSubject(), nil
+		}
+	}
+	return "", oidc.ErrInvalidRequest().WithDescription("the id_token_hint is of another client")
+}
+
+// token handles the token requests of the ciba grant, the library does not know it
+func (b *backchannel) token(next http.Handler) http.Handler {
+	exchange := b.issuerInterceptor.HandlerFunc(b.exchange)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != op.DefaultEndpoints.Token.Relative() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != ciba.GrantType {
+			next.ServeHTTP(w, r)
+			return
+		}
+		exchange(w, r)
+	})
+}
+
+// exchange answers the token requests of poll and ping clients
+func (b *backchannel) exchange(w http.ResponseWriter, r *http.Request) {
+	logger := b.provider.Logger()
+	ctx := r.Context()
+	client, err := authenticateClient(r, b.provider)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	if !op.ValidateGrantType(client, ciba.GrantType) {
+		op.RequestError(w, r, oidc.ErrUnauthorizedClient().WithDescription("the client may not use the ciba grant"), logger)
+		return
+	}
+	if backchannelMode(client) == ciba.ModePush {
+		op.RequestError(w, r, oidc.ErrUnauthorizedClient().WithDescription("push clients get their tokens at the notification endpoint"), logger)
+		return
+	}
+	req, err := b.storage.BackchannelTokenRequest(ctx, r.Form.Get("auth_req_id"), client.GetID())
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	res, err := b.tokens(ctx, req, client)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	httphelper.MarshalJSON(w, res)
+}
+
+// tokens creates the tokens of an issued request like the library does for
+// auth requests, clients of the refresh_token grant get a refresh token too
+func (b *backchannel) tokens(ctx context.Context, req *storage.BackchannelAuthRequest, client op.Client) (*oidc.AccessTokenResponse, error) {
+	store := b.provider.Storage()
+	var (
+		tokenID, refreshToken string
+		expiration            time.Time
+		err                   error
+	)
+	if op.ValidateGrantType(client, oidc.GrantTypeRefreshToken) {
+		tokenID, refreshToken, expiration, err = store.CreateAccessAndRefreshTokens(ctx, req, "")
+	} else {
+		tokenID, expiration, err = store.CreateAccessToken(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var accessToken string
+	if client.AccessTokenType() == op.AccessTokenTypeJWT {
+		accessToken, err = op.CreateJWT(ctx, op.IssuerFromContext(ctx), req, expiration, tokenID, client, store)
+	} else {
+		accessToken, err = op.CreateBearerToken(tokenID, req.GetSubject(), b.provider.Crypto())
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.RefreshToken = refreshToken
+	idToken, err := op.CreateIDToken(ctx, op.IssuerFromContext(ctx), req, client.IDTokenLifetime(), accessToken, "", store, client)
+	if err != nil {
+		return nil, err
+	}
+	return &oidc.AccessTokenResponse{
+		AccessToken:  accessToken,
+		IDToken:      idToken,
+		RefreshToken: refreshToken,
+		TokenType:    oidc.BearerToken,
+		ExpiresIn:    uint64(expiration.Add(client.ClockSkew()).Sub(time.Now().UTC()).Seconds()),
+	}, nil
+}
+
+func renderBackchannel(w http.ResponseWriter, token string, req *storage.BackchannelAuthRequest, client, message string, err error) {
+	data := &struct {
+		Token          string
+		Client         string
+		BindingMessage string
+		Scopes         []string
+		Message        string
+		Error          string
+	}{
+		Token:   token,
+		Client:  client,
+		Message: message,
+		Error:   errMsg(err),
+	}
+	if req != nil {
+		data.BindingMessage = req.BindingMessage
+		data.Scopes = req.Scopes
+	}
+	err = templates.ExecuteTemplate(w, "backchannel", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// request returns the pending request of the link, the user signed in with the
+// browser must be the one the client asked for
+func (b *backchannel) request(r *http.Request, token string) (*storage.BackchannelAuthRequest, error) {
+	id, err := b.links.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	req, err := b.storage.PendingBackchannelAuthRequest(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := b.sessions.UserID(r)
+	if err != nil {
+		return nil, errors.New("please sign in to your account with this browser first")
+	}
+	if userID != req.UserID {
+		return nil, errors.New("the request is of another user")
+	}
+	return req, nil
+}
+
+func (b *backchannel) approveHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	req, err := b.request(r, token)
+	if err != nil {
+		renderBackchannel(w, "", nil, "", "", err)
+		return
+	}
+	renderBackchannel(w, token, req, b.clientName(r.Context(), req.GetClientID()), "", nil)
+}
+
+// decideHandler records the decision of the user and delivers it to ping and push clients
+func (b *backchannel) decideHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := r.FormValue("token")
+	req, err := b.request(r, token)
+	if err != nil {
+		renderBackchannel(w, "", nil, "", "", err)
+		return
+	}
+	sessionID, err := b.sessions.SessionID(r)
+	if err != nil {
+		renderBackchannel(w, "", nil, "", "", err)
+		return
+	}
+	approve := r.FormValue("decision") == "approve"
+	req, err = b.storage.DecideBackchannelAuthRequest(ctx, req.ID, sessionID, approve)
+	if err != nil {
+		renderBackchannel(w, "", nil, "", "", err)
+		return
+	}
+	// the decision stands even if the client is not reachable, poll and ping clients can still ask
+	if err := b.deliver(ctx, req); err != nil {
+		logrus.Errorf("backchannel delivery of %s: %v", req.ID, err)
+	}
+	client := b.clientName(ctx, req.GetClientID())
+	message := fmt.Sprintf("You denied the request of %s.", client)
+	if approve {
+		message = fmt.Sprintf("You approved the request of %s, you can close this page.", client)
+	}
+	renderBackchannel(w, "", nil, client, message, nil)
+}
+
+// deliver pings ping clients, push clients get their tokens or the denial
+func (b *backchannel) deliver(ctx context.Context, req *storage.BackchannelAuthRequest) error {
+	client, err := b.provider.Storage().GetClientByClientID(ctx, req.GetClientID())
+	if err != nil {
+		return err
+	}
+	endpoint := ""
+	// configured clients may use internal endpoints, registered clients may not
+	var callbacks *http.Client
+	if c, ok := client.(backchannelClient); ok {
+		endpoint = c.BackchannelClientNotificationEndpoint()
+		if c.Registered() {
+			callbacks = b.public
+		}
+	}
+	switch backchannelMode(client) {
+	case ciba.ModePing:
+		return ciba.Callback(ctx, callbacks, endpoint, req.ClientNotificationToken, map[string]string{
+			"auth_req_id": req.ID.String(),
+		})
+	case ciba.ModePush:
+		if req.Status == storage.BackchannelDenied {
+			return ciba.Callback(ctx, callbacks, endpoint, req.ClientNotificationToken, map[string]string{
+				"auth_req_id":       req.ID.String(),
+				"error":             string(oidc.AccessDenied),
+				"error_description": "the user denied the request",
+			})
+		}
+		issued, err := b.storage.BackchannelTokenRequest(ctx, req.ID.String(), req.GetClientID())
+		if err != nil {
+			return err
+		}
+		issued.Push = true
+		tokens, err := b.tokens(ctx, issued, client)
+		if err != nil {
+			return err
+		}
+		return ciba.Callback(ctx, callbacks, endpoint, req.ClientNotificationToken, &struct {
+			AuthReqID string `json:"auth_req_id"`
+			*oidc.AccessTokenResponse
+		}{req.ID.String(), tokens})
+	}
+	return nil
+}
+
+func (b *backchannel) clientName(ctx context.Context, clientID string) string {
+	client, err := b.provider.Storage().GetClientByClientID(ctx, clientID)
+	if err != nil {
+		return clientID
+	}
+	return clientName(client)
+}
+
+// clientName is the name of the client for users, its id if it has none
+func clientName(client op.Client) string {
+	if named, ok := client.(interface{ Name() string }); ok && named.Name() != "" {
+		return named.Name()
+	}
+	return client.GetID()
+}
+
+// backchannelMode is the token delivery mode of a ciba client
+func backchannelMode(client op.Client) string {
+	if c, ok := client.(backchannelClient); ok {
+		return c.BackchannelTokenDeliveryMode()
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package exampleop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+type consentStorage interface {
+	PendingAuthorizationDetails(ctx context.Context, id string) ([]storage.DescribedAuthorizationDetail, error)
+	ApproveAuthorizationDetails(ctx context.Context, id string, userID uuid.UUID) error
+}
+
+// consent asks the signed in user to approve the authorization details
+// (RFC 9396) of the auth request before the client gets its tokens
+type consent struct {
+	storage  consentStorage
+	login    *login
+	provider op.OpenIDProvider
+}
+
+func NewConsent(store consentStorage, l *login, provider op.OpenIDProvider, issuerInterceptor *op.IssuerInterceptor) *consent {
+	c := &consent{
+		storage:  store,
+		login:    l,
+		provider: provider,
+	}
+	l.router.Get("/consent", c.consentHandler)
+	l.router.Post("/consent", issuerInterceptor.HandlerFunc(c.decideHandler))
+	l.consent = c
+	return c
+}
+
+// pending tells if the user has to approve authorization details of the request
+func (c *consent) pending(ctx context.Context, id string) (bool, error) {
+	details, err := c.storage.PendingAuthorizationDetails(ctx, id)
+	return len(details) > 0, err
+}
+
+type consentDetail struct {
+	Type        string
+	Description string
+	Fields      []consentField
+}
+
+type consentField struct {
+	Name  string
+	Value string
+}
+
+// describe lists the fields of the detail besides its type, values which are
+// not strings are shown as JSON
+func describe(d storage.DescribedAuthorizationDetail) consentDetail {
+	detail := consentDetail{Type: d.Type, Description: d.Description}
+	for name, v := range d.Detail {
+		if name == "type" {
+			continue
+		}
+		value, ok := v.(string)
+		if !ok {
+			js, _ := json.Marshal(v)
+			value = string(js)
+		}
+		detail.Fields = append(detail.Fields, consentField{Name: name, Value: value})
+	}
+	sort.Slice(detail.Fields, func(i, j int) bool {
+		return detail.Fields[i].Name < detail.Fields[j].Name
+	})
+	return detail
+}
+
+// request returns the auth request of the signed in user of the browser
+func (c *consent) request(r *http.Request, id string) (op.AuthRequest, uuid.UUID, error) {
+	userID, err := c.login.sessions.UserID(r)
+	if err != nil {
+		return nil, uuid.Nil, errors.New("not signed in")
+	}
+	request, err := c.login.authenticate.AuthRequestByID(r.Context(), id)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if request.GetSubject() != userID.String() {
+		return nil, uuid.Nil, errors.New("the request is of another user")
+	}
+	return request, userID, nil
+}
+
+func (c *consent) consentHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue(queryAuthRequestID)
+	request, _, err := c.request(r, id)
+	if err != nil {
+		c.login.renderLogin(w, r, id, err)
+		return
+	}
+	pending, err := c.storage.PendingAuthorizationDetails(r.Context(), id)
+	if err != nil {
+		c.login.renderLogin(w, r, id, err)
+		return
+	}
+	data := &struct {
+		ID      string
+		Client  string
+		Scopes  []string
+		Details []consentDetail
+	}{
+		ID:     id,
+		Client: request.GetClientID(),
+		Scopes: request.GetScopes(),
+	}
+	if client, err := c.provider.Storage().GetClientByClientID(r.Context(), request.GetClientID()); err == nil {
+		data.Client = clientName(client)
+	}
+	for _, d := range pending {
+		data.Details = append(data.Details, describe(d))
+	}
+	err = templates.ExecuteTemplate(w, "consent", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// decideHandler continues the auth request if the user approves, else the
+// client gets access_denied
+func (c *consent) decideHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot parse form:%s", err), http.StatusInternalServerError)
+		return
+	}
+	id := r.FormValue("id")
+	request, userID, err := c.request(r, id)
+	if err != nil {
+		c.login.renderLogin(w, r, id, err)
+		return
+	}
+	if r.FormValue("decision") != "approve" {
+		op.AuthRequestError(w, r, request, oidc.ErrAccessDenied().WithDescription("the user denied the request"), c.provider)
+		return
+	}
+	err = c.storage.ApproveAuthorizationDetails(r.Context(), id, userID)
+	if err != nil {
+		c.login.renderLogin(w, r, id, err)
+		return
+	}
+	http.Redirect(w, r, c.login.callback(r.Context(), id), http.StatusFound)
+}
@@ -0,0 +1,103 @@
+package exampleop
+
+import (
+	"context"
+	"net/http"
+
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"github.com/zltl/xoidc/server/internal/pkg/dpop"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// discovery is the discovery document of the library with the metadata of the
+// specifications it does not know about
+type discovery struct {
+	*oidc.DiscoveryConfiguration
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint,omitempty"`
+	RequirePushedAuthorizationRequests bool   `json:"require_pushed_authorization_requests"`
+	// JARM
+	AuthorizationSigningAlgValuesSupported    []string `json:"authorization_signing_alg_values_supported,omitempty"`
+	AuthorizationEncryptionAlgValuesSupported []string `json:"authorization_encryption_alg_values_supported,omitempty"`
+	AuthorizationEncryptionEncValuesSupported []string `json:"authorization_encryption_enc_values_supported,omitempty"`
+	// RFC 9449
+	DPoPSigningAlgValuesSupported []string `json:"dpop_signing_alg_values_supported,omitempty"`
+	// RFC 8705
+	TLSClientCertificateBoundAccessTokens bool `json:"tls_client_certificate_bound_access_tokens"`
+	// RFC 9396
+	AuthorizationDetailsTypesSupported []string `json:"authorization_details_types_supported,omitempty"`
+	// CIBA
+	BackchannelAuthenticationEndpoint      string   `json:"backchannel_authentication_endpoint,omitempty"`
+	BackchannelTokenDeliveryModesSupported []string `json:"backchannel_token_delivery_modes_supported,omitempty"`
+	BackchannelUserCodeParameterSupported  bool     `json:"backchannel_user_code_parameter_supported"`
+	// front- and back-channel logout
+	FrontchannelLogoutSupported        bool `json:"frontchannel_logout_supported"`
+	FrontchannelLogoutSessionSupported bool `json:"frontchannel_logout_session_supported"`
+	BackchannelLogoutSupported         bool `json:"backchannel_logout_supported"`
+	BackchannelLogoutSessionSupported  bool `json:"backchannel_logout_session_supported"`
+}
+
+type discoveryStorage interface {
+	op.DiscoverStorage
+	AuthorizationDetailsTypesSupported(ctx context.Context) ([]string, error)
+}
+
+// discoveryHandler serves the discovery document of the provider with the
+// endpoints the provider does not know about, like the registration endpoint
+func discoveryHandler(provider op.OpenIDProvider, storage discoveryStorage, config ServerConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d := op.CreateDiscoveryConfig(r.Context(), provider, storage)
+		ext := extendDiscovery(d, op.IssuerFromContext(r.Context()), config)
+		// the types operators registered in any namespace
+		types, err := storage.AuthorizationDetailsTypesSupported(r.Context())
+		if err != nil {
+			httphelper.MarshalJSONWithStatus(w, oidc.ErrServerError(), http.StatusInternalServerError)
+			return
+		}
+		ext.AuthorizationDetailsTypesSupported = types
+		httphelper.MarshalJSON(w, ext)
+	}
+}
+
+func extendDiscovery(d *oidc.DiscoveryConfiguration, issuer string, config ServerConfig) *discovery {
+	if config.ClientRegistration != nil {
+		d.RegistrationEndpoint = op.NewEndpoint("register").Absolute(issuer)
+	}
+	d.ResponseModesSupported = []string{
+		string(oidc.ResponseModeQuery),
+		string(oidc.ResponseModeFragment),
+		string(storage.ResponseModeFormPost),
+		string(storage.ResponseModeJWT),
+		string(storage.ResponseModeQueryJWT),
+		string(storage.ResponseModeFragmentJWT),
+		string(storage.ResponseModeFormPostJWT),
+	}
+	d.GrantTypesSupported = append(d.GrantTypesSupported, ciba.GrantType)
+	d.TokenEndpointAuthMethodsSupported = append(d.TokenEndpointAuthMethodsSupported,
+		storage.AuthMethodTLSClientAuth,
+		storage.AuthMethodSelfSignedTLSClientAuth,
+	)
+	return &discovery{
+		DiscoveryConfiguration:             d,
+		PushedAuthorizationRequestEndpoint: op.NewEndpoint("par").Absolute(issuer),
+
+		AuthorizationSigningAlgValuesSupported:    d.IDTokenSigningAlgValuesSupported,
+		AuthorizationEncryptionAlgValuesSupported: clientmeta.KeyAlgorithms(),
+		AuthorizationEncryptionEncValuesSupported: clientmeta.ContentEncryptions(),
+
+		DPoPSigningAlgValuesSupported: dpop.SigningAlgorithms(),
+
+		TLSClientCertificateBoundAccessTokens: true,
+
+		BackchannelAuthenticationEndpoint:      op.NewEndpoint("bc-authorize").Absolute(issuer),
+		BackchannelTokenDeliveryModesSupported: ciba.Modes(),
+
+		FrontchannelLogoutSupported:        true,
+		FrontchannelLogoutSessionSupported: true,
+		BackchannelLogoutSupported:         true,
+		BackchannelLogoutSessionSupported:  true,
+	}
+}
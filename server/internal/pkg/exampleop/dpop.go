@@ -0,0 +1,126 @@
+package exampleop
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/dpop"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+type dpopStorage interface {
+	SeenDPoPProof(ctx context.Context, jkt, jti string, expire time.Time) (bool, error)
+}
+
+// dpopProofs validates the DPoP proofs (RFC 9449) of token and userinfo
+// requests, the library only knows bearer tokens. The storage binds the tokens
+// of token requests with a proof to its key, and accepts bound tokens only
+// with a proof of it.
+type dpopProofs struct {
+	provider op.OpenIDProvider
+	storage  dpopStorage
+	nonces   *dpop.Nonces
+	// requireNonce rejects proofs without a current DPoP-Nonce of the server
+	requireNonce bool
+}
+
+func (d *dpopProofs) handler(next http.Handler) http.Handler {
+	endpoints := op.DefaultEndpoints
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case endpoints.Token.Relative():
+			d.token(w, r, next)
+		case endpoints.Userinfo.Relative():
+			d.userinfo(w, r, next)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// token binds the tokens of requests with a proof, their token_type is DPoP
+func (d *dpopProofs) token(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	w.Header().Set(dpop.NonceHeader, d.nonces.New(time.Now()))
+	uri := op.DefaultEndpoints.Token.Absolute(d.provider.IssuerFromRequest(r))
+	proof, err := d.check(r, uri, "")
+	if err != nil {
+		op.RequestError(w, r, err, d.provider.Logger())
+		return
+	}
+	if proof == nil {
+		next.ServeHTTP(w, r)
+		return
+	}
+	buf := &bufferedResponse{header: http.Header{}}
+	next.ServeHTTP(buf, r.WithContext(storage.WithDPoPProof(r.Context(), proof.JKT)))
+	if buf.status == http.StatusOK {
+		var res map[string]json.RawMessage
+		if json.Unmarshal(buf.body.Bytes(), &res) == nil && res["token_type"] != nil {
+			res["token_type"], _ = json.Marshal(dpop.TokenType)
+			body, _ := json.Marshal(res)
+			buf.body.Reset()
+			buf.body.Write(body)
+			buf.header.Del("Content-Length")
+		}
+	}
+	buf.writeTo(w)
+}
+
+// userinfo accepts access tokens of the DPoP scheme with a proof of their key
+func (d *dpopProofs) userinfo(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	w.Header().Set(dpop.NonceHeader, d.nonces.New(time.Now()))
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, dpop.TokenType) {
+		next.ServeHTTP(w, r)
+		return
+	}
+	uri := op.DefaultEndpoints.Userinfo.Absolute(d.provider.IssuerFromRequest(r))
+	proof, err := d.check(r, uri, token)
+	if err == nil && proof == nil {
+		err = dpop.ErrInvalidProof("DPoP access tokens require a DPoP proof")
+	}
+	if err != nil {
+		e := oidc.DefaultToServerError(err, err.Error())
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`DPoP error=%q, error_description=%q, algs=%q`,
+			e.ErrorType, e.Description, strings.Join(dpop.SigningAlgorithms(), " ")))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	// the library reads bearer tokens
+	r.Header.Set("Authorization", oidc.PrefixBearer+token)
+	next.ServeHTTP(w, r.WithContext(storage.WithDPoPProof(r.Context(), proof.JKT)))
+}
+
+// check validates the DPoP proof of the request, nil if it has none
+func (d *dpopProofs) check(r *http.Request, uri, accessToken string) (*dpop.Proof, error) {
+	headers := r.Header.Values(dpop.Header)
+	switch len(headers) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, dpop.ErrInvalidProof("the request must have one DPoP header")
+	}
+	now := time.Now()
+	proof, err := dpop.Parse(headers[0], r.Method, uri, accessToken, now)
+	if err != nil {
+		return nil, dpop.ErrInvalidProof(err.Error())
+	}
+	if (d.requireNonce || proof.Nonce != "") && !d.nonces.Valid(proof.Nonce, now) {
+		return nil, dpop.ErrUseNonce()
+	}
+	seen, err := d.storage.SeenDPoPProof(r.Context(), proof.JKT, proof.JTI, proof.Expiration())
+	if err != nil {
+		return nil, oidc.ErrServerError().WithParent(err)
+	}
+	if seen {
+		return nil, dpop.ErrInvalidProof("the DPoP proof was used before")
+	}
+	return proof, nil
+}
@@ -0,0 +1,155 @@
+package exampleop
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/federation"
+)
+
+type federationStorage interface {
+	federation.Store
+	ClientIdentityProviders(ctx context.Context, clientID uuid.UUID) ([]*federation.Provider, error)
+	GetIdentityProvider(ctx context.Context, id uuid.UUID) (*federation.Provider, error)
+	CompleteFederatedLogin(ctx context.Context, reqid string, userID uuid.UUID) error
+}
+
+// loginProvider is a button of the login page
+type loginProvider struct {
+	ID   string
+	Name string
+}
+
+// federatedLogin signs users in with the upstream providers of the namespace of the client
+type federatedLogin struct {
+	storage federationStorage
+	login   *login
+	broker  *federation.Broker
+	router  chi.Router
+}
+
+func NewFederatedLogin(store federationStorage, l *login, key [32]byte, issuer string, issuerInterceptor *op.IssuerInterceptor) *federatedLogin {
+	hashKey := sha256.Sum256(append([]byte("federation-hash:"), key[:]...))
+	encryptKey := sha256.Sum256(append([]byte("federation-encrypt:"), key[:]...))
+	var opts []httphelper.CookieHandlerOpt
+	if strings.HasPrefix(issuer, "http://") {
+		opts = append(opts, httphelper.WithUnsecure())
+	}
+	// the state and pkce cookies come back with the redirect of the provider
+	opts = append(opts, httphelper.WithSameSite(http.SameSiteLaxMode), httphelper.WithPath("/login/federated/"))
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	f := &federatedLogin{
+		storage: store,
+		login:   l,
+		broker: federation.NewBroker(func(p *federation.Provider) string {
+			return issuer + "/login/federated/" + p.ID.String() + "/callback"
+		}, httphelper.NewCookieHandler(hashKey[:], encryptKey[:], opts...), nil),
+	}
+	f.router = chi.NewRouter()
+	f.router.Get("/{provider_id}", f.startHandler)
+	f.router.Get("/{provider_id}/callback", issuerInterceptor.HandlerFunc(f.callbackHandler))
+	l.federated = f
+	l.router.Mount("/federated", f.router)
+	return f
+}
+
+// loginProviders returns the buttons for the auth request, errors only hide the buttons
+func (f *federatedLogin) loginProviders(ctx context.Context, id string) []loginProvider {
+	request, err := f.login.authenticate.AuthRequestByID(ctx, id)
+	if err != nil {
+		return nil
+	}
+	clientID, err := uuid.Parse(request.GetClientID())
+	if err != nil {
+		return nil
+	}
+	providers, err := f.storage.ClientIdentityProviders(ctx, clientID)
+	if err != nil {
+		logrus.Errorf("ClientIdentityProviders: %v", err)
+		return nil
+	}
+	var buttons []loginProvider
+	for _, p := range providers {
+		buttons = append(buttons, loginProvider{ID: p.ID.String(), Name: p.Name})
+	}
+	return buttons
+}
+
+// provider loads the provider of the url, it must be enabled for the client of the auth request
+func (f *federatedLogin) provider(r *http.Request, authRequestID string) (*federation.Provider, error) {
+	ctx := r.Context()
+	providerID, err := uuid.Parse(chi.URLParam(r, "provider_id"))
+	if err != nil {
+		return nil, err
+	}
+	request, err := f.login.authenticate.AuthRequestByID(ctx, authRequestID)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := uuid.Parse(request.GetClientID())
+	if err != nil {
+		return nil, err
+	}
+	providers, err := f.storage.ClientIdentityProviders(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		if p.ID == providerID {
+			return p, nil
+		}
+	}
+	return nil, errors.New("the provider is not available for this application")
+}
+
+func (f *federatedLogin) startHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue(queryAuthRequestID)
+	p, err := f.provider(r, id)
+	if err != nil {
+		f.login.renderLogin(w, r, id, err)
+		return
+	}
+	// the auth request id comes back as state
+	f.broker.AuthURLHandler(p, id)(w, r)
+}
+
+func (f *federatedLogin) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	providerID, err := uuid.Parse(chi.URLParam(r, "provider_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, err := f.storage.GetIdentityProvider(r.Context(), providerID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.broker.CallbackHandler(p, func(w http.ResponseWriter, r *http.Request, id string, claims map[string]any) {
+		// check the provider against the client of the auth request again, the state is only as good as its cookie
+		p, err := f.provider(r, id)
+		if err != nil {
+			f.login.renderLogin(w, r, id, err)
+			return
+		}
+		userID, err := federation.Resolve(r.Context(), f.storage, p, claims)
+		if err != nil {
+			f.login.renderLogin(w, r, id, err)
+			return
+		}
+		err = f.storage.CompleteFederatedLogin(r.Context(), id, userID)
+		if err != nil {
+			f.login.renderLogin(w, r, id, err)
+			return
+		}
+		f.login.finishLogin(w, r, id)
+	})(w, r)
+}
@@ -2,22 +2,45 @@ package exampleop
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
 	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+// the login UI is mounted on pathLogin, the rate limits count the posts
+// to its routes
+const (
+	pathLogin             = "/login"
+	pathLoginUsername     = "/username"
+	pathLoginPasskeyBegin = "/passkey/begin"
 )
 
 type login struct {
 	authenticate authenticate
+	passkeys     passkeyStorage
+	webauthn     *webauthn.WebAuthn
+	sessions     *sessionCookies
 	router       chi.Router
 	callback     func(context.Context, string) string
+	// federated adds the buttons of upstream providers, if set
+	federated *federatedLogin
+	// consent asks the user to approve the authorization details of requests, if set
+	consent *consent
 }
 
-func NewLogin(authenticate authenticate, callback func(context.Context, string) string, issuerInterceptor *op.IssuerInterceptor) *login {
+func NewLogin(authenticate authenticate, passkeys passkeyStorage, webAuthn *webauthn.WebAuthn, sessions *sessionCookies, callback func(context.Context, string) string, issuerInterceptor *op.IssuerInterceptor) *login {
 	l := &login{
 		authenticate: authenticate,
+		passkeys:     passkeys,
+		webauthn:     webAuthn,
+		sessions:     sessions,
 		callback:     callback,
 	}
 	l.createRouter(issuerInterceptor)
@@ -26,12 +49,38 @@ func NewLogin(authenticate authenticate, callback func(context.Context, string)
 
 func (l *login) createRouter(issuerInterceptor *op.IssuerInterceptor) {
 	l.router = chi.NewRouter()
+	l.router.Get("/select", l.selectHandler)
 	l.router.Get("/username", l.loginHandler)
-	l.router.Post("/username", issuerInterceptor.HandlerFunc(l.checkLoginHandler))
+	l.router.Post(pathLoginUsername, issuerInterceptor.HandlerFunc(l.checkLoginHandler))
+	l.router.Post("/password", issuerInterceptor.HandlerFunc(l.changeExpiredPasswordHandler))
+	l.router.Get("/passkey", l.passkeyHandler)
+	l.router.Post(pathLoginPasskeyBegin, l.beginPasskeyHandler)
+	l.router.Post("/passkey/finish", issuerInterceptor.HandlerFunc(l.finishPasskeyHandler))
 }
 
 type authenticate interface {
-	CheckUsernamePassword(username, password, id string) error
+	CheckUsernamePassword(username, password, id, remoteIP string) error
+	ChangeExpiredPassword(username, oldPassword, newPassword, id, remoteIP string) error
+	AuthRequestByID(ctx context.Context, id string) (op.AuthRequest, error)
+	SetAuthRequestSession(ctx context.Context, id string, sessionID uuid.UUID) error
+}
+
+// selectHandler lets the user choose how to sign in
+func (l *login) selectHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot parse form:%s", err), http.StatusInternalServerError)
+		return
+	}
+	data := &struct {
+		ID string
+	}{
+		ID: r.FormValue(queryAuthRequestID),
+	}
+	err = templates.ExecuteTemplate(w, "select", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
 func (l *login) loginHandler(w http.ResponseWriter, r *http.Request) {
@@ -42,17 +91,21 @@ func (l *login) loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	// the oidc package will pass the id of the auth request as query parameter
 	// we will use this id through the login process and therefore pass it to the login page
-	renderLogin(w, r.FormValue(queryAuthRequestID), nil)
+	l.renderLogin(w, r, r.FormValue(queryAuthRequestID), nil)
 }
 
-func renderLogin(w http.ResponseWriter, id string, err error) {
+func (l *login) renderLogin(w http.ResponseWriter, r *http.Request, id string, err error) {
 	data := &struct {
-		ID    string
-		Error string
+		ID        string
+		Error     string
+		Providers []loginProvider
 	}{
 		ID:    id,
 		Error: errMsg(err),
 	}
+	if l.federated != nil {
+		data.Providers = l.federated.loginProviders(r.Context(), id)
+	}
 	err = templates.ExecuteTemplate(w, "login", data)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -66,12 +119,121 @@ func (l *login) checkLoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	username := r.FormValue("username")
-	password := r.FormValue("password")
 	id := r.FormValue("id")
-	err = l.authenticate.CheckUsernamePassword(username, password, id)
+	err = l.authenticate.CheckUsernamePassword(username, r.FormValue("password"), id, remoteIP(r))
+	if errors.Is(err, password.ErrExpired) {
+		renderPassword(w, "/login/password", id, username, "", err)
+		return
+	}
+	if err != nil {
+		l.renderLogin(w, r, id, err)
+		return
+	}
+	l.passwordChecked(w, r, id)
+}
+
+// passwordChecked continues the login after the password was verified
+func (l *login) passwordChecked(w http.ResponseWriter, r *http.Request, id string) {
+	// users with a passkey have to confirm the password with it
+	_, pending, err := l.passkeys.PendingSecondFactor(r.Context(), id)
+	if err != nil {
+		l.renderLogin(w, r, id, err)
+		return
+	}
+	if pending {
+		http.Redirect(w, r, "/login/passkey?"+queryAuthRequestID+"="+id, http.StatusFound)
+		return
+	}
+	l.finishLogin(w, r, id)
+}
+
+// changeExpiredPasswordHandler replaces an expired password and continues the login
+func (l *login) changeExpiredPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot parse form:%s", err), http.StatusInternalServerError)
+		return
+	}
+	username := r.FormValue("username")
+	id := r.FormValue("id")
+	err = l.authenticate.ChangeExpiredPassword(username, r.FormValue("password"), r.FormValue("new_password"), id, remoteIP(r))
 	if err != nil {
-		renderLogin(w, id, err)
+		renderPassword(w, "/login/password", id, username, "", err)
 		return
 	}
-	http.Redirect(w, r, l.callback(r.Context(), id), http.StatusFound)
+	l.passwordChecked(w, r, id)
+}
+
+func renderPassword(w http.ResponseWriter, action, id, username, message string, err error) {
+	data := &struct {
+		Action   string
+		ID       string
+		Username string
+		Error    string
+		Message  string
+	}{
+		Action:   action,
+		ID:       id,
+		Username: username,
+		Error:    errMsg(err),
+		Message:  message,
+	}
+	if errors.Is(err, password.ErrExpired) {
+		// the reason to be here, not an error of the form
+		data.Error = ""
+	}
+	err = templates.ExecuteTemplate(w, "password", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// remoteIP returns the address of the client, failed logins are counted per address
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// finishLogin remembers the user of the completed auth request and
+// redirects back to the OP
+func (l *login) finishLogin(w http.ResponseWriter, r *http.Request, id string) {
+	redirect, err := l.completeSession(w, r, id)
+	if err != nil {
+		l.renderLogin(w, r, id, err)
+		return
+	}
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (l *login) completeSession(w http.ResponseWriter, r *http.Request, id string) (string, error) {
+	request, err := l.authenticate.AuthRequestByID(r.Context(), id)
+	if err != nil {
+		return "", err
+	}
+	userID, err := uuid.Parse(request.GetSubject())
+	if err != nil {
+		return "", err
+	}
+	sessionID, err := l.sessions.Start(w, r, userID, request.GetAMR())
+	if err != nil {
+		return "", err
+	}
+	// the id tokens of the request name the session in sid
+	err = l.authenticate.SetAuthRequestSession(r.Context(), id, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if l.consent != nil {
+		pending, err := l.consent.pending(r.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		if pending {
+			return "/login/consent?" + queryAuthRequestID + "=" + id, nil
+		}
+	}
+	return l.callback(r.Context(), id), nil
 }
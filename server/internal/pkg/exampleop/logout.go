@@ -0,0 +1,136 @@
+package exampleop
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/publichttp"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+type logoutStorage interface {
+	DueBackchannelLogouts(ctx context.Context, limit int) ([]*storage.BackchannelLogout, error)
+	DeleteBackchannelLogout(ctx context.Context, id uuid.UUID) error
+	SignLogoutToken(ctx context.Context, issuer string, l *storage.BackchannelLogout) (string, error)
+}
+
+// endSession signs the browser out of its session on the end_session endpoint
+// of the library, and renders the frontchannel_logout_uris of the clients of the
+// session in iframes before the redirect to the post_logout_redirect_uri
+func endSession(sessions *sessionCookies, next http.Handler) http.Handler {
+	path := op.DefaultEndpoints.EndSession.Relative()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			next.ServeHTTP(w, r)
+			return
+		}
+		browser, _ := sessions.SessionID(r)
+		ctx, ended := storage.WithEndedSession(r.Context(), browser)
+		buf := &bufferedResponse{header: http.Header{}}
+		next.ServeHTTP(buf, r.WithContext(ctx))
+
+		if ended.ID != uuid.Nil && ended.ID == browser {
+			sessions.Clear(w)
+		}
+		if buf.status != http.StatusFound || len(ended.Frontchannel) == 0 {
+			buf.writeTo(w)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		err := templates.ExecuteTemplate(w, "logout", struct {
+			Frontchannel []string
+			RedirectURI  string
+		}{ended.Frontchannel, buf.header.Get("Location")})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// backchannelLogouts posts the logout tokens of ended sessions to the
+// backchannel_logout_uris of the clients, and retries the ones they did not accept
+type backchannelLogouts struct {
+	storage logoutStorage
+	issuer  string
+	client  *http.Client
+	// public posts to the uris of registered clients
+	public *http.Client
+}
+
+func newBackchannelLogouts(store logoutStorage, issuer string) *backchannelLogouts {
+	return &backchannelLogouts{
+		storage: store,
+		issuer:  issuer,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		public:  publichttp.Client(10 * time.Second),
+	}
+}
+
+func (b *backchannelLogouts) run(interval time.Duration) {
+	for range time.Tick(interval) {
+		b.deliver(context.Background())
+	}
+}
+
+// deliver posts the due logout tokens, a token is dropped once the client
+// accepted it or it ran out of attempts
+func (b *backchannelLogouts) deliver(ctx context.Context) {
+	logouts, err := b.storage.DueBackchannelLogouts(ctx, 50)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	for _, l := range logouts {
+		err := b.post(ctx, l)
+		if err != nil && l.Attempts < storage.BackchannelLogoutAttempts {
+			logrus.Warnf("backchannel logout of client %s, attempt %d: %v", l.ClientID, l.Attempts, err)
+			continue
+		}
+		if err != nil {
+			logrus.Errorf("backchannel logout of client %s dropped after %d attempts: %v", l.ClientID, l.Attempts, err)
+		}
+		err = b.storage.DeleteBackchannelLogout(ctx, l.ID)
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+func (b *backchannelLogouts) post(ctx context.Context, l *storage.BackchannelLogout) error {
+	if l.URI == "" {
+		// the client removed its backchannel_logout_uri
+		return nil
+	}
+	token, err := b.storage.SignLogoutToken(ctx, b.issuer, l)
+	if err != nil {
+		return err
+	}
+	body := url.Values{"logout_token": {token}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.URI, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := b.client
+	if l.Registered {
+		client = b.public
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s answered %s", l.URI, res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package exampleop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// fakeLogouts keeps the due logout tokens of the delivery tests
+type fakeLogouts struct {
+	due []*storage.BackchannelLogout
+}
+
+func (f *fakeLogouts) DueBackchannelLogouts(ctx context.Context, limit int) ([]*storage.BackchannelLogout, error) {
+	return append([]*storage.BackchannelLogout(nil), f.due...), nil
+}
+
+func (f *fakeLogouts) DeleteBackchannelLogout(ctx context.Context, id uuid.UUID) error {
+	for i, l := range f.due {
+		if l.ID == id {
+			f.due = append(f.due[:i], f.due[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeLogouts) SignLogoutToken(ctx context.Context, issuer string, l *storage.BackchannelLogout) (string, error) {
+	return "token-of-" + l.ID.String(), nil
+}
+
+func TestBackchannelLogoutDelivery(t *testing.T) {
+	tokens := map[string]int{}
+	accepting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens[r.PostFormValue("logout_token")]++
+	}))
+	defer accepting.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens[r.PostFormValue("logout_token")]++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	accepted := &storage.BackchannelLogout{ID: uuid.New(), Attempts: 1, URI: accepting.URL}
+	retried := &storage.BackchannelLogout{ID: uuid.New(), Attempts: 1, URI: failing.URL}
+	dropped := &storage.BackchannelLogout{ID: uuid.New(), Attempts: storage.BackchannelLogoutAttempts, URI: failing.URL}
+	removed := &storage.BackchannelLogout{ID: uuid.New(), Attempts: 1}
+	// the test servers listen on loopback, registered clients must not reach it
+	registered := &storage.BackchannelLogout{ID: uuid.New(), Attempts: 1, URI: accepting.URL, Registered: true}
+	store := &fakeLogouts{due: []*storage.BackchannelLogout{accepted, retried, dropped, removed, registered}}
+
+	logs := test.NewGlobal()
+	defer logs.Reset()
+	newBackchannelLogouts(store, "https://op.example.com").deliver(context.Background())
+
+	for _, l := range []*storage.BackchannelLogout{accepted, retried, dropped} {
+		if tokens["token-of-"+l.ID.String()] != 1 {
+			t.Errorf("the token of %s was posted %d times", l.ID, tokens["token-of-"+l.ID.String()])
+		}
+	}
+	if tokens["token-of-"+registered.ID.String()] != 0 {
+		t.Error("the token of a registered client was posted to loopback")
+	}
+	// the tokens the clients did not accept are kept for the next attempt
+	if len(store.due) != 2 || store.due[0] != retried || store.due[1] != registered {
+		t.Errorf("kept %v", store.due)
+	}
+
+	levels := map[logrus.Level]int{}
+	for _, e := range logs.AllEntries() {
+		levels[e.Level]++
+	}
+	if levels[logrus.WarnLevel] != 2 || levels[logrus.ErrorLevel] != 1 {
+		t.Errorf("logged %d warnings and %d errors, want 2 and 1", levels[logrus.WarnLevel], levels[logrus.ErrorLevel])
+	}
+}
@@ -0,0 +1,142 @@
+package exampleop
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// clientCertificates passes the client certificate of a request to the storage
+// for mutual TLS client authentication and certificate bound tokens (RFC 8705).
+// The certificate is the one of the TLS connection, or behind a TLS
+// terminating proxy the one the proxy passes in a header.
+type clientCertificates struct {
+	// roots verify the certificates of tls_client_auth clients, nil trusts none
+	roots *x509.CertPool
+	// header is the header of the trusted proxy, empty ignores headers
+	header string
+	// proxies are the addresses the header is taken from, none ignores the header
+	proxies []netip.Prefix
+}
+
+func (c *clientCertificates) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cert, intermediates, err := c.certificate(r)
+		if err != nil {
+			logrus.Warnf("client certificate: %v", err)
+		}
+		if cert != nil {
+			r = r.WithContext(storage.WithClientCertificate(r.Context(), cert, c.verify(cert, intermediates)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// certificate returns the client certificate of the request and the
+// intermediates the client sent, nil if it has none
+func (c *clientCertificates) certificate(r *http.Request) (*x509.Certificate, []*x509.Certificate, error) {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0], r.TLS.PeerCertificates[1:], nil
+	}
+	if c.header == "" || !c.fromProxy(r) {
+		return nil, nil, nil
+	}
+	v := r.Header.Get(c.header)
+	if v == "" {
+		return nil, nil, nil
+	}
+	cert, err := parseCertificateHeader(v)
+	return cert, nil, err
+}
+
+// fromProxy tells if the peer of the request is a trusted proxy, clients
+// which reach the server directly must not pass certificates in the header
+func (c *clientCertificates) fromProxy(r *http.Request) bool {
+	addr, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := addr.Addr().Unmap()
+	for _, p := range c.proxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProxies parses a comma separated list of addresses and networks,
+// e.g. "10.0.0.1,192.168.0.0/16"
+func ParseProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			addr, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, err
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, p.Masked())
+	}
+	return proxies, nil
+}
+
+// parseCertificateHeader reads the url escaped PEM proxies like nginx
+// ($ssl_client_escaped_cert) send, or the base64 of the DER
+func parseCertificateHeader(v string) (*x509.Certificate, error) {
+	if strings.Contains(v, "%") {
+		unescaped, err := url.PathUnescape(v)
+		if err != nil {
+			return nil, err
+		}
+		v = unescaped
+	}
+	if block, _ := pem.Decode([]byte(v)); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+	der, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, errors.New("the header is neither PEM nor base64 DER")
+	}
+	return x509.ParseCertificate(der)
+}
+
+// verify tells if the certificate chains to a trusted CA
+func (c *clientCertificates) verify(cert *x509.Certificate, intermediates []*x509.Certificate) bool {
+	if c.roots == nil {
+		return false
+	}
+	pool := x509.NewCertPool()
+	for _, i := range intermediates {
+		pool.AddCert(i)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         c.roots,
+		Intermediates: pool,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err == nil
+}
+
+func isMutualTLS(method oidc.AuthMethod) bool {
+	return method == storage.AuthMethodTLSClientAuth || method == storage.AuthMethodSelfSignedTLSClientAuth
+}
@@ -0,0 +1,105 @@
+package exampleop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// issueCertificate issues a certificate of the name, by parent or self-signed
+func issueCertificate(t *testing.T, name string, ca bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  ca,
+		BasicConstraintsValid: ca,
+	}
+	if ca {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestClientCertificateHeader(t *testing.T) {
+	cert, _ := issueCertificate(t, "agent", false, nil, nil)
+	proxies, err := ParseProxies("10.0.0.1, 192.168.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clientCertificates{header: "X-Client-Cert", proxies: proxies}
+	pemCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		ok     bool
+	}{
+		{"escaped pem", "10.0.0.1:4321", url.PathEscape(pemCert), true},
+		{"pem", "192.168.7.7:4321", pemCert, true},
+		{"base64 der", "10.0.0.1:4321", base64.StdEncoding.EncodeToString(cert.Raw), true},
+		// clients reaching the server directly must not pass certificates
+		{"not a proxy", "10.0.0.2:4321", pemCert, false},
+		{"garbage", "10.0.0.1:4321", "not a certificate", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/oauth/token", nil)
+		r.RemoteAddr = tt.remote
+		r.Header.Set("X-Client-Cert", tt.header)
+		got, _, _ := c.certificate(r)
+		if (got != nil && got.Equal(cert)) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, got)
+		}
+	}
+	if _, err := ParseProxies("10.0.0.1,proxy"); err == nil {
+		t.Error("parsed a host name as proxy")
+	}
+}
+
+func TestClientCertificateVerify(t *testing.T) {
+	ca, caKey := issueCertificate(t, "ca", true, nil, nil)
+	issued, _ := issueCertificate(t, "agent", false, ca, caKey)
+	selfSigned, _ := issueCertificate(t, "agent", false, nil, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	c := &clientCertificates{roots: roots}
+	if !c.verify(issued, nil) {
+		t.Error("the certificate of the CA did not verify")
+	}
+	if c.verify(selfSigned, nil) {
+		t.Error("a self-signed certificate verified")
+	}
+	// without CAs, only self_signed_tls_client_auth clients authenticate
+	if (&clientCertificates{}).verify(issued, nil) {
+		t.Error("verified without CAs")
+	}
+}
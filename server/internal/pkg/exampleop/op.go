@@ -2,8 +2,11 @@ package exampleop
 
 import (
 	"crypto/sha256"
+	"crypto/x509"
 	"log"
 	"net/http"
+	"net/netip"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -13,6 +16,12 @@ import (
 	"golang.org/x/text/language"
 
 	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+	"github.com/zltl/xoidc/server/internal/pkg/clientreg"
+	"github.com/zltl/xoidc/server/internal/pkg/dpop"
+	"github.com/zltl/xoidc/server/internal/pkg/mailer"
+	"github.com/zltl/xoidc/server/internal/pkg/ratelimit"
+	"github.com/zltl/xoidc/server/internal/pkg/sms"
 )
 
 const (
@@ -22,19 +31,73 @@ const (
 type Storage interface {
 	op.Storage
 	authenticate
+	accountStorage
+	passkeyStorage
+	resetStorage
+	verifyStorage
+	registerStorage
+	sessionStorage
+	federationStorage
+	parStorage
+	responseModeStorage
+	dpopStorage
+	consentStorage
+	discoveryStorage
+	backchannelStorage
+	logoutStorage
 	// deviceAuthenticate
 }
 
+// ServerConfig holds the optional services of the server
+type ServerConfig struct {
+	// Limiter limits the requests, nil disables rate limiting
+	Limiter *ratelimit.Limiter
+	// Mailer delivers password reset and verification links, nil logs the mails
+	Mailer mailer.Mailer
+	// SMS delivers the codes verifying phone numbers, nil logs the messages
+	SMS sms.Sender
+	// AccountClientID is the client the account portal signs in with,
+	// its redirect uri is <issuer>/account/callback.
+	// Without it the portal uses the session of the last login of the browser.
+	AccountClientID     string
+	AccountClientSecret string
+	// ClientRegistration serves dynamic client registration on /register, nil disables it
+	ClientRegistration *clientreg.Server
+	// RequireDPoPNonce rejects DPoP proofs without a current nonce of the server,
+	// clients get it from the DPoP-Nonce header of the token and userinfo responses
+	RequireDPoPNonce bool
+	// ClientCAs verify the certificates of tls_client_auth clients, nil trusts none
+	ClientCAs *x509.CertPool
+	// ClientCertificateHeader is the header a TLS terminating proxy passes the
+	// client certificate in, the proxy must remove it from the requests of clients.
+	// Empty only takes the certificates of TLS connections.
+	ClientCertificateHeader string
+	// ClientCertificateProxies are the addresses of the proxies the header is
+	// taken from, the header of other peers is ignored
+	ClientCertificateProxies []netip.Prefix
+	// LinkKey signs the links sent to users, e.g. to reset the password,
+	// SetupServer refuses to start without it, see LoadLinkKey
+	LinkKey []byte
+	// Notifier asks users to approve the backchannel authentication requests (CIBA)
+	// of clients on their device, nil logs the requests
+	Notifier ciba.Notifier
+}
+
 // simple counter for request IDs
 var counter atomic.Int64
 
 // SetupServer creates an OIDC server with Issuer=http://localhost:<port>
 //
 // Use one of the pre-made clients in storage/clients.go or register a new one.
-func SetupServer(issuer string, storage Storage, logger *slog.Logger, wrapServer bool, extraOptions ...op.Option) chi.Router {
+func SetupServer(issuer string, storage Storage, logger *slog.Logger, wrapServer bool, config ServerConfig, extraOptions ...op.Option) chi.Router {
 	// the OpenID Provider requires a 32-byte key for (token) encryption
 	// be sure to create a proper crypto random key and manage it securely!
 	key := sha256.Sum256([]byte("test"))
+	// the links sent to users must not be signed with a known key
+	if len(config.LinkKey) < minLinkKeyLength {
+		log.Fatalf("the link key must have at least %d bytes", minLinkKeyLength)
+	}
+	linkKey := sha256.Sum256(config.LinkKey)
 
 	router := chi.NewRouter()
 	router.Use(logging.Middleware(
@@ -43,6 +106,25 @@ func SetupServer(issuer string, storage Storage, logger *slog.Logger, wrapServer
 			return slog.Int64("id", counter.Add(1))
 		}),
 	))
+	if config.Limiter != nil {
+		router.Use(config.Limiter.Handler)
+	}
+	// mutual TLS clients authenticate with their certificate
+	certs := &clientCertificates{
+		roots:   config.ClientCAs,
+		header:  config.ClientCertificateHeader,
+		proxies: config.ClientCertificateProxies,
+	}
+	router.Use(certs.handler)
+	if config.Mailer == nil {
+		config.Mailer = &mailer.FileMailer{}
+	}
+	if config.SMS == nil {
+		config.SMS = sms.LogSender{}
+	}
+	if config.Notifier == nil {
+		config.Notifier = ciba.LogNotifier{}
+	}
 
 	// for simplicity, we provide a very small default page for users who have signed out
 	router.HandleFunc(pathLoggedOut, func(w http.ResponseWriter, req *http.Request) {
@@ -59,16 +141,71 @@ func SetupServer(issuer string, storage Storage, logger *slog.Logger, wrapServer
 	//the provider will only take care of the OpenID Protocol, so there must be some sort of UI for the login process
 	//for the simplicity of the example this means a simple page with username and password field
 	//be sure to provide an IssuerInterceptor with the IssuerFromRequest from the OP so the login can select / and pass it to the storage
-	l := NewLogin(storage, op.AuthCallbackURL(provider), op.NewIssuerInterceptor(provider.IssuerFromRequest))
+	webAuthn, err := NewWebAuthn(issuer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sessions := newSessionCookies(storage, key, strings.HasPrefix(issuer, "http://"))
+	issuerInterceptor := op.NewIssuerInterceptor(provider.IssuerFromRequest)
+	l := NewLogin(storage, storage, webAuthn, sessions, op.AuthCallbackURL(provider), issuerInterceptor)
+	// users may sign in with the upstream providers of the namespace of the client
+	NewFederatedLogin(storage, l, key, issuer, issuerInterceptor)
+	// users approve the authorization details of requests on /login/consent
+	NewConsent(storage, l, provider, issuerInterceptor)
 
 	// regardless of how many pages / steps there are in the process, the UI must be registered in the router,
 	// so we will direct all calls to /login to the login UI
-	router.Mount("/login/", http.StripPrefix("/login", l.router))
+	router.Mount(pathLogin+"/", http.StripPrefix(pathLogin, l.router))
+
+	// the signed in user manages the own profile, password, contacts, passkeys,
+	// sessions and consents under /account
+	var portal *accountPortal
+	if config.AccountClientID != "" {
+		portal = newAccountPortal(issuer, config.AccountClientID, config.AccountClientSecret, key)
+	}
+	verifier := newVerifier(storage, config.Mailer, config.SMS, linkKey, issuer)
+	a := NewAccount(storage, storage, verifier, webAuthn, sessions, portal)
+	router.Mount(pathAccount+"/", http.StripPrefix(pathAccount, a.router))
+
+	// users who forgot the password get a reset link by mail
+	reset := NewPasswordReset(storage, config.Mailer, linkKey, issuer)
+	router.Mount(pathPassword+"/", http.StripPrefix(pathPassword, reset.router))
+
+	// new users create an account if the namespace of the client allows it
+	register := NewRegistration(storage, l, verifier, issuerInterceptor)
+	router.Mount(pathRegister+"/", http.StripPrefix(pathRegister, register.router))
+
+	// clients register themselves with POST /register, see RFC 7591
+	if config.ClientRegistration != nil {
+		config.ClientRegistration.Serve(router)
+	}
+	router.Get("/.well-known/openid-configuration", issuerInterceptor.HandlerFunc(discoveryHandler(provider, storage, config)))
+
+	// clients push authorization requests to /par and pass the request_uri to /authorize
+	par := &pushedAuthRequests{provider: provider, storage: storage}
+	router.Post(pathPAR, issuerInterceptor.HandlerFunc(par.push))
+
+	// clients start the authentication of users on their own device on /bc-authorize,
+	// the users approve it on the /backchannel/approve link of the notification
+	backchannel := newBackchannel(provider, storage, config.Notifier, sessions, linkKey, issuer, issuerInterceptor)
+	router.Post(pathBackchannelAuthorize, issuerInterceptor.HandlerFunc(backchannel.authorize))
+	router.Mount("/backchannel/", http.StripPrefix("/backchannel", backchannel.router))
 
 	handler := http.Handler(provider)
 	if wrapServer {
 		handler = op.RegisterLegacyServer(op.NewLegacyServer(provider, *op.DefaultEndpoints))
 	}
+	// authorization responses in form_post and the jwt modes
+	responses := &authResponses{provider: provider, storage: storage}
+	handler = par.authorize(resourceIndicators(authorizationDetails(responses.handler(handler))))
+	// the token requests of the ciba grant
+	handler = backchannel.token(handler)
+	// end_session signs the browser out and tells the clients of its session
+	handler = endSession(sessions, handler)
+	go newBackchannelLogouts(storage, issuer).run(10 * time.Second)
+	// DPoP bound tokens at the token and userinfo endpoints
+	dpops := &dpopProofs{provider: provider, storage: storage, nonces: dpop.NewNonces(key[:]), requireNonce: config.RequireDPoPNonce}
+	handler = dpops.handler(handler)
 
 	// we register the http handler of the OP on the root, so that the discovery endpoint (/.well-known/openid-configuration)
 	// is served on the correct path
@@ -0,0 +1,167 @@
+package exampleop
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+const pathPAR = "/par"
+
+type parStorage interface {
+	PushAuthRequest(ctx context.Context, authReq *oidc.AuthRequest, resources []string, authorizationDetails string) (string, error)
+	PushedAuthRequest(ctx context.Context, requestURI, clientID string) (*oidc.AuthRequest, []string, string, error)
+}
+
+// pushedAuthRequests serves pushed authorization requests (RFC 9126), clients
+// push the parameters to /par and pass the returned request_uri to /authorize
+type pushedAuthRequests struct {
+	provider op.OpenIDProvider
+	storage  parStorage
+}
+
+type parResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// push validates and stores the authorization request of an authenticated client
+func (p *pushedAuthRequests) push(w http.ResponseWriter, r *http.Request) {
+	logger := p.provider.Logger()
+	client, err := authenticateClient(r, p.provider)
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	authReq, err := op.ParseAuthorizeRequest(r, p.provider.Decoder())
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	if r.Form.Get("request_uri") != "" {
+		op.RequestError(w, r, oidc.ErrInvalidRequest().WithDescription("request_uri must not be pushed"), logger)
+		return
+	}
+	if authReq.RequestParam != "" {
+		op.RequestError(w, r, oidc.ErrRequestNotSupported(), logger)
+		return
+	}
+	// the client authenticated, the client_id of the request can only be its own
+	authReq.ClientID = client.GetID()
+	if authReq.RedirectURI == "" {
+		op.RequestError(w, r, oidc.ErrInvalidRequest().WithDescription("auth request is missing redirect_uri"), logger)
+		return
+	}
+	ctx := r.Context()
+	_, err = op.ValidateAuthRequest(ctx, authReq, p.provider.Storage(), p.provider.IDTokenHintVerifier(ctx))
+	if err != nil {
+		op.RequestError(w, r, err, logger)
+		return
+	}
+	requestURI, err := p.storage.PushAuthRequest(ctx, authReq, r.Form["resource"], r.Form.Get("authorization_details"))
+	if err != nil {
+		op.RequestError(w, r, oidc.DefaultToServerError(err, "unable to save auth request"), logger)
+		return
+	}
+	httphelper.MarshalJSONWithStatus(w, parResponse{
+		RequestURI: requestURI,
+		ExpiresIn:  int(storage.PushedAuthRequestLifetime.Seconds()),
+	}, http.StatusCreated)
+}
+
+// authenticateClient authenticates the client like the token endpoint, public
+// clients only send their client_id
+func authenticateClient(r *http.Request, provider op.OpenIDProvider) (op.Client, error) {
+	ctx := r.Context()
+	clientID, authenticated, err := op.ClientIDFromRequest(r, provider)
+	if err != nil {
+		return nil, err
+	}
+	client, err := provider.Storage().GetClientByClientID(ctx, clientID)
+	if err != nil {
+		return nil, oidc.ErrInvalidClient().WithParent(err)
+	}
+	// client_secret_post, and the mutual TLS clients, the storage checks their certificate
+	secret := r.PostForm.Get("client_secret")
+	if !authenticated && (secret != "" || isMutualTLS(client.AuthMethod())) {
+		err = provider.Storage().AuthorizeClientIDSecret(ctx, clientID, secret)
+		if err != nil {
+			return nil, oidc.ErrInvalidClient().WithParent(err)
+		}
+		authenticated = true
+	}
+	if !authenticated && client.AuthMethod() != oidc.AuthMethodNone {
+		return nil, oidc.ErrInvalidClient().WithDescription("client must authenticate")
+	}
+	return client, nil
+}
+
+// authorize replaces the parameters of authorization requests with a
+// request_uri by the pushed ones
+func (p *pushedAuthRequests) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != op.DefaultEndpoints.Authorization.Relative() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.Form.Get("request_uri") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authReq, resources, details, err := p.storage.PushedAuthRequest(r.Context(), r.Form.Get("request_uri"), r.Form.Get("client_id"))
+		if err != nil {
+			op.AuthRequestError(w, r, nil, oidc.ErrInvalidRequest().WithDescription(err.Error()), p.provider)
+			return
+		}
+		form := authRequestValues(authReq)
+		form["resource"] = resources
+		if details != "" {
+			form.Set("authorization_details", details)
+		}
+		r = r.WithContext(storage.WithPushedAuthRequest(r.Context()))
+		r.URL.RawQuery = form.Encode()
+		r.Form = form
+		r.PostForm = url.Values{}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authRequestValues are the parameters of an authorization request
+func authRequestValues(a *oidc.AuthRequest) url.Values {
+	v := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	set("scope", a.Scopes.String())
+	set("response_type", string(a.ResponseType))
+	set("client_id", a.ClientID)
+	set("redirect_uri", a.RedirectURI)
+	set("state", a.State)
+	set("nonce", a.Nonce)
+	set("response_mode", string(a.ResponseMode))
+	set("display", string(a.Display))
+	set("prompt", a.Prompt.String())
+	if a.MaxAge != nil {
+		set("max_age", strconv.FormatUint(uint64(*a.MaxAge), 10))
+	}
+	locales := make([]string, 0, len(a.UILocales))
+	for _, l := range a.UILocales {
+		locales = append(locales, l.String())
+	}
+	set("ui_locales", strings.Join(locales, " "))
+	set("id_token_hint", a.IDTokenHint)
+	set("login_hint", a.LoginHint)
+	set("acr_values", a.ACRValues.String())
+	set("code_challenge", a.CodeChallenge)
+	set("code_challenge_method", string(a.CodeChallengeMethod))
+	return v
+}
@@ -0,0 +1,209 @@
+package exampleop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+type passkeyStorage interface {
+	PendingSecondFactor(ctx context.Context, id string) (userID string, pending bool, err error)
+	CompleteAuthRequest(ctx context.Context, id string, userID uuid.UUID, amr []string) error
+	GetPasskeyUser(ctx context.Context, userID uuid.UUID) (*storage.PasskeyUser, error)
+	GetPasskeyUserByHandle(ctx context.Context, userHandle []byte) (*storage.PasskeyUser, error)
+	StorePasskeyCredential(ctx context.Context, userID uuid.UUID, name string, cred *webauthn.Credential) error
+	UpdatePasskeyCredentialUsage(ctx context.Context, userID uuid.UUID, cred *webauthn.Credential) error
+	DeletePasskeyCredential(ctx context.Context, userID, id uuid.UUID) error
+	StoreWebAuthnSession(ctx context.Context, id string, session *webauthn.SessionData) error
+	TakeWebAuthnSession(ctx context.Context, id string) (*webauthn.SessionData, error)
+}
+
+// NewWebAuthn creates the relying party for passkeys, the issuer host is used as RP ID
+func NewWebAuthn(issuer string) (*webauthn.WebAuthn, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, err
+	}
+	return webauthn.New(&webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: "xoidc",
+		RPOrigins:     []string{u.Scheme + "://" + u.Host},
+	})
+}
+
+func passkeyLoginSession(authRequestID string) string {
+	return "login:" + authRequestID
+}
+
+func passkeyRegisterSession(userID uuid.UUID) string {
+	return "register:" + userID.String()
+}
+
+// passkeyHandler renders the page running the assertion ceremony, either for a
+// passwordless login or as second factor after the password
+func (l *login) passkeyHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get(queryAuthRequestID)
+	_, pending, err := l.passkeys.PendingSecondFactor(r.Context(), id)
+	data := &struct {
+		ID           string
+		SecondFactor bool
+		Error        string
+	}{
+		ID:           id,
+		SecondFactor: pending,
+		Error:        errMsg(err),
+	}
+	err = templates.ExecuteTemplate(w, "passkey", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (l *login) beginPasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.URL.Query().Get(queryAuthRequestID)
+	userID, pending, err := l.passkeys.PendingSecondFactor(ctx, id)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+	)
+	if pending {
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		user, err := l.passkeys.GetPasskeyUser(ctx, uid)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		assertion, session, err = l.webauthn.BeginLogin(user)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		// a passkey alone must prove the user is present and verified
+		assertion, session, err = l.webauthn.BeginDiscoverableLogin(
+			webauthn.WithUserVerification(protocol.VerificationRequired),
+		)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	err = l.passkeys.StoreWebAuthnSession(ctx, passkeyLoginSession(id), session)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, assertion)
+}
+
+func (l *login) finishPasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.URL.Query().Get(queryAuthRequestID)
+	session, err := l.passkeys.TakeWebAuthnSession(ctx, passkeyLoginSession(id))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	pendingUserID, pending, err := l.passkeys.PendingSecondFactor(ctx, id)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var (
+		user *storage.PasskeyUser
+		cred *webauthn.Credential
+		amr  []string
+	)
+	if pending {
+		uid, err := uuid.Parse(pendingUserID)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		user, err = l.passkeys.GetPasskeyUser(ctx, uid)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		cred, err = l.webauthn.FinishLogin(user, *session, r)
+		if err == nil {
+			amr = []string{storage.AMRPassword, storage.PasskeyAMR(cred), storage.AMRMultipleFactor}
+		}
+	} else {
+		cred, err = l.webauthn.FinishDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			user, err = l.passkeys.GetPasskeyUserByHandle(ctx, userHandle)
+			return user, err
+		}, *session, r)
+		if err == nil {
+			amr = []string{storage.PasskeyAMR(cred)}
+		}
+	}
+	if err != nil {
+		logrus.Error(err)
+		writeJSONError(w, http.StatusUnauthorized, errors.New("passkey verification failed"))
+		return
+	}
+
+	// the library flags a sign count that did not increase, which means the
+	// authenticator may have been cloned, the counter of the original is kept
+	if cred.Authenticator.CloneWarning {
+		writeJSONError(w, http.StatusUnauthorized, errors.New("passkey sign count did not increase, the authenticator may be cloned"))
+		return
+	}
+	err = l.passkeys.UpdatePasskeyCredentialUsage(ctx, user.ID, cred)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = l.passkeys.CompleteAuthRequest(ctx, id, user.ID, amr)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	redirect, err := l.completeSession(w, r, id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"redirect": redirect,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{
+		"error": errMsg(err),
+	})
+}
@@ -0,0 +1,106 @@
+package exampleop
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/client/rp"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+const portalCookieName = "xoidc_account"
+
+// accountPortal signs users in to the account pages like any other client:
+// with the authorization code flow against this server
+type accountPortal struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	cookies      *httphelper.CookieHandler
+
+	// the relying party needs the discovery document of the server,
+	// so it is created on the first sign in, when the server is up
+	lock  sync.Mutex
+	party rp.RelyingParty
+}
+
+func newAccountPortal(issuer, clientID, clientSecret string, key [32]byte) *accountPortal {
+	hashKey := sha256.Sum256(append([]byte("account-hash:"), key[:]...))
+	encryptKey := sha256.Sum256(append([]byte("account-encrypt:"), key[:]...))
+
+	var opts []httphelper.CookieHandlerOpt
+	if strings.HasPrefix(issuer, "http://") {
+		opts = append(opts, httphelper.WithUnsecure())
+	}
+	opts = append(opts, httphelper.WithPath("/account/"))
+	return &accountPortal{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		cookies:      httphelper.NewCookieHandler(hashKey[:], encryptKey[:], opts...),
+	}
+}
+
+func (p *accountPortal) relyingParty(ctx context.Context) (rp.RelyingParty, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.party != nil {
+		return p.party, nil
+	}
+	party, err := rp.NewRelyingPartyOIDC(ctx, p.issuer+"/", p.clientID, p.clientSecret,
+		p.issuer+"/account/callback",
+		[]string{oidc.ScopeOpenID, oidc.ScopeProfile, oidc.ScopeEmail},
+		rp.WithPKCE(p.cookies),
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.party = party
+	return party, nil
+}
+
+// loginHandler starts the authorization code flow
+func (p *accountPortal) loginHandler(w http.ResponseWriter, r *http.Request) {
+	party, err := p.relyingParty(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rp.AuthURLHandler(uuid.NewString, party)(w, r)
+}
+
+// callbackHandler exchanges the code and remembers the subject of the id token
+func (p *accountPortal) callbackHandler(w http.ResponseWriter, r *http.Request) {
+	party, err := p.relyingParty(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	callback := func(w http.ResponseWriter, r *http.Request, tokens *oidc.Tokens[*oidc.IDTokenClaims], state string, _ rp.RelyingParty) {
+		err := p.cookies.SetCookie(w, portalCookieName, tokens.IDTokenClaims.GetSubject())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/account/", http.StatusFound)
+	}
+	rp.CodeExchangeHandler(callback, party)(w, r)
+}
+
+// UserID returns the user signed in to the portal
+func (p *accountPortal) UserID(r *http.Request) (uuid.UUID, error) {
+	value, err := p.cookies.CheckCookie(r, portalCookieName)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return uuid.Parse(value)
+}
+
+func (p *accountPortal) Clear(w http.ResponseWriter) {
+	p.cookies.DeleteCookie(w, portalCookieName)
+}
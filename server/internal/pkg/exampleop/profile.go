@@ -0,0 +1,122 @@
+package exampleop
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zltl/xoidc/server/gen/xoidc/public/model"
+)
+
+const birthdateLayout = "2006-01-02"
+
+func (a *account) indexHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	user, err := a.users.GetUserProfile(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = templates.ExecuteTemplate(w, "account", user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// logoutHandler ends the session of this browser
+func (a *account) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err == nil {
+		sessionID, _ := a.sessions.SessionID(r)
+		err = a.users.DeleteUserSession(r.Context(), userID, sessionID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	a.sessions.Clear(w)
+	if a.portal != nil {
+		a.portal.Clear(w)
+	}
+	http.Redirect(w, r, pathLoggedOut, http.StatusFound)
+}
+
+func renderProfile(w http.ResponseWriter, user *model.User, message string, err error) {
+	data := &struct {
+		User      *model.User
+		Birthdate string
+		Message   string
+		Error     string
+	}{
+		User:    user,
+		Message: message,
+		Error:   errMsg(err),
+	}
+	if !user.Birthdate.IsZero() {
+		data.Birthdate = user.Birthdate.Format(birthdateLayout)
+	}
+	err = templates.ExecuteTemplate(w, "profile", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (a *account) profileHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	user, err := a.users.GetUserProfile(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	renderProfile(w, user, "", nil)
+}
+
+func (a *account) saveProfileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	user, err := a.users.GetUserProfile(ctx, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	field := func(name string) string {
+		return strings.TrimSpace(r.FormValue(name))
+	}
+	user.Nickname = field("nickname")
+	user.GivenName = field("given_name")
+	user.FamilyName = field("family_name")
+	user.MiddleName = field("middle_name")
+	user.PreferredUsername = field("preferred_username")
+	user.Profile = field("profile")
+	user.Picture = field("picture")
+	user.Website = field("website")
+	user.Gender = field("gender")
+	user.Zoneinfo = field("zoneinfo")
+	user.Locale = field("locale")
+	user.Address = field("address")
+	if birthdate := field("birthdate"); birthdate != "" {
+		user.Birthdate, err = time.Parse(birthdateLayout, birthdate)
+		if err != nil {
+			renderProfile(w, user, "", err)
+			return
+		}
+	}
+	err = a.users.UpdateUserProfile(ctx, userID, user)
+	if err != nil {
+		renderProfile(w, user, "", err)
+		return
+	}
+	renderProfile(w, user, "profile saved", nil)
+}
@@ -0,0 +1,99 @@
+package exampleop
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/ratelimit"
+)
+
+// DefaultRateLimits returns the limits of the token, introspection, revocation,
+// device authorization, pushed authorization, backchannel authentication, login, password reset,
+// registration and verification endpoints, ratelimit.Configure changes them
+func DefaultRateLimits() []ratelimit.Rule {
+	endpoints := op.DefaultEndpoints
+	return []ratelimit.Rule{
+		{
+			Name: "token:client", Method: http.MethodPost, Path: endpoints.Token.Relative(),
+			Key: ratelimit.ByClientID, Limit: 600, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "token:ip", Method: http.MethodPost, Path: endpoints.Token.Relative(),
+			Key: ratelimit.ByIP, Limit: 1200, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "introspect:client", Method: http.MethodPost, Path: endpoints.Introspection.Relative(),
+			Key: ratelimit.ByClientID, Limit: 1200, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "revoke:client", Method: http.MethodPost, Path: endpoints.Revocation.Relative(),
+			Key: ratelimit.ByClientID, Limit: 300, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "revoke:ip", Method: http.MethodPost, Path: endpoints.Revocation.Relative(),
+			Key: ratelimit.ByIP, Limit: 600, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "device:client", Method: http.MethodPost, Path: endpoints.DeviceAuthorization.Relative(),
+			Key: ratelimit.ByClientID, Limit: 60, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "device:ip", Method: http.MethodPost, Path: endpoints.DeviceAuthorization.Relative(),
+			Key: ratelimit.ByIP, Limit: 30, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "par:client", Method: http.MethodPost, Path: pathPAR,
+			Key: ratelimit.ByClientID, Limit: 300, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "par:ip", Method: http.MethodPost, Path: pathPAR,
+			Key: ratelimit.ByIP, Limit: 600, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "bc-authorize:client", Method: http.MethodPost, Path: pathBackchannelAuthorize,
+			Key: ratelimit.ByClientID, Limit: 120, Window: time.Minute, OAuth: true,
+		},
+		{
+			// every request notifies the user
+			Name: "bc-authorize:login_hint", Method: http.MethodPost, Path: pathBackchannelAuthorize,
+			Key: ratelimit.ByFormValue("login_hint"), Limit: 5, Window: time.Minute, OAuth: true,
+		},
+		{
+			Name: "login:ip", Method: http.MethodPost, Path: pathLogin + pathLoginUsername,
+			Key: ratelimit.ByIP, Limit: 60, Window: time.Minute,
+		},
+		{
+			Name: "login:username", Method: http.MethodPost, Path: pathLogin + pathLoginUsername,
+			Key: ratelimit.ByFormValue("username"), Limit: 20, Window: time.Minute,
+		},
+		{
+			Name: "passkey:ip", Method: http.MethodPost, Path: pathLogin + pathLoginPasskeyBegin,
+			Key: ratelimit.ByIP, Limit: 60, Window: time.Minute,
+		},
+		{
+			Name: "forgot:ip", Method: http.MethodPost, Path: pathPassword + pathPasswordForgot,
+			Key: ratelimit.ByIP, Limit: 10, Window: time.Minute,
+		},
+		{
+			Name: "forgot:username", Method: http.MethodPost, Path: pathPassword + pathPasswordForgot,
+			Key: ratelimit.ByFormValue("username"), Limit: 5, Window: time.Hour,
+		},
+		{
+			Name: "register:ip", Method: http.MethodPost, Path: pathRegister + "/",
+			Key: ratelimit.ByIP, Limit: 10, Window: time.Minute,
+		},
+		{
+			Name: "verify-email:ip", Method: http.MethodPost, Path: pathAccount + pathAccountEmailVerify,
+			Key: ratelimit.ByIP, Limit: 5, Window: time.Minute,
+		},
+		{
+			Name: "verify-phone:ip", Method: http.MethodPost, Path: pathAccount + pathAccountPhoneVerify,
+			Key: ratelimit.ByIP, Limit: 5, Window: time.Minute,
+		},
+		{
+			Name: "confirm-phone:ip", Method: http.MethodPost, Path: pathAccount + pathAccountPhoneConfirm,
+			Key: ratelimit.ByIP, Limit: 10, Window: time.Minute,
+		},
+	}
+}
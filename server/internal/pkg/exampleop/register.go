@@ -0,0 +1,165 @@
+package exampleop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// the registration page is mounted on pathRegister, the form posts to its root
+const pathRegister = "/register"
+
+type registerStorage interface {
+	ClientRegistrationPolicy(ctx context.Context, clientID uuid.UUID) (*storage.RegistrationPolicy, error)
+	RegisterUser(ctx context.Context, clientID uuid.UUID, u *storage.User, plainPassword string) (uuid.UUID, error)
+}
+
+// registration lets users create an account in the namespace of the client
+// they are signing in to, if the registration policy of the namespace allows it
+type registration struct {
+	storage  registerStorage
+	login    *login
+	verifier *verifier
+	router   chi.Router
+}
+
+func NewRegistration(store registerStorage, l *login, v *verifier, issuerInterceptor *op.IssuerInterceptor) *registration {
+	g := &registration{
+		storage:  store,
+		login:    l,
+		verifier: v,
+	}
+	g.router = chi.NewRouter()
+	g.router.Get("/", g.registerHandler)
+	g.router.Post("/", issuerInterceptor.HandlerFunc(g.createHandler))
+	return g
+}
+
+// registerForm is the data of the "register" template
+type registerForm struct {
+	ID       string
+	Enabled  bool
+	Required map[string]bool
+	Values   map[string]string
+	Message  string
+	Error    string
+	// Continue links back to the login after the account was created
+	Continue bool
+}
+
+func (g *registration) policy(ctx context.Context, id string) (*storage.RegistrationPolicy, error) {
+	request, err := g.login.authenticate.AuthRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	clientID, err := uuid.Parse(request.GetClientID())
+	if err != nil {
+		return nil, err
+	}
+	return g.storage.ClientRegistrationPolicy(ctx, clientID)
+}
+
+func (g *registration) render(w http.ResponseWriter, r *http.Request, id string, data *registerForm) {
+	data.ID = id
+	policy, err := g.policy(r.Context(), id)
+	if err != nil {
+		data.Error = errMsg(err)
+	} else {
+		data.Enabled = policy.Enabled
+		data.Required = map[string]bool{}
+		for _, f := range storage.RegistrationFields {
+			data.Required[f] = policy.Requires(f)
+		}
+	}
+	err = templates.ExecuteTemplate(w, "register", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (g *registration) registerHandler(w http.ResponseWriter, r *http.Request) {
+	g.render(w, r, r.FormValue(queryAuthRequestID), &registerForm{})
+}
+
+func (g *registration) createHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot parse form:%s", err), http.StatusInternalServerError)
+		return
+	}
+	ctx := r.Context()
+	id := r.FormValue("id")
+	values := map[string]string{}
+	for _, f := range append([]string{"username"}, storage.RegistrationFields...) {
+		values[f] = strings.TrimSpace(r.FormValue(f))
+	}
+	form := &registerForm{Values: values}
+
+	request, err := g.login.authenticate.AuthRequestByID(ctx, id)
+	if err != nil {
+		form.Error = errMsg(err)
+		g.render(w, r, id, form)
+		return
+	}
+	clientID, err := uuid.Parse(request.GetClientID())
+	if err != nil {
+		form.Error = errMsg(err)
+		g.render(w, r, id, form)
+		return
+	}
+	plainPassword := r.FormValue("password")
+	if plainPassword != r.FormValue("password_repeat") {
+		form.Error = "the passwords do not match"
+		g.render(w, r, id, form)
+		return
+	}
+
+	user := &storage.User{
+		Username:  values["username"],
+		FirstName: values["given_name"],
+		LastName:  values["family_name"],
+		Nickname:  values["nickname"],
+		Email:     values["email"],
+		Phone:     values["phone_number"],
+	}
+	userID, err := g.storage.RegisterUser(ctx, clientID, user, plainPassword)
+	if err != nil {
+		form.Error = errMsg(err)
+		g.render(w, r, id, form)
+		return
+	}
+	if user.Email != "" {
+		user.ID = userID
+		err = g.verifier.StartEmail(ctx, user)
+		if err != nil {
+			logrus.Errorf("send email verification to user %s: %v", userID, err)
+		}
+	}
+
+	// the login decides if the new user may sign in now
+	err = g.login.authenticate.CheckUsernamePassword(user.Username, plainPassword, id, remoteIP(r))
+	switch {
+	case errors.Is(err, storage.ErrPendingApproval):
+		g.render(w, r, id, &registerForm{
+			Message:  "Your account was created, it can be used after an administrator approved it.",
+			Continue: true,
+		})
+	case errors.Is(err, storage.ErrEmailNotVerified):
+		g.render(w, r, id, &registerForm{
+			Message:  "Your account was created, open the link we sent to " + user.Email + " and then sign in.",
+			Continue: true,
+		})
+	case err != nil:
+		g.render(w, r, id, &registerForm{Error: errMsg(err), Continue: true})
+	default:
+		g.login.passwordChecked(w, r, id)
+	}
+}
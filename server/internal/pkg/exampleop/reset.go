@@ -0,0 +1,189 @@
+package exampleop
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/mailer"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// a reset link can be used within this time
+const passwordResetLife = 30 * time.Minute
+
+// the reset pages are mounted on pathPassword
+const (
+	pathPassword       = "/password"
+	pathPasswordForgot = "/forgot"
+	pathPasswordReset  = "/reset"
+)
+
+type resetStorage interface {
+	AuthRequestByID(ctx context.Context, id string) (op.AuthRequest, error)
+	GetUserByUsername(ctx context.Context, name string, clientID uuid.UUID) (*storage.User, error)
+	CreatePasswordReset(ctx context.Context, userID uuid.UUID, life time.Duration) (uuid.UUID, error)
+	PasswordResetUser(ctx context.Context, id uuid.UUID) (*storage.User, error)
+	ResetPassword(ctx context.Context, id uuid.UUID, newPassword string) error
+}
+
+// passwordReset serves the "forgot password" pages
+type passwordReset struct {
+	storage resetStorage
+	mailer  mailer.Mailer
+	tokens  *signedIDs
+	issuer  string
+	router  chi.Router
+}
+
+func NewPasswordReset(store resetStorage, m mailer.Mailer, key [32]byte, issuer string) *passwordReset {
+	p := &passwordReset{
+		storage: store,
+		mailer:  m,
+		tokens:  newSignedIDs(key, "password-reset", storage.ErrInvalidResetToken),
+		issuer:  strings.TrimSuffix(issuer, "/"),
+	}
+	p.router = chi.NewRouter()
+	p.router.Get(pathPasswordForgot, p.forgotHandler)
+	p.router.Post(pathPasswordForgot, p.sendResetHandler)
+	p.router.Get(pathPasswordReset, p.resetHandler)
+	p.router.Post(pathPasswordReset, p.completeResetHandler)
+	return p
+}
+
+func renderForgot(w http.ResponseWriter, id, message string, err error) {
+	data := &struct {
+		ID      string
+		Message string
+		Error   string
+	}{
+		ID:      id,
+		Message: message,
+		Error:   errMsg(err),
+	}
+	err = templates.ExecuteTemplate(w, "forgot", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (p *passwordReset) forgotHandler(w http.ResponseWriter, r *http.Request) {
+	renderForgot(w, r.FormValue(queryAuthRequestID), "", nil)
+}
+
+// sendResetHandler mails a reset link. It answers the same whether the user
+// exists or not, and the mail is sent in the background so the timing does not tell either.
+func (p *passwordReset) sendResetHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.FormValue("id")
+	username := r.FormValue("username")
+	request, err := p.storage.AuthRequestByID(ctx, id)
+	if err != nil {
+		renderForgot(w, id, "", err)
+		return
+	}
+	clientID, err := uuid.Parse(request.GetClientID())
+	if err != nil {
+		renderForgot(w, id, "", err)
+		return
+	}
+
+	user, err := p.storage.GetUserByUsername(ctx, username, clientID)
+	if err == nil && user.Email != "" {
+		go p.sendReset(user, id)
+	}
+	renderForgot(w, id, "If the account exists and has an email address, we sent a link to reset the password.", nil)
+}
+
+func (p *passwordReset) sendReset(user *storage.User, authRequestID string) {
+	ctx := context.Background()
+	expiration := time.Now().Add(passwordResetLife)
+	resetID, err := p.storage.CreatePasswordReset(ctx, user.ID, passwordResetLife)
+	if err != nil {
+		return
+	}
+	link := p.issuer + pathPassword + pathPasswordReset + "?" + url.Values{
+		"token":            {p.tokens.Sign(resetID, expiration)},
+		queryAuthRequestID: {authRequestID},
+	}.Encode()
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "Hello %s,\n\n", user.Username)
+	fmt.Fprintf(&body, "open the following link to choose a new password:\n\n%s\n\n", link)
+	fmt.Fprintf(&body, "The link expires in %d minutes and can be used once.\n", int(passwordResetLife.Minutes()))
+	body.WriteString("If you did not ask to reset your password, ignore this mail.\n")
+	err = p.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body.String(),
+	})
+	if err != nil {
+		logrus.Errorf("send password reset to user %s: %v", user.ID, err)
+	}
+}
+
+func renderReset(w http.ResponseWriter, token, id, username string, done bool, err error) {
+	data := &struct {
+		Token    string
+		ID       string
+		Username string
+		Done     bool
+		Error    string
+	}{
+		Token:    token,
+		ID:       id,
+		Username: username,
+		Done:     done,
+		Error:    errMsg(err),
+	}
+	err = templates.ExecuteTemplate(w, "reset", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (p *passwordReset) resetHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	id := r.FormValue(queryAuthRequestID)
+	resetID, err := p.tokens.Verify(token)
+	if err != nil {
+		renderReset(w, "", id, "", false, err)
+		return
+	}
+	user, err := p.storage.PasswordResetUser(r.Context(), resetID)
+	if err != nil {
+		renderReset(w, "", id, "", false, err)
+		return
+	}
+	renderReset(w, token, id, user.Username, false, nil)
+}
+
+func (p *passwordReset) completeResetHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	id := r.FormValue("id")
+	username := r.FormValue("username")
+	resetID, err := p.tokens.Verify(token)
+	if err != nil {
+		renderReset(w, "", id, "", false, err)
+		return
+	}
+	err = p.storage.ResetPassword(r.Context(), resetID, r.FormValue("new_password"))
+	if errors.Is(err, storage.ErrInvalidResetToken) {
+		renderReset(w, "", id, "", false, err)
+		return
+	}
+	if err != nil {
+		renderReset(w, token, id, username, false, err)
+		return
+	}
+	renderReset(w, "", id, username, true, nil)
+}
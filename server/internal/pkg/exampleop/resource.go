@@ -0,0 +1,24 @@
+package exampleop
+
+import (
+	"net/http"
+
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// resourceIndicators passes the resource parameters (RFC 8707) of authorization
+// and token requests to the storage, the library ignores them
+func resourceIndicators(next http.Handler) http.Handler {
+	endpoints := op.DefaultEndpoints
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case endpoints.Authorization.Relative(), endpoints.Token.Relative():
+			// the library parses the form again, which is a no-op then
+			if err := r.ParseForm(); err == nil {
+				r = r.WithContext(storage.WithResourceRequest(r.Context(), r.Form["resource"]))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
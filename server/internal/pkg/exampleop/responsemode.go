@@ -0,0 +1,156 @@
+package exampleop
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+type responseModeStorage interface {
+	SignAuthResponse(ctx context.Context, issuer, clientID string, params url.Values) (string, error)
+}
+
+// authResponses delivers the authorization responses of the response modes the
+// library does not know, form_post and the jwt modes (JARM). The library
+// redirects to the client with query or fragment parameters, those redirects
+// are rewritten in the mode the stored request asked for.
+type authResponses struct {
+	provider op.OpenIDProvider
+	storage  responseModeStorage
+}
+
+// bufferedResponse holds back the response of the library
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
+
+func (a *authResponses) handler(next http.Handler) http.Handler {
+	authorize := op.DefaultEndpoints.Authorization.Relative()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the library redirects to the client on the authorization endpoint and its callback
+		if r.URL.Path != authorize && r.URL.Path != authorize+"/callback" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, res := storage.WithAuthResponse(r.Context())
+		buf := &bufferedResponse{header: http.Header{}}
+		next.ServeHTTP(buf, r.WithContext(ctx))
+
+		params, ok := redirectParams(buf, res)
+		if !ok {
+			buf.writeTo(w)
+			return
+		}
+		a.respond(w, r, res, params)
+	})
+}
+
+// redirectParams are the parameters of a redirect of the library to the
+// client, ok is false for other responses and the modes the library handles
+func redirectParams(buf *bufferedResponse, res *storage.AuthResponse) (url.Values, bool) {
+	switch res.Mode {
+	case "", oidc.ResponseModeQuery, oidc.ResponseModeFragment:
+		return nil, false
+	}
+	if buf.status != http.StatusFound {
+		return nil, false
+	}
+	location, err := url.Parse(buf.header.Get("Location"))
+	if err != nil {
+		return nil, false
+	}
+	redirect, err := url.Parse(res.RedirectURI)
+	if err != nil {
+		return nil, false
+	}
+	if location.Scheme != redirect.Scheme || location.Host != redirect.Host || location.Path != redirect.Path {
+		// e.g. the redirect to the login
+		return nil, false
+	}
+	if location.Fragment != "" {
+		params, err := url.ParseQuery(location.Fragment)
+		return params, err == nil
+	}
+	params := location.Query()
+	for k := range redirect.Query() {
+		params.Del(k)
+	}
+	return params, true
+}
+
+func (a *authResponses) respond(w http.ResponseWriter, r *http.Request, res *storage.AuthResponse, params url.Values) {
+	mode := res.Mode
+	if mode == storage.ResponseModeJWT {
+		// the default mode of the response type, in jwt
+		mode = storage.ResponseModeFragmentJWT
+		if res.ResponseType == oidc.ResponseTypeCode {
+			mode = storage.ResponseModeQueryJWT
+		}
+	}
+	if strings.HasSuffix(string(mode), ".jwt") {
+		token, err := a.storage.SignAuthResponse(r.Context(), a.provider.IssuerFromRequest(r), res.ClientID, params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		params = url.Values{"response": {token}}
+		mode = oidc.ResponseMode(strings.TrimSuffix(string(mode), ".jwt"))
+	}
+
+	redirect, _ := url.Parse(res.RedirectURI)
+	switch mode {
+	case storage.ResponseModeFormPost:
+		w.Header().Set("Cache-Control", "no-store")
+		err := templates.ExecuteTemplate(w, "form_post", struct {
+			RedirectURI string
+			Params      url.Values
+		}{res.RedirectURI, params})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case oidc.ResponseModeFragment:
+		redirect.Fragment = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	default:
+		query := redirect.Query()
+		for k, v := range params {
+			query[k] = v
+		}
+		redirect.RawQuery = query.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	}
+}
@@ -0,0 +1,112 @@
+package exampleop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// fakeIssuer is a provider of which the responses only need the issuer
+type fakeIssuer struct {
+	op.OpenIDProvider
+}
+
+func (fakeIssuer) IssuerFromRequest(r *http.Request) string {
+	return "https://op.example.com"
+}
+
+// fakeAuthResponses signs the parameters into a readable "token"
+type fakeAuthResponses struct{}
+
+func (fakeAuthResponses) SignAuthResponse(ctx context.Context, issuer, clientID string, params url.Values) (string, error) {
+	return issuer + "|" + clientID + "|" + params.Encode(), nil
+}
+
+func TestRedirectParams(t *testing.T) {
+	res := &storage.AuthResponse{Mode: storage.ResponseModeQueryJWT, RedirectURI: "https://rp.example.com/cb?tenant=a"}
+	tests := []struct {
+		name     string
+		mode     oidc.ResponseMode
+		status   int
+		location string
+		params   url.Values
+	}{
+		{"query", oidc.ResponseModeQuery, http.StatusFound, "https://rp.example.com/cb?tenant=a&code=abc", nil},
+		{"not a redirect", storage.ResponseModeQueryJWT, http.StatusOK, "", nil},
+		{"login", storage.ResponseModeQueryJWT, http.StatusFound, "/login/select?authRequestID=1", nil},
+		{"other client path", storage.ResponseModeQueryJWT, http.StatusFound, "https://rp.example.com/other?code=abc", nil},
+		// the parameters of the redirect_uri are not part of the response
+		{"query.jwt", storage.ResponseModeQueryJWT, http.StatusFound, "https://rp.example.com/cb?tenant=a&code=abc&state=s", url.Values{"code": {"abc"}, "state": {"s"}}},
+		{"fragment", storage.ResponseModeFormPost, http.StatusFound, "https://rp.example.com/cb?tenant=a#id_token=t&state=s", url.Values{"id_token": {"t"}, "state": {"s"}}},
+	}
+	for _, tt := range tests {
+		buf := &bufferedResponse{header: http.Header{}, status: tt.status}
+		buf.header.Set("Location", tt.location)
+		res.Mode = tt.mode
+		params, ok := redirectParams(buf, res)
+		if ok != (tt.params != nil) || params.Encode() != tt.params.Encode() {
+			t.Errorf("%s: got %v, %v", tt.name, params, ok)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	a := &authResponses{provider: fakeIssuer{}, storage: fakeAuthResponses{}}
+	params := url.Values{"code": {"abc"}, "state": {"s"}}
+	signed := "https://op.example.com|rp|" + params.Encode()
+
+	respond := func(mode oidc.ResponseMode, responseType oidc.ResponseType) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		res := &storage.AuthResponse{Mode: mode, ResponseType: responseType, ClientID: "rp", RedirectURI: "https://rp.example.com/cb?tenant=a"}
+		a.respond(w, httptest.NewRequest(http.MethodGet, "/authorize/callback", nil), res, params)
+		return w
+	}
+	redirect := func(w *httptest.ResponseRecorder) *url.URL {
+		if w.Code != http.StatusFound {
+			t.Fatalf("got %d, want a redirect", w.Code)
+		}
+		u, err := url.Parse(w.Header().Get("Location"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	// the jwt modes replace the parameters with the signed response
+	u := redirect(respond(storage.ResponseModeQueryJWT, oidc.ResponseTypeCode))
+	if u.Query().Get("response") != signed || u.Query().Get("tenant") != "a" || u.Query().Get("code") != "" {
+		t.Errorf("query.jwt: %s", u)
+	}
+	u = redirect(respond(storage.ResponseModeFragmentJWT, oidc.ResponseTypeCode))
+	if fragment, _ := url.ParseQuery(u.Fragment); fragment.Get("response") != signed || u.Query().Get("response") != "" {
+		t.Errorf("fragment.jwt: %s", u)
+	}
+	// jwt is the default mode of the response type: query for code, fragment for the others
+	u = redirect(respond(storage.ResponseModeJWT, oidc.ResponseTypeCode))
+	if u.Query().Get("response") != signed {
+		t.Errorf("jwt for code: %s", u)
+	}
+	u = redirect(respond(storage.ResponseModeJWT, oidc.ResponseTypeIDToken))
+	if fragment, _ := url.ParseQuery(u.Fragment); fragment.Get("response") != signed {
+		t.Errorf("jwt for id_token: %s", u)
+	}
+
+	// form_post renders the parameters in a form posting to the client
+	w := respond(storage.ResponseModeFormPostJWT, oidc.ResponseTypeCode)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `action="https://rp.example.com/cb?tenant=a"`) || !strings.Contains(body, `name="response"`) || strings.Contains(body, `name="code"`) {
+		t.Errorf("form_post.jwt: %d %s", w.Code, body)
+	}
+	w = respond(storage.ResponseModeFormPost, oidc.ResponseTypeCode)
+	body = w.Body.String()
+	if !strings.Contains(body, `name="code" value="abc"`) || !strings.Contains(body, `name="state" value="s"`) || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("form_post: %s", body)
+	}
+}
@@ -0,0 +1,74 @@
+package exampleop
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+
+	"github.com/google/uuid"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+)
+
+const (
+	sessionCookieName = "xoidc_session"
+)
+
+type sessionStorage interface {
+	CreateUserSession(ctx context.Context, userID uuid.UUID, userAgent, remoteIP string, amr []string) (uuid.UUID, error)
+	TouchUserSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error
+}
+
+// sessionCookies remembers the user who signed in last with this browser,
+// so pages outside of an auth request (e.g. the account page) know who is calling.
+// The cookie holds the id of a user_session, so sessions can be listed and revoked.
+type sessionCookies struct {
+	handler *httphelper.CookieHandler
+	storage sessionStorage
+}
+
+func newSessionCookies(store sessionStorage, key [32]byte, insecure bool) *sessionCookies {
+	// derive separate keys for signing and encrypting the cookie
+	hashKey := sha256.Sum256(append([]byte("session-hash:"), key[:]...))
+	encryptKey := sha256.Sum256(append([]byte("session-encrypt:"), key[:]...))
+
+	var opts []httphelper.CookieHandlerOpt
+	if insecure {
+		opts = append(opts, httphelper.WithUnsecure())
+	}
+	return &sessionCookies{
+		handler: httphelper.NewCookieHandler(hashKey[:], encryptKey[:], opts...),
+		storage: store,
+	}
+}
+
+// Start creates a session of the signed in user and stores its id
+func (s *sessionCookies) Start(w http.ResponseWriter, r *http.Request, userID uuid.UUID, amr []string) (uuid.UUID, error) {
+	id, err := s.storage.CreateUserSession(r.Context(), userID, r.UserAgent(), remoteIP(r), amr)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, s.handler.SetCookie(w, sessionCookieName, id.String())
+}
+
+// SessionID returns the id of the session of this browser
+func (s *sessionCookies) SessionID(r *http.Request) (uuid.UUID, error) {
+	value, err := s.handler.CheckCookie(r, sessionCookieName)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return uuid.Parse(value)
+}
+
+// UserID returns the id of the signed in user, if the session was not revoked
+func (s *sessionCookies) UserID(r *http.Request) (uuid.UUID, error) {
+	id, err := s.SessionID(r)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return s.storage.TouchUserSession(r.Context(), id)
+}
+
+func (s *sessionCookies) Clear(w http.ResponseWriter) {
+	s.handler.DeleteCookie(w, sessionCookieName)
+}
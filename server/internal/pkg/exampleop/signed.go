@@ -0,0 +1,88 @@
+package exampleop
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// minLinkKeyLength is the minimum length of the secret signing the links
+const minLinkKeyLength = 32
+
+// LoadLinkKey returns the secret signing the links sent to users, read from the
+// file at path or else taken from value. There is no default key,
+// with a known key anyone could forge the links.
+func LoadLinkKey(value, path string) ([]byte, error) {
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		value = strings.TrimSpace(string(b))
+	}
+	if len(value) < minLinkKeyLength {
+		return nil, fmt.Errorf("the link key must have at least %d bytes", minLinkKeyLength)
+	}
+	return []byte(value), nil
+}
+
+// signedIDs signs ids with an expiration for links sent by mail,
+// so the links cannot be forged or used after they expired
+type signedIDs struct {
+	key []byte
+	// invalid is returned for any token that does not verify
+	invalid error
+}
+
+// newSignedIDs derives a key for the purpose, tokens of one purpose are not valid for another
+func newSignedIDs(key [32]byte, purpose string, invalid error) *signedIDs {
+	k := sha256.Sum256(append([]byte(purpose+":"), key[:]...))
+	return &signedIDs{key: k[:], invalid: invalid}
+}
+
+func (t *signedIDs) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, t.key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Sign returns the token of an id: base64url(id || expiration) "." base64url(hmac)
+func (t *signedIDs) Sign(id uuid.UUID, expiration time.Time) string {
+	payload := make([]byte, 0, 24)
+	payload = append(payload, id[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiration.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(t.mac(payload))
+}
+
+// Verify returns the id of a token that is authentic and not expired
+func (t *signedIDs) Verify(token string) (uuid.UUID, error) {
+	p, m, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, t.invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil || len(payload) != 24 {
+		return uuid.Nil, t.invalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(m)
+	if err != nil || !hmac.Equal(mac, t.mac(payload)) {
+		return uuid.Nil, t.invalid
+	}
+	expiration := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	if time.Now().After(expiration) {
+		return uuid.Nil, t.invalid
+	}
+	id, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, t.invalid
+	}
+	return id, nil
+}
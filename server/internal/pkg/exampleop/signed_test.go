@@ -0,0 +1,80 @@
+package exampleop
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var errInvalidLink = errors.New("invalid link")
+
+func TestSignedIDs(t *testing.T) {
+	key := sha256.Sum256([]byte("a key of the tests"))
+	reset := newSignedIDs(key, "password-reset", errInvalidLink)
+	id := uuid.New()
+
+	token := reset.Sign(id, time.Now().Add(time.Minute))
+	got, err := reset.Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id {
+		t.Errorf("got %s, want %s", got, id)
+	}
+
+	payload, mac, _ := strings.Cut(token, ".")
+	other := newSignedIDs(key, "email-verification", errInvalidLink)
+	otherKey := newSignedIDs(sha256.Sum256([]byte("another key")), "password-reset", errInvalidLink)
+	tests := []struct {
+		name   string
+		ids    *signedIDs
+		token  string
+		expect error
+	}{
+		{"expired", reset, reset.Sign(id, time.Now().Add(-time.Second)), errInvalidLink},
+		{"other purpose", other, token, errInvalidLink},
+		{"other key", otherKey, token, errInvalidLink},
+		{"other id", reset, reset.Sign(uuid.New(), time.Now().Add(time.Minute))[:len(payload)] + "." + mac, errInvalidLink},
+		{"no mac", reset, payload, errInvalidLink},
+		{"garbage", reset, "x.y", errInvalidLink},
+	}
+	for _, tt := range tests {
+		if _, err := tt.ids.Verify(tt.token); err != tt.expect {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.expect)
+		}
+	}
+}
+
+func TestLoadLinkKey(t *testing.T) {
+	secret := strings.Repeat("k", minLinkKeyLength)
+	path := filepath.Join(t.TempDir(), "link.key")
+	err := os.WriteFile(path, []byte(secret+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := LoadLinkKey("", path)
+	if err != nil || string(key) != secret {
+		t.Errorf("from file: got %q, %v", key, err)
+	}
+	// the file wins over the value
+	key, err = LoadLinkKey("short", path)
+	if err != nil || string(key) != secret {
+		t.Errorf("file and value: got %q, %v", key, err)
+	}
+	if _, err := LoadLinkKey("", ""); err == nil {
+		t.Error("no key was accepted")
+	}
+	if _, err := LoadLinkKey(secret[1:], ""); err == nil {
+		t.Error("a short key was accepted")
+	}
+	if _, err := LoadLinkKey("", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("a missing file was accepted")
+	}
+}
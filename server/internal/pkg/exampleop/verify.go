@@ -0,0 +1,240 @@
+package exampleop
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/mailer"
+	"github.com/zltl/xoidc/server/internal/pkg/sms"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+const (
+	emailVerificationLife = 24 * time.Hour
+	phoneVerificationLife = 10 * time.Minute
+)
+
+// the verification routes of the account pages
+const (
+	pathAccountEmailVerify  = "/contact/email/verify"
+	pathAccountPhoneVerify  = "/contact/phone/verify"
+	pathAccountPhoneConfirm = "/contact/phone/confirm"
+	pathAccountEmailConfirm = "/verify/email"
+)
+
+type verifyStorage interface {
+	GetUserByID(ctx context.Context, id uuid.UUID) (*storage.User, error)
+	SetUserEmail(ctx context.Context, userID uuid.UUID, email string) error
+	SetUserPhone(ctx context.Context, userID uuid.UUID, phone string) error
+	CreateVerification(ctx context.Context, userID uuid.UUID, kind, address, code string, life time.Duration) (uuid.UUID, error)
+	ConfirmEmailVerification(ctx context.Context, id uuid.UUID) error
+	ConfirmPhoneVerification(ctx context.Context, userID uuid.UUID, code string) error
+}
+
+// verifier sends the email links and sms codes verifying the contacts of users
+type verifier struct {
+	storage verifyStorage
+	mailer  mailer.Mailer
+	sms     sms.Sender
+	links   *signedIDs
+	issuer  string
+}
+
+func newVerifier(store verifyStorage, m mailer.Mailer, s sms.Sender, key [32]byte, issuer string) *verifier {
+	return &verifier{
+		storage: store,
+		mailer:  m,
+		sms:     s,
+		links:   newSignedIDs(key, "email-verification", storage.ErrInvalidVerification),
+		issuer:  strings.TrimSuffix(issuer, "/"),
+	}
+}
+
+// StartEmail mails a link confirming the current email address of the user
+func (v *verifier) StartEmail(ctx context.Context, user *storage.User) error {
+	if user.Email == "" {
+		return errors.New("no email address to verify")
+	}
+	id, err := v.storage.CreateVerification(ctx, user.ID, storage.VerifyEmail, user.Email, "", emailVerificationLife)
+	if err != nil {
+		return err
+	}
+	link := v.issuer + pathAccount + pathAccountEmailConfirm + "?" + url.Values{
+		"token": {v.links.Sign(id, time.Now().Add(emailVerificationLife))},
+	}.Encode()
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "Hello %s,\n\n", user.Username)
+	fmt.Fprintf(&body, "open the following link to confirm your email address:\n\n%s\n\n", link)
+	fmt.Fprintf(&body, "The link expires in %d hours.\n", int(emailVerificationLife.Hours()))
+	return v.mailer.Send(ctx, &mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body:    body.String(),
+	})
+}
+
+// StartPhone sends a one time code to the current phone number of the user
+func (v *verifier) StartPhone(ctx context.Context, user *storage.User) error {
+	if user.Phone == "" {
+		return errors.New("no phone number to verify")
+	}
+	code, err := otpCode()
+	if err != nil {
+		return err
+	}
+	_, err = v.storage.CreateVerification(ctx, user.ID, storage.VerifyPhone, user.Phone, code, phoneVerificationLife)
+	if err != nil {
+		return err
+	}
+	return v.sms.Send(ctx, user.Phone, fmt.Sprintf("Your verification code is %s, it expires in %d minutes.",
+		code, int(phoneVerificationLife.Minutes())))
+}
+
+// otpCode returns a random code of 6 digits
+func otpCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// contactRedirect shows the contact page with a message or an error
+func contactRedirect(w http.ResponseWriter, r *http.Request, message string, err error) {
+	q := url.Values{}
+	if err != nil {
+		q.Set("error", err.Error())
+	} else {
+		q.Set("message", message)
+	}
+	http.Redirect(w, r, "/account/contact?"+q.Encode(), http.StatusFound)
+}
+
+func (a *account) contactHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	user, err := a.verifier.storage.GetUserByID(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := &struct {
+		Email         string
+		EmailVerified bool
+		Phone         string
+		PhoneVerified bool
+		Message       string
+		Error         string
+	}{
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Phone:         user.Phone,
+		PhoneVerified: user.PhoneVerified,
+		Message:       r.URL.Query().Get("message"),
+		Error:         r.URL.Query().Get("error"),
+	}
+	err = templates.ExecuteTemplate(w, "contact", data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// changeContactHandler stores a new email address or phone number and starts its verification
+func (a *account) changeContactHandler(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID, err := a.userID(r)
+		if err != nil {
+			a.signIn(w, r)
+			return
+		}
+		value := strings.TrimSpace(r.FormValue(kind))
+		if kind == storage.VerifyEmail {
+			err = a.verifier.storage.SetUserEmail(ctx, userID, value)
+		} else {
+			err = a.verifier.storage.SetUserPhone(ctx, userID, value)
+		}
+		if err != nil {
+			contactRedirect(w, r, "", err)
+			return
+		}
+		if value == "" {
+			contactRedirect(w, r, kind+" removed", nil)
+			return
+		}
+		a.startVerification(w, r, userID, kind)
+	}
+}
+
+// sendVerificationHandler verifies the current address on demand
+func (a *account) sendVerificationHandler(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := a.userID(r)
+		if err != nil {
+			a.signIn(w, r)
+			return
+		}
+		a.startVerification(w, r, userID, kind)
+	}
+}
+
+func (a *account) startVerification(w http.ResponseWriter, r *http.Request, userID uuid.UUID, kind string) {
+	ctx := r.Context()
+	user, err := a.verifier.storage.GetUserByID(ctx, userID)
+	if err != nil {
+		contactRedirect(w, r, "", err)
+		return
+	}
+	if kind == storage.VerifyEmail {
+		if user.EmailVerified {
+			contactRedirect(w, r, "email address is verified", nil)
+			return
+		}
+		err = a.verifier.StartEmail(ctx, user)
+		contactRedirect(w, r, "we sent a link to "+user.Email, err)
+		return
+	}
+	if user.PhoneVerified {
+		contactRedirect(w, r, "phone number is verified", nil)
+		return
+	}
+	err = a.verifier.StartPhone(ctx, user)
+	contactRedirect(w, r, "we sent a code to "+user.Phone, err)
+}
+
+func (a *account) confirmPhoneHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := a.userID(r)
+	if err != nil {
+		a.signIn(w, r)
+		return
+	}
+	err = a.verifier.storage.ConfirmPhoneVerification(r.Context(), userID, strings.TrimSpace(r.FormValue("code")))
+	contactRedirect(w, r, "phone number verified", err)
+}
+
+// confirmEmailHandler is the link of the verification mail, it works without a session
+// because the mail may be opened in another browser
+func (a *account) confirmEmailHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.verifier.links.Verify(r.URL.Query().Get("token"))
+	if err == nil {
+		err = a.verifier.storage.ConfirmEmailVerification(r.Context(), id)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte("email address verified"))
+}
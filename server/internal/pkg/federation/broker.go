@@ -0,0 +1,139 @@
+// Package federation signs users in with upstream OIDC providers
+package federation
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/client/rp"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// AMR of logins brokered by an upstream provider
+const AMRFederated = "fed"
+
+// Provider is an upstream identity provider of a namespace
+type Provider struct {
+	ID           uuid.UUID
+	NamespaceID  uuid.UUID
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// ClaimMapping is parsed by ParseMapping
+	ClaimMapping string
+	// JITProvisioning creates local users for unknown identities
+	JITProvisioning bool
+	// LinkByEmail links unknown identities to the local user with the same verified email
+	LinkByEmail bool
+	Enabled     bool
+}
+
+// Callback gets the claims of the upstream identity after the code exchange,
+// state is the one given to AuthURLHandler
+type Callback func(w http.ResponseWriter, r *http.Request, state string, claims map[string]any)
+
+// Broker runs the authorization code flow with upstream providers
+type Broker struct {
+	// RedirectURI returns the redirect uri registered at the provider
+	RedirectURI func(p *Provider) string
+	cookies     *httphelper.CookieHandler
+	httpClient  *http.Client
+
+	lock    sync.Mutex
+	parties map[uuid.UUID]*party
+}
+
+type party struct {
+	rp.RelyingParty
+	// the provider the relying party was created with, a changed provider needs a new one
+	provider Provider
+}
+
+func NewBroker(redirectURI func(p *Provider) string, cookies *httphelper.CookieHandler, httpClient *http.Client) *Broker {
+	if httpClient == nil {
+		httpClient = httphelper.DefaultHTTPClient
+	}
+	return &Broker{
+		RedirectURI: redirectURI,
+		cookies:     cookies,
+		httpClient:  httpClient,
+		parties:     map[uuid.UUID]*party{},
+	}
+}
+
+// relyingParty runs the discovery of the provider once
+func (b *Broker) relyingParty(ctx context.Context, p *Provider) (rp.RelyingParty, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if cached, ok := b.parties[p.ID]; ok && sameProvider(&cached.provider, p) {
+		return cached.RelyingParty, nil
+	}
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, oidc.ScopeProfile, oidc.ScopeEmail}
+	}
+	relyingParty, err := rp.NewRelyingPartyOIDC(ctx, p.Issuer, p.ClientID, p.ClientSecret, b.RedirectURI(p), scopes,
+		rp.WithPKCE(b.cookies),
+		rp.WithHTTPClient(b.httpClient),
+	)
+	if err != nil {
+		return nil, err
+	}
+	b.parties[p.ID] = &party{RelyingParty: relyingParty, provider: *p}
+	return relyingParty, nil
+}
+
+func sameProvider(a, b *Provider) bool {
+	return a.Issuer == b.Issuer &&
+		a.ClientID == b.ClientID &&
+		a.ClientSecret == b.ClientSecret &&
+		strings.Join(a.Scopes, " ") == strings.Join(b.Scopes, " ")
+}
+
+// AuthURLHandler redirects the browser to the provider
+func (b *Broker) AuthURLHandler(p *Provider, state string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		relyingParty, err := b.relyingParty(r.Context(), p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		rp.AuthURLHandler(func() string { return state }, relyingParty)(w, r)
+	}
+}
+
+// CallbackHandler exchanges the code of the provider and passes the claims
+// of the id token and the userinfo to the callback
+func (b *Broker) CallbackHandler(p *Provider, callback Callback) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		relyingParty, err := b.relyingParty(r.Context(), p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		exchanged := func(w http.ResponseWriter, r *http.Request, tokens *oidc.Tokens[*oidc.IDTokenClaims], state string, relyingParty rp.RelyingParty) {
+			claims := map[string]any{}
+			for k, v := range tokens.IDTokenClaims.Claims {
+				claims[k] = v
+			}
+			if relyingParty.UserinfoEndpoint() != "" {
+				info, err := rp.Userinfo[*oidc.UserInfo](r.Context(), tokens.AccessToken, tokens.TokenType, tokens.IDTokenClaims.GetSubject(), relyingParty)
+				if err != nil {
+					http.Error(w, "userinfo of "+p.Name+": "+err.Error(), http.StatusBadGateway)
+					return
+				}
+				for k, v := range info.Claims {
+					claims[k] = v
+				}
+			}
+			callback(w, r, state, claims)
+		}
+		rp.CodeExchangeHandler(exchanged, relyingParty)(w, r)
+	}
+}
@@ -0,0 +1,339 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+	"github.com/google/uuid"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+)
+
+// mockIdP is a minimal OIDC provider: its authorization endpoint redirects
+// back at once with a code for the configured claims
+type mockIdP struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	claims   map[string]any
+	// userinfo is served by the userinfo endpoint
+	userinfo map[string]any
+}
+
+func newMockIdP(t *testing.T, clientID string) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIdP{key: key, clientID: clientID}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/keys", m.keys)
+	mux.HandleFunc("/userinfo", m.info)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (m *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := m.server.URL
+	writeJSON(w, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/keys",
+		"userinfo_endpoint":                     issuer + "/userinfo",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != m.clientID || q.Get("code_challenge") == "" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	redirect := q.Get("redirect_uri") + "?" + url.Values{
+		"code":  {"mock-code"},
+		"state": {q.Get("state")},
+	}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (m *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("code") != "mock-code" || r.FormValue("code_verifier") == "" {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	claims := map[string]any{
+		"iss": m.server.URL,
+		"aud": m.clientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	payload, _ := json.Marshal(claims)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: m.key},
+		(&jose.SignerOptions{}).WithHeader("kid", "mock"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idToken, _ := signed.CompactSerialize()
+	writeJSON(w, map[string]any{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (m *mockIdP) keys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       &m.key.PublicKey,
+		KeyID:     "mock",
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}})
+}
+
+func (m *mockIdP) info(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer mock-access-token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	info := map[string]any{"sub": m.claims["sub"]}
+	for k, v := range m.userinfo {
+		info[k] = v
+	}
+	writeJSON(w, info)
+}
+
+// memoryStore implements Store for the tests
+type memoryStore struct {
+	identities map[string]uuid.UUID
+	emails     map[string]uuid.UUID
+	usernames  map[string]bool
+	created    []*Profile
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		identities: map[string]uuid.UUID{},
+		emails:     map[string]uuid.UUID{},
+		usernames:  map[string]bool{},
+	}
+}
+
+func (s *memoryStore) IdentityUser(ctx context.Context, providerID uuid.UUID, subject string) (uuid.UUID, error) {
+	id, ok := s.identities[providerID.String()+subject]
+	if !ok {
+		return uuid.Nil, ErrNotFound
+	}
+	return id, nil
+}
+
+func (s *memoryStore) UserByVerifiedEmail(ctx context.Context, namespace uuid.UUID, email string) (uuid.UUID, error) {
+	id, ok := s.emails[email]
+	if !ok {
+		return uuid.Nil, ErrNotFound
+	}
+	return id, nil
+}
+
+func (s *memoryStore) CreateFederatedUser(ctx context.Context, namespace uuid.UUID, profile *Profile) (uuid.UUID, error) {
+	if s.usernames[profile.Username] {
+		return uuid.Nil, ErrUsernameTaken
+	}
+	s.usernames[profile.Username] = true
+	p := *profile
+	s.created = append(s.created, &p)
+	return uuid.New(), nil
+}
+
+func (s *memoryStore) LinkIdentity(ctx context.Context, providerID uuid.UUID, subject string, userID uuid.UUID, email string) error {
+	s.identities[providerID.String()+subject] = userID
+	return nil
+}
+
+// login runs the brokered flow against the mock provider like a browser
+// and returns the claims passed to the callback
+func login(t *testing.T, idp *mockIdP, p *Provider) (string, map[string]any) {
+	t.Helper()
+	key := make([]byte, 32)
+	cookies := httphelper.NewCookieHandler(key, key, httphelper.WithUnsecure())
+	broker := NewBroker(func(p *Provider) string {
+		return "http://xoidc.test/login/federated/" + p.ID.String() + "/callback"
+	}, cookies, idp.server.Client())
+
+	// start at the broker, which redirects to the provider
+	start := httptest.NewRecorder()
+	broker.AuthURLHandler(p, "auth-request-1")(start, httptest.NewRequest(http.MethodGet, "/start", nil))
+	if start.Code != http.StatusFound {
+		t.Fatalf("start: %d %s", start.Code, start.Body)
+	}
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := noRedirect.Get(start.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	callbackURL, err := url.Parse(res.Header.Get("Location"))
+	if err != nil || callbackURL.Query().Get("code") == "" {
+		t.Fatalf("provider did not redirect back: %s", res.Header.Get("Location"))
+	}
+
+	// come back with the cookies of the start
+	req := httptest.NewRequest(http.MethodGet, callbackURL.String(), nil)
+	for _, c := range start.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	var (
+		state  string
+		claims map[string]any
+	)
+	callback := httptest.NewRecorder()
+	broker.CallbackHandler(p, func(w http.ResponseWriter, r *http.Request, s string, c map[string]any) {
+		state, claims = s, c
+	})(callback, req)
+	if claims == nil {
+		t.Fatalf("callback: %d %s", callback.Code, callback.Body)
+	}
+	return state, claims
+}
+
+func testProvider(idp *mockIdP) *Provider {
+	return &Provider{
+		ID:           uuid.New(),
+		NamespaceID:  uuid.New(),
+		Name:         "mock",
+		Issuer:       idp.server.URL,
+		ClientID:     idp.clientID,
+		ClientSecret: "secret",
+		Enabled:      true,
+	}
+}
+
+func TestBrokerClaims(t *testing.T) {
+	idp := newMockIdP(t, "xoidc")
+	idp.claims = map[string]any{"sub": "u-1", "email": "ann@corp.example", "email_verified": true}
+	idp.userinfo = map[string]any{"given_name": "Ann", "upn": "ann@corp"}
+
+	state, claims := login(t, idp, testProvider(idp))
+	if state != "auth-request-1" {
+		t.Errorf("state = %q", state)
+	}
+	if claims["sub"] != "u-1" || claims["email"] != "ann@corp.example" || claims["given_name"] != "Ann" {
+		t.Errorf("claims = %v", claims)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx := context.Background()
+	idp := newMockIdP(t, "xoidc")
+
+	t.Run("jit provisioning with claim mapping", func(t *testing.T) {
+		idp.claims = map[string]any{"sub": "u-2", "email": "bob@corp.example", "email_verified": true}
+		idp.userinfo = map[string]any{"upn": "bob", "givenName": "Bob"}
+		p := testProvider(idp)
+		p.JITProvisioning = true
+		p.ClaimMapping = `{"username": "{{.upn}}", "email": "{{.email}}", "email_verified": "{{.email_verified}}", "given_name": "{{.givenName}}"}`
+		store := newMemoryStore()
+		store.usernames["bob"] = true
+
+		_, claims := login(t, idp, p)
+		userID, err := Resolve(ctx, store, p, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(store.created) != 1 {
+			t.Fatalf("created %d users", len(store.created))
+		}
+		created := store.created[0]
+		if created.Username == "bob" || created.GivenName != "Bob" || !created.EmailVerified {
+			t.Errorf("created %+v", created)
+		}
+		// the next login finds the linked identity
+		again, err := Resolve(ctx, store, p, claims)
+		if err != nil || again != userID || len(store.created) != 1 {
+			t.Errorf("second login: %v %v, created %d", again, err, len(store.created))
+		}
+	})
+
+	t.Run("link by verified email", func(t *testing.T) {
+		idp.claims = map[string]any{"sub": "u-3", "email": "cid@corp.example", "email_verified": true}
+		idp.userinfo = nil
+		p := testProvider(idp)
+		p.LinkByEmail = true
+		store := newMemoryStore()
+		local := uuid.New()
+		store.emails["cid@corp.example"] = local
+
+		_, claims := login(t, idp, p)
+		userID, err := Resolve(ctx, store, p, claims)
+		if err != nil || userID != local {
+			t.Fatalf("resolved %v %v", userID, err)
+		}
+		if store.identities[p.ID.String()+"u-3"] != local {
+			t.Error("identity not linked")
+		}
+	})
+
+	t.Run("unverified email is not linked", func(t *testing.T) {
+		idp.claims = map[string]any{"sub": "u-4", "email": "cid@corp.example", "email_verified": false}
+		p := testProvider(idp)
+		p.LinkByEmail = true
+		store := newMemoryStore()
+		store.emails["cid@corp.example"] = uuid.New()
+
+		_, claims := login(t, idp, p)
+		_, err := Resolve(ctx, store, p, claims)
+		if err != ErrNoAccount {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestParseMapping(t *testing.T) {
+	_, err := ParseMapping(`{"password": "{{.sub}}"}`)
+	if err == nil {
+		t.Error("unknown field accepted")
+	}
+	m, err := ParseMapping("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := m.Map(map[string]any{"sub": "s-1", "email": "a@b.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Username != "a@b.example" || profile.Email != "a@b.example" || profile.GivenName != "" || profile.EmailVerified {
+		t.Errorf("profile = %+v", profile)
+	}
+}
@@ -0,0 +1,105 @@
+package federation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// profile fields a claim mapping can set
+var mappingFields = []string{
+	"username",
+	"email",
+	"email_verified",
+	"given_name",
+	"family_name",
+	"nickname",
+	"phone_number",
+}
+
+// DefaultMapping maps the standard claims of OIDC providers
+const DefaultMapping = `{
+	"username": "{{or .preferred_username .email .sub}}",
+	"email": "{{.email}}",
+	"email_verified": "{{.email_verified}}",
+	"given_name": "{{.given_name}}",
+	"family_name": "{{.family_name}}",
+	"nickname": "{{.nickname}}",
+	"phone_number": "{{.phone_number}}"
+}`
+
+// Profile is the local user an upstream identity maps to
+type Profile struct {
+	Username      string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Nickname      string
+	Phone         string
+}
+
+// Mapping turns the claims of an upstream provider into a Profile.
+// It is a JSON object of profile fields to text/template strings executed
+// with the claims, e.g. {"username": "{{.upn}}", "email": "{{.mail}}"}.
+type Mapping struct {
+	templates map[string]*template.Template
+}
+
+// ParseMapping parses a mapping, an empty mapping is the DefaultMapping
+func ParseMapping(s string) (*Mapping, error) {
+	if strings.TrimSpace(s) == "" {
+		s = DefaultMapping
+	}
+	var fields map[string]string
+	err := json.Unmarshal([]byte(s), &fields)
+	if err != nil {
+		return nil, fmt.Errorf("claim mapping: %w", err)
+	}
+	m := &Mapping{templates: map[string]*template.Template{}}
+	for field, text := range fields {
+		if !knownField(field) {
+			return nil, fmt.Errorf("claim mapping: unknown field %q", field)
+		}
+		t, err := template.New(field).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("claim mapping of %s: %w", field, err)
+		}
+		m.templates[field] = t
+	}
+	return m, nil
+}
+
+func knownField(field string) bool {
+	for _, f := range mappingFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Map executes the mapping with the claims of the upstream identity
+func (m *Mapping) Map(claims map[string]any) (*Profile, error) {
+	values := map[string]string{}
+	for field, t := range m.templates {
+		var buf bytes.Buffer
+		err := t.Execute(&buf, claims)
+		if err != nil {
+			return nil, fmt.Errorf("claim mapping of %s: %w", field, err)
+		}
+		// missing claims print as <no value>
+		values[field] = strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", ""))
+	}
+	return &Profile{
+		Username:      values["username"],
+		Email:         values["email"],
+		EmailVerified: values["email_verified"] == "true",
+		GivenName:     values["given_name"],
+		FamilyName:    values["family_name"],
+		Nickname:      values["nickname"],
+		Phone:         values["phone_number"],
+	}, nil
+}
@@ -0,0 +1,94 @@
+package federation
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrNotFound is returned by a Store without a matching identity or user
+	ErrNotFound = errors.New("not found")
+	// ErrUsernameTaken is returned by a Store if the username of a new user exists
+	ErrUsernameTaken = errors.New("username taken")
+	// ErrNoAccount rejects unknown identities of providers without JIT provisioning
+	ErrNoAccount = errors.New("no account is linked to this identity")
+)
+
+// Store keeps the identities linked to local users
+type Store interface {
+	// IdentityUser returns the user linked to the identity, or ErrNotFound
+	IdentityUser(ctx context.Context, providerID uuid.UUID, subject string) (uuid.UUID, error)
+	// UserByVerifiedEmail returns the user of the namespace with the verified email, or ErrNotFound
+	UserByVerifiedEmail(ctx context.Context, namespace uuid.UUID, email string) (uuid.UUID, error)
+	// CreateFederatedUser creates a user without password, or returns ErrUsernameTaken
+	CreateFederatedUser(ctx context.Context, namespace uuid.UUID, profile *Profile) (uuid.UUID, error)
+	LinkIdentity(ctx context.Context, providerID uuid.UUID, subject string, userID uuid.UUID, email string) error
+}
+
+// a taken username of a new user gets a random suffix, this often
+const usernameAttempts = 3
+
+// Resolve finds the local user of an upstream identity:
+// the linked user, else the user with the same verified email if the provider
+// links by email, else a new user if the provider provisions just in time
+func Resolve(ctx context.Context, store Store, p *Provider, claims map[string]any) (uuid.UUID, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return uuid.Nil, errors.New("the provider returned no subject")
+	}
+	userID, err := store.IdentityUser(ctx, p.ID, subject)
+	if err == nil {
+		return userID, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return uuid.Nil, err
+	}
+
+	mapping, err := ParseMapping(p.ClaimMapping)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	profile, err := mapping.Map(claims)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// only addresses both sides verified prove the same owner
+	if p.LinkByEmail && profile.Email != "" && profile.EmailVerified {
+		userID, err = store.UserByVerifiedEmail(ctx, p.NamespaceID, profile.Email)
+		if err == nil {
+			return userID, store.LinkIdentity(ctx, p.ID, subject, userID, profile.Email)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return uuid.Nil, err
+		}
+	}
+
+	if !p.JITProvisioning {
+		return uuid.Nil, ErrNoAccount
+	}
+	if profile.Username == "" {
+		profile.Username = subject
+	}
+	username := profile.Username
+	for i := 0; ; i++ {
+		userID, err = store.CreateFederatedUser(ctx, p.NamespaceID, profile)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrUsernameTaken) || i+1 == usernameAttempts {
+			return uuid.Nil, err
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return uuid.Nil, err
+		}
+		profile.Username = fmt.Sprintf("%s-%04d", username, n.Int64())
+	}
+	return userID, store.LinkIdentity(ctx, p.ID, subject, userID, profile.Email)
+}
@@ -0,0 +1,368 @@
+// Package jsonschema validates JSON values with the subset of JSON Schema
+// (draft 2020-12) operators write for the types of authorization details:
+// types, objects, arrays, enums, bounds, patterns and the combinators.
+// References and the other keywords are rejected, so a schema never allows
+// values its author meant to forbid. Formats are annotations only.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled schema, the boolean schemas true and false have only Bool set
+type Schema struct {
+	Bool *bool
+
+	Type                 []string
+	Enum                 []any
+	Const                *any
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *Schema
+	Items                *Schema
+	MinItems             *int
+	MaxItems             *int
+	MinLength            *int
+	MaxLength            *int
+	Minimum              *float64
+	Maximum              *float64
+	Pattern              *regexp.Regexp
+	AllOf                []*Schema
+	AnyOf                []*Schema
+	OneOf                []*Schema
+	Not                  *Schema
+}
+
+var types = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+// annotations are the keywords which do not constrain values
+var annotations = []string{
+	"$schema", "$id", "$comment", "$defs", "title", "description", "default", "examples",
+	"deprecated", "readOnly", "writeOnly", "format", "contentEncoding", "contentMediaType",
+}
+
+// Compile parses a schema
+func Compile(data []byte) (*Schema, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return compile(v, "")
+}
+
+func compile(v any, path string) (*Schema, error) {
+	if b, ok := v.(bool); ok {
+		return &Schema{Bool: &b}, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: a schema is an object or a boolean", at(path))
+	}
+	s := &Schema{}
+	for key, value := range m {
+		p := path + "/" + key
+		var err error
+		switch key {
+		case "$ref", "$dynamicRef":
+			return nil, fmt.Errorf("%s: references are not supported", at(p))
+		case "type":
+			s.Type, err = stringList(value, p)
+			for _, t := range s.Type {
+				if !contains(types, t) {
+					return nil, fmt.Errorf("%s: unknown type %q", at(p), t)
+				}
+			}
+		case "enum":
+			list, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be an array", at(p))
+			}
+			s.Enum = list
+		case "const":
+			s.Const = &value
+		case "properties":
+			props, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be an object", at(p))
+			}
+			s.Properties = map[string]*Schema{}
+			for name, prop := range props {
+				s.Properties[name], err = compile(prop, p+"/"+name)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			s.Required, err = stringList(value, p)
+		case "additionalProperties":
+			s.AdditionalProperties, err = compile(value, p)
+		case "items":
+			s.Items, err = compile(value, p)
+		case "minItems":
+			s.MinItems, err = count(value, p)
+		case "maxItems":
+			s.MaxItems, err = count(value, p)
+		case "minLength":
+			s.MinLength, err = count(value, p)
+		case "maxLength":
+			s.MaxLength, err = count(value, p)
+		case "minimum":
+			s.Minimum, err = number(value, p)
+		case "maximum":
+			s.Maximum, err = number(value, p)
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a string", at(p))
+			}
+			s.Pattern, err = regexp.Compile(pattern)
+		case "allOf":
+			s.AllOf, err = compileList(value, p)
+		case "anyOf":
+			s.AnyOf, err = compileList(value, p)
+		case "oneOf":
+			s.OneOf, err = compileList(value, p)
+		case "not":
+			s.Not, err = compile(value, p)
+		default:
+			if !contains(annotations, key) {
+				return nil, fmt.Errorf("%s: the keyword is not supported", at(p))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func compileList(v any, path string) ([]*Schema, error) {
+	list, ok := v.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("%s: must be a non-empty array", at(path))
+	}
+	schemas := make([]*Schema, len(list))
+	for i, item := range list {
+		s, err := compile(item, fmt.Sprintf("%s/%d", path, i))
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = s
+	}
+	return schemas, nil
+}
+
+func stringList(v any, path string) ([]string, error) {
+	if s, ok := v.(string); ok {
+		return []string{s}, nil
+	}
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a string or an array of strings", at(path))
+	}
+	var strs []string
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: must be a string or an array of strings", at(path))
+		}
+		strs = append(strs, s)
+	}
+	return strs, nil
+}
+
+func count(v any, path string) (*int, error) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%s: must be a non-negative integer", at(path))
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func number(v any, path string) (*float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a number", at(path))
+	}
+	return &f, nil
+}
+
+// Validate checks a value decoded by encoding/json against the schema
+func (s *Schema) Validate(v any) error {
+	return s.validate(v, "")
+}
+
+func (s *Schema) validate(v any, path string) error {
+	if s.Bool != nil {
+		if !*s.Bool {
+			return fmt.Errorf("%s: not allowed", at(path))
+		}
+		return nil
+	}
+	if len(s.Type) > 0 && !hasType(v, s.Type) {
+		return fmt.Errorf("%s: must be of type %s", at(path), strings.Join(s.Type, " or "))
+	}
+	if s.Enum != nil && !containsValue(s.Enum, v) {
+		return fmt.Errorf("%s: must be one of the enum values", at(path))
+	}
+	if s.Const != nil && !reflect.DeepEqual(*s.Const, v) {
+		return fmt.Errorf("%s: must be %v", at(path), *s.Const)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if err := s.validateObject(v, path); err != nil {
+			return err
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: must have at least %d items", at(path), *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s: must have at most %d items", at(path), *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fmt.Errorf("%s: must have at least %d characters", at(path), *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fmt.Errorf("%s: must have at most %d characters", at(path), *s.MaxLength)
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(v) {
+			return fmt.Errorf("%s: must match %s", at(path), s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s: must be at least %v", at(path), *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Errorf("%s: must be at most %v", at(path), *s.Maximum)
+		}
+	}
+	for _, sub := range s.AllOf {
+		if err := sub.validate(v, path); err != nil {
+			return err
+		}
+	}
+	if s.AnyOf != nil && matching(s.AnyOf, v, path) == 0 {
+		return fmt.Errorf("%s: must match a schema of anyOf", at(path))
+	}
+	if s.OneOf != nil && matching(s.OneOf, v, path) != 1 {
+		return fmt.Errorf("%s: must match exactly one schema of oneOf", at(path))
+	}
+	if s.Not != nil && s.Not.validate(v, path) == nil {
+		return fmt.Errorf("%s: must not match the schema of not", at(path))
+	}
+	return nil
+}
+
+func (s *Schema) validateObject(v map[string]any, path string) error {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			return fmt.Errorf("%s: %s is required", at(path), name)
+		}
+	}
+	// sorted, the first error is the same every time
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			prop = s.AdditionalProperties
+		}
+		if prop == nil {
+			continue
+		}
+		if err := prop.validate(v[name], path+"/"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matching counts the schemas the value matches
+func matching(schemas []*Schema, v any, path string) int {
+	n := 0
+	for _, s := range schemas {
+		if s.validate(v, path) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func hasType(v any, types []string) bool {
+	for _, t := range types {
+		switch v := v.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case float64:
+			if t == "number" || t == "integer" && v == math.Trunc(v) {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// at names the location of an error, the root is the empty path
+func at(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,95 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	s, err := Compile([]byte(`{
+		"type": "object",
+		"required": ["type", "instructedAmount"],
+		"properties": {
+			"type": {"const": "payment_initiation"},
+			"actions": {"type": "array", "items": {"enum": ["initiate", "status", "cancel"]}, "minItems": 1},
+			"instructedAmount": {
+				"type": "object",
+				"required": ["currency", "amount"],
+				"properties": {
+					"currency": {"type": "string", "pattern": "^[A-Z]{3}$"},
+					"amount": {"type": ["string", "number"], "oneOf": [{"type": "string", "maxLength": 20}, {"type": "number", "minimum": 0}]}
+				},
+				"additionalProperties": false
+			},
+			"creditorName": {"type": "string", "minLength": 1},
+			"count": {"type": "integer", "maximum": 10}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for doc, valid := range map[string]bool{
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "123.50"}}`:                       true,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": 12}, "actions": ["initiate"]}`:    true,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": 12}, "locations": ["https://x"]}`: true,
+		`{"type": "account_information", "instructedAmount": {"currency": "EUR", "amount": "1"}}`:                           false,
+		`{"type": "payment_initiation"}`: false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "euro", "amount": "1"}}`:                       false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": -1}}`:                         false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "1", "fee": 1}}`:              false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "1"}, "actions": []}`:         false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "1"}, "actions": ["delete"]}`: false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "1"}, "creditorName": ""}`:    false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "1"}, "count": 1.5}`:          false,
+		`{"type": "payment_initiation", "instructedAmount": {"currency": "EUR", "amount": "1"}, "count": 11}`:           false,
+	} {
+		var v any
+		if err := json.Unmarshal([]byte(doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Validate(v); (err == nil) != valid {
+			t.Errorf("%s: valid %v, got %v", doc, valid, err)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	for _, invalid := range []string{
+		`[]`,
+		`{"type": "date"}`,
+		`{"$ref": "#/$defs/amount"}`,
+		`{"properties": {"a": 1}}`,
+		`{"minLength": -1}`,
+		`{"pattern": "("}`,
+		`{"anyOf": []}`,
+		`{"exclusiveMinimum": 0}`,
+		`{"exclusiveMaximum": 10}`,
+		`{"multipleOf": 2}`,
+		`{"uniqueItems": true}`,
+		`{"patternProperties": {"^x-": false}}`,
+		`{"minProperties": 1}`,
+		`{"maxProperties": 1}`,
+		`{"propertyNames": {"pattern": "^[a-z]+$"}}`,
+		`{"prefixItems": [{"type": "string"}]}`,
+		`{"contains": {"const": 1}}`,
+		`{"dependentRequired": {"a": ["b"]}}`,
+		`{"if": {"type": "string"}, "then": {"minLength": 1}, "else": false}`,
+		`{"properties": {"amount": {"type": "number", "exclusiveMinimum": 0}}}`,
+		`{"typo": 1}`,
+	} {
+		if _, err := Compile([]byte(invalid)); err == nil {
+			t.Errorf("%s compiled", invalid)
+		}
+	}
+	_, err := Compile([]byte(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "amount", "description": "an amount", "format": "decimal", "examples": ["1.00"]}`))
+	if err != nil {
+		t.Errorf("annotations: %v", err)
+	}
+	s, err := Compile([]byte(`{"not": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Validate("anything") == nil {
+		t.Error("not true accepted a value")
+	}
+}
@@ -0,0 +1,55 @@
+package m
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// APIResource is a backend API clients request tokens for with the resource parameter
+type APIResource struct {
+	ID          uuid.UUID `json:"id"`
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	// Identifier is the absolute uri of the api, the aud of its tokens
+	Identifier string   `json:"identifier"`
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`
+	// AccessTokenType is the preferred format of its access tokens, 0 bearer, 1 jwt
+	AccessTokenType int       `json:"access_token_type"`
+	CreateTime      time.Time `json:"create_time"`
+}
+
+type APIResourceResponse struct {
+	Response
+	APIResource APIResource `json:"api_resource"`
+}
+
+type APIResourceListResponse struct {
+	Response
+	APIResources []APIResource `json:"api_resources"`
+}
+
+func APIResourceDB2View(r *storage.APIResource) APIResource {
+	return APIResource{
+		ID:              r.ID,
+		NamespaceID:     r.NamespaceID,
+		Identifier:      r.Identifier,
+		Name:            r.Name,
+		Scopes:          nonNil(r.Scopes),
+		AccessTokenType: int(r.AccessTokenType),
+		CreateTime:      r.CreateTime,
+	}
+}
+
+func (r *APIResource) View2DB() *storage.APIResource {
+	return &storage.APIResource{
+		ID:              r.ID,
+		NamespaceID:     r.NamespaceID,
+		Identifier:      r.Identifier,
+		Name:            r.Name,
+		Scopes:          r.Scopes,
+		AccessTokenType: op.AccessTokenType(r.AccessTokenType),
+	}
+}
@@ -0,0 +1,52 @@
+package m
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// AuthorizationDetailsType is a type of the authorization_details parameter clients may request
+type AuthorizationDetailsType struct {
+	ID          uuid.UUID `json:"id"`
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Type        string    `json:"type"`
+	// Description is shown to the users on the consent screen
+	Description string `json:"description"`
+	// Schema is the JSON schema the entries of the type must match
+	Schema     json.RawMessage `json:"schema"`
+	CreateTime time.Time       `json:"create_time"`
+}
+
+type AuthorizationDetailsTypeResponse struct {
+	Response
+	AuthorizationDetailsType AuthorizationDetailsType `json:"authorization_details_type"`
+}
+
+type AuthorizationDetailsTypeListResponse struct {
+	Response
+	AuthorizationDetailsTypes []AuthorizationDetailsType `json:"authorization_details_types"`
+}
+
+func AuthorizationDetailsTypeDB2View(t *storage.AuthorizationDetailsType) AuthorizationDetailsType {
+	return AuthorizationDetailsType{
+		ID:          t.ID,
+		NamespaceID: t.NamespaceID,
+		Type:        t.Type,
+		Description: t.Description,
+		Schema:      t.Schema,
+		CreateTime:  t.CreateTime,
+	}
+}
+
+func (t *AuthorizationDetailsType) View2DB() *storage.AuthorizationDetailsType {
+	return &storage.AuthorizationDetailsType{
+		ID:          t.ID,
+		NamespaceID: t.NamespaceID,
+		Type:        t.Type,
+		Description: t.Description,
+		Schema:      t.Schema,
+	}
+}
@@ -0,0 +1,85 @@
+package m
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/clientreg"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// ClientRegistrationPolicy controls the dynamic registration of clients in a namespace
+type ClientRegistrationPolicy struct {
+	Enabled                   bool     `json:"enabled"`
+	RequireInitialAccessToken bool     `json:"require_initial_access_token"`
+	RequireSoftwareStatement  bool     `json:"require_software_statement"`
+	SoftwareStatementJWKS     string   `json:"software_statement_jwks"`
+	GrantTypes                []string `json:"grant_types"`
+	RedirectURIGlobs          []string `json:"redirect_uri_globs"`
+}
+
+type ClientRegistrationPolicyResponse struct {
+	Response
+	Policy ClientRegistrationPolicy `json:"policy"`
+}
+
+func ClientRegistrationPolicyDB2View(p *clientreg.Policy) ClientRegistrationPolicy {
+	return ClientRegistrationPolicy{
+		Enabled:                   p.Enabled,
+		RequireInitialAccessToken: p.RequireInitialAccessToken,
+		RequireSoftwareStatement:  p.RequireSoftwareStatement,
+		SoftwareStatementJWKS:     p.SoftwareStatementJWKS,
+		GrantTypes:                nonNil(p.GrantTypes),
+		RedirectURIGlobs:          nonNil(p.RedirectURIGlobs),
+	}
+}
+
+func (p *ClientRegistrationPolicy) View2DB() *clientreg.Policy {
+	return &clientreg.Policy{
+		Enabled:                   p.Enabled,
+		RequireInitialAccessToken: p.RequireInitialAccessToken,
+		RequireSoftwareStatement:  p.RequireSoftwareStatement,
+		SoftwareStatementJWKS:     p.SoftwareStatementJWKS,
+		GrantTypes:                nonNil(p.GrantTypes),
+		RedirectURIGlobs:          nonNil(p.RedirectURIGlobs),
+	}
+}
+
+// InitialAccessToken registers clients in a namespace, Token is only set when it is created
+type InitialAccessToken struct {
+	ID          uuid.UUID `json:"id"`
+	NamespaceID uuid.UUID `json:"namespace_id"`
+	Description string    `json:"description"`
+	// ExpiresIn is the lifetime in seconds of a new token, 0 never expires
+	ExpiresIn    int64      `json:"expires_in,omitempty"`
+	Token        string     `json:"token,omitempty"`
+	ExpireTime   *time.Time `json:"expire_time,omitempty"`
+	CreateTime   time.Time  `json:"create_time"`
+	LastUsedTime *time.Time `json:"last_used_time,omitempty"`
+}
+
+func InitialAccessTokenDB2View(t *storage.InitialAccessToken) InitialAccessToken {
+	v := InitialAccessToken{
+		ID:          t.ID,
+		NamespaceID: t.NamespaceID,
+		Description: t.Description,
+		CreateTime:  t.CreateTime,
+	}
+	if !t.ExpireTime.IsZero() {
+		v.ExpireTime = &t.ExpireTime
+	}
+	if !t.LastUsedTime.IsZero() {
+		v.LastUsedTime = &t.LastUsedTime
+	}
+	return v
+}
+
+type InitialAccessTokenResponse struct {
+	Response
+	Token InitialAccessToken `json:"token"`
+}
+
+type InitialAccessTokenListResponse struct {
+	Response
+	Tokens []InitialAccessToken `json:"tokens"`
+}
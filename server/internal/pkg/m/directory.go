@@ -0,0 +1,122 @@
+package m
+
+import (
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/directory"
+)
+
+// Directory is an LDAP or Active Directory of a namespace, the bind password is write only
+type Directory struct {
+	ID                 uuid.UUID `json:"id"`
+	NamespaceID        uuid.UUID `json:"namespace_id"`
+	Name               string    `json:"name"`
+	Kind               string    `json:"kind"`
+	Priority           int       `json:"priority"`
+	URL                string    `json:"url"`
+	StartTLS           bool      `json:"start_tls"`
+	InsecureSkipVerify bool      `json:"insecure_skip_verify"`
+	RootCA             string    `json:"root_ca"`
+	BindDN             string    `json:"bind_dn"`
+	BindPassword       string    `json:"bind_password,omitempty"`
+	BaseDN             string    `json:"base_dn"`
+	UserFilter         string    `json:"user_filter"`
+	GroupBaseDN        string    `json:"group_base_dn"`
+	GroupFilter        string    `json:"group_filter"`
+	GroupNameAttribute string    `json:"group_name_attribute"`
+	AttributeMapping   string    `json:"attribute_mapping"`
+	SyncUsers          bool      `json:"sync_users"`
+	Enabled            bool      `json:"enabled"`
+}
+
+type DirectoryResponse struct {
+	Response
+	Directory Directory `json:"directory"`
+}
+
+type DirectoryListResponse struct {
+	Response
+	Directories []Directory `json:"directories"`
+}
+
+// DirectoryTest is a login to try a directory with
+type DirectoryTest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// DirectoryEntry is the user a directory returned, mapped to claims
+type DirectoryEntry struct {
+	DN          string   `json:"dn"`
+	Username    string   `json:"username"`
+	GivenName   string   `json:"given_name"`
+	FamilyName  string   `json:"family_name"`
+	Nickname    string   `json:"nickname"`
+	Email       string   `json:"email"`
+	PhoneNumber string   `json:"phone_number"`
+	Groups      []string `json:"groups"`
+}
+
+type DirectoryTestResponse struct {
+	Response
+	Entry DirectoryEntry `json:"entry"`
+}
+
+func DirectoryDB2View(d *directory.Directory) Directory {
+	return Directory{
+		ID:                 d.ID,
+		NamespaceID:        d.NamespaceID,
+		Name:               d.Name,
+		Kind:               d.Kind,
+		Priority:           d.Priority,
+		URL:                d.URL,
+		StartTLS:           d.StartTLS,
+		InsecureSkipVerify: d.InsecureSkipVerify,
+		RootCA:             d.RootCA,
+		BindDN:             d.BindDN,
+		BaseDN:             d.BaseDN,
+		UserFilter:         d.UserFilter,
+		GroupBaseDN:        d.GroupBaseDN,
+		GroupFilter:        d.GroupFilter,
+		GroupNameAttribute: d.GroupNameAttribute,
+		AttributeMapping:   d.AttributeMapping,
+		SyncUsers:          d.SyncUsers,
+		Enabled:            d.Enabled,
+	}
+}
+
+func (d *Directory) View2DB() *directory.Directory {
+	return &directory.Directory{
+		ID:                 d.ID,
+		NamespaceID:        d.NamespaceID,
+		Name:               d.Name,
+		Kind:               d.Kind,
+		Priority:           d.Priority,
+		URL:                d.URL,
+		StartTLS:           d.StartTLS,
+		InsecureSkipVerify: d.InsecureSkipVerify,
+		RootCA:             d.RootCA,
+		BindDN:             d.BindDN,
+		BindPassword:       d.BindPassword,
+		BaseDN:             d.BaseDN,
+		UserFilter:         d.UserFilter,
+		GroupBaseDN:        d.GroupBaseDN,
+		GroupFilter:        d.GroupFilter,
+		GroupNameAttribute: d.GroupNameAttribute,
+		AttributeMapping:   d.AttributeMapping,
+		SyncUsers:          d.SyncUsers,
+		Enabled:            d.Enabled,
+	}
+}
+
+func DirectoryEntryView(e *directory.Entry) DirectoryEntry {
+	return DirectoryEntry{
+		DN:          e.DN,
+		Username:    e.Username,
+		GivenName:   e.GivenName,
+		FamilyName:  e.FamilyName,
+		Nickname:    e.Nickname,
+		Email:       e.Email,
+		PhoneNumber: e.Phone,
+		Groups:      nonNil(e.Groups),
+	}
+}
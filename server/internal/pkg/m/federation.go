@@ -0,0 +1,62 @@
+package m
+
+import (
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/federation"
+)
+
+// IdentityProvider is an upstream OIDC provider, the client secret is write only
+type IdentityProvider struct {
+	ID              uuid.UUID `json:"id"`
+	NamespaceID     uuid.UUID `json:"namespace_id"`
+	Name            string    `json:"name"`
+	Issuer          string    `json:"issuer"`
+	ClientID        string    `json:"client_id"`
+	ClientSecret    string    `json:"client_secret,omitempty"`
+	Scopes          []string  `json:"scopes"`
+	ClaimMapping    string    `json:"claim_mapping"`
+	JITProvisioning bool      `json:"jit_provisioning"`
+	LinkByEmail     bool      `json:"link_by_email"`
+	Enabled         bool      `json:"enabled"`
+}
+
+type IdentityProviderResponse struct {
+	Response
+	Provider IdentityProvider `json:"provider"`
+}
+
+type IdentityProviderListResponse struct {
+	Response
+	Providers []IdentityProvider `json:"providers"`
+}
+
+func IdentityProviderDB2View(p *federation.Provider) IdentityProvider {
+	return IdentityProvider{
+		ID:              p.ID,
+		NamespaceID:     p.NamespaceID,
+		Name:            p.Name,
+		Issuer:          p.Issuer,
+		ClientID:        p.ClientID,
+		Scopes:          nonNil(p.Scopes),
+		ClaimMapping:    p.ClaimMapping,
+		JITProvisioning: p.JITProvisioning,
+		LinkByEmail:     p.LinkByEmail,
+		Enabled:         p.Enabled,
+	}
+}
+
+func (p *IdentityProvider) View2DB() *federation.Provider {
+	return &federation.Provider{
+		ID:              p.ID,
+		NamespaceID:     p.NamespaceID,
+		Name:            p.Name,
+		Issuer:          p.Issuer,
+		ClientID:        p.ClientID,
+		ClientSecret:    p.ClientSecret,
+		Scopes:          nonNil(p.Scopes),
+		ClaimMapping:    p.ClaimMapping,
+		JITProvisioning: p.JITProvisioning,
+		LinkByEmail:     p.LinkByEmail,
+		Enabled:         p.Enabled,
+	}
+}
@@ -0,0 +1,55 @@
+package m
+
+import (
+	"time"
+
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	MaxAgeDays    int  `json:"max_age_days"`
+	History       int  `json:"history"`
+	CheckBreached bool `json:"check_breached"`
+}
+
+type PasswordPolicyResponse struct {
+	Response
+	Policy PasswordPolicy `json:"policy"`
+}
+
+type SetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+const day = 24 * time.Hour
+
+func PasswordPolicyDB2View(p *password.Policy) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     p.MinLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+		MaxAgeDays:    int(p.MaxAge / day),
+		History:       p.History,
+		CheckBreached: p.CheckBreached,
+	}
+}
+
+func (p *PasswordPolicy) View2DB() *password.Policy {
+	return &password.Policy{
+		MinLength:     p.MinLength,
+		RequireUpper:  p.RequireUpper,
+		RequireLower:  p.RequireLower,
+		RequireDigit:  p.RequireDigit,
+		RequireSymbol: p.RequireSymbol,
+		MaxAge:        time.Duration(p.MaxAgeDays) * day,
+		History:       p.History,
+		CheckBreached: p.CheckBreached,
+	}
+}
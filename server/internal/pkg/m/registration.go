@@ -0,0 +1,87 @@
+package m
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+type RegistrationPolicy struct {
+	Enabled                  bool     `json:"enabled"`
+	RequiredFields           []string `json:"required_fields"`
+	EmailAllowDomains        []string `json:"email_allow_domains"`
+	EmailDenyDomains         []string `json:"email_deny_domains"`
+	RequireEmailVerification bool     `json:"require_email_verification"`
+	RequireApproval          bool     `json:"require_approval"`
+	// when require_email_verification was turned on, read only
+	EmailVerificationTime time.Time `json:"email_verification_time"`
+}
+
+type RegistrationPolicyResponse struct {
+	Response
+	Policy RegistrationPolicy `json:"policy"`
+}
+
+// PendingUser is a self registered user waiting for approval
+type PendingUser struct {
+	ID                  uuid.UUID `json:"id"`
+	Username            string    `json:"username"`
+	GivenName           string    `json:"given_name"`
+	FamilyName          string    `json:"family_name"`
+	Nickname            string    `json:"nickname"`
+	Email               string    `json:"email"`
+	EmailVerified       bool      `json:"email_verified"`
+	PhoneNumber         string    `json:"phone_number"`
+	PhoneNumberVerified bool      `json:"phone_number_verified"`
+}
+
+type PendingUserListResponse struct {
+	Response
+	Users []PendingUser `json:"users"`
+}
+
+func RegistrationPolicyDB2View(p *storage.RegistrationPolicy) RegistrationPolicy {
+	return RegistrationPolicy{
+		Enabled:                  p.Enabled,
+		RequiredFields:           nonNil(p.RequiredFields),
+		EmailAllowDomains:        nonNil(p.EmailAllowDomains),
+		EmailDenyDomains:         nonNil(p.EmailDenyDomains),
+		RequireEmailVerification: p.RequireEmailVerification,
+		RequireApproval:          p.RequireApproval,
+		EmailVerificationTime:    p.EmailVerificationTime,
+	}
+}
+
+func (p *RegistrationPolicy) View2DB() *storage.RegistrationPolicy {
+	return &storage.RegistrationPolicy{
+		Enabled:                  p.Enabled,
+		RequiredFields:           nonNil(p.RequiredFields),
+		EmailAllowDomains:        nonNil(p.EmailAllowDomains),
+		EmailDenyDomains:         nonNil(p.EmailDenyDomains),
+		RequireEmailVerification: p.RequireEmailVerification,
+		RequireApproval:          p.RequireApproval,
+	}
+}
+
+func PendingUserDB2View(u *storage.User) PendingUser {
+	return PendingUser{
+		ID:                  u.ID,
+		Username:            u.Username,
+		GivenName:           u.FirstName,
+		FamilyName:          u.LastName,
+		Nickname:            u.Nickname,
+		Email:               u.Email,
+		EmailVerified:       u.EmailVerified,
+		PhoneNumber:         u.Phone,
+		PhoneNumberVerified: u.PhoneVerified,
+	}
+}
+
+// nonNil keeps empty lists as [] in json and '{}' in the database
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
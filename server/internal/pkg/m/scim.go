@@ -0,0 +1,41 @@
+package m
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// SCIMToken is a provisioning token of a client, Token is only set when it is created
+type SCIMToken struct {
+	ID           uuid.UUID  `json:"id"`
+	ClientID     uuid.UUID  `json:"client_id"`
+	Description  string     `json:"description"`
+	Token        string     `json:"token,omitempty"`
+	CreateTime   time.Time  `json:"create_time"`
+	LastUsedTime *time.Time `json:"last_used_time,omitempty"`
+}
+
+func SCIMTokenDB2View(t *storage.SCIMToken) SCIMToken {
+	v := SCIMToken{
+		ID:          t.ID,
+		ClientID:    t.ClientID,
+		Description: t.Description,
+		CreateTime:  t.CreateTime,
+	}
+	if !t.LastUsedTime.IsZero() {
+		v.LastUsedTime = &t.LastUsedTime
+	}
+	return v
+}
+
+type SCIMTokenResponse struct {
+	Response
+	Token SCIMToken `json:"token"`
+}
+
+type SCIMTokenListResponse struct {
+	Response
+	Tokens []SCIMToken `json:"tokens"`
+}
@@ -0,0 +1,64 @@
+package m
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zltl/xoidc/server/internal/pkg/storage"
+)
+
+// TokenPolicy has lifetimes like 10m or 720h, empty ones are 0
+type TokenPolicy struct {
+	AccessTokenLifetime      string `json:"access_token_lifetime,omitempty"`
+	IDTokenLifetime          string `json:"id_token_lifetime,omitempty"`
+	RefreshTokenLifetime     string `json:"refresh_token_lifetime,omitempty"`
+	RefreshTokenIdleLifetime string `json:"refresh_token_idle_lifetime,omitempty"`
+	// RefreshTokens is enabled, disabled or offline_access
+	RefreshTokens string `json:"refresh_tokens,omitempty"`
+}
+
+type TokenPolicyResponse struct {
+	Response
+	Policy TokenPolicy `json:"policy"`
+}
+
+func duration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func TokenPolicyDB2View(p storage.TokenPolicy) TokenPolicy {
+	return TokenPolicy{
+		AccessTokenLifetime:      duration(p.AccessTokenLifetime),
+		IDTokenLifetime:          duration(p.IDTokenLifetime),
+		RefreshTokenLifetime:     duration(p.RefreshTokenLifetime),
+		RefreshTokenIdleLifetime: duration(p.RefreshTokenIdleLifetime),
+		RefreshTokens:            p.RefreshTokens,
+	}
+}
+
+func (p *TokenPolicy) View2DB() (*storage.TokenPolicy, error) {
+	r := &storage.TokenPolicy{RefreshTokens: p.RefreshTokens}
+	for _, f := range []struct {
+		name string
+		s    string
+		d    *time.Duration
+	}{
+		{"access_token_lifetime", p.AccessTokenLifetime, &r.AccessTokenLifetime},
+		{"id_token_lifetime", p.IDTokenLifetime, &r.IDTokenLifetime},
+		{"refresh_token_lifetime", p.RefreshTokenLifetime, &r.RefreshTokenLifetime},
+		{"refresh_token_idle_lifetime", p.RefreshTokenIdleLifetime, &r.RefreshTokenIdleLifetime},
+	} {
+		if f.s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.d = d
+	}
+	return r, r.Validate()
+}
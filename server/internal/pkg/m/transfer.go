@@ -0,0 +1,9 @@
+package m
+
+import "github.com/zltl/xoidc/server/internal/pkg/transfer"
+
+// ImportResponse reports every row of an import
+type ImportResponse struct {
+	Response
+	Result *transfer.Result `json:"result"`
+}
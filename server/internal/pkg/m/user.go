@@ -0,0 +1,7 @@
+package m
+
+// SetVerifiedRequest sets the verification flags of a user, omitted flags stay unchanged
+type SetVerifiedRequest struct {
+	EmailVerified       *bool `json:"email_verified"`
+	PhoneNumberVerified *bool `json:"phone_number_verified"`
+}
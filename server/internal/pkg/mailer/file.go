@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FileMailer appends messages to a file instead of sending them, or logs them
+// if Path is empty. Use it for development and tests.
+type FileMailer struct {
+	Path string
+	From string
+
+	lock sync.Mutex
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	from := m.From
+	if from == "" {
+		from = "xoidc@localhost"
+	}
+	data := format(from, msg)
+	if m.Path == "" {
+		logrus.Infof("mail to %s:\n%s", msg.To, data)
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	f, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, "\r\n\r\n"...))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
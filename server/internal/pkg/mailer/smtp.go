@@ -0,0 +1,29 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends through an SMTP server, STARTTLS is used when the server offers it
+type SMTPMailer struct {
+	// Addr is host:port of the server
+	Addr string
+	From string
+	// Username and Password authenticate with PLAIN, leave empty for servers without auth
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, format(m.From, msg))
+}
@@ -0,0 +1,97 @@
+// Package publichttp posts to the endpoints clients registered themselves,
+// e.g. the backchannel logout uris and ciba notification endpoints of dynamic
+// client registration. Anyone may register a client, so the requests must not
+// reach the loopback, private or link-local networks of the server.
+package publichttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNotPublic is the error of addresses outside of the internet
+var ErrNotPublic = errors.New("not a public address")
+
+// reserved are the ranges not covered by the methods of netip.Addr
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Public tells if ip is an address of the internet, loopback, private,
+// link-local, multicast, unspecified and reserved addresses are not
+func Public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reserved {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL checks uri is an https uri without fragment whose host is
+// not localhost or an address outside of the internet. Hosts resolving
+// to such addresses are refused when Client connects to them.
+func CheckURL(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.Fragment != "" {
+		return fmt.Errorf("%s is not an https uri without fragment", uri)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%s: %w", uri, ErrNotPublic)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !Public(ip) {
+		return fmt.Errorf("%s: %w", uri, ErrNotPublic)
+	}
+	return nil
+}
+
+// Client returns an http client which only connects to public addresses,
+// without proxy and without following redirects
+func Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// control runs after the host is resolved, so it sees the address
+// the connection goes to
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !Public(ip) {
+		return fmt.Errorf("%s: %w", host, ErrNotPublic)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package publichttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := Public(netip.MustParseAddr(tt.ip)); got != tt.public {
+			t.Errorf("Public(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		uri string
+		ok  bool
+	}{
+		{"https://rp.example.com/logout", true},
+		{"https://93.184.216.34/cb", true},
+		{"http://rp.example.com/logout", false},
+		{"https://rp.example.com/logout#now", false},
+		{"/logout", false},
+		{"https://localhost/logout", false},
+		{"https://api.localhost./logout", false},
+		{"https://127.0.0.1:8443/logout", false},
+		{"https://[::1]/logout", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://10.0.0.5/cb", false},
+	}
+	for _, tt := range tests {
+		if err := CheckURL(tt.uri); (err == nil) != tt.ok {
+			t.Errorf("CheckURL(%s): %v", tt.uri, err)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	reached := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer srv.Close()
+
+	// the test server listens on loopback
+	_, err := Client(time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrNotPublic) {
+		t.Errorf("got %v, want %v", err, ErrNotPublic)
+	}
+	if reached {
+		t.Error("the client reached a loopback address")
+	}
+}
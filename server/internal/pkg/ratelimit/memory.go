@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired counters are removed from memory
+const sweepInterval = time.Minute
+
+type counter struct {
+	count int64
+	reset time.Time
+}
+
+// MemoryStore keeps the counters in the process, use it when running a single node
+type MemoryStore struct {
+	lock      sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters:  make(map[string]*counter),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		for k, c := range m.counters {
+			if !now.Before(c.reset) {
+				delete(m.counters, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	c, ok := m.counters[key]
+	if !ok || !now.Before(c.reset) {
+		c = &counter{reset: now.Add(window)}
+		m.counters[key] = c
+	}
+	c.count++
+	return c.count, c.reset, nil
+}
@@ -0,0 +1,191 @@
+// Package ratelimit limits the requests per key (client_id, ip, username, ...)
+// in fixed time windows.
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// Store counts the requests of a key in the current window.
+// It returns the count including this request and the end of the window.
+type Store interface {
+	Incr(ctx context.Context, key string, window time.Duration) (count int64, reset time.Time, err error)
+}
+
+// StoreFunc allows to use an ordinary function as Store,
+// e.g. the postgres counters of storage.Storage.IncrRateLimit
+type StoreFunc func(ctx context.Context, key string, window time.Duration) (int64, time.Time, error)
+
+func (f StoreFunc) Incr(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	return f(ctx, key, window)
+}
+
+// KeyFunc extracts the key a rule counts by, an empty key skips the rule
+type KeyFunc func(r *http.Request) string
+
+// Rule limits the requests to one endpoint
+type Rule struct {
+	// Name prefixes the counter keys, it must be unique
+	Name   string
+	Method string
+	Path   string
+	Key    KeyFunc
+	// Limit requests are allowed per Window
+	Limit  int64
+	Window time.Duration
+	// OAuth rejects with a slow_down error response instead of plain text
+	OAuth bool
+}
+
+type Limiter struct {
+	store Store
+	rules map[string][]Rule
+}
+
+func New(store Store, rules ...Rule) *Limiter {
+	l := &Limiter{
+		store: store,
+		rules: make(map[string][]Rule),
+	}
+	for _, rule := range rules {
+		k := rule.Method + " " + rule.Path
+		l.rules[k] = append(l.rules[k], rule)
+	}
+	return l
+}
+
+// Configure changes the limits of rules by name, e.g. "token:client=600/1m,login:ip=30/1m".
+// A limit of "off" drops the rule.
+func Configure(rules []Rule, s string) ([]Rule, error) {
+	byName := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		byName[rule.Name] = i
+	}
+	off := make(map[string]bool)
+	rules = append([]Rule(nil), rules...)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, limit, ok := strings.Cut(field, "=")
+		i, known := byName[name]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid rate limit %q: unknown rule", field)
+		}
+		if limit == "off" {
+			off[name] = true
+			continue
+		}
+		count, window, ok := strings.Cut(limit, "/")
+		n, err := strconv.ParseInt(count, 10, 64)
+		if !ok || err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid rate limit %q: want <name>=<count>/<window>", field)
+		}
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: want <name>=<count>/<window>", field)
+		}
+		rules[i].Limit, rules[i].Window = n, d
+	}
+	kept := rules[:0]
+	for _, rule := range rules {
+		if !off[rule.Name] {
+			kept = append(kept, rule)
+		}
+	}
+	return kept, nil
+}
+
+// Handler rejects requests exceeding a limit with 429 and a Retry-After header
+func (l *Limiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, rule := range l.rules[r.Method+" "+r.URL.Path] {
+			key := rule.Key(r)
+			if key == "" {
+				continue
+			}
+			count, reset, err := l.store.Incr(r.Context(), rule.Name+":"+key, rule.Window)
+			if err != nil {
+				// a broken counter store must not take down the login
+				logrus.Error(err)
+				continue
+			}
+			if count > rule.Limit {
+				reject(w, rule, reset)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func reject(w http.ResponseWriter, rule Rule, reset time.Time) {
+	retry := int64(math.Ceil(time.Until(reset).Seconds()))
+	if retry < 1 {
+		retry = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retry, 10))
+	if !rule.OAuth {
+		http.Error(w, "too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+	body, err := json.Marshal(oidc.ErrSlowDown().WithDescription("too many requests"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(body)
+}
+
+// ByIP counts by the remote address
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByClientID counts by the client_id of HTTP basic auth or the form and the
+// remote address. The client is not authenticated yet, so anyone may send its
+// client_id, but only from the own address.
+func ByClientID(r *http.Request) string {
+	id := clientID(r)
+	if id == "" {
+		return ""
+	}
+	return id + "@" + ByIP(r)
+}
+
+func clientID(r *http.Request) string {
+	if id, _, ok := r.BasicAuth(); ok {
+		// basic auth credentials are form-urlencoded, RFC 6749 2.3.1
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			return unescaped
+		}
+		return id
+	}
+	return r.FormValue("client_id")
+}
+
+// ByFormValue counts by a form field, e.g. the username of the login
+func ByFormValue(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.FormValue(name)
+	}
+}
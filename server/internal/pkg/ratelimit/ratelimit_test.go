@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimiterByClientID(t *testing.T) {
+	l := New(NewMemoryStore(), Rule{
+		Name: "token", Method: http.MethodPost, Path: "/oauth/token",
+		Key: ByClientID, Limit: 2, Window: time.Minute, OAuth: true,
+	})
+	h := l.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	post := func(clientID string, remoteAddr ...string) *httptest.ResponseRecorder {
+		form := url.Values{"client_id": {clientID}}
+		r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(remoteAddr) > 0 {
+			r.RemoteAddr = remoteAddr[0]
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := post("a"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d", i, w.Code)
+		}
+	}
+	w := post("a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After")
+	}
+	if !strings.Contains(w.Body.String(), `"slow_down"`) {
+		t.Errorf("body %s has no slow_down error", w.Body.String())
+	}
+	// other clients have their own counter
+	if w := post("b"); w.Code != http.StatusOK {
+		t.Fatalf("other client: got %d", w.Code)
+	}
+	// the client_id is not authenticated, requests from other addresses
+	// do not use up the limit of the client
+	if w := post("a", "198.51.100.7:4711"); w.Code != http.StatusOK {
+		t.Fatalf("other address: got %d", w.Code)
+	}
+}
+
+func TestMemoryStoreWindow(t *testing.T) {
+	m := NewMemoryStore()
+	count, _, _ := m.Incr(context.Background(), "k", time.Millisecond)
+	if count != 1 {
+		t.Fatalf("got %d, want 1", count)
+	}
+	time.Sleep(2 * time.Millisecond)
+	count, _, _ = m.Incr(context.Background(), "k", time.Millisecond)
+	if count != 1 {
+		t.Fatalf("count after window: got %d, want 1", count)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	rules := []Rule{
+		{Name: "token", Limit: 2, Window: time.Minute},
+		{Name: "login", Limit: 5, Window: time.Minute},
+	}
+	got, err := Configure(rules, "token=10/1h, login=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "token" || got[0].Limit != 10 || got[0].Window != time.Hour {
+		t.Errorf("got %+v", got)
+	}
+	if rules[0].Limit != 2 || len(rules) != 2 {
+		t.Error("the default rules changed")
+	}
+	for _, s := range []string{"nope=1/1m", "token", "token=1", "token=0/1m", "token=1/forever", "token=1/-1m"} {
+		if _, err := Configure(rules, s); err == nil {
+			t.Errorf("%q is valid", s)
+		}
+	}
+}
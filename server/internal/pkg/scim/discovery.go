@@ -0,0 +1,173 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) handleServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	supported := func(ok bool) map[string]any { return map[string]any{"supported": ok} }
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schemas": []string{SchemaServiceProviderConfig},
+		"patch":   supported(true),
+		"bulk": map[string]any{
+			"supported":      true,
+			"maxOperations":  maxBulkOperations,
+			"maxPayloadSize": maxPayloadSize,
+		},
+		"filter": map[string]any{
+			"supported":  true,
+			"maxResults": maxCount,
+		},
+		"changePassword": supported(true),
+		"sort":           supported(false),
+		"etag":           supported(true),
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "a provisioning token of a client",
+			"primary":     true,
+		}},
+		"meta": map[string]any{
+			"resourceType": "ServiceProviderConfig",
+			"location":     s.BaseURL + "/ServiceProviderConfig",
+		},
+	})
+}
+
+func (s *Server) resourceTypes() []map[string]any {
+	var types []map[string]any
+	for _, e := range s.endpoints() {
+		types = append(types, map[string]any{
+			"schemas":     []string{SchemaResourceType},
+			"id":          e.name,
+			"name":        e.name,
+			"endpoint":    e.path,
+			"description": e.name,
+			"schema":      e.schema,
+			"meta": map[string]any{
+				"resourceType": "ResourceType",
+				"location":     s.BaseURL + "/ResourceTypes/" + e.name,
+			},
+		})
+	}
+	return types
+}
+
+// handleResourceTypes lists the resource types, or returns the one of the url
+func (s *Server) handleResourceTypes(w http.ResponseWriter, r *http.Request) {
+	s.writeDiscovery(w, chi.URLParam(r, "name"), s.resourceTypes())
+}
+
+func (s *Server) handleSchemas(w http.ResponseWriter, r *http.Request) {
+	s.writeDiscovery(w, chi.URLParam(r, "id"), s.schemas())
+}
+
+func (s *Server) writeDiscovery(w http.ResponseWriter, id string, all []map[string]any) {
+	if id == "" {
+		res := ListResponse{
+			Schemas:      []string{SchemaListResponse},
+			TotalResults: len(all),
+			ItemsPerPage: len(all),
+			StartIndex:   1,
+		}
+		for _, v := range all {
+			res.Resources = append(res.Resources, v)
+		}
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+	for _, v := range all {
+		if strings.EqualFold(v["id"].(string), id) {
+			writeJSON(w, http.StatusOK, v)
+			return
+		}
+	}
+	writeError(w, ErrNotFound)
+}
+
+// attr describes an attribute of a schema
+func attr(name, typ string, multi bool, mutability string, subs ...map[string]any) map[string]any {
+	a := map[string]any{
+		"name":        name,
+		"type":        typ,
+		"multiValued": multi,
+		"required":    name == "userName" || name == "displayName" && mutability == "readWrite",
+		"caseExact":   name == "id" || name == "externalId",
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	if name == "userName" {
+		a["uniqueness"] = "server"
+	}
+	if name == "password" {
+		a["returned"] = "never"
+	}
+	if len(subs) > 0 {
+		a["subAttributes"] = subs
+	}
+	return a
+}
+
+func multiValued(name string) map[string]any {
+	return attr(name, "complex", true, "readWrite",
+		attr("value", "string", false, "readWrite"),
+		attr("display", "string", false, "readWrite"),
+		attr("type", "string", false, "readWrite"),
+		attr("primary", "boolean", false, "readWrite"),
+	)
+}
+
+func reference(name, mutability string) map[string]any {
+	return attr(name, "complex", true, mutability,
+		attr("value", "string", false, "immutable"),
+		attr("$ref", "reference", false, "immutable"),
+		attr("display", "string", false, "readOnly"),
+		attr("type", "string", false, "immutable"),
+	)
+}
+
+func (s *Server) schemas() []map[string]any {
+	schema := func(id, name string, attrs ...map[string]any) map[string]any {
+		return map[string]any{
+			"schemas":    []string{SchemaSchema},
+			"id":         id,
+			"name":       name,
+			"attributes": attrs,
+			"meta": map[string]any{
+				"resourceType": "Schema",
+				"location":     s.BaseURL + "/Schemas/" + id,
+			},
+		}
+	}
+	return []map[string]any{
+		schema(SchemaUser, "User",
+			attr("userName", "string", false, "readWrite"),
+			attr("externalId", "string", false, "readWrite"),
+			attr("name", "complex", false, "readWrite",
+				attr("formatted", "string", false, "readWrite"),
+				attr("familyName", "string", false, "readWrite"),
+				attr("givenName", "string", false, "readWrite"),
+				attr("middleName", "string", false, "readWrite"),
+			),
+			attr("displayName", "string", false, "readOnly"),
+			attr("nickName", "string", false, "readWrite"),
+			attr("profileUrl", "reference", false, "readWrite"),
+			attr("locale", "string", false, "readWrite"),
+			attr("timezone", "string", false, "readWrite"),
+			attr("active", "boolean", false, "readWrite"),
+			attr("password", "string", false, "writeOnly"),
+			multiValued("emails"),
+			multiValued("phoneNumbers"),
+			reference("groups", "readOnly"),
+		),
+		schema(SchemaGroup, "Group",
+			attr("displayName", "string", false, "readWrite"),
+			attr("externalId", "string", false, "readWrite"),
+			reference("members", "readWrite"),
+		),
+	}
+}
@@ -0,0 +1,496 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter matches resources in their JSON form
+type Filter interface {
+	Match(resource map[string]any) bool
+}
+
+// ParseFilter parses the filter of RFC 7644 section 3.4.2.2
+func ParseFilter(s string) (Filter, error) {
+	p, err := newParser(s)
+	if err != nil {
+		return nil, err
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, badRequest("invalidFilter", "unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpen
+	tokenClose
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(s string) (*parser, error) {
+	p := &parser{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			p.tokens = append(p.tokens, token{tokenOpen, "("})
+			i++
+		case c == ')':
+			p.tokens = append(p.tokens, token{tokenClose, ")"})
+			i++
+		case c == '[':
+			p.tokens = append(p.tokens, token{tokenOpenBracket, "["})
+			i++
+		case c == ']':
+			p.tokens = append(p.tokens, token{tokenCloseBracket, "]"})
+			i++
+		case c == '"':
+			// a JSON string, with its escapes
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, badRequest("invalidFilter", "unterminated string")
+			}
+			var str string
+			err := json.Unmarshal([]byte(s[i:j+1]), &str)
+			if err != nil {
+				return nil, badRequest("invalidFilter", "invalid string %s", s[i:j+1])
+			}
+			p.tokens = append(p.tokens, token{tokenString, str})
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(s) && !strings.ContainsRune(" \t\n()[]\"", rune(s[j])); j++ {
+			}
+			p.tokens = append(p.tokens, token{tokenWord, s[i:j]})
+			i = j
+		}
+	}
+	return p, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword reports if the next token is the word, and consumes it
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokenWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if p.next().kind != kind {
+		return badRequest("invalidFilter", "expected %s", text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Filter, error) {
+	if p.keyword("not") {
+		if err := p.expect(tokenOpen, "("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenClose, ")"); err != nil {
+			return nil, err
+		}
+		return notFilter{f}, nil
+	}
+	if p.peek().kind == tokenOpen {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenClose, ")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	return p.parseAttrExp()
+}
+
+func (p *parser) parseAttrExp() (Filter, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, badRequest("invalidFilter", "expected an attribute")
+	}
+	path := splitPath(t.text)
+	if p.peek().kind == tokenOpenBracket {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		return valuePathFilter{path, f}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokenWord {
+		return nil, badRequest("invalidFilter", "expected an operator after %s", t.text)
+	}
+	operator := strings.ToLower(op.text)
+	if operator == "pr" {
+		return presentFilter{path}, nil
+	}
+	switch operator {
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, badRequest("invalidFilter", "unknown operator %s", op.text)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return compareFilter{path, operator, value}, nil
+}
+
+func (p *parser) parseValue() (any, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenWord:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, badRequest("invalidFilter", "invalid value %s", t.text)
+		}
+		return n, nil
+	}
+	return nil, badRequest("invalidFilter", "expected a value")
+}
+
+// splitPath splits an attribute path into the keys of the JSON form,
+// attributes of extension schemas are kept below the schema URN
+func splitPath(s string) []string {
+	for _, core := range []string{SchemaUser, SchemaGroup} {
+		if len(s) > len(core) && strings.EqualFold(s[:len(core)+1], core+":") {
+			return strings.Split(s[len(core)+1:], ".")
+		}
+	}
+	if strings.HasPrefix(strings.ToLower(s), "urn:") {
+		i := strings.LastIndex(s, ":")
+		return append([]string{s[:i]}, strings.Split(s[i+1:], ".")...)
+	}
+	return strings.Split(s, ".")
+}
+
+// lookupKey finds the key of a JSON object ignoring case, attribute names are case insensitive
+func lookupKey(m map[string]any, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return name, false
+}
+
+func lookup(m map[string]any, name string) any {
+	k, ok := lookupKey(m, name)
+	if !ok {
+		return nil
+	}
+	return m[k]
+}
+
+// resolve returns the values of the path, multi-valued attributes are flattened
+func resolve(v any, path []string) []any {
+	values := []any{v}
+	for _, name := range path {
+		var next []any
+		for _, v := range values {
+			m, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch a := lookup(m, name).(type) {
+			case nil:
+			case []any:
+				next = append(next, a...)
+			default:
+				next = append(next, a)
+			}
+		}
+		values = next
+	}
+	// complex values of multi-valued attributes compare by their value
+	for i, v := range values {
+		if m, ok := v.(map[string]any); ok {
+			values[i] = lookup(m, "value")
+		}
+	}
+	return values
+}
+
+type orFilter struct{ left, right Filter }
+
+func (f orFilter) Match(r map[string]any) bool { return f.left.Match(r) || f.right.Match(r) }
+
+type andFilter struct{ left, right Filter }
+
+func (f andFilter) Match(r map[string]any) bool { return f.left.Match(r) && f.right.Match(r) }
+
+type notFilter struct{ f Filter }
+
+func (f notFilter) Match(r map[string]any) bool { return !f.f.Match(r) }
+
+type presentFilter struct{ path []string }
+
+func (f presentFilter) Match(r map[string]any) bool {
+	for _, v := range resolve(r, f.path) {
+		if v != nil && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// valuePathFilter matches if an element of a multi-valued attribute matches
+type valuePathFilter struct {
+	path   []string
+	filter Filter
+}
+
+func (f valuePathFilter) Match(r map[string]any) bool {
+	for _, e := range elements(r, f.path) {
+		if m, ok := e.(map[string]any); ok && f.filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// elements returns the elements of the attribute without flattening their values
+func elements(r map[string]any, path []string) []any {
+	var parent any = r
+	for i, name := range path {
+		m, ok := parent.(map[string]any)
+		if !ok {
+			return nil
+		}
+		parent = lookup(m, name)
+		if i == len(path)-1 {
+			if a, ok := parent.([]any); ok {
+				return a
+			}
+			if parent != nil {
+				return []any{parent}
+			}
+		}
+	}
+	return nil
+}
+
+type compareFilter struct {
+	path  []string
+	op    string
+	value any
+}
+
+func (f compareFilter) Match(r map[string]any) bool {
+	values := resolve(r, f.path)
+	if f.value == nil {
+		present := presentFilter{f.path}.Match(r)
+		return (f.op == "eq") != present
+	}
+	if f.op == "ne" {
+		return !compareFilter{f.path, "eq", f.value}.Match(r)
+	}
+	caseExact := false
+	switch strings.ToLower(f.path[len(f.path)-1]) {
+	case "id", "externalid":
+		caseExact = true
+	}
+	for _, v := range values {
+		if compare(v, f.op, f.value, caseExact) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(v any, op string, want any, caseExact bool) bool {
+	switch w := want.(type) {
+	case bool:
+		b, ok := v.(bool)
+		return ok && op == "eq" && b == w
+	case float64:
+		n, ok := v.(float64)
+		if !ok {
+			return false
+		}
+		return compareOrder(op, n-w)
+	case string:
+		s, ok := v.(string)
+		if !ok {
+			return false
+		}
+		// dateTime values compare as times
+		if t1, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			if t2, err := time.Parse(time.RFC3339Nano, w); err == nil {
+				return compareOrder(op, float64(t1.Sub(t2)))
+			}
+		}
+		if !caseExact {
+			s, w = strings.ToLower(s), strings.ToLower(w)
+		}
+		switch op {
+		case "eq":
+			return s == w
+		case "co":
+			return strings.Contains(s, w)
+		case "sw":
+			return strings.HasPrefix(s, w)
+		case "ew":
+			return strings.HasSuffix(s, w)
+		}
+		return compareOrder(op, float64(strings.Compare(s, w)))
+	}
+	return false
+}
+
+func compareOrder(op string, diff float64) bool {
+	switch op {
+	case "eq":
+		return diff == 0
+	case "gt":
+		return diff > 0
+	case "ge":
+		return diff >= 0
+	case "lt":
+		return diff < 0
+	case "le":
+		return diff <= 0
+	}
+	return false
+}
+
+// Path is the target of a PATCH operation: attr, attr.sub, attr[filter] or attr[filter].sub
+type Path struct {
+	Attr   []string
+	Filter Filter
+	Sub    string
+}
+
+func ParsePath(s string) (*Path, error) {
+	p, err := newParser(s)
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != tokenWord || t.text == "" || !unicode.IsLetter(rune(t.text[0])) {
+		return nil, badRequest("invalidPath", "invalid path %q", s)
+	}
+	path := &Path{Attr: splitPath(t.text)}
+	if p.peek().kind == tokenOpenBracket {
+		p.next()
+		path.Filter, err = p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, badRequest("invalidPath", "invalid path %q", s)
+		}
+		if p.peek().kind == tokenWord && strings.HasPrefix(p.peek().text, ".") {
+			path.Sub = strings.TrimPrefix(p.next().text, ".")
+		}
+	}
+	if !p.done() {
+		return nil, badRequest("invalidPath", "invalid path %q", s)
+	}
+	return path, nil
+}
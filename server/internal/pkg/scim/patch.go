@@ -0,0 +1,267 @@
+package scim
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PatchOp is the body of a PATCH request
+type PatchOp struct {
+	Schemas    []string    `json:"schemas"`
+	Operations []Operation `json:"Operations"`
+}
+
+type Operation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// Patch applies the operations of RFC 7644 section 3.5.2 to the JSON form of a resource
+func Patch(resource map[string]any, ops []Operation) error {
+	for _, op := range ops {
+		err := patch(resource, strings.ToLower(op.Op), op.Path, op.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func patch(resource map[string]any, op, path string, value any) error {
+	switch op {
+	case "add", "replace", "remove":
+	default:
+		return badRequest("invalidSyntax", "unknown op %q", op)
+	}
+	if path == "" {
+		if op == "remove" {
+			return badRequest("noTarget", "remove needs a path")
+		}
+		attrs, ok := value.(map[string]any)
+		if !ok {
+			return badRequest("invalidValue", "%s without path needs an object", op)
+		}
+		// each attribute is applied on its own, some clients send paths as keys
+		for k, v := range attrs {
+			if ext, ok := v.(map[string]any); ok && strings.HasPrefix(strings.ToLower(k), "urn:") && !isCoreSchema(k) {
+				for k2, v2 := range ext {
+					err := patch(resource, op, k+":"+k2, v2)
+					if err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if strings.EqualFold(k, "schemas") {
+				continue
+			}
+			err := patch(resource, op, k, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(p.Attr[0]) {
+	case "id", "meta", "schemas":
+		return badRequest("mutability", "%s is read only", p.Attr[0])
+	}
+
+	// the object holding the attribute
+	parent := resource
+	for _, name := range p.Attr[:len(p.Attr)-1] {
+		k, _ := lookupKey(parent, name)
+		child, ok := parent[k].(map[string]any)
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			child = map[string]any{}
+			parent[k] = child
+		}
+		parent = child
+	}
+	key, exists := lookupKey(parent, p.Attr[len(p.Attr)-1])
+
+	if p.Filter == nil {
+		switch op {
+		case "add":
+			parent[key] = add(parent[key], value)
+		case "replace":
+			old, isMap := parent[key].(map[string]any)
+			values, valueIsMap := value.(map[string]any)
+			if isMap && valueIsMap {
+				for k, v := range values {
+					k, _ = lookupKey(old, k)
+					old[k] = v
+				}
+			} else {
+				parent[key] = value
+			}
+		case "remove":
+			if !exists {
+				return nil
+			}
+			// some clients name the values to remove of a multi-valued attribute
+			if elems, ok := parent[key].([]any); ok && value != nil {
+				parent[key] = without(elems, value)
+				if len(parent[key].([]any)) == 0 {
+					delete(parent, key)
+				}
+				return nil
+			}
+			delete(parent, key)
+		}
+		return nil
+	}
+
+	elems, _ := parent[key].([]any)
+	matched := false
+	var kept []any
+	for _, e := range elems {
+		m, ok := e.(map[string]any)
+		if !ok || !p.Filter.Match(m) {
+			kept = append(kept, e)
+			continue
+		}
+		matched = true
+		switch {
+		case op == "remove" && p.Sub == "":
+			continue
+		case op == "remove":
+			k, _ := lookupKey(m, p.Sub)
+			delete(m, k)
+		case p.Sub != "":
+			k, _ := lookupKey(m, p.Sub)
+			m[k] = value
+		default:
+			v, ok := value.(map[string]any)
+			if !ok {
+				return badRequest("invalidValue", "%s of %s needs an object", op, path)
+			}
+			for k, v := range v {
+				k, _ = lookupKey(m, k)
+				m[k] = v
+			}
+		}
+		kept = append(kept, m)
+	}
+	if !matched && op != "remove" {
+		// a missing value of an equality filter is created, like emails[type eq "work"].value
+		e, ok := elementOf(p.Filter)
+		if !ok {
+			return badRequest("noTarget", "no value matches %s", path)
+		}
+		if p.Sub != "" {
+			e[p.Sub] = value
+		} else if v, ok := value.(map[string]any); ok {
+			for k, v := range v {
+				e[k] = v
+			}
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) == 0 {
+		delete(parent, key)
+		return nil
+	}
+	parent[key] = kept
+	return nil
+}
+
+func isCoreSchema(s string) bool {
+	return strings.EqualFold(s, SchemaUser) || strings.EqualFold(s, SchemaGroup)
+}
+
+// add appends to multi-valued attributes, skipping values already there,
+// merges objects and sets the others
+func add(old, value any) any {
+	elems, isArray := old.([]any)
+	values, valueIsArray := value.([]any)
+	if isArray || valueIsArray {
+		if !valueIsArray {
+			values = []any{value}
+		}
+		for _, v := range values {
+			if !contains(elems, v) {
+				elems = append(elems, v)
+			}
+		}
+		return elems
+	}
+	if m, ok := old.(map[string]any); ok {
+		if v, ok := value.(map[string]any); ok {
+			for k, v := range v {
+				k, _ = lookupKey(m, k)
+				m[k] = v
+			}
+			return m
+		}
+	}
+	return value
+}
+
+// sameValue compares elements of multi-valued attributes by their value
+func sameValue(a, b any) bool {
+	if m, ok := a.(map[string]any); ok {
+		a = lookup(m, "value")
+	}
+	if m, ok := b.(map[string]any); ok {
+		b = lookup(m, "value")
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func contains(elems []any, v any) bool {
+	for _, e := range elems {
+		if sameValue(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func without(elems []any, value any) []any {
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	var kept []any
+	for _, e := range elems {
+		if !contains(values, e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// elementOf builds the element an equality filter, or an and of them, matches
+func elementOf(f Filter) (map[string]any, bool) {
+	switch f := f.(type) {
+	case compareFilter:
+		if f.op != "eq" || len(f.path) != 1 || f.value == nil {
+			return nil, false
+		}
+		return map[string]any{f.path[0]: f.value}, true
+	case andFilter:
+		left, ok := elementOf(f.left)
+		if !ok {
+			return nil, false
+		}
+		right, ok := elementOf(f.right)
+		if !ok {
+			return nil, false
+		}
+		for k, v := range right {
+			left[k] = v
+		}
+		return left, true
+	}
+	return nil, false
+}
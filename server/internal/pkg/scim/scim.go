@@ -0,0 +1,187 @@
+// Package scim is a SCIM 2.0 server (RFC 7643, RFC 7644) for the users and
+// groups of a namespace
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaBulkRequest           = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	SchemaBulkResponse          = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+	// ContentType of requests and responses, application/json is accepted too
+	ContentType = "application/scim+json"
+)
+
+var (
+	// ErrNotFound is returned by a Backend for unknown resources
+	ErrNotFound = errors.New("resource not found")
+	// ErrConflict is returned by a Backend if a unique attribute exists
+	ErrConflict = errors.New("resource exists")
+)
+
+// Meta of a resource, Version is the ETag
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location,omitempty"`
+	Version      string    `json:"version,omitempty"`
+}
+
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	MiddleName string `json:"middleName,omitempty"`
+}
+
+// MultiValued is an email or phone number
+type MultiValued struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Reference is a member of a group or a group of a user
+type Reference struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+}
+
+type User struct {
+	Schemas      []string      `json:"schemas"`
+	ID           string        `json:"id,omitempty"`
+	ExternalID   string        `json:"externalId,omitempty"`
+	UserName     string        `json:"userName"`
+	Name         *Name         `json:"name,omitempty"`
+	DisplayName  string        `json:"displayName,omitempty"`
+	NickName     string        `json:"nickName,omitempty"`
+	ProfileURL   string        `json:"profileUrl,omitempty"`
+	Locale       string        `json:"locale,omitempty"`
+	Timezone     string        `json:"timezone,omitempty"`
+	Active       *bool         `json:"active,omitempty"`
+	Password     string        `json:"password,omitempty"`
+	Emails       []MultiValued `json:"emails,omitempty"`
+	PhoneNumbers []MultiValued `json:"phoneNumbers,omitempty"`
+	// Groups is read only, memberships change with the groups
+	Groups []Reference `json:"groups,omitempty"`
+	Meta   *Meta       `json:"meta,omitempty"`
+}
+
+// Primary returns the primary value, or the first
+func Primary(values []MultiValued) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+type Group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	ExternalID  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []Reference `json:"members,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// Backend stores the resources of namespaces
+type Backend interface {
+	// ProvisioningNamespace returns the namespace a bearer token may provision
+	ProvisioningNamespace(ctx context.Context, token string) (uuid.UUID, error)
+
+	// ListSCIMUsers returns the page of the query and the count of all filtered users
+	ListSCIMUsers(ctx context.Context, namespace uuid.UUID, q *Query) ([]*User, int, error)
+	GetSCIMUser(ctx context.Context, namespace uuid.UUID, id string) (*User, error)
+	// CreateSCIMUser sets the id and meta of the user
+	CreateSCIMUser(ctx context.Context, namespace uuid.UUID, u *User) error
+	// ReplaceSCIMUser keeps the password if the user has none
+	ReplaceSCIMUser(ctx context.Context, namespace uuid.UUID, u *User) error
+	DeleteSCIMUser(ctx context.Context, namespace uuid.UUID, id string) error
+
+	ListSCIMGroups(ctx context.Context, namespace uuid.UUID, q *Query) ([]*Group, int, error)
+	GetSCIMGroup(ctx context.Context, namespace uuid.UUID, id string) (*Group, error)
+	CreateSCIMGroup(ctx context.Context, namespace uuid.UUID, g *Group) error
+	ReplaceSCIMGroup(ctx context.Context, namespace uuid.UUID, g *Group) error
+	DeleteSCIMGroup(ctx context.Context, namespace uuid.UUID, id string) error
+}
+
+// Version is the weak ETag of a resource modified at t
+func Version(t time.Time) string {
+	return `W/"` + strconv.FormatInt(t.UnixNano(), 36) + `"`
+}
+
+// Error is a SCIM error response
+type Error struct {
+	Status   int    `json:"-"`
+	ScimType string `json:"scimType,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.ScimType != "" {
+		return e.ScimType + ": " + e.Detail
+	}
+	return e.Detail
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Schemas  []string `json:"schemas"`
+		Status   string   `json:"status"`
+		ScimType string   `json:"scimType,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+	}{[]string{SchemaError}, strconv.Itoa(e.Status), e.ScimType, e.Detail})
+}
+
+func badRequest(scimType, format string, args ...any) *Error {
+	return &Error{Status: http.StatusBadRequest, ScimType: scimType, Detail: fmt.Sprintf(format, args...)}
+}
+
+// toError maps the errors of a Backend
+func toError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, ErrNotFound):
+		return &Error{Status: http.StatusNotFound, Detail: err.Error()}
+	case errors.Is(err, ErrConflict):
+		return &Error{Status: http.StatusConflict, ScimType: "uniqueness", Detail: err.Error()}
+	}
+	return &Error{Status: http.StatusInternalServerError, Detail: err.Error()}
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
@@ -0,0 +1,360 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestFilter(t *testing.T) {
+	user := map[string]any{
+		"userName": "Ann",
+		"name":     map[string]any{"givenName": "Ann", "familyName": "Lee"},
+		"active":   true,
+		"emails": []any{
+			map[string]any{"value": "ann@work.example", "type": "work"},
+			map[string]any{"value": "ann@home.example", "type": "home"},
+		},
+		"meta": map[string]any{"lastModified": "2024-05-13T04:42:34.5Z"},
+	}
+	for filter, want := range map[string]bool{
+		`userName eq "ann"`:                                            true,
+		`USERNAME sw "A" and name.familyName eq "Lee"`:                 true,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "Ann"`: true,
+		`emails co "home.example"`:                                     true,
+		`emails[type eq "work" and value ew "work.example"]`:           true,
+		`emails[type eq "other"]`:                                      false,
+		`not (active eq true) or title pr`:                             false,
+		`(title pr or active eq true) and not(userName ne "ann")`:      true,
+		`meta.lastModified gt "2024-05-13T04:42:34Z"`:                  true,
+		`nickName eq null`:                                             true,
+	} {
+		f, err := ParseFilter(filter)
+		if err != nil {
+			t.Errorf("%s: %v", filter, err)
+			continue
+		}
+		if got := f.Match(user); got != want {
+			t.Errorf("%s = %v, want %v", filter, got, want)
+		}
+	}
+	for _, invalid := range []string{`userName xx "a"`, `userName eq`, `(userName pr`, `emails[type eq "a"`} {
+		if _, err := ParseFilter(invalid); err == nil {
+			t.Errorf("%s accepted", invalid)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	columns := map[string]Column{
+		"id":           {Expr: "id::text", Type: ColumnCaseExact},
+		"username":     {Expr: "username"},
+		"active":       {Expr: "status = 'active'", Type: ColumnBool},
+		"emails.value": {Expr: "ARRAY[email]", Multi: true},
+		"meta.created": {Expr: "create_time", Type: ColumnTime},
+	}
+	for filter, want := range map[string]string{
+		`userName eq "Ann"`:                      `coalesce(lower(username) = $1, false)`,
+		`id eq "A" and not (active eq true)`:     `(coalesce(id::text = $1, false) AND NOT (coalesce(status = 'active' = $2, false)))`,
+		`emails co "50%"`:                        `EXISTS (SELECT 1 FROM unnest(ARRAY[email]) AS v WHERE lower(v) LIKE $1)`,
+		`emails[value sw "a"] or userName pr`:    `(EXISTS (SELECT 1 FROM unnest(ARRAY[email]) AS v WHERE lower(v) LIKE $1) OR coalesce(username <> '', false))`,
+		`userName eq null`:                       `NOT coalesce(username <> '', false)`,
+		`userName ne "x"`:                        `NOT coalesce(lower(username) = $1, false)`,
+		`meta.created ge "2024-05-13T04:42:34Z"`: `coalesce(create_time >= $1, false)`,
+		`active eq "yes"`:                        `false`,
+	} {
+		f, err := ParseFilter(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var args []any
+		got, err := SQL(f, columns, &args)
+		if err != nil {
+			t.Errorf("%s: %v", filter, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s = %s, want %s", filter, got, want)
+		}
+	}
+
+	var args []any
+	f, _ := ParseFilter(`emails co "50%_"`)
+	SQL(f, columns, &args)
+	if len(args) != 1 || args[0] != `%50\%\_%` {
+		t.Errorf("args = %q", args)
+	}
+	f, _ = ParseFilter(`title eq "x"`)
+	if _, err := SQL(f, columns, &args); err == nil {
+		t.Error("unknown attribute translated")
+	}
+}
+
+func TestPatch(t *testing.T) {
+	group := map[string]any{
+		"displayName": "devs",
+		"members":     []any{map[string]any{"value": "u1"}, map[string]any{"value": "u2"}},
+	}
+	err := Patch(group, []Operation{
+		{Op: "Add", Path: "members", Value: []any{map[string]any{"value": "u3"}, map[string]any{"value": "u1"}}},
+		{Op: "remove", Path: `members[value eq "u2"]`},
+		{Op: "Replace", Value: map[string]any{"displayName": "developers"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(group)
+	if string(b) != `{"displayName":"developers","members":[{"value":"u1"},{"value":"u3"}]}` {
+		t.Errorf("group = %s", b)
+	}
+
+	user := map[string]any{"userName": "ann", "emails": []any{map[string]any{"value": "a@old.example", "type": "work"}}}
+	err = Patch(user, []Operation{
+		{Op: "replace", Path: `emails[type eq "work"].value`, Value: "a@new.example"},
+		{Op: "add", Path: `phoneNumbers[type eq "mobile"].value`, Value: "+100"},
+		{Op: "replace", Value: map[string]any{"name.givenName": "Ann", "active": "False"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = json.Marshal(user)
+	want := `{"active":"False","emails":[{"type":"work","value":"a@new.example"}],"name":{"givenName":"Ann"},"phoneNumbers":[{"type":"mobile","value":"+100"}],"userName":"ann"}`
+	if string(b) != want {
+		t.Errorf("user = %s", b)
+	}
+
+	if err := Patch(user, []Operation{{Op: "replace", Path: "id", Value: "x"}}); err == nil {
+		t.Error("id replaced")
+	}
+	if err := Patch(user, []Operation{{Op: "remove"}}); err == nil {
+		t.Error("remove without path accepted")
+	}
+}
+
+// memoryBackend implements Backend for the tests
+type memoryBackend struct {
+	users  []*User
+	groups []*Group
+}
+
+func (b *memoryBackend) ProvisioningNamespace(ctx context.Context, token string) (uuid.UUID, error) {
+	if token != "secret" {
+		return uuid.Nil, ErrNotFound
+	}
+	return uuid.Nil, nil
+}
+
+func touch(m **Meta) {
+	now := time.Now()
+	if *m == nil {
+		*m = &Meta{Created: now}
+	}
+	(*m).LastModified = now
+}
+
+// page filters and pages the resources in their JSON form
+func page[T any](list []T, q *Query) ([]T, int) {
+	var matched []T
+	for _, r := range list {
+		b, _ := json.Marshal(r)
+		var m map[string]any
+		json.Unmarshal(b, &m)
+		if q.Filter == nil || q.Filter.Match(m) {
+			matched = append(matched, r)
+		}
+	}
+	from := min(q.StartIndex-1, len(matched))
+	return matched[from:min(from+q.Count, len(matched))], len(matched)
+}
+
+func (b *memoryBackend) ListSCIMUsers(ctx context.Context, ns uuid.UUID, q *Query) ([]*User, int, error) {
+	users, total := page(b.users, q)
+	return users, total, nil
+}
+
+func (b *memoryBackend) GetSCIMUser(ctx context.Context, ns uuid.UUID, id string) (*User, error) {
+	for _, u := range b.users {
+		if u.ID == id {
+			c := *u
+			m := *u.Meta
+			c.Meta = &m
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (b *memoryBackend) CreateSCIMUser(ctx context.Context, ns uuid.UUID, u *User) error {
+	for _, o := range b.users {
+		if strings.EqualFold(o.UserName, u.UserName) {
+			return ErrConflict
+		}
+	}
+	u.ID = uuid.NewString()
+	touch(&u.Meta)
+	c := *u
+	b.users = append(b.users, &c)
+	return nil
+}
+
+func (b *memoryBackend) ReplaceSCIMUser(ctx context.Context, ns uuid.UUID, u *User) error {
+	for i, o := range b.users {
+		if o.ID == u.ID {
+			touch(&u.Meta)
+			c := *u
+			b.users[i] = &c
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (b *memoryBackend) DeleteSCIMUser(ctx context.Context, ns uuid.UUID, id string) error {
+	for i, o := range b.users {
+		if o.ID == id {
+			b.users = append(b.users[:i], b.users[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (b *memoryBackend) ListSCIMGroups(ctx context.Context, ns uuid.UUID, q *Query) ([]*Group, int, error) {
+	groups, total := page(b.groups, q)
+	return groups, total, nil
+}
+
+func (b *memoryBackend) GetSCIMGroup(ctx context.Context, ns uuid.UUID, id string) (*Group, error) {
+	for _, g := range b.groups {
+		if g.ID == id {
+			c := *g
+			m := *g.Meta
+			c.Meta = &m
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (b *memoryBackend) CreateSCIMGroup(ctx context.Context, ns uuid.UUID, g *Group) error {
+	g.ID = uuid.NewString()
+	touch(&g.Meta)
+	c := *g
+	b.groups = append(b.groups, &c)
+	return nil
+}
+
+func (b *memoryBackend) ReplaceSCIMGroup(ctx context.Context, ns uuid.UUID, g *Group) error {
+	for i, o := range b.groups {
+		if o.ID == g.ID {
+			touch(&g.Meta)
+			c := *g
+			b.groups[i] = &c
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (b *memoryBackend) DeleteSCIMGroup(ctx context.Context, ns uuid.UUID, id string) error {
+	for i, o := range b.groups {
+		if o.ID == id {
+			b.groups = append(b.groups[:i], b.groups[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+type client struct {
+	t      *testing.T
+	server *httptest.Server
+}
+
+func (c *client) do(method, path, body string, header map[string]string) (*http.Response, map[string]any) {
+	c.t.Helper()
+	req, _ := http.NewRequest(method, c.server.URL+"/scim/v2"+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", ContentType)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var m map[string]any
+	_ = json.NewDecoder(res.Body).Decode(&m)
+	return res, m
+}
+
+func TestServer(t *testing.T) {
+	backend := &memoryBackend{}
+	router := chi.NewRouter()
+	c := &client{t: t}
+	c.server = httptest.NewServer(router)
+	defer c.server.Close()
+	router.Route("/scim/v2", NewServer(backend, c.server.URL+"/scim/v2").Serve)
+
+	res, _ := c.do("GET", "/Users", "", map[string]string{"Authorization": "Bearer wrong"})
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d", res.StatusCode)
+	}
+
+	res, user := c.do("POST", "/Users", `{"schemas":["`+SchemaUser+`"],"userName":"ann","password":"s3cret","emails":[{"value":"ann@example.com","primary":true}]}`, nil)
+	if res.StatusCode != http.StatusCreated || res.Header.Get("Location") == "" || user["password"] != nil {
+		t.Fatalf("create: %d %v", res.StatusCode, user)
+	}
+	id := user["id"].(string)
+	etag := res.Header.Get("ETag")
+
+	res, _ = c.do("POST", "/Users", `{"userName":"ANN"}`, nil)
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("duplicate: %d", res.StatusCode)
+	}
+
+	_, list := c.do("GET", `/Users?filter=`+`emails%20co%20%22example.com%22&attributes=userName`, "", nil)
+	if list["totalResults"] != 1.0 || list["Resources"].([]any)[0].(map[string]any)["emails"] != nil {
+		t.Errorf("list: %v", list)
+	}
+
+	res, _ = c.do("GET", "/Users/"+id, "", map[string]string{"If-None-Match": etag})
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match: %d", res.StatusCode)
+	}
+	time.Sleep(time.Millisecond)
+	res, patched := c.do("PATCH", "/Users/"+id, `{"schemas":["`+SchemaPatchOp+`"],"Operations":[{"op":"replace","path":"active","value":"False"}]}`, map[string]string{"If-Match": etag})
+	if res.StatusCode != http.StatusOK || patched["active"] != false {
+		t.Fatalf("patch: %d %v", res.StatusCode, patched)
+	}
+	res, _ = c.do("DELETE", "/Users/"+id, "", map[string]string{"If-Match": etag})
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("stale If-Match: %d", res.StatusCode)
+	}
+
+	// a bulk request creating a user and a group with it as member
+	_, bulk := c.do("POST", "/Bulk", `{"schemas":["`+SchemaBulkRequest+`"],"Operations":[
+		{"method":"POST","path":"/Users","bulkId":"u","data":{"userName":"bob"}},
+		{"method":"POST","path":"/Groups","bulkId":"g","data":{"displayName":"devs","members":[{"value":"bulkId:u"}]}},
+		{"method":"PATCH","path":"/Groups/bulkId:g","data":{"Operations":[{"op":"add","path":"members","value":[{"value":"`+id+`"}]}]}},
+		{"method":"DELETE","path":"/Users/unknown"}
+	]}`, nil)
+	var statuses []string
+	for _, op := range bulk["Operations"].([]any) {
+		statuses = append(statuses, op.(map[string]any)["status"].(string))
+	}
+	if strings.Join(statuses, ",") != "201,201,200,404" {
+		t.Errorf("bulk statuses: %v", statuses)
+	}
+	if len(backend.groups) != 1 || len(backend.groups[0].Members) != 2 || backend.groups[0].Members[0].Value != backend.users[1].ID {
+		t.Errorf("group: %+v", backend.groups)
+	}
+}
@@ -0,0 +1,628 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// the count of a list without count, and its maximum
+	defaultCount = 100
+	maxCount     = 1000
+
+	maxBulkOperations = 1000
+	maxPayloadSize    = 1 << 20
+)
+
+var errPayloadTooLarge = &Error{Status: http.StatusRequestEntityTooLarge, Detail: "the payload exceeds " + strconv.Itoa(maxPayloadSize) + " bytes"}
+
+// Server serves the SCIM endpoints, the namespace comes from the bearer token
+type Server struct {
+	backend Backend
+	// BaseURL is the absolute url of the endpoints, for meta.location
+	BaseURL string
+	// resources serves the user and group endpoints, also for bulk operations
+	resources chi.Router
+}
+
+func NewServer(backend Backend, baseURL string) *Server {
+	s := &Server{
+		backend: backend,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+	s.resources = chi.NewRouter()
+	for _, e := range s.endpoints() {
+		e := e
+		s.resources.Get(e.path, func(w http.ResponseWriter, r *http.Request) { s.handleList(w, r, e) })
+		s.resources.Post(e.path, func(w http.ResponseWriter, r *http.Request) { s.handleCreate(w, r, e) })
+		s.resources.Get(e.path+"/{id}", func(w http.ResponseWriter, r *http.Request) { s.handleGet(w, r, e) })
+		s.resources.Put(e.path+"/{id}", func(w http.ResponseWriter, r *http.Request) { s.handleReplace(w, r, e) })
+		s.resources.Patch(e.path+"/{id}", func(w http.ResponseWriter, r *http.Request) { s.handlePatch(w, r, e) })
+		s.resources.Delete(e.path+"/{id}", func(w http.ResponseWriter, r *http.Request) { s.handleDelete(w, r, e) })
+	}
+	return s
+}
+
+// Serve registers the endpoints, e.g. router.Route("/scim/v2", s.Serve)
+func (s *Server) Serve(r chi.Router) {
+	r.Get("/ServiceProviderConfig", s.handleServiceProviderConfig)
+	r.Get("/ResourceTypes", s.handleResourceTypes)
+	r.Get("/ResourceTypes/{name}", s.handleResourceTypes)
+	r.Get("/Schemas", s.handleSchemas)
+	r.Get("/Schemas/{id}", s.handleSchemas)
+	r.Group(func(r chi.Router) {
+		r.Use(s.authenticate)
+		r.Post("/Bulk", s.handleBulk)
+		r.Mount("/", s.resources)
+	})
+}
+
+type namespaceKey struct{}
+
+func namespaceOf(ctx context.Context) uuid.UUID {
+	ns, _ := ctx.Value(namespaceKey{}).(uuid.UUID)
+	return ns
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			writeError(w, &Error{Status: http.StatusUnauthorized, Detail: "bearer token required"})
+			return
+		}
+		ns, err := s.backend.ProvisioningNamespace(r.Context(), token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim", error="invalid_token"`)
+			writeError(w, &Error{Status: http.StatusUnauthorized, Detail: "invalid token"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), namespaceKey{}, ns)))
+	})
+}
+
+// resource is a User or a Group
+type resource interface {
+	meta() *Meta
+	setID(id string)
+	validate() error
+}
+
+func (u *User) meta() *Meta {
+	if u.Meta == nil {
+		u.Meta = &Meta{}
+	}
+	return u.Meta
+}
+
+func (u *User) setID(id string) { u.ID = id }
+
+func (u *User) validate() error {
+	if u.UserName == "" {
+		return badRequest("invalidValue", "userName is required")
+	}
+	return nil
+}
+
+func (g *Group) meta() *Meta {
+	if g.Meta == nil {
+		g.Meta = &Meta{}
+	}
+	return g.Meta
+}
+
+func (g *Group) setID(id string) { g.ID = id }
+
+func (g *Group) validate() error {
+	if g.DisplayName == "" {
+		return badRequest("invalidValue", "displayName is required")
+	}
+	return nil
+}
+
+// endpoint adapts the backend methods of a resource type
+type endpoint struct {
+	name    string
+	path    string
+	schema  string
+	new     func() resource
+	list    func(ctx context.Context, ns uuid.UUID, q *Query) ([]resource, int, error)
+	get     func(ctx context.Context, ns uuid.UUID, id string) (resource, error)
+	create  func(ctx context.Context, ns uuid.UUID, r resource) error
+	replace func(ctx context.Context, ns uuid.UUID, r resource) error
+	delete  func(ctx context.Context, ns uuid.UUID, id string) error
+}
+
+func (s *Server) endpoints() []*endpoint {
+	b := s.backend
+	return []*endpoint{{
+		name:   "User",
+		path:   "/Users",
+		schema: SchemaUser,
+		new:    func() resource { return &User{} },
+		list: func(ctx context.Context, ns uuid.UUID, q *Query) ([]resource, int, error) {
+			users, total, err := b.ListSCIMUsers(ctx, ns, q)
+			var list []resource
+			for _, u := range users {
+				list = append(list, u)
+			}
+			return list, total, err
+		},
+		get: func(ctx context.Context, ns uuid.UUID, id string) (resource, error) {
+			return b.GetSCIMUser(ctx, ns, id)
+		},
+		create: func(ctx context.Context, ns uuid.UUID, r resource) error {
+			return b.CreateSCIMUser(ctx, ns, r.(*User))
+		},
+		replace: func(ctx context.Context, ns uuid.UUID, r resource) error {
+			return b.ReplaceSCIMUser(ctx, ns, r.(*User))
+		},
+		delete: b.DeleteSCIMUser,
+	}, {
+		name:   "Group",
+		path:   "/Groups",
+		schema: SchemaGroup,
+		new:    func() resource { return &Group{} },
+		list: func(ctx context.Context, ns uuid.UUID, q *Query) ([]resource, int, error) {
+			groups, total, err := b.ListSCIMGroups(ctx, ns, q)
+			var list []resource
+			for _, g := range groups {
+				list = append(list, g)
+			}
+			return list, total, err
+		},
+		get: func(ctx context.Context, ns uuid.UUID, id string) (resource, error) {
+			return b.GetSCIMGroup(ctx, ns, id)
+		},
+		create: func(ctx context.Context, ns uuid.UUID, r resource) error {
+			return b.CreateSCIMGroup(ctx, ns, r.(*Group))
+		},
+		replace: func(ctx context.Context, ns uuid.UUID, r resource) error {
+			return b.ReplaceSCIMGroup(ctx, ns, r.(*Group))
+		},
+		delete: b.DeleteSCIMGroup,
+	}}
+}
+
+// toMap returns the JSON form of a resource as the client sees it
+func (s *Server) toMap(e *endpoint, r resource) (map[string]any, error) {
+	meta := r.meta()
+	meta.ResourceType = e.name
+	meta.Version = Version(meta.LastModified)
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+	m["schemas"] = []any{e.schema}
+	delete(m, "password")
+	if meta, ok := m["meta"].(map[string]any); ok {
+		meta["location"] = s.BaseURL + e.path + "/" + m["id"].(string)
+	}
+	return m, nil
+}
+
+func fromMap(e *endpoint, m map[string]any) (resource, error) {
+	// some clients send booleans as strings
+	if v, ok := m["active"].(string); ok {
+		m["active"] = strings.EqualFold(v, "true")
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	r := e.new()
+	err = json.Unmarshal(b, r)
+	if err != nil {
+		return nil, badRequest("invalidValue", "%v", err)
+	}
+	return r, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e := toError(err)
+	if e.Status >= 500 {
+		logrus.Error(err)
+	}
+	writeJSON(w, e.Status, e)
+}
+
+// writeResource writes a resource with its ETag
+func (s *Server) writeResource(w http.ResponseWriter, r *http.Request, status int, e *endpoint, res resource) {
+	m, err := s.toMap(e, res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	m = project(m, r.URL.Query().Get("attributes"), r.URL.Query().Get("excludedAttributes"))
+	w.Header().Set("ETag", res.meta().Version)
+	if status == http.StatusCreated {
+		w.Header().Set("Location", s.BaseURL+e.path+"/"+m["id"].(string))
+	}
+	writeJSON(w, status, m)
+}
+
+// project keeps the top level attributes asked for, id, schemas and meta are always returned
+func project(m map[string]any, attributes, excluded string) map[string]any {
+	if attributes == "" && excluded == "" {
+		return m
+	}
+	keep := func(k string) bool {
+		switch strings.ToLower(k) {
+		case "id", "schemas", "meta":
+			return true
+		}
+		if attributes != "" {
+			return listed(attributes, k)
+		}
+		return !listed(excluded, k)
+	}
+	out := map[string]any{}
+	for k, v := range m {
+		if keep(k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// listed reports if the comma separated attribute list names the attribute or one of its sub-attributes
+func listed(list, attr string) bool {
+	for _, a := range strings.Split(list, ",") {
+		path := splitPath(strings.TrimSpace(a))
+		if len(path) > 0 && strings.EqualFold(path[0], attr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	q := r.URL.Query()
+	query := &Query{StartIndex: 1, Count: defaultCount}
+	if f := q.Get("filter"); f != "" {
+		var err error
+		query.Filter, err = ParseFilter(f)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	if v := q.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, badRequest("invalidValue", "invalid startIndex"))
+			return
+		}
+		// values below 1 are taken as 1
+		query.StartIndex = max(n, 1)
+	}
+	if v := q.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, badRequest("invalidValue", "invalid count"))
+			return
+		}
+		query.Count = min(max(n, 0), maxCount)
+	}
+
+	// the backend filters and pages, the filter is on the JSON form of the resources
+	list, total, err := e.list(r.Context(), namespaceOf(r.Context()), query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res := ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   query.StartIndex,
+		Resources:    []any{},
+	}
+	for _, resource := range list {
+		m, err := s.toMap(e, resource)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		res.Resources = append(res.Resources, project(m, q.Get("attributes"), q.Get("excludedAttributes")))
+	}
+	res.ItemsPerPage = len(res.Resources)
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	res, err := e.get(r.Context(), namespaceOf(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && match == Version(res.meta().LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.writeResource(w, r, http.StatusOK, e, res)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPayloadSize)).Decode(v)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errPayloadTooLarge
+	}
+	if err != nil {
+		return badRequest("invalidSyntax", "%v", err)
+	}
+	return nil
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	res := e.new()
+	err := decodeBody(w, r, res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = res.validate()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res.setID("")
+	err = e.create(r.Context(), namespaceOf(r.Context()), res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeResource(w, r, http.StatusCreated, e, res)
+}
+
+// current loads the resource and checks If-Match
+func (s *Server) current(r *http.Request, e *endpoint) (resource, error) {
+	res, err := e.get(r.Context(), namespaceOf(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, err
+	}
+	match := r.Header.Get("If-Match")
+	if match != "" && match != "*" && match != Version(res.meta().LastModified) {
+		return nil, &Error{Status: http.StatusPreconditionFailed, Detail: "the resource was modified"}
+	}
+	return res, nil
+}
+
+func (s *Server) handleReplace(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	old, err := s.current(r, e)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res := e.new()
+	err = decodeBody(w, r, res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = res.validate()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res.setID(chi.URLParam(r, "id"))
+	*res.meta() = *old.meta()
+	err = e.replace(r.Context(), namespaceOf(r.Context()), res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeResource(w, r, http.StatusOK, e, res)
+}
+
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	old, err := s.current(r, e)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var op PatchOp
+	err = decodeBody(w, r, &op)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	m, err := s.toMap(e, old)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = Patch(m, op.Operations)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res, err := fromMap(e, m)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = res.validate()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res.setID(chi.URLParam(r, "id"))
+	*res.meta() = *old.meta()
+	err = e.replace(r.Context(), namespaceOf(r.Context()), res)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	s.writeResource(w, r, http.StatusOK, e, res)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	_, err := s.current(r, e)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	err = e.delete(r.Context(), namespaceOf(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type BulkRequest struct {
+	Schemas      []string        `json:"schemas"`
+	FailOnErrors int             `json:"failOnErrors,omitempty"`
+	Operations   []BulkOperation `json:"Operations"`
+}
+
+type BulkOperation struct {
+	Method  string          `json:"method"`
+	BulkID  string          `json:"bulkId,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Path    string          `json:"path"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type BulkResponse struct {
+	Schemas    []string     `json:"schemas"`
+	Operations []BulkResult `json:"Operations"`
+}
+
+type BulkResult struct {
+	Method   string `json:"method"`
+	BulkID   string `json:"bulkId,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Location string `json:"location,omitempty"`
+	Status   string `json:"status"`
+	Response any    `json:"response,omitempty"`
+}
+
+// recorder keeps the response of a bulk operation
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header         { return r.header }
+func (r *recorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+func (r *recorder) WriteHeader(status int)      { r.status = status }
+
+// handleBulk runs the operations in order through the resource endpoints,
+// bulkId:<id> references are replaced by the ids created before
+func (s *Server) handleBulk(w http.ResponseWriter, r *http.Request) {
+	var req BulkRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPayloadSize)).Decode(&req)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, errPayloadTooLarge)
+		return
+	}
+	if err != nil {
+		writeError(w, badRequest("invalidSyntax", "%v", err))
+		return
+	}
+	if len(req.Operations) > maxBulkOperations {
+		writeError(w, &Error{Status: http.StatusRequestEntityTooLarge, Detail: "more than " + strconv.Itoa(maxBulkOperations) + " operations"})
+		return
+	}
+
+	res := BulkResponse{Schemas: []string{SchemaBulkResponse}, Operations: []BulkResult{}}
+	ids := map[string]string{}
+	errs := 0
+	for _, op := range req.Operations {
+		if req.FailOnErrors > 0 && errs >= req.FailOnErrors {
+			break
+		}
+		result := s.bulkOperation(r, op, ids)
+		if n, _ := strconv.Atoi(result.Status); n >= 400 {
+			errs++
+		}
+		res.Operations = append(res.Operations, result)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) bulkOperation(r *http.Request, op BulkOperation, ids map[string]string) BulkResult {
+	result := BulkResult{Method: op.Method, BulkID: op.BulkID}
+	fail := func(e *Error) BulkResult {
+		result.Status = strconv.Itoa(e.Status)
+		result.Response = e
+		return result
+	}
+	method := strings.ToUpper(op.Method)
+	if method == http.MethodPost && op.BulkID == "" {
+		return fail(badRequest("invalidValue", "bulkId is required for POST"))
+	}
+	path, data := op.Path, string(op.Data)
+	for bulkID, id := range ids {
+		path = strings.ReplaceAll(path, "bulkId:"+bulkID, id)
+		data = strings.ReplaceAll(data, "bulkId:"+bulkID, id)
+	}
+	if strings.Contains(path, "bulkId:") || strings.Contains(data, "bulkId:") {
+		return fail(&Error{Status: http.StatusConflict, ScimType: "invalidValue", Detail: "unresolved bulkId reference"})
+	}
+	if !strings.HasPrefix(path, "/Users") && !strings.HasPrefix(path, "/Groups") {
+		return fail(badRequest("invalidPath", "invalid path %q", op.Path))
+	}
+
+	// the operation is routed on its own, not in the route of the bulk request
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext())
+	req, err := http.NewRequestWithContext(ctx, method, path, strings.NewReader(data))
+	if err != nil {
+		return fail(badRequest("invalidPath", "%v", err))
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if op.Version != "" {
+		req.Header.Set("If-Match", op.Version)
+	}
+	rec := &recorder{header: http.Header{}, status: http.StatusOK}
+	s.resources.ServeHTTP(rec, req)
+
+	result.Status = strconv.Itoa(rec.status)
+	result.Location = rec.header.Get("Location")
+	result.Version = rec.header.Get("ETag")
+	if rec.status >= 400 {
+		var body any
+		_ = json.Unmarshal(rec.body.Bytes(), &body)
+		result.Response = body
+		return result
+	}
+	if method == http.MethodPost {
+		var created struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(rec.body.Bytes(), &created)
+		ids[op.BulkID] = created.ID
+	}
+	if result.Location == "" && method != http.MethodDelete {
+		result.Location = s.BaseURL + path
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+package scim
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query selects a page of the filtered resources of a list
+type Query struct {
+	// Filter is nil for all resources, SQL translates it for databases
+	Filter Filter
+	// StartIndex is the 1-based index of the first resource of the page
+	StartIndex int
+	Count      int
+}
+
+// ColumnType tells how the values of a column compare
+type ColumnType int
+
+const (
+	// ColumnString compares case insensitive
+	ColumnString ColumnType = iota
+	// ColumnCaseExact is a string like id and externalId
+	ColumnCaseExact
+	ColumnBool
+	ColumnTime
+)
+
+// Column is the SQL expression of a filterable attribute
+type Column struct {
+	Expr string
+	Type ColumnType
+	// Multi expressions are arrays, they match if an element matches
+	Multi bool
+}
+
+// SQL translates a filter into the condition of a WHERE clause. Columns are the
+// filterable attributes by their lower case path, e.g. "name.givenname", the
+// values are appended to args and referenced as $n.
+func SQL(f Filter, columns map[string]Column, args *[]any) (string, error) {
+	t := &sqlTranslator{columns: columns, args: args}
+	return t.condition(f, nil)
+}
+
+type sqlTranslator struct {
+	columns map[string]Column
+	args    *[]any
+}
+
+func (t *sqlTranslator) condition(f Filter, prefix []string) (string, error) {
+	switch f := f.(type) {
+	case orFilter:
+		return t.join(f.left, f.right, " OR ", prefix)
+	case andFilter:
+		return t.join(f.left, f.right, " AND ", prefix)
+	case notFilter:
+		c, err := t.condition(f.f, prefix)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + c + ")", nil
+	case valuePathFilter:
+		// the attributes of the element are sub-attributes of the path
+		return t.condition(f.filter, append(prefix, f.path...))
+	case presentFilter:
+		col, err := t.column(prefix, f.path)
+		if err != nil {
+			return "", err
+		}
+		return t.present(col), nil
+	case compareFilter:
+		col, err := t.column(prefix, f.path)
+		if err != nil {
+			return "", err
+		}
+		if f.value == nil {
+			if f.op == "eq" {
+				return "NOT " + t.present(col), nil
+			}
+			return t.present(col), nil
+		}
+		if f.op == "ne" {
+			c, err := t.compare(col, "eq", f.value)
+			return "NOT " + c, err
+		}
+		return t.compare(col, f.op, f.value)
+	}
+	return "", badRequest("invalidFilter", "unsupported filter")
+}
+
+func (t *sqlTranslator) join(left, right Filter, op string, prefix []string) (string, error) {
+	l, err := t.condition(left, prefix)
+	if err != nil {
+		return "", err
+	}
+	r, err := t.condition(right, prefix)
+	if err != nil {
+		return "", err
+	}
+	return "(" + l + op + r + ")", nil
+}
+
+func (t *sqlTranslator) column(prefix, path []string) (Column, error) {
+	name := strings.ToLower(strings.Join(append(append([]string(nil), prefix...), path...), "."))
+	col, ok := t.columns[name]
+	if !ok {
+		// complex multi-valued attributes compare by their value
+		col, ok = t.columns[name+".value"]
+	}
+	if !ok {
+		return Column{}, badRequest("invalidFilter", "filtering by %s is not supported", name)
+	}
+	return col, nil
+}
+
+func (t *sqlTranslator) arg(v any) string {
+	*t.args = append(*t.args, v)
+	return "$" + strconv.Itoa(len(*t.args))
+}
+
+// each returns the condition on the value of a column,
+// for arrays on any of the elements
+func each(col Column, cond func(v string) string) string {
+	if !col.Multi {
+		return "coalesce(" + cond(col.Expr) + ", false)"
+	}
+	return "EXISTS (SELECT 1 FROM unnest(" + col.Expr + ") AS v WHERE " + cond("v") + ")"
+}
+
+func (t *sqlTranslator) present(col Column) string {
+	return each(col, func(v string) string {
+		if col.Type == ColumnString || col.Type == ColumnCaseExact {
+			return v + " <> ''"
+		}
+		return v + " IS NOT NULL"
+	})
+}
+
+var sqlOperators = map[string]string{"eq": "=", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}
+
+func (t *sqlTranslator) compare(col Column, op string, value any) (string, error) {
+	switch col.Type {
+	case ColumnBool:
+		b, ok := value.(bool)
+		if !ok || op != "eq" {
+			return "false", nil
+		}
+		return each(col, func(v string) string { return v + " = " + t.arg(b) }), nil
+	case ColumnTime:
+		s, ok := value.(string)
+		if !ok {
+			return "false", nil
+		}
+		at, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return "", badRequest("invalidValue", "invalid dateTime %q", s)
+		}
+		sqlOp, ok := sqlOperators[op]
+		if !ok {
+			return "false", nil
+		}
+		return each(col, func(v string) string { return v + " " + sqlOp + " " + t.arg(at) }), nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "false", nil
+	}
+	lower := func(v string) string { return v }
+	if col.Type == ColumnString {
+		s = strings.ToLower(s)
+		lower = func(v string) string { return "lower(" + v + ")" }
+	}
+	if sqlOp, ok := sqlOperators[op]; ok {
+		return each(col, func(v string) string { return lower(v) + " " + sqlOp + " " + t.arg(s) }), nil
+	}
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	switch op {
+	case "co":
+		pattern = "%" + pattern + "%"
+	case "sw":
+		pattern = pattern + "%"
+	case "ew":
+		pattern = "%" + pattern
+	}
+	return each(col, func(v string) string { return lower(v) + " LIKE " + t.arg(pattern) }), nil
+}
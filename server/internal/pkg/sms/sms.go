@@ -0,0 +1,21 @@
+// Package sms delivers text messages, e.g. one time codes to verify phone numbers.
+package sms
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sender delivers a text message to a phone number
+type Sender interface {
+	Send(ctx context.Context, to, text string) error
+}
+
+// LogSender only logs the messages, use it for development and tests
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, to, text string) error {
+	logrus.Infof("sms to %s: %s", to, text)
+	return nil
+}
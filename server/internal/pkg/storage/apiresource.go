@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zitadel/oidc/v3/pkg/op"
+)
+
+var ErrAPIResourceNotFound = errors.New("api resource not found")
+
+// APIResource is a backend API of a namespace, clients pass its identifier as
+// resource parameter (RFC 8707) to get tokens only it accepts
+type APIResource struct {
+	ID          uuid.UUID
+	NamespaceID uuid.UUID
+	// Identifier is an absolute uri without fragment, the aud of its tokens
+	Identifier string
+	Name       string
+	// Scopes are the scopes of the api, clients which may request the api may request them
+	Scopes []string
+	// AccessTokenType is the preferred format of access tokens for the api
+	AccessTokenType op.AccessTokenType
+	CreateTime      time.Time
+}
+
+func (r *APIResource) Validate() error {
+	u, err := url.Parse(r.Identifier)
+	if err != nil {
+		return fmt.Errorf("identifier: %w", err)
+	}
+	if !u.IsAbs() || u.Fragment != "" {
+		return errors.New("identifier must be an absolute uri without fragment")
+	}
+	switch r.AccessTokenType {
+	case op.AccessTokenTypeBearer, op.AccessTokenTypeJWT:
+	default:
+		return errors.New("access_token_type must be 0 (bearer) or 1 (jwt)")
+	}
+	return nil
+}
+
+const apiResourceColumns = `
+		id,
+		namespace_id,
+		identifier,
+		name,
+		scopes,
+		access_token_type,
+		create_time
+`
+
+func scanAPIResource(row interface{ Scan(...any) error }) (*APIResource, error) {
+	r := &APIResource{}
+	var tokenType int
+	err := row.Scan(
+		&r.ID,
+		&r.NamespaceID,
+		&r.Identifier,
+		&r.Name,
+		pq.Array(&r.Scopes),
+		&tokenType,
+		&r.CreateTime,
+	)
+	r.AccessTokenType = op.AccessTokenType(tokenType)
+	return r, err
+}
+
+func (s *Storage) queryAPIResources(ctx context.Context, cmd string, args ...any) ([]*APIResource, error) {
+	rows, err := s.db.QueryContext(ctx, cmd, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var resources []*APIResource
+	for rows.Next() {
+		r, err := scanAPIResource(rows)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	return resources, rows.Err()
+}
+
+// ListAPIResources returns the api resources of a namespace by identifier
+func (s *Storage) ListAPIResources(ctx context.Context, namespace uuid.UUID) ([]*APIResource, error) {
+	return s.queryAPIResources(ctx, `
+	SELECT`+apiResourceColumns+`
+	FROM
+		api_resource
+	WHERE
+		namespace_id = $1
+	ORDER BY identifier
+	`, namespace)
+}
+
+func (s *Storage) GetAPIResource(ctx context.Context, id uuid.UUID) (*APIResource, error) {
+	cmd := `
+	SELECT` + apiResourceColumns + `
+	FROM
+		api_resource
+	WHERE
+		id = $1
+	`
+	r, err := scanAPIResource(s.db.QueryRowContext(ctx, cmd, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIResourceNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return r, nil
+}
+
+// SaveAPIResource creates the api resource if its id is nil, else updates it
+func (s *Storage) SaveAPIResource(ctx context.Context, r *APIResource) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+	if r.ID == uuid.Nil {
+		cmd := `
+		INSERT INTO api_resource (
+			namespace_id,
+			identifier,
+			name,
+			scopes,
+			access_token_type
+		) VALUES (
+			$1, $2, $3, $4, $5
+		) RETURNING id, create_time
+		`
+		err = s.db.QueryRowContext(ctx, cmd,
+			r.NamespaceID,
+			r.Identifier,
+			r.Name,
+			pq.Array(nonNilStrings(r.Scopes)),
+			int(r.AccessTokenType),
+		).Scan(&r.ID, &r.CreateTime)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		return nil
+	}
+
+	cmd := `
+	UPDATE api_resource
+	SET identifier = $2,
+		name = $3,
+		scopes = $4,
+		access_token_type = $5
+	WHERE id = $1
+	`
+	res, err := s.db.ExecContext(ctx, cmd,
+		r.ID,
+		r.Identifier,
+		r.Name,
+		pq.Array(nonNilStrings(r.Scopes)),
+		int(r.AccessTokenType),
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPIResourceNotFound
+	}
+	return nil
+}
+
+// DeleteAPIResource removes the api resource, clients listing it can not
+// request it anymore
+func (s *Storage) DeleteAPIResource(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `
+	DELETE FROM api_resource
+	WHERE id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPIResourceNotFound
+	}
+	return nil
+}
+
+// clientAPIResources returns the registered api resources of the identifiers a client lists
+func (s *Storage) clientAPIResources(ctx context.Context, namespace uuid.UUID, identifiers []string) (map[string]*APIResource, error) {
+	resources := map[string]*APIResource{}
+	if len(identifiers) == 0 {
+		return resources, nil
+	}
+	list, err := s.queryAPIResources(ctx, `
+	SELECT`+apiResourceColumns+`
+	FROM
+		api_resource
+	WHERE
+		namespace_id = $1
+	AND identifier = ANY($2)
+	`, namespace, pq.Array(identifiers))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range list {
+		resources[r.Identifier] = r
+	}
+	return resources, nil
+}
+
+// resourceRequest holds the resource parameters of an authorization or token
+// request, the library does not pass them to the storage
+type resourceRequest struct {
+	resources []string
+	// audience is what the access token of a token request is issued for
+	audience []string
+}
+
+type resourceRequestKey struct{}
+
+// WithResourceRequest passes the resource parameters of a request to the storage
+func WithResourceRequest(ctx context.Context, resources []string) context.Context {
+	return context.WithValue(ctx, resourceRequestKey{}, &resourceRequest{resources: resources})
+}
+
+func resourceRequestOf(ctx context.Context) *resourceRequest {
+	r, _ := ctx.Value(resourceRequestKey{}).(*resourceRequest)
+	return r
+}
+
+func errInvalidTarget(format string, args ...any) *oidc.Error {
+	return &oidc.Error{
+		ErrorType:   "invalid_target",
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// checkResources tells if the client may request the resources
+func (c *Client) checkResources(resources []string) error {
+	for _, r := range resources {
+		if _, ok := c.apiResources[r]; !ok {
+			return errInvalidTarget("the client may not request the resource %s", r)
+		}
+	}
+	return nil
+}
+
+// narrowResources picks the requested resources of a token request from the
+// granted audience, nil if the request has none
+func narrowResources(ctx context.Context, granted []string) ([]string, error) {
+	req := resourceRequestOf(ctx)
+	if req == nil {
+		return nil, nil
+	}
+	if len(req.resources) == 0 {
+		req.audience = granted
+		return nil, nil
+	}
+	for _, r := range req.resources {
+		if !contains(granted, r) {
+			return nil, errInvalidTarget("the resource %s was not granted", r)
+		}
+	}
+	req.audience = req.resources
+	return req.resources, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceTokenType is the access token format the api resources in the
+// audience of the token request agree on, ok is false if they don't or there
+// are none
+func (c *Client) resourceTokenType() (t op.AccessTokenType, ok bool) {
+	req := c.resourceRequest
+	if req == nil {
+		return 0, false
+	}
+	for _, aud := range req.audience {
+		r, found := c.apiResources[aud]
+		if !found {
+			continue
+		}
+		if ok && r.AccessTokenType != t {
+			return 0, false
+		}
+		t, ok = r.AccessTokenType, true
+	}
+	return t, ok
+}
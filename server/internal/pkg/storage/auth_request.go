@@ -9,6 +9,7 @@ import (
 	. "github.com/go-jet/jet/v2/postgres"
 	"github.com/go-jet/jet/v2/qrm"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	"github.com/zitadel/oidc/v3/pkg/oidc"
 	"github.com/zltl/xoidc/server/gen/xoidc/public/model"
@@ -38,6 +39,39 @@ func (s *Storage) TXGetAuthRequestByUUID(ctx context.Context, tx qrm.DB, id uuid
 		UserID:       res.UserID,
 		IsDone:       res.Done,
 		AuthTime:     res.AuthTime,
+		SessionID:    res.SessionID,
+
+		DetailsApproved: res.DetailsApproved,
+	}
+
+	var amr pq.StringArray
+	err = amr.Scan(res.Amr)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	a.AMR = amr
+
+	var audience pq.StringArray
+	err = audience.Scan(res.Audience)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	a.Audience = audience
+
+	var resources pq.StringArray
+	err = resources.Scan(res.Resources)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	a.Resources = resources
+
+	a.AuthorizationDetails, err = ParseAuthorizationDetails(res.AuthorizationDetails)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
 	}
 
 	err = a.SetContent(res.Content)
@@ -57,14 +91,24 @@ INSERT INTO auth_request (
     user_id,
     done,
     auth_time,
-    content
+    content,
+    amr,
+    audience,
+    resources,
+    request_uri,
+    authorization_details
 ) VALUES (
     gen_random_uuid(),
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 ) RETURNING id
 `
 	var uid uuid.UUID
@@ -76,6 +120,11 @@ INSERT INTO auth_request (
 		a.IsDone,
 		a.AuthTime,
 		a.Content(),
+		pq.Array(a.AMR),
+		pq.Array(nonNilStrings(a.Audience)),
+		pq.Array(nonNilStrings(a.Resources)),
+		a.RequestURI,
+		a.AuthorizationDetails.String(),
 	)
 	if err != nil {
 		logrus.Error(err)
@@ -99,17 +148,21 @@ func (s *Storage) TXUpdateAuthRequest(ctx context.Context, tx qrm.DB, a *AuthReq
 UPDATE auth_request
 SET user_id=$1,
     done=$2,
-    auth_time=$3
+    auth_time=$3,
+    amr=$4,
+    details_approved=$6
 WHERE
-    id=$4
+    id=$5
 `
 	_, err := tx.ExecContext(
 		ctx,
 		stmt,
 		a.UserID,
-		a.Done(),
+		a.IsDone,
 		a.AuthTime,
+		pq.Array(a.AMR),
 		a.ID,
+		a.DetailsApproved,
 	)
 	if err != nil {
 		logrus.Error(err)
@@ -164,6 +217,26 @@ type AuthRequest struct {
 	UserID       uuid.UUID
 	IsDone       bool
 	AuthTime     time.Time
+	// AMR lists the authentication methods the user passed so far,
+	// e.g. ["pwd"] while a second factor is pending and ["pwd", "hwk", "mfa"] once done
+	AMR []string
+	// Audience are the audiences of the tokens besides the client
+	Audience []string
+	// Resources are the resource parameters of the request, the audience of
+	// its tokens instead if not empty
+	Resources []string
+	// narrowed are the resources of the token request, a subset of Resources
+	narrowed []string
+	// RequestURI is the request_uri of pushed requests the client did not use yet
+	RequestURI string
+	// AuthorizationDetails are the authorization_details of the request (RFC 9396)
+	AuthorizationDetails AuthorizationDetails
+	// DetailsApproved tells if the user approved the AuthorizationDetails on the consent screen
+	DetailsApproved bool
+	// narrowedDetails are the authorization details of the token request, a subset of AuthorizationDetails
+	narrowedDetails AuthorizationDetails
+	// SessionID is the user_session the login started, uuid.Nil before
+	SessionID uuid.UUID
 }
 
 func (a *AuthRequest) GetID() string {
@@ -175,15 +248,28 @@ func (a *AuthRequest) GetACR() string {
 }
 
 func (a *AuthRequest) GetAMR() []string {
-	// this example only uses password for authentication
 	if a.IsDone {
-		return []string{"pwd"}
+		return a.AMR
 	}
 	return nil
 }
 
+// GetAudience has the client for the id token, and the resources of the
+// request instead of the other audiences if it has some
 func (a *AuthRequest) GetAudience() []string {
-	return []string{a.AuthReq.ClientID} // always just use the client_id as audience
+	if len(a.narrowed) > 0 {
+		return append([]string{a.AuthReq.ClientID}, a.narrowed...)
+	}
+	return a.grantedAudience()
+}
+
+// grantedAudience is the audience of the whole grant, refresh tokens keep it
+// while token requests may narrow their access tokens to some resources
+func (a *AuthRequest) grantedAudience() []string {
+	if len(a.Resources) > 0 {
+		return append([]string{a.AuthReq.ClientID}, a.Resources...)
+	}
+	return append([]string{a.AuthReq.ClientID}, a.Audience...)
 }
 
 func (a *AuthRequest) GetAuthTime() time.Time {
@@ -213,8 +299,15 @@ func (a *AuthRequest) GetResponseType() oidc.ResponseType {
 	return a.AuthReq.ResponseType
 }
 
+// GetResponseMode only tells the library the modes it handles itself, the
+// others are the defaults of the response type to the library and rewritten by
+// the authorization response handler of the server
 func (a *AuthRequest) GetResponseMode() oidc.ResponseMode {
-	return "" // we won't handle response mode in this example
+	switch a.AuthReq.ResponseMode {
+	case oidc.ResponseModeQuery, oidc.ResponseModeFragment:
+		return a.AuthReq.ResponseMode
+	}
+	return ""
 }
 
 func (a *AuthRequest) GetScopes() []string {
@@ -229,8 +322,18 @@ func (a *AuthRequest) GetSubject() string {
 	return a.UserID.String()
 }
 
+// Done tells if the user signed in, and approved the authorization details if
+// the request has some
 func (a *AuthRequest) Done() bool {
-	return a.IsDone
+	return a.IsDone && (len(a.AuthorizationDetails) == 0 || a.DetailsApproved)
+}
+
+// GetAuthorizationDetails are the authorization details of the tokens of the request
+func (a *AuthRequest) GetAuthorizationDetails() AuthorizationDetails {
+	if len(a.narrowedDetails) > 0 {
+		return a.narrowedDetails
+	}
+	return a.AuthorizationDetails
 }
 
 func (a *AuthRequest) Content() string {
@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zltl/xoidc/server/internal/pkg/jsonschema"
+)
+
+var ErrAuthorizationDetailsTypeNotFound = errors.New("authorization details type not found")
+
+// ClaimAuthorizationDetails lists the authorization details in JWT access
+// tokens and introspection responses
+const ClaimAuthorizationDetails = "authorization_details"
+
+// AuthorizationDetailsType is a type of the authorization_details parameter
+// (RFC 9396) clients of the namespace may request, its entries must match the schema
+type AuthorizationDetailsType struct {
+	ID          uuid.UUID
+	NamespaceID uuid.UUID
+	Type        string
+	// Description tells the users on the consent screen what the type is about
+	Description string
+	// Schema is the JSON schema of the entries of the type
+	Schema     json.RawMessage
+	CreateTime time.Time
+}
+
+func (t *AuthorizationDetailsType) Validate() error {
+	if t.Type == "" {
+		return errors.New("type is required")
+	}
+	if len(t.Schema) == 0 {
+		return errors.New("schema is required")
+	}
+	_, err := jsonschema.Compile(t.Schema)
+	if err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+	return nil
+}
+
+const authorizationDetailsTypeColumns = `
+		id,
+		namespace_id,
+		type,
+		description,
+		schema,
+		create_time
+`
+
+func scanAuthorizationDetailsType(row interface{ Scan(...any) error }) (*AuthorizationDetailsType, error) {
+	t := &AuthorizationDetailsType{}
+	var schema string
+	err := row.Scan(
+		&t.ID,
+		&t.NamespaceID,
+		&t.Type,
+		&t.Description,
+		&schema,
+		&t.CreateTime,
+	)
+	t.Schema = json.RawMessage(schema)
+	return t, err
+}
+
+func (s *Storage) queryAuthorizationDetailsTypes(ctx context.Context, cmd string, args ...any) ([]*AuthorizationDetailsType, error) {
+	rows, err := s.db.QueryContext(ctx, cmd, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var types []*AuthorizationDetailsType
+	for rows.Next() {
+		t, err := scanAuthorizationDetailsType(rows)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+// ListAuthorizationDetailsTypes returns the authorization details types of a namespace by type
+func (s *Storage) ListAuthorizationDetailsTypes(ctx context.Context, namespace uuid.UUID) ([]*AuthorizationDetailsType, error) {
+	return s.queryAuthorizationDetailsTypes(ctx, `
+	SELECT`+authorizationDetailsTypeColumns+`
+	FROM
+		authorization_details_type
+	WHERE
+		namespace_id = $1
+	ORDER BY type
+	`, namespace)
+}
+
+func (s *Storage) GetAuthorizationDetailsType(ctx context.Context, id uuid.UUID) (*AuthorizationDetailsType, error) {
+	cmd := `
+	SELECT` + authorizationDetailsTypeColumns + `
+	FROM
+		authorization_details_type
+	WHERE
+		id = $1
+	`
+	t, err := scanAuthorizationDetailsType(s.db.QueryRowContext(ctx, cmd, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAuthorizationDetailsTypeNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return t, nil
+}
+
+// SaveAuthorizationDetailsType creates the type if its id is nil, else updates it
+func (s *Storage) SaveAuthorizationDetailsType(ctx context.Context, t *AuthorizationDetailsType) error {
+	err := t.Validate()
+	if err != nil {
+		return err
+	}
+	if t.ID == uuid.Nil {
+		cmd := `
+		INSERT INTO authorization_details_type (
+			namespace_id,
+			type,
+			description,
+			schema
+		) VALUES (
+			$1, $2, $3, $4
+		) RETURNING id, create_time
+		`
+		err = s.db.QueryRowContext(ctx, cmd,
+			t.NamespaceID,
+			t.Type,
+			t.Description,
+			string(t.Schema),
+		).Scan(&t.ID, &t.CreateTime)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		return nil
+	}
+
+	cmd := `
+	UPDATE authorization_details_type
+	SET type = $2,
+		description = $3,
+		schema = $4
+	WHERE id = $1
+	`
+	res, err := s.db.ExecContext(ctx, cmd,
+		t.ID,
+		t.Type,
+		t.Description,
+		string(t.Schema),
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAuthorizationDetailsTypeNotFound
+	}
+	return nil
+}
+
+// DeleteAuthorizationDetailsType removes the type, clients can not request it
+// anymore while the grants of before keep their details
+func (s *Storage) DeleteAuthorizationDetailsType(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `
+	DELETE FROM authorization_details_type
+	WHERE id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAuthorizationDetailsTypeNotFound
+	}
+	return nil
+}
+
+// AuthorizationDetailsTypesSupported returns the types of all namespaces for the discovery document
+func (s *Storage) AuthorizationDetailsTypesSupported(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT DISTINCT type
+	FROM
+		authorization_details_type
+	ORDER BY type
+	`)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var types []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+// namespaceAuthorizationDetailsTypes returns the registered types of the namespace by type
+func (s *Storage) namespaceAuthorizationDetailsTypes(ctx context.Context, namespace uuid.UUID, types []string) (map[string]*AuthorizationDetailsType, error) {
+	list, err := s.queryAuthorizationDetailsTypes(ctx, `
+	SELECT`+authorizationDetailsTypeColumns+`
+	FROM
+		authorization_details_type
+	WHERE
+		namespace_id = $1
+	AND type = ANY($2)
+	`, namespace, pq.Array(types))
+	if err != nil {
+		return nil, err
+	}
+	registered := map[string]*AuthorizationDetailsType{}
+	for _, t := range list {
+		registered[t.Type] = t
+	}
+	return registered, nil
+}
+
+// AuthorizationDetails is the value of the authorization_details parameter,
+// objects with at least a type
+type AuthorizationDetails []map[string]any
+
+// ParseAuthorizationDetails parses the JSON array of the parameter, the empty string has none
+func ParseAuthorizationDetails(s string) (AuthorizationDetails, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var details AuthorizationDetails
+	err := json.Unmarshal([]byte(s), &details)
+	if err != nil {
+		return nil, errors.New("authorization_details must be a JSON array of objects")
+	}
+	for i, d := range details {
+		t, _ := d["type"].(string)
+		if t == "" {
+			return nil, fmt.Errorf("authorization_details[%d] has no type", i)
+		}
+	}
+	return details, nil
+}
+
+// String is the JSON array, empty if there are no details
+func (d AuthorizationDetails) String() string {
+	if len(d) == 0 {
+		return ""
+	}
+	js, err := json.Marshal(d)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	return string(js)
+}
+
+// Types are the distinct types of the details
+func (d AuthorizationDetails) Types() []string {
+	var types []string
+	for _, detail := range d {
+		t, _ := detail["type"].(string)
+		if !contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// contains tells if the detail is one of the details
+func (d AuthorizationDetails) contains(detail map[string]any) bool {
+	for _, v := range d {
+		if reflect.DeepEqual(v, detail) {
+			return true
+		}
+	}
+	return false
+}
+
+func errInvalidAuthorizationDetails(format string, args ...any) *oidc.Error {
+	return &oidc.Error{
+		ErrorType:   "invalid_authorization_details",
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// checkAuthorizationDetails tells if the details are of registered types of the
+// namespace and match their schemas
+func (s *Storage) checkAuthorizationDetails(ctx context.Context, namespace uuid.UUID, details AuthorizationDetails) error {
+	if len(details) == 0 {
+		return nil
+	}
+	registered, err := s.namespaceAuthorizationDetailsTypes(ctx, namespace, details.Types())
+	if err != nil {
+		return oidc.ErrServerError().WithParent(err)
+	}
+	for i, d := range details {
+		t, ok := registered[d["type"].(string)]
+		if !ok {
+			return errInvalidAuthorizationDetails("the type %v of authorization_details[%d] is not supported", d["type"], i)
+		}
+		schema, err := jsonschema.Compile(t.Schema)
+		if err != nil {
+			return oidc.ErrServerError().WithParent(err)
+		}
+		if err := schema.Validate(map[string]any(d)); err != nil {
+			return errInvalidAuthorizationDetails("authorization_details[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// authorizationDetailsRequest holds the authorization_details parameter of an
+// authorization or token request, the library does not pass it to the storage
+type authorizationDetailsRequest struct {
+	param string
+	// issued are the details of the tokens of a token request, the token response lists them
+	issued AuthorizationDetails
+}
+
+type authorizationDetailsRequestKey struct{}
+
+// WithAuthorizationDetails passes the authorization_details parameter of a request to the storage
+func WithAuthorizationDetails(ctx context.Context, param string) context.Context {
+	return context.WithValue(ctx, authorizationDetailsRequestKey{}, &authorizationDetailsRequest{param: param})
+}
+
+func authorizationDetailsRequestOf(ctx context.Context) *authorizationDetailsRequest {
+	r, _ := ctx.Value(authorizationDetailsRequestKey{}).(*authorizationDetailsRequest)
+	return r
+}
+
+// IssuedAuthorizationDetails returns the authorization details of the tokens
+// the storage issued for the request, none if it has none
+func IssuedAuthorizationDetails(ctx context.Context) AuthorizationDetails {
+	if req := authorizationDetailsRequestOf(ctx); req != nil {
+		return req.issued
+	}
+	return nil
+}
+
+// issueAuthorizationDetails remembers the details of the issued tokens for the
+// token response and the claims of JWT access tokens
+func issueAuthorizationDetails(ctx context.Context, details AuthorizationDetails) {
+	if req := authorizationDetailsRequestOf(ctx); req != nil {
+		req.issued = details
+	}
+}
+
+// requestedAuthorizationDetails parses and checks the authorization_details of
+// the request for a client of the namespace, nil if the request has none
+func (s *Storage) requestedAuthorizationDetails(ctx context.Context, namespace uuid.UUID) (AuthorizationDetails, error) {
+	req := authorizationDetailsRequestOf(ctx)
+	if req == nil {
+		return nil, nil
+	}
+	return s.parseAuthorizationDetails(ctx, namespace, req.param)
+}
+
+// parseAuthorizationDetails parses and checks the parameter for a client of the namespace
+func (s *Storage) parseAuthorizationDetails(ctx context.Context, namespace uuid.UUID, param string) (AuthorizationDetails, error) {
+	details, err := ParseAuthorizationDetails(param)
+	if err != nil {
+		return nil, errInvalidAuthorizationDetails("%v", err)
+	}
+	err = s.checkAuthorizationDetails(ctx, namespace, details)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// narrowAuthorizationDetails picks the requested details of a token request from
+// the granted ones, nil if the request has none
+func narrowAuthorizationDetails(ctx context.Context, granted AuthorizationDetails) (AuthorizationDetails, error) {
+	req := authorizationDetailsRequestOf(ctx)
+	if req == nil || req.param == "" {
+		return nil, nil
+	}
+	details, err := ParseAuthorizationDetails(req.param)
+	if err != nil {
+		return nil, errInvalidAuthorizationDetails("%v", err)
+	}
+	for i, d := range details {
+		if !granted.contains(d) {
+			return nil, errInvalidAuthorizationDetails("authorization_details[%d] was not granted", i)
+		}
+	}
+	return details, nil
+}
+
+// authorizationDetailsOf are the authorization details of the tokens of the request
+func authorizationDetailsOf(req any) AuthorizationDetails {
+	if r, ok := req.(interface {
+		GetAuthorizationDetails() AuthorizationDetails
+	}); ok {
+		return r.GetAuthorizationDetails()
+	}
+	return nil
+}
+
+// grantedAuthorizationDetails are the authorization details the refresh token of the request keeps
+func grantedAuthorizationDetails(req any) AuthorizationDetails {
+	if authReq, ok := req.(*AuthRequest); ok {
+		return authReq.AuthorizationDetails
+	}
+	return authorizationDetailsOf(req)
+}
+
+// DescribedAuthorizationDetail is an authorization detail with the description
+// of its type, for the consent screen
+type DescribedAuthorizationDetail struct {
+	Type        string
+	Description string
+	Detail      map[string]any
+}
+
+// PendingAuthorizationDetails returns the authorization details of the auth
+// request the user did not approve yet, none if there is nothing to approve
+func (s *Storage) PendingAuthorizationDetails(ctx context.Context, id string) ([]DescribedAuthorizationDetail, error) {
+	rid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	request, err := s.GetAuthRequestByUUID(ctx, rid)
+	if err != nil {
+		return nil, errors.New("request not found")
+	}
+	if request.DetailsApproved || len(request.AuthorizationDetails) == 0 {
+		return nil, nil
+	}
+	client, err := s.GetClient(ctx, request.GetClientID())
+	if err != nil {
+		return nil, err
+	}
+	registered, err := s.namespaceAuthorizationDetailsTypes(ctx, client.userNamespaceID, request.AuthorizationDetails.Types())
+	if err != nil {
+		return nil, err
+	}
+	var described []DescribedAuthorizationDetail
+	for _, d := range request.AuthorizationDetails {
+		t, _ := d["type"].(string)
+		desc := DescribedAuthorizationDetail{Type: t, Detail: d}
+		if r, ok := registered[t]; ok {
+			desc.Description = r.Description
+		}
+		described = append(described, desc)
+	}
+	return described, nil
+}
+
+// ApproveAuthorizationDetails records that the signed in user of the auth
+// request approved its authorization details
+func (s *Storage) ApproveAuthorizationDetails(ctx context.Context, id string, userID uuid.UUID) error {
+	rid, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `
+	UPDATE auth_request
+	SET details_approved = true
+	WHERE id = $1
+	AND user_id = $2
+	AND done
+	`, rid, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("request not found")
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package storage
+
+import "testing"
+
+func TestAuthorizationDetailsTypeValidate(t *testing.T) {
+	tests := []struct {
+		schema string
+		valid  bool
+	}{
+		{`{"type": "object", "properties": {"amount": {"type": "number", "minimum": 0}}}`, true},
+		// keywords the validator does not know must not be ignored
+		{`{"type": "object", "properties": {"amount": {"type": "number", "exclusiveMinimum": 0}}}`, false},
+		{`{"type": "array", "uniqueItems": true}`, false},
+		{`{"type": "object", "patternProperties": {"^x-": false}}`, false},
+		{`{"if": {"required": ["a"]}, "then": {"required": ["b"]}}`, false},
+	}
+	for _, tt := range tests {
+		typ := &AuthorizationDetailsType{Type: "payment_initiation", Schema: []byte(tt.schema)}
+		if err := typ.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: valid %v, got %v", tt.schema, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zltl/xoidc/server/internal/pkg/ciba"
+)
+
+const (
+	// BackchannelAuthRequestLifetime is how long users have to approve a
+	// backchannel authentication request, clients may ask for less with requested_expiry
+	BackchannelAuthRequestLifetime = 5 * time.Minute
+	// BackchannelPollInterval is the interval of token requests clients start with, slow_down adds to it
+	BackchannelPollInterval = 5 * time.Second
+)
+
+// the status of a backchannel authentication request
+const (
+	BackchannelPending  = "pending"
+	BackchannelApproved = "approved"
+	BackchannelDenied   = "denied"
+	// issued requests got their tokens, an auth_req_id is used only once
+	BackchannelIssued = "issued"
+)
+
+// ClaimAuthReqID is the claim of the id tokens of push deliveries naming the request
+const ClaimAuthReqID = "urn:openid:params:jwt:claim:auth_req_id"
+
+var ErrBackchannelAuthRequestNotFound = errors.New("the backchannel authentication request is invalid or expired")
+
+// BackchannelAuthRequest is a client initiated backchannel authentication
+// request (CIBA), the client waits until the user approved it on the own device
+type BackchannelAuthRequest struct {
+	ID                      uuid.UUID
+	ClientID                uuid.UUID
+	UserID                  uuid.UUID
+	Scopes                  []string
+	BindingMessage          string
+	ClientNotificationToken string
+	Status                  string
+	AMR                     []string
+	AuthTime                time.Time
+	PollInterval            time.Duration
+	LastPollTime            time.Time
+	Expiration              time.Time
+	CreateTime              time.Time
+
+	// Audience of the tokens, set when the request is issued
+	Audience []string
+	// Push is set on push deliveries, their id token names the request and
+	// has the hash of the RefreshToken
+	Push         bool
+	RefreshToken string
+}
+
+func (r *BackchannelAuthRequest) GetAMR() []string {
+	return r.AMR
+}
+
+func (r *BackchannelAuthRequest) GetAudience() []string {
+	return append([]string{r.ClientID.String()}, r.Audience...)
+}
+
+func (r *BackchannelAuthRequest) GetAuthTime() time.Time {
+	return r.AuthTime
+}
+
+func (r *BackchannelAuthRequest) GetClientID() string {
+	return r.ClientID.String()
+}
+
+func (r *BackchannelAuthRequest) GetScopes() []string {
+	return r.Scopes
+}
+
+func (r *BackchannelAuthRequest) GetSubject() string {
+	return r.UserID.String()
+}
+
+// Expired tells if the user can no longer approve the request
+func (r *BackchannelAuthRequest) Expired() bool {
+	return time.Now().After(r.Expiration)
+}
+
+const backchannelAuthRequestColumns = `
+		id,
+		client_id,
+		user_id,
+		scopes,
+		binding_message,
+		client_notification_token,
+		status,
+		amr,
+		auth_time,
+		poll_interval,
+		last_poll_time,
+		expiration,
+		create_time
+`
+
+func scanBackchannelAuthRequest(row interface{ Scan(...any) error }) (*BackchannelAuthRequest, error) {
+	r := &BackchannelAuthRequest{}
+	var interval int
+	err := row.Scan(
+		&r.ID,
+		&r.ClientID,
+		&r.UserID,
+		pq.Array(&r.Scopes),
+		&r.BindingMessage,
+		&r.ClientNotificationToken,
+		&r.Status,
+		pq.Array(&r.AMR),
+		&r.AuthTime,
+		&interval,
+		&r.LastPollTime,
+		&r.Expiration,
+		&r.CreateTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	r.PollInterval = time.Duration(interval) * time.Second
+	return r, nil
+}
+
+// BackchannelUser returns the user a client asks to authenticate, by the
+// username of the login_hint or the subject of the id_token_hint
+func (s *Storage) BackchannelUser(ctx context.Context, clientID, loginHint, subject string) (*User, error) {
+	client, err := s.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	var u *User
+	if subject != "" {
+		var id uuid.UUID
+		id, err = uuid.Parse(subject)
+		if err == nil {
+			u, err = s.GetUserByID(ctx, id)
+		}
+	} else {
+		u, err = s.GetUserByUsername(ctx, loginHint, client.id)
+	}
+	if errors.Is(err, sql.ErrNoRows) || err == nil && u.NamespaceID != client.userNamespaceID {
+		return nil, errUnknownUserID()
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	if u.Status != UserStatusActive {
+		return nil, errUnknownUserID()
+	}
+	return u, nil
+}
+
+func errUnknownUserID() *oidc.Error {
+	return &oidc.Error{
+		ErrorType:   "unknown_user_id",
+		Description: "the user of the hint is unknown",
+	}
+}
+
+// CreateBackchannelAuthRequest checks the client may use the ciba grant with
+// the scopes and stores the pending request
+func (s *Storage) CreateBackchannelAuthRequest(ctx context.Context, r *BackchannelAuthRequest) error {
+	client, err := s.GetClientByUUID(ctx, r.ClientID)
+	if err != nil {
+		return err
+	}
+	granted := false
+	for _, g := range client.grantTypes {
+		granted = granted || g == ciba.GrantType
+	}
+	if !granted || client.backchannelTokenDeliveryMode == "" {
+		return oidc.ErrUnauthorizedClient().WithDescription("the client may not use the ciba grant")
+	}
+	if client.backchannelTokenDeliveryMode != ciba.ModePoll && r.ClientNotificationToken == "" {
+		return oidc.ErrInvalidRequest().WithDescription("client_notification_token is required")
+	}
+	r.Scopes, err = client.CheckScopes(r.Scopes)
+	if err != nil {
+		return err
+	}
+	if !contains(r.Scopes, oidc.ScopeOpenID) {
+		return oidc.ErrInvalidScope().WithDescription("the scope openid is required")
+	}
+	if r.Expiration.IsZero() || r.Expiration.After(time.Now().Add(BackchannelAuthRequestLifetime)) {
+		r.Expiration = time.Now().Add(BackchannelAuthRequestLifetime)
+	}
+	r.PollInterval = BackchannelPollInterval
+	r.Status = BackchannelPending
+
+	cmd := `
+	INSERT INTO backchannel_auth_request (
+		client_id,
+		user_id,
+		scopes,
+		binding_message,
+		client_notification_token,
+		poll_interval,
+		expiration
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	) RETURNING id, create_time
+	`
+	err = s.db.QueryRowContext(ctx, cmd,
+		r.ClientID,
+		r.UserID,
+		pq.Array(r.Scopes),
+		r.BindingMessage,
+		r.ClientNotificationToken,
+		int(r.PollInterval.Seconds()),
+		r.Expiration,
+	).Scan(&r.ID, &r.CreateTime)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// PendingBackchannelAuthRequest returns a request the user has not decided yet
+func (s *Storage) PendingBackchannelAuthRequest(ctx context.Context, id uuid.UUID) (*BackchannelAuthRequest, error) {
+	cmd := `
+	SELECT` + backchannelAuthRequestColumns + `	FROM
+		backchannel_auth_request
+	WHERE
+		id = $1
+	AND status = 'pending'
+	AND expiration > now()
+	`
+	r, err := scanBackchannelAuthRequest(s.db.QueryRowContext(ctx, cmd, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBackchannelAuthRequestNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return r, nil
+}
+
+// DecideBackchannelAuthRequest approves or denies the pending request of the
+// user of the session, approved requests are authenticated like the session
+func (s *Storage) DecideBackchannelAuthRequest(ctx context.Context, id, sessionID uuid.UUID, approve bool) (*BackchannelAuthRequest, error) {
+	status := BackchannelDenied
+	if approve {
+		status = BackchannelApproved
+	}
+	cmd := `
+	UPDATE backchannel_auth_request
+	SET status = $3,
+		amr = user_session.amr,
+		auth_time = user_session.create_time
+	FROM
+		user_session
+	WHERE
+		backchannel_auth_request.id = $1
+	AND user_session.id = $2
+	AND user_session.user_id = backchannel_auth_request.user_id
+	AND user_session.expiration > now()
+	AND backchannel_auth_request.status = 'pending'
+	AND backchannel_auth_request.expiration > now()
+	RETURNING
+		backchannel_auth_request.id,
+		backchannel_auth_request.client_id,
+		backchannel_auth_request.user_id,
+		backchannel_auth_request.scopes,
+		backchannel_auth_request.binding_message,
+		backchannel_auth_request.client_notification_token,
+		backchannel_auth_request.status,
+		backchannel_auth_request.amr,
+		backchannel_auth_request.auth_time,
+		backchannel_auth_request.poll_interval,
+		backchannel_auth_request.last_poll_time,
+		backchannel_auth_request.expiration,
+		backchannel_auth_request.create_time
+	`
+	r, err := scanBackchannelAuthRequest(s.db.QueryRowContext(ctx, cmd, id, sessionID, status))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBackchannelAuthRequestNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return r, nil
+}
+
+// BackchannelTokenRequest returns the approved request of a token request of
+// the client and marks it issued. Until the user decides the client gets
+// authorization_pending, or slow_down if it polls faster than the interval.
+func (s *Storage) BackchannelTokenRequest(ctx context.Context, authReqID, clientID string) (*BackchannelAuthRequest, error) {
+	id, err := uuid.Parse(authReqID)
+	if err != nil {
+		return nil, oidc.ErrInvalidGrant().WithDescription(ErrBackchannelAuthRequestNotFound.Error())
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer tx.Rollback()
+	cmd := `
+	SELECT` + backchannelAuthRequestColumns + `	FROM
+		backchannel_auth_request
+	WHERE
+		id = $1
+	AND client_id::text = $2
+	FOR UPDATE
+	`
+	r, err := scanBackchannelAuthRequest(tx.QueryRowContext(ctx, cmd, id, clientID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oidc.ErrInvalidGrant().WithDescription(ErrBackchannelAuthRequestNotFound.Error())
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	switch {
+	case r.Status == BackchannelIssued:
+		return nil, oidc.ErrInvalidGrant().WithDescription("the auth_req_id was used already")
+	case r.Status == BackchannelDenied:
+		return nil, oidc.ErrAccessDenied().WithDescription("the user denied the request")
+	case r.Status == BackchannelPending && r.Expired():
+		return nil, oidc.ErrExpiredDeviceCode().WithDescription("the user did not approve the request in time")
+	case r.Status == BackchannelPending:
+		pollErr := oidc.ErrAuthorizationPending()
+		interval := r.PollInterval
+		if time.Since(r.LastPollTime) < r.PollInterval {
+			pollErr = oidc.ErrSlowDown()
+			interval += BackchannelPollInterval
+		}
+		_, err = tx.ExecContext(ctx, `
+		UPDATE backchannel_auth_request
+		SET last_poll_time = now(),
+			poll_interval = $2
+		WHERE id = $1
+		`, id, int(interval.Seconds()))
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		err = tx.Commit()
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		return nil, pollErr
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE backchannel_auth_request SET status = 'issued' WHERE id = $1`, id)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	r.Status = BackchannelIssued
+	client, err := s.GetClientByUUID(ctx, r.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	r.Audience = client.audiences
+	return r, nil
+}
+
+// DeleteExpiredBackchannelAuthRequests removes the requests nobody can use anymore
+func (s *Storage) DeleteExpiredBackchannelAuthRequests(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+	DELETE FROM backchannel_auth_request
+	WHERE expiration < $1
+	`, time.Now().Add(-BackchannelAuthRequestLifetime))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// setBackchannelClaims adds the claims of push deliveries to their id token
+func (s *Storage) setBackchannelClaims(ctx context.Context, userinfo *oidc.UserInfo, r *BackchannelAuthRequest) error {
+	if !r.Push {
+		return nil
+	}
+	userinfo.AppendClaims(ClaimAuthReqID, r.ID.String())
+	if r.RefreshToken == "" {
+		return nil
+	}
+	key, err := s.SigningKey(ctx)
+	if err != nil {
+		return err
+	}
+	hash, err := oidc.ClaimHash(r.RefreshToken, key.SignatureAlgorithm())
+	if err != nil {
+		return err
+	}
+	userinfo.AppendClaims("urn:openid:params:jwt:claim:rt_hash", hash)
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+	"github.com/zltl/xoidc/server/internal/pkg/dpop"
+)
+
+// tokenBinding is what the tokens of a token request are bound to, the key of
+// its DPoP proof and the certificate the client presented, empty for bearer tokens
+type tokenBinding struct {
+	jkt string
+	x5t string
+	// refreshToken tells if the refresh token is bound to the DPoP key too, only
+	// public clients get them, confidential clients authenticate when they use
+	// refresh tokens (RFC 9449 section 5)
+	refreshToken bool
+}
+
+// bindTokens returns the binding of the tokens the client gets for the request
+func (s *Storage) bindTokens(ctx context.Context, clientID string) (tokenBinding, error) {
+	b := tokenBinding{jkt: dpopOf(ctx)}
+	if clientID == "" {
+		return b, nil
+	}
+	client, err := s.GetClient(ctx, clientID)
+	if err != nil {
+		return tokenBinding{}, err
+	}
+	if b.jkt == "" && client.dpopBoundAccessTokens {
+		return tokenBinding{}, dpop.ErrInvalidProof("the client must send a DPoP proof")
+	}
+	if client.tlsClientCertificateBoundAccessTokens {
+		b.x5t = certificateThumbprintOf(ctx)
+		if b.x5t == "" {
+			return tokenBinding{}, oidc.ErrInvalidRequest().WithDescription("the client must present its certificate")
+		}
+	}
+	b.refreshToken = b.jkt != "" && client.AuthMethod() == oidc.AuthMethodNone
+	return b, nil
+}
+
+// checkBinding tells if the request may use a token bound to the DPoP key and the certificate
+func checkBinding(ctx context.Context, jkt, x5t string) error {
+	if err := checkDPoPBinding(ctx, jkt); err != nil {
+		return err
+	}
+	if x5t != "" && certificateThumbprintOf(ctx) != x5t {
+		return &oidc.Error{
+			ErrorType:   "invalid_token",
+			Description: "the token is bound to another client certificate",
+		}
+	}
+	return nil
+}
+
+// confirmation is the cnf claim of bound tokens, nil for bearer tokens
+func confirmation(jkt, x5t string) map[string]any {
+	cnf := map[string]any{}
+	if jkt != "" {
+		// RFC 9449
+		cnf["jkt"] = jkt
+	}
+	if x5t != "" {
+		// RFC 8705
+		cnf["x5t#S256"] = x5t
+	}
+	if len(cnf) == 0 {
+		return nil
+	}
+	return cnf
+}
@@ -0,0 +1,535 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/bootstrap"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"github.com/zltl/xoidc/server/internal/pkg/transfer"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+// PlanBootstrap compares a bootstrap file with the database and returns the changes
+// reconciling them. With prune the scopes and clients of the declared namespaces the
+// file does not name are deleted, but not the dynamically registered clients, and
+// their admins it does not name lose the flag.
+// Users and namespaces missing in the file are never deleted.
+func (s *Storage) PlanBootstrap(ctx context.Context, cfg *bootstrap.Config, prune bool) (*bootstrap.Plan, error) {
+	plan := &bootstrap.Plan{}
+	for _, ns := range cfg.Namespaces {
+		steps := []func(context.Context, *bootstrap.Plan, *bootstrap.Namespace, bool) error{
+			s.planNamespace,
+			s.planPolicies,
+			s.planScopes,
+			s.planClients,
+			s.planUsers,
+		}
+		for _, step := range steps {
+			err := step(ctx, plan, ns, prune)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plan, nil
+}
+
+// ApplyBootstrap applies a plan in one transaction, a failing change
+// rolls back the whole plan
+func (s *Storage) ApplyBootstrap(ctx context.Context, plan *bootstrap.Plan) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		return plan.Apply(ctx, tx)
+	})
+}
+
+// inTx runs fn in a transaction committed if fn succeeds
+func (s *Storage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) planNamespace(ctx context.Context, plan *bootstrap.Plan, ns *bootstrap.Namespace, _ bool) error {
+	id := uuid.MustParse(ns.ID)
+	var name string
+	err := s.db.QueryRowContext(ctx, `SELECT name FROM namespace WHERE id = $1`, id).Scan(&name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logrus.Error(err)
+		return err
+	}
+	if err == nil && name == ns.Name {
+		return nil
+	}
+	c := &bootstrap.Change{
+		Action:    bootstrap.ActionCreate,
+		Kind:      bootstrap.KindNamespace,
+		Namespace: ns.Name,
+		Key:       ns.ID,
+		Run: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `
+			INSERT INTO namespace (
+				id,
+				name
+			) VALUES (
+				$1, $2
+			) ON CONFLICT (id) DO UPDATE
+			SET name = EXCLUDED.name
+			`, id, ns.Name)
+			if err != nil {
+				logrus.Error(err)
+			}
+			return err
+		},
+	}
+	if err == nil {
+		c.Action = bootstrap.ActionUpdate
+		c.Fields = []string{"name"}
+	}
+	plan.Add(c)
+	return nil
+}
+
+// planPolicies updates the policies given, a namespace without its own policy
+// has the default one
+func (s *Storage) planPolicies(ctx context.Context, plan *bootstrap.Plan, ns *bootstrap.Namespace, _ bool) error {
+	id := uuid.MustParse(ns.ID)
+	if ns.PasswordPolicy != nil {
+		cur, err := s.GetPasswordPolicy(ctx, id)
+		if err != nil {
+			return err
+		}
+		fields := bootstrap.Diff(bootstrap.PasswordPolicyOf(cur), ns.PasswordPolicy)
+		if len(fields) > 0 {
+			plan.Add(&bootstrap.Change{
+				Action:    bootstrap.ActionUpdate,
+				Kind:      bootstrap.KindPasswordPolicy,
+				Namespace: ns.Name,
+				Key:       ns.ID,
+				Fields:    fields,
+				Run: func(ctx context.Context, tx *sql.Tx) error {
+					return s.TXSetPasswordPolicy(ctx, tx, id, ns.PasswordPolicy.Policy())
+				},
+			})
+		}
+	}
+	if ns.RegistrationPolicy != nil {
+		cur, err := s.GetRegistrationPolicy(ctx, id)
+		if err != nil {
+			return err
+		}
+		fields := bootstrap.Diff(bootstrapRegistrationPolicy(cur), ns.RegistrationPolicy)
+		if len(fields) > 0 {
+			p := ns.RegistrationPolicy
+			plan.Add(&bootstrap.Change{
+				Action:    bootstrap.ActionUpdate,
+				Kind:      bootstrap.KindRegistrationPolicy,
+				Namespace: ns.Name,
+				Key:       ns.ID,
+				Fields:    fields,
+				Run: func(ctx context.Context, tx *sql.Tx) error {
+					return s.TXSetRegistrationPolicy(ctx, tx, id, &RegistrationPolicy{
+						Enabled:                  p.Enabled,
+						RequiredFields:           nonNilStrings(p.RequiredFields),
+						EmailAllowDomains:        nonNilStrings(p.EmailAllowDomains),
+						EmailDenyDomains:         nonNilStrings(p.EmailDenyDomains),
+						RequireEmailVerification: p.RequireEmailVerification,
+						RequireApproval:          p.RequireApproval,
+					})
+				},
+			})
+		}
+	}
+	return nil
+}
+
+func bootstrapRegistrationPolicy(p *RegistrationPolicy) *bootstrap.RegistrationPolicy {
+	return &bootstrap.RegistrationPolicy{
+		Enabled:                  p.Enabled,
+		RequiredFields:           p.RequiredFields,
+		EmailAllowDomains:        p.EmailAllowDomains,
+		EmailDenyDomains:         p.EmailDenyDomains,
+		RequireEmailVerification: p.RequireEmailVerification,
+		RequireApproval:          p.RequireApproval,
+	}
+}
+
+// ListScopes returns the scopes declared for the clients of a namespace
+func (s *Storage) ListScopes(ctx context.Context, namespace uuid.UUID) ([]*bootstrap.Scope, error) {
+	cmd := `
+	SELECT
+		name,
+		description
+	FROM
+		scope
+	WHERE
+		namespace_id = $1
+	ORDER BY name
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var scopes []*bootstrap.Scope
+	for rows.Next() {
+		sc := &bootstrap.Scope{}
+		err := rows.Scan(&sc.Name, &sc.Description)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		scopes = append(scopes, sc)
+	}
+	return scopes, rows.Err()
+}
+
+func (s *Storage) planScopes(ctx context.Context, plan *bootstrap.Plan, ns *bootstrap.Namespace, prune bool) error {
+	id := uuid.MustParse(ns.ID)
+	scopes, err := s.ListScopes(ctx, id)
+	if err != nil {
+		return err
+	}
+	current := map[string]*bootstrap.Scope{}
+	for _, sc := range scopes {
+		current[sc.Name] = sc
+	}
+	for _, sc := range ns.Scopes {
+		sc := sc
+		c := &bootstrap.Change{
+			Action:    bootstrap.ActionCreate,
+			Kind:      bootstrap.KindScope,
+			Namespace: ns.Name,
+			Key:       sc.Name,
+			Run: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `
+				INSERT INTO scope (
+					namespace_id,
+					name,
+					description
+				) VALUES (
+					$1, $2, $3
+				) ON CONFLICT (namespace_id, name) DO UPDATE
+				SET description = EXCLUDED.description
+				`, id, sc.Name, sc.Description)
+				if err != nil {
+					logrus.Error(err)
+				}
+				return err
+			},
+		}
+		if cur, ok := current[sc.Name]; ok {
+			delete(current, sc.Name)
+			c.Fields = bootstrap.Diff(cur, sc)
+			if len(c.Fields) == 0 {
+				continue
+			}
+			c.Action = bootstrap.ActionUpdate
+		}
+		plan.Add(c)
+	}
+	if !prune {
+		return nil
+	}
+	for _, sc := range scopes {
+		if _, ok := current[sc.Name]; !ok {
+			continue
+		}
+		name := sc.Name
+		plan.Add(&bootstrap.Change{
+			Action:    bootstrap.ActionDelete,
+			Kind:      bootstrap.KindScope,
+			Namespace: ns.Name,
+			Key:       name,
+			Run: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `
+				DELETE FROM scope
+				WHERE namespace_id = $1
+				AND name = $2
+				`, id, name)
+				if err != nil {
+					logrus.Error(err)
+				}
+				return err
+			},
+		})
+	}
+	return nil
+}
+
+func (s *Storage) planClients(ctx context.Context, plan *bootstrap.Plan, ns *bootstrap.Namespace, prune bool) error {
+	clients, err := s.ExportClients(ctx, uuid.MustParse(ns.ID), true)
+	if err != nil {
+		return err
+	}
+	byID := map[string]*clientmeta.Client{}
+	byName := map[string][]*clientmeta.Client{}
+	for _, c := range clients {
+		byID[c.ID] = c
+		byName[c.Name] = append(byName[c.Name], c)
+	}
+	kept := map[string]bool{}
+	for _, c := range ns.Clients {
+		c := c
+		change := &bootstrap.Change{
+			Action:    bootstrap.ActionCreate,
+			Kind:      bootstrap.KindClient,
+			Namespace: ns.Name,
+			Key:       c.Key(),
+			Run: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := importClient(ctx, tx, c)
+				return err
+			},
+		}
+		cur := byID[c.ID]
+		if c.ID == "" {
+			switch named := byName[c.Name]; len(named) {
+			case 0:
+			case 1:
+				cur = named[0]
+			default:
+				return errors.New("client " + c.Name + ": several clients have the name, give the id")
+			}
+		}
+		if cur != nil {
+			kept[cur.ID] = true
+			// compare what the import would write, an empty secret keeps the current one
+			want := *c
+			want.ID = cur.ID
+			if want.Secret == "" {
+				want.Secret = cur.Secret
+			}
+			want.Normalize()
+			change.Fields = bootstrap.Diff(cur, &want)
+			if len(change.Fields) == 0 {
+				continue
+			}
+			change.Action = bootstrap.ActionUpdate
+		}
+		plan.Add(change)
+	}
+	if !prune {
+		return nil
+	}
+	registered, err := s.registeredClientIDs(ctx, uuid.MustParse(ns.ID))
+	if err != nil {
+		return err
+	}
+	for _, c := range prunedClients(clients, kept, registered) {
+		id := uuid.MustParse(c.ID)
+		plan.Add(&bootstrap.Change{
+			Action:    bootstrap.ActionDelete,
+			Kind:      bootstrap.KindClient,
+			Namespace: ns.Name,
+			Key:       c.ID,
+			Run: func(ctx context.Context, tx *sql.Tx) error {
+				err := s.TXDeleteClient(ctx, tx, id)
+				if err != nil {
+					return err
+				}
+				s.forgetClientRefreshTokens(id)
+				return nil
+			},
+		})
+	}
+	return nil
+}
+
+// prunedClients are the clients prune deletes, those neither kept nor
+// registered dynamically, which their registration access token manages
+func prunedClients(clients []*clientmeta.Client, kept, registered map[string]bool) []*clientmeta.Client {
+	var pruned []*clientmeta.Client
+	for _, c := range clients {
+		if !kept[c.ID] && !registered[c.ID] {
+			pruned = append(pruned, c)
+		}
+	}
+	return pruned
+}
+
+func (s *Storage) registeredClientIDs(ctx context.Context, namespace uuid.UUID) (map[string]bool, error) {
+	var ids []string
+	err := s.db.QueryRowContext(ctx, `
+	SELECT
+		ARRAY(
+			SELECT
+				id::text
+			FROM
+				client
+			WHERE
+				user_namespace_id = $1
+			AND registration_token_hash <> ''
+		)
+	`, namespace).Scan(pq.Array(&ids))
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	registered := map[string]bool{}
+	for _, id := range ids {
+		registered[id] = true
+	}
+	return registered, nil
+}
+
+// userList collects the users of an export
+type userList []*transfer.User
+
+func (l *userList) Write(u *transfer.User) error {
+	*l = append(*l, u)
+	return nil
+}
+
+func (l *userList) Flush() error {
+	return nil
+}
+
+func (s *Storage) planUsers(ctx context.Context, plan *bootstrap.Plan, ns *bootstrap.Namespace, prune bool) error {
+	id := uuid.MustParse(ns.ID)
+	var users userList
+	err := s.ExportUsers(ctx, id, &users, false)
+	if err != nil {
+		return err
+	}
+	current := map[string]*transfer.User{}
+	for _, u := range users {
+		current[u.Username] = u
+	}
+	admins, err := s.adminUsernames(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range ns.Users {
+		u := u
+		change := &bootstrap.Change{
+			Action:    bootstrap.ActionCreate,
+			Kind:      bootstrap.KindUser,
+			Namespace: ns.Name,
+			Key:       u.Username,
+		}
+		want := u.User
+		if cur, ok := current[u.Username]; ok {
+			// the password is only set for new users, so it can be changed afterwards
+			want.Password = ""
+			want.PasswordHash = ""
+			if want.Status == "" {
+				want.Status = cur.Status
+			}
+			if want.Groups == nil {
+				want.Groups = cur.Groups
+			}
+			want.Groups = append([]string{}, want.Groups...)
+			sort.Strings(want.Groups)
+			change.Fields = bootstrap.Diff(cur, &want)
+			if admins[u.Username] != u.Admin {
+				change.Fields = append(change.Fields, "admin")
+			}
+			if len(change.Fields) == 0 {
+				continue
+			}
+			change.Action = bootstrap.ActionUpdate
+		}
+		change.Run = func(ctx context.Context, tx *sql.Tx) error {
+			// the policy of the file applies to its users, it is not committed yet
+			policy, err := s.bootstrapPasswordPolicy(ctx, ns)
+			if err != nil {
+				return err
+			}
+			_, err = s.importUser(ctx, tx, policy, id, &want)
+			if err != nil {
+				return err
+			}
+			return setUserAdmin(ctx, tx, id, u.Username, u.Admin)
+		}
+		plan.Add(change)
+	}
+	if !prune {
+		return nil
+	}
+	for _, u := range ns.Users {
+		delete(admins, u.Username)
+	}
+	for _, u := range users {
+		if !admins[u.Username] {
+			continue
+		}
+		username := u.Username
+		plan.Add(&bootstrap.Change{
+			Action:    bootstrap.ActionUpdate,
+			Kind:      bootstrap.KindUser,
+			Namespace: ns.Name,
+			Key:       username,
+			Fields:    []string{"admin"},
+			Run: func(ctx context.Context, tx *sql.Tx) error {
+				return setUserAdmin(ctx, tx, id, username, false)
+			},
+		})
+	}
+	return nil
+}
+
+func (s *Storage) bootstrapPasswordPolicy(ctx context.Context, ns *bootstrap.Namespace) (*password.Policy, error) {
+	if ns.PasswordPolicy != nil {
+		return ns.PasswordPolicy.Policy(), nil
+	}
+	return s.GetPasswordPolicy(ctx, uuid.MustParse(ns.ID))
+}
+
+func (s *Storage) adminUsernames(ctx context.Context, namespace uuid.UUID) (map[string]bool, error) {
+	var names []string
+	err := s.db.QueryRowContext(ctx, `
+	SELECT
+		ARRAY(
+			SELECT
+				username
+			FROM
+				"user"
+			WHERE
+				namespace_id = $1
+			AND is_admin
+		)
+	`, namespace).Scan(pq.Array(&names))
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	admins := map[string]bool{}
+	for _, name := range names {
+		admins[name] = true
+	}
+	return admins, nil
+}
+
+func setUserAdmin(ctx context.Context, tx *sql.Tx, namespace uuid.UUID, username string, admin bool) error {
+	_, err := tx.ExecContext(ctx, `
+	UPDATE "user"
+	SET is_admin = $3
+	WHERE namespace_id = $1
+	AND username = $2
+	`, namespace, username, admin)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
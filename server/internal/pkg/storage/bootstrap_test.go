@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+)
+
+func TestPrunedClients(t *testing.T) {
+	clients := []*clientmeta.Client{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	tests := []struct {
+		kept       map[string]bool
+		registered map[string]bool
+		want       []string
+	}{
+		{nil, nil, []string{"a", "b", "c", "d"}},
+		{map[string]bool{"a": true}, nil, []string{"b", "c", "d"}},
+		// registered clients are managed by their registration access token
+		{nil, map[string]bool{"b": true, "d": true}, []string{"a", "c"}},
+		{map[string]bool{"a": true, "c": true}, map[string]bool{"b": true}, []string{"d"}},
+		{map[string]bool{"a": true, "b": true}, map[string]bool{"c": true, "d": true}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range prunedClients(clients, tt.kept, tt.registered) {
+			got = append(got, c.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("kept %v, registered %v: pruned %v, want %v", tt.kept, tt.registered, got, tt.want)
+		}
+	}
+}
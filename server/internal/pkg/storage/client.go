@@ -2,10 +2,11 @@ package storage
 
 import (
 	"context"
-	"log"
+	"database/sql"
 	"time"
 	"unsafe"
 
+	"github.com/go-jet/jet/v2/qrm"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/sanyokbig/pqinterval"
@@ -17,7 +18,7 @@ import (
 var (
 	// we use the default login UI and pass the (auth request) id
 	defaultLoginURL = func(id string) string {
-		return "/login/username?authRequestID=" + id
+		return "/login/select?authRequestID=" + id
 	}
 )
 
@@ -37,9 +38,7 @@ func (s *Storage) TotalClient(ctx context.Context) (int64, error) {
 	return total, nil
 }
 
-func (s *Storage) GetAllClient(ctx context.Context, offset, count int64) ([]Client, error) {
-	cmd := `
-	SELECT
+const clientColumns = `
 		id,
 		secret,
 		redirect_uris,
@@ -54,8 +53,134 @@ func (s *Storage) GetAllClient(ctx context.Context, offset, count int64) ([]Clie
 		post_logout_redirect_uri_globs,
 		redirect_uri_globs,
 		user_namespace_id,
-		name
-	FROM
+		name,
+		access_token_lifetime,
+		id_token_lifetime,
+		refresh_token_lifetime,
+		refresh_token_idle_lifetime,
+		refresh_tokens,
+		allowed_scopes,
+		default_scopes,
+		audiences,
+		disallowed_scopes,
+		resources,
+		require_pushed_authorization_requests,
+		response_modes,
+		authorization_encrypted_response_alg,
+		authorization_encrypted_response_enc,
+		jwks,
+		dpop_bound_access_tokens,
+		tls_client_auth_subject_dn,
+		tls_client_auth_san_dns,
+		tls_client_auth_san_uri,
+		tls_client_auth_san_ip,
+		tls_client_auth_san_email,
+		tls_client_certificate_bound_access_tokens,
+		backchannel_token_delivery_mode,
+		backchannel_client_notification_endpoint,
+		frontchannel_logout_uri,
+		frontchannel_logout_session_required,
+		backchannel_logout_uri,
+		backchannel_logout_session_required,
+		registration_token_hash <> ''
+`
+
+func scanClient(row interface{ Scan(...any) error }) (*Client, error) {
+	c := &Client{}
+	intervals := make([]pqinterval.Interval, 5)
+	err := row.Scan(
+		&c.id,
+		&c.secret,
+		pq.Array(&c.redirectURIs),
+		(*int)(unsafe.Pointer(&c.applicationType)),
+		&c.authMethod,
+		pq.Array((*[]string)(unsafe.Pointer(&c.responseTypes))),
+		pq.Array((*[]string)(unsafe.Pointer(&c.grantTypes))),
+		(*int)(unsafe.Pointer(&c.accessTokenType)),
+		&c.devMode,
+		&c.idTokenUserinfoClaimsAssertion,
+		&intervals[0],
+		pq.Array(&c.postLogoutRedirectURIGlobs),
+		pq.Array(&c.redirectURIGlobs),
+		&c.userNamespaceID,
+		&c.name,
+		&intervals[1],
+		&intervals[2],
+		&intervals[3],
+		&intervals[4],
+		&c.tokenPolicy.RefreshTokens,
+		pq.Array(&c.clientAllowedScopes),
+		pq.Array(&c.defaultScopes),
+		pq.Array(&c.audiences),
+		&c.disallowedScopes,
+		pq.Array(&c.resources),
+		&c.requirePushedAuthRequests,
+		pq.Array(&c.responseModes),
+		&c.authorizationEncryptedResponseAlg,
+		&c.authorizationEncryptedResponseEnc,
+		&c.jwks,
+		&c.dpopBoundAccessTokens,
+		&c.tlsClientAuth.SubjectDN,
+		&c.tlsClientAuth.SANDNS,
+		&c.tlsClientAuth.SANURI,
+		&c.tlsClientAuth.SANIP,
+		&c.tlsClientAuth.SANEmail,
+		&c.tlsClientCertificateBoundAccessTokens,
+		&c.backchannelTokenDeliveryMode,
+		&c.backchannelClientNotificationEndpoint,
+		&c.frontchannelLogoutURI,
+		&c.frontchannelLogoutSessionRequired,
+		&c.backchannelLogoutURI,
+		&c.backchannelLogoutSessionRequired,
+		&c.registered,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = durations(intervals,
+		&c.clockSkew,
+		&c.tokenPolicy.AccessTokenLifetime,
+		&c.tokenPolicy.IDTokenLifetime,
+		&c.tokenPolicy.RefreshTokenLifetime,
+		&c.tokenPolicy.RefreshTokenIdleLifetime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.loginURL = defaultLoginURL
+	return c, nil
+}
+
+// resolveClient resolves the token lifetimes and scopes the client inherits from its namespace
+func (s *Storage) resolveClient(ctx context.Context, c *Client) error {
+	p, err := s.GetTokenPolicy(ctx, c.userNamespaceID)
+	if err != nil {
+		return err
+	}
+	c.tokens = p.Override(c.tokenPolicy)
+	c.allowedScopes = c.clientAllowedScopes
+	if len(c.allowedScopes) == 0 {
+		c.allowedScopes, err = s.namespaceScopes(ctx, c.userNamespaceID)
+		if err != nil {
+			return err
+		}
+	}
+	// the client may request the scopes of its api resources too
+	c.apiResources, err = s.clientAPIResources(ctx, c.userNamespaceID, c.resources)
+	if err != nil {
+		return err
+	}
+	for _, r := range c.resources {
+		if api, ok := c.apiResources[r]; ok {
+			c.allowedScopes = append(c.allowedScopes, api.Scopes...)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetAllClient(ctx context.Context, offset, count int64) ([]Client, error) {
+	cmd := `
+	SELECT` + clientColumns + `	FROM
 		client
 	LIMIT $1 OFFSET $2
 	`
@@ -69,102 +194,43 @@ func (s *Storage) GetAllClient(ctx context.Context, offset, count int64) ([]Clie
 	var clients []Client
 
 	for rows.Next() {
-		c := &Client{}
-		var interval pqinterval.Interval
-		err := rows.Scan(
-			&c.id,
-			&c.secret,
-			pq.Array(&c.redirectURIs),
-			(*int)(unsafe.Pointer(&c.applicationType)),
-			&c.authMethod,
-			pq.Array((*[]string)(unsafe.Pointer(&c.responseTypes))),
-			pq.Array((*[]string)(unsafe.Pointer(&c.grantTypes))),
-			(*int)(unsafe.Pointer(&c.accessTokenType)),
-			&c.devMode,
-			&c.idTokenUserinfoClaimsAssertion,
-			&interval,
-			pq.Array(&c.postLogoutRedirectURIGlobs),
-			pq.Array(&c.redirectURIGlobs),
-			&c.userNamespaceID,
-			&c.name,
-		)
+		c, err := scanClient(rows)
 		if err != nil {
 			logrus.Error(err)
 			return nil, err
 		}
-
-		dura, err := interval.Duration()
+		clients = append(clients, *c)
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	for i := range clients {
+		err = s.resolveClient(ctx, &clients[i])
 		if err != nil {
-			logrus.Error(err)
 			return nil, err
 		}
-
-		c.clockSkew = dura
-		c.loginURL = defaultLoginURL
-
-		clients = append(clients, *c)
 	}
 	return clients, nil
 }
 
 func (s *Storage) GetClientByUUID(ctx context.Context, clientID uuid.UUID) (*Client, error) {
 	stmt := `
-		SELECT
-			id,
-			secret,
-			redirect_uris,
-			application_type,
-			auth_method,
-			response_types,
-			grant_types,
-			access_token_type,
-			dev_mode,
-			id_token_user_info_claims_assertion,
-			clock_skew,
-			post_logout_redirect_uri_globs,
-			redirect_uri_globs,
-			user_namespace_id,
-			name
-		FROM
+		SELECT` + clientColumns + `		FROM
 			client
 		WHERE
 			id = $1
 	`
-	c := &Client{}
-	var interval pqinterval.Interval
-	err := s.db.QueryRowContext(ctx, stmt, clientID).Scan(
-		&c.id,
-		&c.secret,
-		pq.Array(&c.redirectURIs),
-		(*int)(unsafe.Pointer(&c.applicationType)),
-		&c.authMethod,
-		pq.Array((*[]string)(unsafe.Pointer(&c.responseTypes))),
-		pq.Array((*[]string)(unsafe.Pointer(&c.grantTypes))),
-		(*int)(unsafe.Pointer(&c.accessTokenType)),
-		&c.devMode,
-		&c.idTokenUserinfoClaimsAssertion,
-		&interval,
-		pq.Array(&c.postLogoutRedirectURIGlobs),
-		pq.Array(&c.redirectURIGlobs),
-		&c.userNamespaceID,
-		&c.name,
-	)
+	c, err := scanClient(s.db.QueryRowContext(ctx, stmt, clientID))
 	if err != nil {
 		logrus.Error(err)
 		return nil, err
 	}
-
-	dura, err := interval.Duration()
+	err = s.resolveClient(ctx, c)
 	if err != nil {
-		logrus.Error(err)
 		return nil, err
 	}
-
-	c.clockSkew = dura
-
-	c.loginURL = defaultLoginURL
-
-	return c, err
+	return c, nil
 }
 
 // Client represents the storage model of an OAuth/OIDC client
@@ -186,91 +252,49 @@ type Client struct {
 	redirectURIGlobs               []string
 	userNamespaceID                uuid.UUID
 	name                           string
-}
-
-// RegisterClients enables you to register clients for the example implementation
-// there are some clients (web and native) to try out different cases
-// add more if necessary
-//
-// RegisterClients should be called before the Storage is used so that there are
-// no race conditions.
-func RegisterClients(registerClients ...*Client) {
-	for _, client := range registerClients {
-		log.Printf("Registering client: %s", client.id)
-		// clients[client.id] = client
-	}
-}
-
-// NativeClient will create a client of type native, which will always use PKCE and allow the use of refresh tokens
-// user-defined redirectURIs may include:
-// - http://localhost without port specification (e.g. http://localhost/auth/callback)
-// - custom protocol (e.g. custom://auth/callback)
-// (the examples will be used as default, if none is provided)
-func NativeClient(id string, redirectURIs ...string) *Client {
-	if len(redirectURIs) == 0 {
-		redirectURIs = []string{
-			"http://localhost/auth/callback",
-			"custom://auth/callback",
-		}
-	}
-	return &Client{
-		id:                             uuid.MustParse(id),
-		secret:                         "", // no secret needed (due to PKCE)
-		redirectURIs:                   redirectURIs,
-		applicationType:                op.ApplicationTypeNative,
-		authMethod:                     oidc.AuthMethodNone,
-		loginURL:                       defaultLoginURL,
-		responseTypes:                  []oidc.ResponseType{oidc.ResponseTypeCode},
-		grantTypes:                     []oidc.GrantType{oidc.GrantTypeCode, oidc.GrantTypeRefreshToken},
-		accessTokenType:                op.AccessTokenTypeBearer,
-		devMode:                        false,
-		idTokenUserinfoClaimsAssertion: false,
-		clockSkew:                      0,
-	}
-}
-
-// WebClient will create a client of type web, which will always use Basic Auth and allow the use of refresh tokens
-// user-defined redirectURIs may include:
-// - http://localhost with port specification (e.g. http://localhost:9999/auth/callback)
-// (the example will be used as default, if none is provided)
-func WebClient(id, secret string, redirectURIs ...string) *Client {
-	if len(redirectURIs) == 0 {
-		redirectURIs = []string{
-			"http://localhost:9999/auth/callback",
-		}
-	}
-	return &Client{
-		id:                             uuid.MustParse(id),
-		secret:                         secret,
-		redirectURIs:                   redirectURIs,
-		applicationType:                op.ApplicationTypeWeb,
-		authMethod:                     oidc.AuthMethodBasic,
-		loginURL:                       defaultLoginURL,
-		responseTypes:                  []oidc.ResponseType{oidc.ResponseTypeCode},
-		grantTypes:                     []oidc.GrantType{oidc.GrantTypeCode, oidc.GrantTypeRefreshToken},
-		accessTokenType:                op.AccessTokenTypeBearer,
-		devMode:                        false,
-		idTokenUserinfoClaimsAssertion: false,
-		clockSkew:                      0,
-	}
-}
-
-// DeviceClient creates a device client with Basic authentication.
-func DeviceClient(id, secret string) *Client {
-	return &Client{
-		id:                             uuid.MustParse(id),
-		secret:                         secret,
-		redirectURIs:                   nil,
-		applicationType:                op.ApplicationTypeWeb,
-		authMethod:                     oidc.AuthMethodBasic,
-		loginURL:                       defaultLoginURL,
-		responseTypes:                  []oidc.ResponseType{oidc.ResponseTypeCode},
-		grantTypes:                     []oidc.GrantType{oidc.GrantTypeDeviceCode},
-		accessTokenType:                op.AccessTokenTypeBearer,
-		devMode:                        false,
-		idTokenUserinfoClaimsAssertion: false,
-		clockSkew:                      0,
-	}
+	// tokenPolicy is what the client overrides of the policy of its namespace,
+	// tokens is the result
+	tokenPolicy TokenPolicy
+	tokens      TokenPolicy
+	// clientAllowedScopes are the allowed scopes of the client, allowedScopes
+	// those of its namespace if it has none
+	clientAllowedScopes []string
+	allowedScopes       []string
+	defaultScopes       []string
+	audiences           []string
+	disallowedScopes    string
+	// resources are the identifiers of the api resources the client may
+	// request, apiResources those of them which are registered
+	resources    []string
+	apiResources map[string]*APIResource
+	// resourceRequest is the resource parameters of the token request the
+	// client was loaded for
+	resourceRequest *resourceRequest
+	// requirePushedAuthRequests rejects authorization requests the client did not push
+	requirePushedAuthRequests bool
+	// responseModes the client may request, empty for query, fragment and form_post
+	responseModes []string
+	// jwt authorization responses are encrypted to a key of jwks if the alg is set
+	authorizationEncryptedResponseAlg string
+	authorizationEncryptedResponseEnc string
+	jwks                              string
+	// dpopBoundAccessTokens requires DPoP proofs at the token endpoint
+	dpopBoundAccessTokens bool
+	// tlsClientAuth is the certificate of tls_client_auth clients
+	tlsClientAuth TLSClientAuth
+	// tlsClientCertificateBoundAccessTokens binds the access tokens to the certificate of the client
+	tlsClientCertificateBoundAccessTokens bool
+	// backchannelTokenDeliveryMode is how a ciba client gets its tokens: poll, ping or push
+	backchannelTokenDeliveryMode          string
+	backchannelClientNotificationEndpoint string
+	// the client is told about the end of the sessions of its users in an iframe
+	// on the end_session page and with logout tokens posted to the backchannel uri
+	frontchannelLogoutURI             string
+	frontchannelLogoutSessionRequired bool
+	backchannelLogoutURI              string
+	backchannelLogoutSessionRequired  bool
+	// registered clients registered themselves with dynamic client registration
+	registered bool
 }
 
 type hasRedirectGlobs struct {
@@ -295,3 +319,278 @@ func RedirectGlobsClient(client *Client) op.Client {
 	}
 	return client
 }
+
+// GetID must return the client_id
+func (c *Client) GetID() string {
+	return c.id.String()
+}
+
+func (c *Client) GetSecret() string {
+	return c.secret
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
+func (c *Client) UserNamespaceID() uuid.UUID {
+	return c.userNamespaceID
+}
+
+// RedirectGlobs are the redirect uri globs, only used in dev mode, see RedirectGlobsClient
+func (c *Client) RedirectGlobs() []string {
+	return c.redirectURIGlobs
+}
+
+// PostLogoutRedirectGlobs are the post logout redirect uri globs, only used in dev mode
+func (c *Client) PostLogoutRedirectGlobs() []string {
+	return c.postLogoutRedirectURIGlobs
+}
+
+// TokenPolicy is what the client overrides of the token policy of its namespace
+func (c *Client) TokenPolicy() TokenPolicy {
+	return c.tokenPolicy
+}
+
+// EffectiveTokenPolicy is the token policy the client's tokens are issued with
+func (c *Client) EffectiveTokenPolicy() TokenPolicy {
+	return c.tokens
+}
+
+// AllowedScopes are the scope globs of the client, empty for the scopes of its namespace
+func (c *Client) AllowedScopes() []string {
+	return c.clientAllowedScopes
+}
+
+func (c *Client) DefaultScopes() []string {
+	return c.defaultScopes
+}
+
+// Audiences are the audiences of the client's tokens besides itself
+func (c *Client) Audiences() []string {
+	return c.audiences
+}
+
+// DisallowedScopes is downscope or reject
+func (c *Client) DisallowedScopes() string {
+	return c.disallowedScopes
+}
+
+// Resources are the identifiers of the api resources the client may request
+func (c *Client) Resources() []string {
+	return c.resources
+}
+
+// RequirePushedAuthRequests tells if the client must push its authorization requests to /par
+func (c *Client) RequirePushedAuthRequests() bool {
+	return c.requirePushedAuthRequests
+}
+
+// ResponseModes are the response modes the client may request, empty for query, fragment and form_post
+func (c *Client) ResponseModes() []string {
+	return c.responseModes
+}
+
+func (c *Client) AuthorizationEncryptedResponseAlg() string {
+	return c.authorizationEncryptedResponseAlg
+}
+
+func (c *Client) AuthorizationEncryptedResponseEnc() string {
+	return c.authorizationEncryptedResponseEnc
+}
+
+// JWKS is the json web key set of the public keys of the client
+func (c *Client) JWKS() string {
+	return c.jwks
+}
+
+// DPoPBoundAccessTokens tells if the access tokens of the client are always DPoP bound
+func (c *Client) DPoPBoundAccessTokens() bool {
+	return c.dpopBoundAccessTokens
+}
+
+// TLSClientAuth tells which certificate a tls_client_auth client authenticates with
+func (c *Client) TLSClientAuth() TLSClientAuth {
+	return c.tlsClientAuth
+}
+
+// TLSClientCertificateBoundAccessTokens tells if the access tokens of the client are bound to its certificate
+func (c *Client) TLSClientCertificateBoundAccessTokens() bool {
+	return c.tlsClientCertificateBoundAccessTokens
+}
+
+// BackchannelTokenDeliveryMode is poll, ping or push for ciba clients, empty for the others
+func (c *Client) BackchannelTokenDeliveryMode() string {
+	return c.backchannelTokenDeliveryMode
+}
+
+// BackchannelClientNotificationEndpoint is where ping and push clients get their callbacks
+func (c *Client) BackchannelClientNotificationEndpoint() string {
+	return c.backchannelClientNotificationEndpoint
+}
+
+// Registered tells if the client registered itself with dynamic client registration,
+// the server must not post to the network it runs in for such clients
+func (c *Client) Registered() bool {
+	return c.registered
+}
+
+// FrontchannelLogoutURI is rendered in an iframe when a user signs out, empty for none
+func (c *Client) FrontchannelLogoutURI() string {
+	return c.frontchannelLogoutURI
+}
+
+// FrontchannelLogoutSessionRequired tells if the FrontchannelLogoutURI gets the iss and sid parameters
+func (c *Client) FrontchannelLogoutSessionRequired() bool {
+	return c.frontchannelLogoutSessionRequired
+}
+
+// BackchannelLogoutURI gets the logout tokens of the sessions of the client, empty for none
+func (c *Client) BackchannelLogoutURI() string {
+	return c.backchannelLogoutURI
+}
+
+// BackchannelLogoutSessionRequired tells if the logout tokens must have the sid claim
+func (c *Client) BackchannelLogoutSessionRequired() bool {
+	return c.backchannelLogoutSessionRequired
+}
+
+// RedirectURIs must return the registered redirect_uris for Code and Implicit Flow
+func (c *Client) RedirectURIs() []string {
+	return c.redirectURIs
+}
+
+// PostLogoutRedirectURIs must return the registered post_logout_redirect_uris for sign-outs
+func (c *Client) PostLogoutRedirectURIs() []string {
+	return []string{}
+}
+
+// ApplicationType must return the type of the client (app, native, user agent)
+func (c *Client) ApplicationType() op.ApplicationType {
+	return c.applicationType
+}
+
+// AuthMethod must return the authentication method (client_secret_basic, client_secret_post, none, private_key_jwt)
+func (c *Client) AuthMethod() oidc.AuthMethod {
+	return c.authMethod
+}
+
+// ResponseTypes must return all allowed response types (code, id_token token, id_token)
+// these must match with the allowed grant types
+func (c *Client) ResponseTypes() []oidc.ResponseType {
+	return c.responseTypes
+}
+
+// GrantTypes must return all allowed grant types (authorization_code, refresh_token, urn:ietf:params:oauth:grant-type:jwt-bearer)
+func (c *Client) GrantTypes() []oidc.GrantType {
+	return c.grantTypes
+}
+
+// LoginURL will be called to redirect the user (agent) to the login UI
+// you could implement some logic here to redirect the users to different login UIs depending on the client
+func (c *Client) LoginURL(id string) string {
+	return c.loginURL(id)
+}
+
+// AccessTokenType must return the type of access token the client uses (Bearer (opaque) or JWT)
+// the api resources of a token request may prefer another type
+func (c *Client) AccessTokenType() op.AccessTokenType {
+	if t, ok := c.resourceTokenType(); ok {
+		return t
+	}
+	return c.accessTokenType
+}
+
+// IDTokenLifetime must return the lifetime of the client's id_tokens
+func (c *Client) IDTokenLifetime() time.Duration {
+	return c.tokens.IDTokenLifetime
+}
+
+// DevMode enables the use of non-compliant configs such as redirect_uris (e.g. http schema for user agent client)
+func (c *Client) DevMode() bool {
+	return c.devMode
+}
+
+// RestrictAdditionalIdTokenScopes allows specifying which custom scopes shall be asserted into the id_token
+// tokens of scopes the client lost since they were issued don't assert them anymore
+func (c *Client) RestrictAdditionalIdTokenScopes() func(scopes []string) []string {
+	return c.restrictScopes
+}
+
+// RestrictAdditionalAccessTokenScopes allows specifying which custom scopes shall be asserted into the JWT access_token
+func (c *Client) RestrictAdditionalAccessTokenScopes() func(scopes []string) []string {
+	return c.restrictScopes
+}
+
+// IsScopeAllowed enables Client specific custom scopes validation
+// the library drops the scopes it does not allow, clients rejecting them
+// allow all here and CreateAuthRequest fails with invalid_scope instead
+func (c *Client) IsScopeAllowed(scope string) bool {
+	return c.disallowedScopes == DisallowedScopesReject || c.scopeAllowed(scope)
+}
+
+// IDTokenUserinfoClaimsAssertion allows specifying if claims of scope profile, email, phone and address are asserted into the id_token
+// even if an access token if issued which violates the OIDC Core spec
+// (5.4. Requesting Claims using Scope Values: https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims)
+// some clients though require that e.g. email is always in the id_token when requested even if an access_token is issued
+func (c *Client) IDTokenUserinfoClaimsAssertion() bool {
+	return c.idTokenUserinfoClaimsAssertion
+}
+
+// ClockSkew enables clients to instruct the OP to apply a clock skew on the various times and expirations
+// (subtract from issued_at, add to expiration, ...)
+func (c *Client) ClockSkew() time.Duration {
+	return c.clockSkew
+}
+
+// DeleteClient removes a client with its consents, tokens and SCIM tokens
+func (s *Storage) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.TXDeleteClient(ctx, tx, id)
+	})
+	if err != nil {
+		return err
+	}
+	s.forgetClientRefreshTokens(id)
+	return nil
+}
+
+// TXDeleteClient is DeleteClient within a transaction, the caller forgets the
+// cached refresh tokens of the client after the commit
+func (s *Storage) TXDeleteClient(ctx context.Context, tx qrm.DB, id uuid.UUID) error {
+	cmds := []string{
+		`DELETE FROM scim_token WHERE client_id = $1`,
+		`DELETE FROM consent WHERE client_id = $1`,
+		`DELETE FROM token WHERE application_id = $1`,
+		`DELETE FROM refresh_token WHERE application_id = $1::text`,
+		`DELETE FROM backchannel_auth_request WHERE client_id = $1`,
+		`DELETE FROM user_session_client WHERE client_id = $1`,
+		`DELETE FROM backchannel_logout WHERE client_id = $1`,
+	}
+	for _, cmd := range cmds {
+		_, err := tx.ExecContext(ctx, cmd, id)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM client WHERE id = $1`, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Storage) forgetClientRefreshTokens(id uuid.UUID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for k, t := range s.refreshTokens {
+		if t.ApplicationID == id {
+			delete(s.refreshTokens, k)
+		}
+	}
+}
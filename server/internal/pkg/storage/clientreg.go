@@ -0,0 +1,442 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"github.com/zltl/xoidc/server/internal/pkg/clientreg"
+)
+
+var ErrInitialAccessTokenNotFound = errors.New("initial access token not found")
+
+// InitialAccessToken allows registering clients in a namespace, only its hash is stored
+type InitialAccessToken struct {
+	ID           uuid.UUID
+	NamespaceID  uuid.UUID
+	Description  string
+	ExpireTime   time.Time
+	CreateTime   time.Time
+	LastUsedTime time.Time
+}
+
+// newToken returns a random bearer token
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CreateInitialAccessToken issues a token registering clients in the namespace until
+// it expires, a zero expireTime never expires. The token is returned only here.
+func (s *Storage) CreateInitialAccessToken(ctx context.Context, namespace uuid.UUID, description string, expireTime time.Time) (uuid.UUID, string, error) {
+	token, err := newToken()
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, "", err
+	}
+	cmd := `
+	INSERT INTO initial_access_token (
+		namespace_id,
+		token_hash,
+		description,
+		expire_time
+	) VALUES (
+		$1, $2, $3, $4
+	) RETURNING id
+	`
+	var id uuid.UUID
+	err = s.db.QueryRowContext(ctx, cmd, namespace, hashToken(token), description,
+		sql.NullTime{Time: expireTime, Valid: !expireTime.IsZero()}).Scan(&id)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, "", err
+	}
+	return id, token, nil
+}
+
+func (s *Storage) ListInitialAccessTokens(ctx context.Context, namespace uuid.UUID) ([]*InitialAccessToken, error) {
+	cmd := `
+	SELECT
+		id,
+		namespace_id,
+		description,
+		expire_time,
+		create_time,
+		last_used_time
+	FROM
+		initial_access_token
+	WHERE
+		namespace_id = $1
+	ORDER BY create_time
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []*InitialAccessToken
+	for rows.Next() {
+		t := &InitialAccessToken{}
+		var expire, lastUsed sql.NullTime
+		err := rows.Scan(&t.ID, &t.NamespaceID, &t.Description, &expire, &t.CreateTime, &lastUsed)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		t.ExpireTime = expire.Time
+		t.LastUsedTime = lastUsed.Time
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *Storage) DeleteInitialAccessToken(ctx context.Context, namespace, id uuid.UUID) error {
+	cmd := `
+	DELETE FROM initial_access_token
+	WHERE namespace_id = $1
+	AND id = $2
+	`
+	res, err := s.db.ExecContext(ctx, cmd, namespace, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInitialAccessTokenNotFound
+	}
+	return nil
+}
+
+// InitialAccessTokenNamespace implements clientreg.Backend
+func (s *Storage) InitialAccessTokenNamespace(ctx context.Context, token string) (uuid.UUID, error) {
+	cmd := `
+	UPDATE initial_access_token
+	SET last_used_time = now()
+	WHERE token_hash = $1
+	AND (expire_time IS NULL OR expire_time > now())
+	RETURNING namespace_id
+	`
+	var namespace uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, hashToken(token)).Scan(&namespace)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, clientreg.ErrInvalidToken
+	}
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return namespace, nil
+}
+
+// DynamicRegistrationPolicy implements clientreg.Backend,
+// namespaces without a policy do not allow dynamic registration
+func (s *Storage) DynamicRegistrationPolicy(ctx context.Context, namespace uuid.UUID) (*clientreg.Policy, error) {
+	cmd := `
+	SELECT
+		enabled,
+		require_initial_access_token,
+		require_software_statement,
+		software_statement_jwks,
+		grant_types,
+		redirect_uri_globs
+	FROM
+		client_registration_policy
+	WHERE
+		namespace_id = $1
+	`
+	p := &clientreg.Policy{}
+	err := s.db.QueryRowContext(ctx, cmd, namespace).Scan(
+		&p.Enabled,
+		&p.RequireInitialAccessToken,
+		&p.RequireSoftwareStatement,
+		&p.SoftwareStatementJWKS,
+		pq.Array(&p.GrantTypes),
+		pq.Array(&p.RedirectURIGlobs),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &clientreg.Policy{RequireInitialAccessToken: true}, nil
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *Storage) SetDynamicRegistrationPolicy(ctx context.Context, namespace uuid.UUID, p *clientreg.Policy) error {
+	cmd := `
+	INSERT INTO client_registration_policy (
+		namespace_id,
+		enabled,
+		require_initial_access_token,
+		require_software_statement,
+		software_statement_jwks,
+		grant_types,
+		redirect_uri_globs
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	) ON CONFLICT (namespace_id) DO UPDATE SET
+		enabled=EXCLUDED.enabled,
+		require_initial_access_token=EXCLUDED.require_initial_access_token,
+		require_software_statement=EXCLUDED.require_software_statement,
+		software_statement_jwks=EXCLUDED.software_statement_jwks,
+		grant_types=EXCLUDED.grant_types,
+		redirect_uri_globs=EXCLUDED.redirect_uri_globs
+	`
+	_, err := s.db.ExecContext(ctx, cmd,
+		namespace,
+		p.Enabled,
+		p.RequireInitialAccessToken,
+		p.RequireSoftwareStatement,
+		p.SoftwareStatementJWKS,
+		pq.Array(nonNilStrings(p.GrantTypes)),
+		pq.Array(nonNilStrings(p.RedirectURIGlobs)),
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// importRegisteredClient imports the client of registered metadata, metadata
+// the client row rejects is invalid_client_metadata
+func importRegisteredClient(ctx context.Context, tx *sql.Tx, c *clientmeta.Client) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", &clientreg.Error{Status: 400, Code: "invalid_client_metadata", Description: err.Error()}
+	}
+	return importClient(ctx, tx, c)
+}
+
+// checkRegisteredScopes rejects registrations of scopes which are neither
+// standard nor scopes of the namespace, an empty scope gets the namespace's
+func (s *Storage) checkRegisteredScopes(ctx context.Context, namespace uuid.UUID, md *clientreg.Metadata) error {
+	scopes, err := s.namespaceScopes(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, sc := range scopes {
+		known[sc] = true
+	}
+	for _, sc := range strings.Fields(md.Scope) {
+		if !standardScopes[sc] && !known[sc] {
+			return &clientreg.Error{Status: 400, Code: "invalid_client_metadata", Description: "scope " + sc + " is not allowed"}
+		}
+	}
+	return nil
+}
+
+// RegisterClient implements clientreg.Backend
+func (s *Storage) RegisterClient(ctx context.Context, namespace uuid.UUID, md *clientreg.Metadata) (*clientreg.Client, error) {
+	err := s.checkRegisteredScopes(ctx, namespace, md)
+	if err != nil {
+		return nil, err
+	}
+	var secret string
+	if md.TokenEndpointAuthMethod != "none" {
+		secret, err = newToken()
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+	}
+	token, err := newToken()
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	metadata, err := json.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New()
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := importRegisteredClient(ctx, tx, md.ClientMetadata(id.String(), namespace, secret))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+		UPDATE client
+		SET registration_token_hash = $2,
+			registration_metadata = $3
+		WHERE id = $1
+		`, id, hashToken(token), metadata)
+		if err != nil {
+			logrus.Error(err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.registeredClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client.RegistrationAccessToken = token
+	return client, nil
+}
+
+// registeredClient reads the registered metadata, the columns win over it as
+// they may have been changed by an admin since
+func (s *Storage) registeredClient(ctx context.Context, id uuid.UUID) (*clientreg.Client, error) {
+	cmd := `
+	SELECT
+		secret,
+		redirect_uris,
+		application_type,
+		auth_method,
+		response_types,
+		grant_types,
+		name,
+		user_namespace_id,
+		create_time,
+		registration_metadata,
+		allowed_scopes
+	FROM
+		client
+	WHERE
+		id = $1
+	AND registration_token_hash != ''
+	`
+	c := &clientreg.Client{ClientID: id.String()}
+	var (
+		applicationType int
+		createTime      time.Time
+		metadata        []byte
+		scopes          []string
+	)
+	err := s.db.QueryRowContext(ctx, cmd, id).Scan(
+		&c.ClientSecret,
+		pq.Array(&c.RedirectURIs),
+		&applicationType,
+		&c.TokenEndpointAuthMethod,
+		pq.Array(&c.ResponseTypes),
+		pq.Array(&c.GrantTypes),
+		&c.ClientName,
+		&c.Namespace,
+		&createTime,
+		&metadata,
+		pq.Array(&scopes),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, clientreg.ErrInvalidToken
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	columns := c.Metadata
+	err = json.Unmarshal(metadata, &c.Metadata)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	c.RedirectURIs = columns.RedirectURIs
+	c.TokenEndpointAuthMethod = columns.TokenEndpointAuthMethod
+	c.ResponseTypes = columns.ResponseTypes
+	c.GrantTypes = columns.GrantTypes
+	c.ClientName = columns.ClientName
+	c.Scope = strings.Join(scopes, " ")
+	c.ApplicationType = "web"
+	if op.ApplicationType(applicationType) == op.ApplicationTypeNative {
+		c.ApplicationType = "native"
+	}
+	c.ClientIDIssuedAt = createTime.Unix()
+	return c, nil
+}
+
+// RegisteredClient implements clientreg.Backend
+func (s *Storage) RegisteredClient(ctx context.Context, clientID, token string) (*clientreg.Client, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil || token == "" {
+		return nil, clientreg.ErrInvalidToken
+	}
+	var hash string
+	err = s.db.QueryRowContext(ctx, `SELECT registration_token_hash FROM client WHERE id = $1`, id).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, clientreg.ErrInvalidToken
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	if hash == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(token))) != 1 {
+		return nil, clientreg.ErrInvalidToken
+	}
+	return s.registeredClient(ctx, id)
+}
+
+// UpdateRegisteredClient implements clientreg.Backend, the secret is kept
+// unless the client needs one and has none
+func (s *Storage) UpdateRegisteredClient(ctx context.Context, clientID string, md *clientreg.Metadata) (*clientreg.Client, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, clientreg.ErrInvalidToken
+	}
+	cur, err := s.registeredClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkRegisteredScopes(ctx, cur.Namespace, md)
+	if err != nil {
+		return nil, err
+	}
+	var secret string
+	if cur.ClientSecret == "" && md.TokenEndpointAuthMethod != "none" {
+		secret, err = newToken()
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+	}
+	metadata, err := json.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := importRegisteredClient(ctx, tx, md.ClientMetadata(clientID, cur.Namespace, secret))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+		UPDATE client
+		SET registration_metadata = $2
+		WHERE id = $1
+		`, id, metadata)
+		if err != nil {
+			logrus.Error(err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.registeredClient(ctx, id)
+}
+
+// DeleteRegisteredClient implements clientreg.Backend
+func (s *Storage) DeleteRegisteredClient(ctx context.Context, clientID string) error {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return clientreg.ErrInvalidToken
+	}
+	return s.DeleteClient(ctx, id)
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// what happens to requests of scopes a client may not request
+const (
+	DisallowedScopesDownscope = "downscope"
+	DisallowedScopesReject    = "reject"
+)
+
+// standardScopes are allowed for all clients
+var standardScopes = map[string]bool{
+	oidc.ScopeOpenID:        true,
+	oidc.ScopeProfile:       true,
+	oidc.ScopeEmail:         true,
+	oidc.ScopePhone:         true,
+	oidc.ScopeAddress:       true,
+	oidc.ScopeOfflineAccess: true,
+}
+
+// namespaceScopes returns the names of the scopes of a namespace
+func (s *Storage) namespaceScopes(ctx context.Context, namespace uuid.UUID) ([]string, error) {
+	var names []string
+	err := s.db.QueryRowContext(ctx, `
+	SELECT
+		coalesce(array_agg(name ORDER BY name), '{}')
+	FROM
+		scope
+	WHERE
+		namespace_id = $1
+	`, namespace).Scan(pq.Array(&names))
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return names, nil
+}
+
+// scopeAllowed tells if the client may request the scope, its allowed scopes
+// are globs like custom_scope:impersonate:*
+func (c *Client) scopeAllowed(scope string) bool {
+	if standardScopes[scope] {
+		return true
+	}
+	for _, g := range c.allowedScopes {
+		if ok, _ := path.Match(g, scope); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictScopes drops the scopes the client may not request
+func (c *Client) restrictScopes(scopes []string) []string {
+	allowed := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if c.scopeAllowed(scope) {
+			allowed = append(allowed, scope)
+		}
+	}
+	return allowed
+}
+
+// CheckScopes adds the default scopes of the client to requested ones, and
+// drops or rejects the scopes it may not request
+func (c *Client) CheckScopes(scopes []string) ([]string, error) {
+	result := make([]string, 0, len(scopes)+len(c.defaultScopes))
+	seen := map[string]bool{}
+	for _, scope := range append(append([]string{}, scopes...), c.defaultScopes...) {
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		if c.scopeAllowed(scope) {
+			result = append(result, scope)
+			continue
+		}
+		if c.disallowedScopes == DisallowedScopesReject {
+			return nil, oidc.ErrInvalidScope().WithDescription(fmt.Sprintf("the client may not request the scope %s", scope))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+)
+
+// Consent is what a user granted to a client
+type Consent struct {
+	ClientID   uuid.UUID
+	ClientName string
+	Scopes     []string
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+// RecordConsent remembers the scopes a user granted to a client,
+// scopes granted before are kept
+func (s *Storage) RecordConsent(ctx context.Context, userID, clientID uuid.UUID, scopes []string) error {
+	cmd := `
+	INSERT INTO consent (
+		user_id,
+		client_id,
+		scopes
+	) VALUES (
+		$1, $2, $3
+	) ON CONFLICT (user_id, client_id) DO UPDATE SET
+		scopes = ARRAY(
+			SELECT DISTINCT unnest(consent.scopes || EXCLUDED.scopes)
+		),
+		update_time = now()
+	`
+	_, err := s.db.ExecContext(ctx, cmd, userID, clientID, pq.Array(scopes))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// recordConsentOfRequest records the consent of the user of an auth request or
+// a backchannel authentication request, other token requests have no user granting anything
+func (s *Storage) recordConsentOfRequest(ctx context.Context, request op.TokenRequest) {
+	var userID, clientID uuid.UUID
+	switch req := request.(type) {
+	case *AuthRequest:
+		userID = req.UserID
+		clientID, _ = uuid.Parse(req.GetClientID())
+	case *BackchannelAuthRequest:
+		userID, clientID = req.UserID, req.ClientID
+	}
+	if userID == uuid.Nil || clientID == uuid.Nil {
+		return
+	}
+	err := s.RecordConsent(ctx, userID, clientID, request.GetScopes())
+	if err != nil {
+		// the tokens are issued anyway, the consent only shows up in the account portal
+		logrus.Errorf("RecordConsent: %v", err)
+	}
+}
+
+// ListConsents returns the clients the user granted access
+func (s *Storage) ListConsents(ctx context.Context, userID uuid.UUID) ([]*Consent, error) {
+	cmd := `
+	SELECT
+		consent.client_id,
+		COALESCE(client.name, ''),
+		consent.scopes,
+		consent.create_time,
+		consent.update_time
+	FROM
+		consent
+	LEFT JOIN client ON client.id = consent.client_id
+	WHERE
+		consent.user_id = $1
+	ORDER BY consent.update_time DESC
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, userID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var consents []*Consent
+	for rows.Next() {
+		c := &Consent{}
+		err := rows.Scan(
+			&c.ClientID,
+			&c.ClientName,
+			pq.Array(&c.Scopes),
+			&c.CreateTime,
+			&c.UpdateTime,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		consents = append(consents, c)
+	}
+	return consents, rows.Err()
+}
+
+// RevokeConsent withdraws the consent of the user to a client,
+// and revokes all tokens the client holds for the user
+func (s *Storage) RevokeConsent(ctx context.Context, userID, clientID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+	DELETE FROM consent
+	WHERE user_id = $1
+	AND client_id = $2
+	`, userID, clientID)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = tx.Rollback()
+		return errors.New("no consent to the client")
+	}
+	err = s.DeleteTokenByApplicationAndSubject(ctx, tx, clientID, userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM refresh_token
+	WHERE application_id = $1
+	AND user_id = $2
+	`, clientID.String(), userID.String())
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for k, t := range s.refreshTokens {
+		if t.UserID == userID && t.ApplicationID == clientID {
+			delete(s.refreshTokens, k)
+		}
+	}
+	return nil
+}
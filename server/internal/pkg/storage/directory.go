@@ -0,0 +1,389 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/directory"
+)
+
+var (
+	ErrDirectoryNotFound = errors.New("directory not found")
+	// ErrDirectoryUser rejects password changes of users whose password lives in a directory
+	ErrDirectoryUser = errors.New("the password of the user is managed by its directory")
+)
+
+const (
+	// ScopeGroups asks for the groups claim, the groups of directory users
+	ScopeGroups = "groups"
+	ClaimGroups = "groups"
+)
+
+const directoryColumns = `
+		id,
+		namespace_id,
+		name,
+		kind,
+		priority,
+		url,
+		start_tls,
+		insecure_skip_verify,
+		root_ca,
+		bind_dn,
+		bind_password,
+		base_dn,
+		user_filter,
+		group_base_dn,
+		group_filter,
+		group_name_attribute,
+		attribute_mapping,
+		sync_users,
+		enabled
+`
+
+func scanDirectory(row interface{ Scan(...any) error }) (*directory.Directory, error) {
+	d := &directory.Directory{}
+	err := row.Scan(
+		&d.ID,
+		&d.NamespaceID,
+		&d.Name,
+		&d.Kind,
+		&d.Priority,
+		&d.URL,
+		&d.StartTLS,
+		&d.InsecureSkipVerify,
+		&d.RootCA,
+		&d.BindDN,
+		&d.BindPassword,
+		&d.BaseDN,
+		&d.UserFilter,
+		&d.GroupBaseDN,
+		&d.GroupFilter,
+		&d.GroupNameAttribute,
+		&d.AttributeMapping,
+		&d.SyncUsers,
+		&d.Enabled,
+	)
+	return d, err
+}
+
+// ListDirectories returns the directories of a namespace by priority
+func (s *Storage) ListDirectories(ctx context.Context, namespace uuid.UUID, enabledOnly bool) ([]*directory.Directory, error) {
+	cmd := `
+	SELECT` + directoryColumns + `
+	FROM
+		directory
+	WHERE
+		namespace_id = $1
+	AND (enabled OR NOT $2)
+	ORDER BY priority, name
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace, enabledOnly)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var directories []*directory.Directory
+	for rows.Next() {
+		d, err := scanDirectory(rows)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		directories = append(directories, d)
+	}
+	return directories, rows.Err()
+}
+
+func (s *Storage) GetDirectory(ctx context.Context, id uuid.UUID) (*directory.Directory, error) {
+	cmd := `
+	SELECT` + directoryColumns + `
+	FROM
+		directory
+	WHERE
+		id = $1
+	`
+	d, err := scanDirectory(s.db.QueryRowContext(ctx, cmd, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDirectoryNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return d, nil
+}
+
+// SaveDirectory creates the directory if its id is nil, else updates it.
+// An empty bind password keeps the stored one.
+func (s *Storage) SaveDirectory(ctx context.Context, d *directory.Directory) error {
+	// the configuration must at least make an authenticator
+	_, err := directory.New(d)
+	if err != nil {
+		return err
+	}
+	if d.ID == uuid.Nil {
+		cmd := `
+		INSERT INTO directory (
+			namespace_id,
+			name,
+			kind,
+			priority,
+			url,
+			start_tls,
+			insecure_skip_verify,
+			root_ca,
+			bind_dn,
+			bind_password,
+			base_dn,
+			user_filter,
+			group_base_dn,
+			group_filter,
+			group_name_attribute,
+			attribute_mapping,
+			sync_users,
+			enabled
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+		) RETURNING id
+		`
+		err = s.db.QueryRowContext(ctx, cmd,
+			d.NamespaceID,
+			d.Name,
+			d.Kind,
+			d.Priority,
+			d.URL,
+			d.StartTLS,
+			d.InsecureSkipVerify,
+			d.RootCA,
+			d.BindDN,
+			d.BindPassword,
+			d.BaseDN,
+			d.UserFilter,
+			d.GroupBaseDN,
+			d.GroupFilter,
+			d.GroupNameAttribute,
+			d.AttributeMapping,
+			d.SyncUsers,
+			d.Enabled,
+		).Scan(&d.ID)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		return nil
+	}
+
+	cmd := `
+	UPDATE directory
+	SET name = $2,
+		kind = $3,
+		priority = $4,
+		url = $5,
+		start_tls = $6,
+		insecure_skip_verify = $7,
+		root_ca = $8,
+		bind_dn = $9,
+		bind_password = CASE WHEN $10 = '' THEN bind_password ELSE $10 END,
+		base_dn = $11,
+		user_filter = $12,
+		group_base_dn = $13,
+		group_filter = $14,
+		group_name_attribute = $15,
+		attribute_mapping = $16,
+		sync_users = $17,
+		enabled = $18
+	WHERE id = $1
+	`
+	res, err := s.db.ExecContext(ctx, cmd,
+		d.ID,
+		d.Name,
+		d.Kind,
+		d.Priority,
+		d.URL,
+		d.StartTLS,
+		d.InsecureSkipVerify,
+		d.RootCA,
+		d.BindDN,
+		d.BindPassword,
+		d.BaseDN,
+		d.UserFilter,
+		d.GroupBaseDN,
+		d.GroupFilter,
+		d.GroupNameAttribute,
+		d.AttributeMapping,
+		d.SyncUsers,
+		d.Enabled,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDirectoryNotFound
+	}
+	return nil
+}
+
+// DeleteDirectory removes the directory, its users stay without password
+// until an admin sets one
+func (s *Storage) DeleteDirectory(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	UPDATE "user"
+	SET directory_id = NULL,
+		password = ''
+	WHERE directory_id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+	DELETE FROM directory
+	WHERE id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = tx.Rollback()
+		return ErrDirectoryNotFound
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// errDirectoryLoginFailed counts as a failed login like a wrong local password
+var errDirectoryLoginFailed = errors.New("directory login failed")
+
+// directoryLogin checks the password of a user in its directory. Unknown users
+// (us is nil) are looked up in the syncing directories of the namespace and
+// created at their first login.
+func (s *Storage) directoryLogin(ctx context.Context, us *User, namespace uuid.UUID, username, plain string) (*User, error) {
+	if us != nil {
+		d, err := s.GetDirectory(ctx, us.DirectoryID)
+		if err != nil {
+			return nil, err
+		}
+		if !d.Enabled {
+			return nil, errDirectoryLoginFailed
+		}
+		entry, err := authenticateDirectory(ctx, d, username, plain)
+		if err != nil {
+			return nil, err
+		}
+		if d.SyncUsers {
+			err = s.syncDirectoryUser(ctx, us.ID, entry)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return us, nil
+	}
+
+	directories, err := s.ListDirectories(ctx, namespace, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range directories {
+		if !d.SyncUsers {
+			continue
+		}
+		entry, err := authenticateDirectory(ctx, d, username, plain)
+		if errors.Is(err, directory.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		id, err := s.CreateUser(ctx, &User{
+			Username:    username,
+			FirstName:   entry.GivenName,
+			LastName:    entry.FamilyName,
+			Nickname:    entry.Nickname,
+			Email:       entry.Email,
+			Phone:       entry.Phone,
+			NamespaceID: namespace,
+			// the directory vouches for the address
+			EmailVerified: entry.Email != "",
+			DirectoryID:   d.ID,
+			Groups:        entry.Groups,
+		}, "")
+		if err != nil {
+			return nil, err
+		}
+		return s.GetUserByID(ctx, id)
+	}
+	return nil, errDirectoryLoginFailed
+}
+
+// authenticateDirectory maps failed logins to errDirectoryLoginFailed
+func authenticateDirectory(ctx context.Context, d *directory.Directory, username, plain string) (*directory.Entry, error) {
+	a, err := directory.New(d)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	entry, err := a.Authenticate(ctx, username, plain)
+	if errors.Is(err, directory.ErrInvalidCredentials) {
+		return nil, errDirectoryLoginFailed
+	}
+	if err != nil && !errors.Is(err, directory.ErrUserNotFound) {
+		logrus.Error(err)
+	}
+	return entry, err
+}
+
+// syncDirectoryUser copies the profile and groups of the directory to the user
+func (s *Storage) syncDirectoryUser(ctx context.Context, userID uuid.UUID, entry *directory.Entry) error {
+	cmd := `
+	UPDATE "user"
+	SET given_name = $2,
+		family_name = $3,
+		nickname = $4,
+		email = $5,
+		email_verified = $5 <> '',
+		phone_number = $6,
+		groups = $7,
+		updated_at = now()
+	WHERE id = $1
+	`
+	_, err := s.db.ExecContext(ctx, cmd,
+		userID,
+		entry.GivenName,
+		entry.FamilyName,
+		entry.Nickname,
+		entry.Email,
+		entry.Phone,
+		pq.Array(nonNilStrings(entry.Groups)),
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
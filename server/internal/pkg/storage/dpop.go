@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/dpop"
+)
+
+type dpopKey struct{}
+
+// WithDPoPProof passes the JWK thumbprint of the validated DPoP proof of a
+// request to the storage, the tokens it issues are bound to the key, and the
+// tokens bound to it are accepted
+func WithDPoPProof(ctx context.Context, jkt string) context.Context {
+	return context.WithValue(ctx, dpopKey{}, jkt)
+}
+
+func dpopOf(ctx context.Context) string {
+	jkt, _ := ctx.Value(dpopKey{}).(string)
+	return jkt
+}
+
+// checkDPoPBinding tells if the request may use a token bound to the thumbprint
+func checkDPoPBinding(ctx context.Context, jkt string) error {
+	if jkt != "" && dpopOf(ctx) != jkt {
+		return dpop.ErrInvalidProof("the token is bound to another DPoP key")
+	}
+	return nil
+}
+
+// SeenDPoPProof records the jti of a proof of the key until the proof expires,
+// it tells if the proof was used before
+func (s *Storage) SeenDPoPProof(ctx context.Context, jkt, jti string, expire time.Time) (bool, error) {
+	cmd := `
+	INSERT INTO dpop_proof (
+		jti,
+		expire_time
+	) VALUES (
+		$1, $2
+	) ON CONFLICT (jti) DO UPDATE SET
+		expire_time = EXCLUDED.expire_time
+	WHERE dpop_proof.expire_time <= now()
+	`
+	res, err := s.db.ExecContext(ctx, cmd, jkt+" "+jti, expire)
+	if err != nil {
+		logrus.Error(err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		logrus.Error(err)
+		return false, err
+	}
+	return n == 0, nil
+}
+
+// DeleteExpiredDPoPProofs removes the jtis of proofs which are not accepted anymore
+func (s *Storage) DeleteExpiredDPoPProofs(ctx context.Context) error {
+	cmd := `
+	DELETE FROM dpop_proof
+	WHERE expire_time <= now()
+	`
+	_, err := s.db.ExecContext(ctx, cmd)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// fakeDB is a database/sql connector which answers the statements of the
+// storage with the handlers of a test, so the storage runs without postgres.
+// A statement is answered by the first handler whose pattern it contains,
+// the rows a handler returns are the rows affected by an exec.
+type fakeDB struct {
+	mu       sync.Mutex
+	handlers []fakeHandler
+}
+
+type fakeHandler struct {
+	pattern string
+	answer  func(query string, args []driver.Value) [][]driver.Value
+}
+
+// on adds the handler of the statements containing pattern
+func (db *fakeDB) on(pattern string, answer func(args []driver.Value) [][]driver.Value) {
+	db.onQuery(pattern, func(_ string, args []driver.Value) [][]driver.Value {
+		return answer(args)
+	})
+}
+
+// onQuery adds a handler which also gets the statement, e.g. to answer
+// the columns a jet select asks for
+func (db *fakeDB) onQuery(pattern string, answer func(query string, args []driver.Value) [][]driver.Value) {
+	db.handlers = append(db.handlers, fakeHandler{pattern, answer})
+}
+
+func (db *fakeDB) storage() *Storage {
+	return &Storage{db: sql.OpenDB(db)}
+}
+
+func (db *fakeDB) run(query string, named []driver.NamedValue) ([][]driver.Value, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	for _, h := range db.handlers {
+		if strings.Contains(query, h.pattern) {
+			return h.answer(query, args), nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected statement: %s", query)
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+func (db *fakeDB) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (db *fakeDB) Close() error                                 { return nil }
+func (db *fakeDB) Begin() (driver.Tx, error)                    { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (db *fakeDB) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+func (db *fakeDB) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,427 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/federation"
+)
+
+var ErrProviderNotFound = errors.New("identity provider not found")
+
+const identityProviderColumns = `
+		id,
+		namespace_id,
+		name,
+		issuer,
+		client_id,
+		client_secret,
+		scopes,
+		claim_mapping,
+		jit_provisioning,
+		link_by_email,
+		enabled
+`
+
+func scanIdentityProvider(row interface{ Scan(...any) error }) (*federation.Provider, error) {
+	p := &federation.Provider{}
+	err := row.Scan(
+		&p.ID,
+		&p.NamespaceID,
+		&p.Name,
+		&p.Issuer,
+		&p.ClientID,
+		&p.ClientSecret,
+		pq.Array(&p.Scopes),
+		&p.ClaimMapping,
+		&p.JITProvisioning,
+		&p.LinkByEmail,
+		&p.Enabled,
+	)
+	return p, err
+}
+
+// ListIdentityProviders returns the upstream providers of a namespace
+func (s *Storage) ListIdentityProviders(ctx context.Context, namespace uuid.UUID, enabledOnly bool) ([]*federation.Provider, error) {
+	cmd := `
+	SELECT` + identityProviderColumns + `
+	FROM
+		identity_provider
+	WHERE
+		namespace_id = $1
+	AND (enabled OR NOT $2)
+	ORDER BY name
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace, enabledOnly)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var providers []*federation.Provider
+	for rows.Next() {
+		p, err := scanIdentityProvider(rows)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, rows.Err()
+}
+
+// ClientIdentityProviders returns the enabled providers of the namespace of the client's users
+func (s *Storage) ClientIdentityProviders(ctx context.Context, clientID uuid.UUID) ([]*federation.Provider, error) {
+	client, err := s.GetClientByUUID(ctx, clientID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return s.ListIdentityProviders(ctx, client.userNamespaceID, true)
+}
+
+func (s *Storage) GetIdentityProvider(ctx context.Context, id uuid.UUID) (*federation.Provider, error) {
+	cmd := `
+	SELECT` + identityProviderColumns + `
+	FROM
+		identity_provider
+	WHERE
+		id = $1
+	`
+	p, err := scanIdentityProvider(s.db.QueryRowContext(ctx, cmd, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProviderNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// SaveIdentityProvider creates the provider if its id is nil, else updates it.
+// An empty client secret keeps the stored one.
+func (s *Storage) SaveIdentityProvider(ctx context.Context, p *federation.Provider) error {
+	_, err := federation.ParseMapping(p.ClaimMapping)
+	if err != nil {
+		return err
+	}
+	if p.ID == uuid.Nil {
+		cmd := `
+		INSERT INTO identity_provider (
+			namespace_id,
+			name,
+			issuer,
+			client_id,
+			client_secret,
+			scopes,
+			claim_mapping,
+			jit_provisioning,
+			link_by_email,
+			enabled
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		) RETURNING id
+		`
+		err = s.db.QueryRowContext(ctx, cmd,
+			p.NamespaceID,
+			p.Name,
+			p.Issuer,
+			p.ClientID,
+			p.ClientSecret,
+			pq.Array(p.Scopes),
+			p.ClaimMapping,
+			p.JITProvisioning,
+			p.LinkByEmail,
+			p.Enabled,
+		).Scan(&p.ID)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		return nil
+	}
+
+	cmd := `
+	UPDATE identity_provider
+	SET name = $2,
+		issuer = $3,
+		client_id = $4,
+		client_secret = CASE WHEN $5 = '' THEN client_secret ELSE $5 END,
+		scopes = $6,
+		claim_mapping = $7,
+		jit_provisioning = $8,
+		link_by_email = $9,
+		enabled = $10
+	WHERE id = $1
+	`
+	res, err := s.db.ExecContext(ctx, cmd,
+		p.ID,
+		p.Name,
+		p.Issuer,
+		p.ClientID,
+		p.ClientSecret,
+		pq.Array(p.Scopes),
+		p.ClaimMapping,
+		p.JITProvisioning,
+		p.LinkByEmail,
+		p.Enabled,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrProviderNotFound
+	}
+	return nil
+}
+
+// DeleteIdentityProvider removes the provider and the identities linked with it
+func (s *Storage) DeleteIdentityProvider(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM user_identity
+	WHERE provider_id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+	DELETE FROM identity_provider
+	WHERE id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = tx.Rollback()
+		return ErrProviderNotFound
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// IdentityUser implements federation.Store
+func (s *Storage) IdentityUser(ctx context.Context, providerID uuid.UUID, subject string) (uuid.UUID, error) {
+	cmd := `
+	UPDATE user_identity
+	SET last_login_time = now()
+	WHERE provider_id = $1
+	AND subject = $2
+	RETURNING user_id
+	`
+	var userID uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, providerID, subject).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, federation.ErrNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// UserByVerifiedEmail implements federation.Store
+func (s *Storage) UserByVerifiedEmail(ctx context.Context, namespace uuid.UUID, email string) (uuid.UUID, error) {
+	cmd := `
+	SELECT
+		id
+	FROM
+		"user"
+	WHERE
+		namespace_id = $1
+	AND lower(email) = lower($2)
+	AND email_verified
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace, email)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		err := rows.Scan(&id)
+		if err != nil {
+			logrus.Error(err)
+			return uuid.Nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	// several users with the address can not tell which one to link
+	if len(ids) != 1 {
+		return uuid.Nil, federation.ErrNotFound
+	}
+	return ids[0], nil
+}
+
+// CreateFederatedUser implements federation.Store, the user has no password
+func (s *Storage) CreateFederatedUser(ctx context.Context, namespace uuid.UUID, profile *federation.Profile) (uuid.UUID, error) {
+	id, err := s.CreateUser(ctx, &User{
+		Username:      profile.Username,
+		FirstName:     profile.GivenName,
+		LastName:      profile.FamilyName,
+		Nickname:      profile.Nickname,
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+		Phone:         profile.Phone,
+		NamespaceID:   namespace,
+	}, "")
+	if errors.Is(err, ErrUsernameTaken) {
+		return uuid.Nil, federation.ErrUsernameTaken
+	}
+	return id, err
+}
+
+// LinkIdentity implements federation.Store
+func (s *Storage) LinkIdentity(ctx context.Context, providerID uuid.UUID, subject string, userID uuid.UUID, email string) error {
+	cmd := `
+	INSERT INTO user_identity (
+		provider_id,
+		subject,
+		user_id,
+		email
+	) VALUES (
+		$1, $2, $3, $4
+	)
+	`
+	_, err := s.db.ExecContext(ctx, cmd, providerID, subject, userID, email)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// UserIdentity is an upstream identity linked to a user
+type UserIdentity struct {
+	ProviderID    uuid.UUID
+	ProviderName  string
+	Subject       string
+	Email         string
+	CreateTime    time.Time
+	LastLoginTime time.Time
+}
+
+func (s *Storage) ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]*UserIdentity, error) {
+	cmd := `
+	SELECT
+		user_identity.provider_id,
+		COALESCE(identity_provider.name, ''),
+		user_identity.subject,
+		user_identity.email,
+		user_identity.create_time,
+		user_identity.last_login_time
+	FROM
+		user_identity
+	LEFT JOIN identity_provider ON identity_provider.id = user_identity.provider_id
+	WHERE
+		user_identity.user_id = $1
+	ORDER BY user_identity.create_time
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, userID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var identities []*UserIdentity
+	for rows.Next() {
+		i := &UserIdentity{}
+		err := rows.Scan(
+			&i.ProviderID,
+			&i.ProviderName,
+			&i.Subject,
+			&i.Email,
+			&i.CreateTime,
+			&i.LastLoginTime,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+// UnlinkIdentity removes an upstream identity of the user
+func (s *Storage) UnlinkIdentity(ctx context.Context, userID, providerID uuid.UUID, subject string) error {
+	cmd := `
+	DELETE FROM user_identity
+	WHERE user_id = $1
+	AND provider_id = $2
+	AND subject = $3
+	`
+	_, err := s.db.ExecContext(ctx, cmd, userID, providerID, subject)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// CompleteFederatedLogin logs the user of an upstream identity in to the auth request
+func (s *Storage) CompleteFederatedLogin(ctx context.Context, reqid string, userID uuid.UUID) error {
+	requid, err := uuid.Parse(reqid)
+	if err != nil {
+		return err
+	}
+	request, err := s.GetAuthRequestByUUID(ctx, requid)
+	if err != nil {
+		logrus.Error(err)
+		return errors.New("request not found")
+	}
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	client, err := s.GetClientByUUID(ctx, uuid.MustParse(request.GetClientID()))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if user.NamespaceID != client.userNamespaceID {
+		return errors.New("the user does not belong to the client")
+	}
+	err = s.checkRegistrationDone(ctx, user)
+	if err != nil {
+		return err
+	}
+	// the upstream provider is responsible for its factors, no local second factor is asked
+	request.UserID = user.ID
+	request.AMR = []string{federation.AMRFederated}
+	request.AuthTime = time.Now()
+	request.IsDone = true
+	err = s.UpdateAuthRequest(ctx, request)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
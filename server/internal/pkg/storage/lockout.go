@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+// failed password logins are counted per account and per client ip.
+// after some free failures every further one doubles the time in which
+// no attempt is checked, until the maximum, which is the temporary lockout.
+const (
+	accountFreeFailures = 3
+	// many users may share one address behind a NAT
+	ipFreeFailures   = 20
+	lockoutBaseDelay = time.Second
+	lockoutMaxDelay  = 15 * time.Minute
+	// counters are forgotten after this time without a failure
+	lockoutResetAfter = 24 * time.Hour
+	// loginMinDuration is the least time a password login takes, longer than the
+	// slowest hash, so the time does not tell which usernames exist
+	loginMinDuration = 500 * time.Millisecond
+)
+
+// the login reports the same errors whether the user exists or not
+var (
+	ErrLoginFailed = errors.New("username or password wrong")
+	ErrLoginLocked = errors.New("too many failed attempts, try again later")
+)
+
+// dummyPasswordHash is compared against when the user does not exist,
+// so that a login takes the same time for known and unknown usernames
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := password.CreateHash(uuid.NewString())
+	if err != nil {
+		logrus.Error(err)
+	}
+	return hash
+})
+
+// waitLogin sleeps until the login started at start took loginMinDuration
+func waitLogin(start time.Time) {
+	time.Sleep(time.Until(start.Add(loginMinDuration)))
+}
+
+// the account key uses the username and not the user id,
+// so unknown usernames get locked the same way as existing ones
+func accountLockKey(namespace uuid.UUID, username string) string {
+	return "user:" + namespace.String() + ":" + strings.ToLower(username)
+}
+
+func ipLockKey(ip string) string {
+	return "ip:" + ip
+}
+
+// lockoutDelay returns how long no attempt is checked after the given number of failures
+func lockoutDelay(failures, free int) time.Duration {
+	if failures < free {
+		return 0
+	}
+	n := failures - free
+	if n >= 30 {
+		return lockoutMaxDelay
+	}
+	d := lockoutBaseDelay << n
+	if d > lockoutMaxDelay {
+		return lockoutMaxDelay
+	}
+	return d
+}
+
+// lockKey is a counter of failed logins and the failures it allows without delay
+type lockKey struct {
+	key  string
+	free int
+}
+
+// acquireLoginAttempt counts an attempt on the keys as a failure before the
+// password is checked, so parallel guesses can not all pass the lock check.
+// It returns ErrLoginLocked without counting if any of the keys is locked.
+func (s *Storage) acquireLoginAttempt(ctx context.Context, keys ...lockKey) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key < keys[j].key })
+	failures := make([]int, len(keys))
+	for i, k := range keys {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO login_failure (
+			key
+		) VALUES (
+			$1
+		) ON CONFLICT (key) DO NOTHING
+		`, k.key)
+		if err != nil {
+			logrus.Error(err)
+			_ = tx.Rollback()
+			return err
+		}
+		var locked, expired bool
+		err = tx.QueryRowContext(ctx, `
+		SELECT
+			failures,
+			locked_until > now(),
+			last_failure_time < now() - make_interval(secs => $2)
+		FROM
+			login_failure
+		WHERE
+			key = $1
+		FOR UPDATE
+		`, k.key, lockoutResetAfter.Seconds()).Scan(&failures[i], &locked, &expired)
+		if err != nil {
+			logrus.Error(err)
+			_ = tx.Rollback()
+			return err
+		}
+		if locked {
+			_ = tx.Rollback()
+			return ErrLoginLocked
+		}
+		if expired {
+			failures[i] = 0
+		}
+	}
+	for i, k := range keys {
+		n := failures[i] + 1
+		_, err = tx.ExecContext(ctx, `
+		UPDATE login_failure
+		SET failures=$2,
+			last_failure_time=now(),
+			locked_until=now() + make_interval(secs => $3)
+		WHERE key=$1
+		`, k.key, n, lockoutDelay(n, k.free).Seconds())
+		if err != nil {
+			logrus.Error(err)
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// releaseLoginAttempt takes back the failure acquireLoginAttempt counted, for
+// the keys of successful logins which are not reset, and for attempts which
+// could not be checked
+func (s *Storage) releaseLoginAttempt(ctx context.Context, keys ...lockKey) error {
+	for _, k := range keys {
+		_, err := s.db.ExecContext(ctx, `
+		UPDATE login_failure
+		SET failures=greatest(failures - 1, 0),
+			locked_until=CASE WHEN failures - 1 < $2 THEN least(locked_until, now()) ELSE locked_until END
+		WHERE key=$1
+		`, k.key, k.free)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ResetLoginFailures forgets the failed attempts of the keys
+func (s *Storage) ResetLoginFailures(ctx context.Context, keys ...string) error {
+	cmd := `
+	DELETE FROM login_failure
+	WHERE key = ANY($1)
+	`
+	_, err := s.db.ExecContext(ctx, cmd, pq.Array(keys))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// UnlockUser removes the lockout of a user account
+func (s *Storage) UnlockUser(ctx context.Context, userID uuid.UUID) error {
+	cmd := `
+	SELECT
+		namespace_id,
+		username
+	FROM
+		"user"
+	WHERE
+		id = $1
+	`
+	var (
+		namespace uuid.UUID
+		username  string
+	)
+	err := s.db.QueryRowContext(ctx, cmd, userID).Scan(&namespace, &username)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return s.ResetLoginFailures(ctx, accountLockKey(namespace, username))
+}
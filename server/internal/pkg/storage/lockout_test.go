@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLockoutDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		free     int
+		want     time.Duration
+	}{
+		{0, accountFreeFailures, 0},
+		{accountFreeFailures - 1, accountFreeFailures, 0},
+		{accountFreeFailures, accountFreeFailures, time.Second},
+		{accountFreeFailures + 1, accountFreeFailures, 2 * time.Second},
+		{accountFreeFailures + 5, accountFreeFailures, 32 * time.Second},
+		{accountFreeFailures + 9, accountFreeFailures, 512 * time.Second},
+		// 1024s is over the maximum
+		{accountFreeFailures + 10, accountFreeFailures, lockoutMaxDelay},
+		{accountFreeFailures + 30, accountFreeFailures, lockoutMaxDelay},
+		// the shift must not overflow
+		{accountFreeFailures + 100, accountFreeFailures, lockoutMaxDelay},
+		{ipFreeFailures - 1, ipFreeFailures, 0},
+		{ipFreeFailures, ipFreeFailures, time.Second},
+	}
+	for _, tt := range tests {
+		got := lockoutDelay(tt.failures, tt.free)
+		if got != tt.want {
+			t.Errorf("lockoutDelay(%d, %d) = %s, want %s", tt.failures, tt.free, got, tt.want)
+		}
+	}
+}
+
+func TestLoginAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := newLoginFailureDB()
+	s := &Storage{db: sql.OpenDB(db)}
+	namespace := uuid.New()
+	account := lockKey{accountLockKey(namespace, "Alice"), accountFreeFailures}
+	ip := lockKey{ipLockKey("192.0.2.1"), ipFreeFailures}
+
+	attempt := func() error {
+		t.Helper()
+		err := s.acquireLoginAttempt(ctx, account, ip)
+		if err != nil && err != ErrLoginLocked {
+			t.Fatal(err)
+		}
+		return err
+	}
+
+	for i := 0; i < accountFreeFailures; i++ {
+		if attempt() != nil {
+			t.Fatalf("attempt %d locked within the free failures", i+1)
+		}
+	}
+	if attempt() != ErrLoginLocked {
+		t.Fatal("not locked after the free failures")
+	}
+	if db.rows[account.key].failures != accountFreeFailures {
+		t.Errorf("the locked attempt was counted: %d failures", db.rows[account.key].failures)
+	}
+	if accountLockKey(namespace, "alice") != account.key {
+		t.Error("the account key depends on the case of the username")
+	}
+
+	// a successful login forgets the failures of the account,
+	// the address only forgets the attempt of the login
+	err := s.ResetLoginFailures(ctx, account.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.releaseLoginAttempt(ctx, ip); err != nil {
+		t.Fatal(err)
+	}
+	if attempt() != nil {
+		t.Fatal("still locked after the reset")
+	}
+	if db.rows[ip.key].failures != accountFreeFailures {
+		t.Errorf("the address has %d failures, want %d", db.rows[ip.key].failures, accountFreeFailures)
+	}
+
+	// and so does a day without failures
+	attempt()
+	attempt()
+	db.now = db.now.Add(lockoutResetAfter + time.Minute)
+	if attempt() != nil {
+		t.Error("failures older than a day still count")
+	}
+	if db.rows[account.key].failures != 1 {
+		t.Errorf("the account has %d failures after a day, want 1", db.rows[account.key].failures)
+	}
+}
+
+func TestLoginAttemptsParallel(t *testing.T) {
+	ctx := context.Background()
+	db := newLoginFailureDB()
+	s := &Storage{db: sql.OpenDB(db)}
+	account := lockKey{accountLockKey(uuid.New(), "bob"), accountFreeFailures}
+
+	// parallel guesses get no more than the free failures
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.acquireLoginAttempt(ctx, account)
+			if err != nil && err != ErrLoginLocked {
+				t.Error(err)
+			}
+			if err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != accountFreeFailures {
+		t.Errorf("%d parallel attempts allowed, want %d", allowed, accountFreeFailures)
+	}
+}
+
+func TestReleaseLoginAttempt(t *testing.T) {
+	ctx := context.Background()
+	db := newLoginFailureDB()
+	s := &Storage{db: sql.OpenDB(db)}
+	ip := lockKey{ipLockKey("192.0.2.2"), 2}
+
+	for i := 0; i < 2; i++ {
+		if err := s.acquireLoginAttempt(ctx, ip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the second attempt locked the address, it was the login of a user
+	if err := s.releaseLoginAttempt(ctx, ip); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.acquireLoginAttempt(ctx, ip); err != nil {
+		t.Errorf("the released attempt still locks: %v", err)
+	}
+}
+
+// loginFailureDB is a database/sql connector which runs the login_failure
+// statements of lockout.go on a map, its transactions run one at a time
+type loginFailureDB struct {
+	mu   sync.Mutex
+	tx   sync.Mutex
+	now  time.Time
+	rows map[string]*loginFailureRow
+}
+
+func newLoginFailureDB() *loginFailureDB {
+	return &loginFailureDB{now: time.Now(), rows: map[string]*loginFailureRow{}}
+}
+
+type loginFailureRow struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func (db *loginFailureDB) Connect(context.Context) (driver.Conn, error) { return db, nil }
+func (db *loginFailureDB) Driver() driver.Driver                        { return nil }
+func (db *loginFailureDB) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (db *loginFailureDB) Close() error                                 { return nil }
+
+func (db *loginFailureDB) Begin() (driver.Tx, error) {
+	db.tx.Lock()
+	return loginFailureTx{db}, nil
+}
+
+type loginFailureTx struct {
+	db *loginFailureDB
+}
+
+func (tx loginFailureTx) Commit() error   { tx.db.tx.Unlock(); return nil }
+func (tx loginFailureTx) Rollback() error { tx.db.tx.Unlock(); return nil }
+
+func (db *loginFailureDB) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	switch {
+	case strings.Contains(query, "INSERT INTO login_failure"):
+		key := args[0].Value.(string)
+		if _, ok := db.rows[key]; !ok {
+			db.rows[key] = &loginFailureRow{lastFailure: db.now, lockedUntil: db.now}
+		}
+	case strings.Contains(query, "greatest(failures - 1, 0)"):
+		row, ok := db.rows[args[0].Value.(string)]
+		if !ok {
+			break
+		}
+		if row.failures > 0 {
+			row.failures--
+		}
+		if row.failures < int(args[1].Value.(int64)) && row.lockedUntil.After(db.now) {
+			row.lockedUntil = db.now
+		}
+	case strings.Contains(query, "UPDATE login_failure"):
+		row := db.rows[args[0].Value.(string)]
+		row.failures = int(args[1].Value.(int64))
+		row.lastFailure = db.now
+		row.lockedUntil = db.now.Add(time.Duration(args[2].Value.(float64) * float64(time.Second)))
+	case strings.Contains(query, "DELETE FROM login_failure"):
+		for _, key := range pgArray(args[0].Value) {
+			delete(db.rows, key)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (db *loginFailureDB) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if !strings.Contains(query, "FOR UPDATE") {
+		return nil, driver.ErrSkip
+	}
+	row := db.rows[args[0].Value.(string)]
+	resetAfter := time.Duration(args[1].Value.(float64) * float64(time.Second))
+	return &loginFailureRows{values: []driver.Value{
+		int64(row.failures),
+		row.lockedUntil.After(db.now),
+		row.lastFailure.Before(db.now.Add(-resetAfter)),
+	}}, nil
+}
+
+// pgArray parses the text of a pq.Array of strings
+func pgArray(v driver.Value) []string {
+	s := strings.Trim(v.(string), "{}")
+	if s == "" {
+		return nil
+	}
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		keys = append(keys, strings.Trim(key, `"`))
+	}
+	return keys
+}
+
+// loginFailureRows is a result of one row
+type loginFailureRows struct {
+	values []driver.Value
+}
+
+func (r *loginFailureRows) Columns() []string { return []string{"failures", "locked", "expired"} }
+func (r *loginFailureRows) Close() error      { return nil }
+
+func (r *loginFailureRows) Next(dest []driver.Value) error {
+	if r.values == nil {
+		return io.EOF
+	}
+	copy(dest, r.values)
+	r.values = nil
+	return nil
+}
@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+)
+
+const (
+	// ClaimSessionID is the claim of the id and logout tokens naming the user_session
+	ClaimSessionID = "sid"
+	// EventBackchannelLogout is the event of logout tokens
+	EventBackchannelLogout = "http://schemas.openid.net/event/backchannel-logout"
+
+	// logoutTokenLifetime is how long clients accept a logout token
+	logoutTokenLifetime = 2 * time.Minute
+	// BackchannelLogoutAttempts is how often a logout token is posted before it is dropped,
+	// the attempts are 30s, 1m, 2m, ... apart
+	BackchannelLogoutAttempts = 8
+)
+
+// SetAuthRequestSession remembers the user_session the login of an auth request
+// started, its id tokens name it in sid and the client is told when it ends
+func (s *Storage) SetAuthRequestSession(ctx context.Context, id string, sessionID uuid.UUID) error {
+	reqid, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	req, err := s.TXGetAuthRequestByUUID(ctx, tx, reqid)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	UPDATE auth_request
+	SET session_id = $2
+	WHERE id = $1
+	`, reqid, sessionID)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO user_session_client (
+		session_id,
+		client_id,
+		user_id
+	) VALUES (
+		$1, $2, $3
+	) ON CONFLICT DO NOTHING
+	`, sessionID, req.GetClientID(), req.UserID)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// sessionOf is the sid of the tokens of a request, empty outside of browser sessions
+func sessionOf(req any) string {
+	switch r := req.(type) {
+	case *AuthRequest:
+		if r.SessionID != uuid.Nil {
+			return r.SessionID.String()
+		}
+	case *RefreshTokenRequest:
+		return r.SessionID
+	}
+	return ""
+}
+
+// EndedSession is the user_session an end_session request ended
+type EndedSession struct {
+	// Browser is the session of the cookie of the browser, ended if the
+	// id_token_hint does not name another one
+	Browser uuid.UUID
+	ID      uuid.UUID
+	// Frontchannel are the frontchannel_logout_uris of the clients of the session,
+	// rendered in iframes
+	Frontchannel []string
+}
+
+type endedSessionKey struct{}
+
+// WithEndedSession passes the session of the browser to TerminateSessionFromRequest,
+// which tells the session it ended
+func WithEndedSession(ctx context.Context, browser uuid.UUID) (context.Context, *EndedSession) {
+	e := &EndedSession{Browser: browser}
+	return context.WithValue(ctx, endedSessionKey{}, e), e
+}
+
+// TerminateSessionFromRequest implements the op.CanTerminateSessionFromRequest interface,
+// besides the tokens of the client it ends the user_session of the sid of the
+// id_token_hint or of the browser, and tells the clients of the session
+func (s *Storage) TerminateSessionFromRequest(ctx context.Context, req *op.EndSessionRequest) (string, error) {
+	if req.UserID != "" && req.ClientID != "" {
+		err := s.TerminateSession(ctx, req.UserID, req.ClientID)
+		if err != nil {
+			return "", err
+		}
+	}
+	ended, _ := ctx.Value(endedSessionKey{}).(*EndedSession)
+	sessionID, userID := uuid.Nil, uuid.Nil
+	if ended != nil {
+		sessionID = ended.Browser
+	}
+	if req.IDTokenHintClaims != nil {
+		userID, _ = uuid.Parse(req.UserID)
+		if sid, ok := req.IDTokenHintClaims.Claims[ClaimSessionID].(string); ok {
+			sessionID, _ = uuid.Parse(sid)
+		}
+	}
+	if sessionID == uuid.Nil {
+		return req.RedirectURI, nil
+	}
+	clients, err := s.endUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if ended == nil {
+		return req.RedirectURI, nil
+	}
+	ended.ID = sessionID
+	issuer := op.IssuerFromContext(ctx)
+	for _, id := range clients {
+		client, err := s.GetClient(ctx, id.String())
+		if err != nil || client.frontchannelLogoutURI == "" {
+			continue
+		}
+		uri, err := url.Parse(client.frontchannelLogoutURI)
+		if err != nil {
+			continue
+		}
+		if client.frontchannelLogoutSessionRequired {
+			query := uri.Query()
+			query.Set("iss", issuer)
+			query.Set(ClaimSessionID, sessionID.String())
+			uri.RawQuery = query.Encode()
+		}
+		ended.Frontchannel = append(ended.Frontchannel, uri.String())
+	}
+	return req.RedirectURI, nil
+}
+
+// endUserSession ends a session of the user, of any user for uuid.Nil.
+// The tokens of the session are revoked but the offline_access ones, and the
+// clients with a backchannel_logout_uri get a logout token. It returns the
+// clients the user signed in to with the session.
+func (s *Storage) endUserSession(ctx context.Context, userID, id uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	var owner uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+	DELETE FROM user_session
+	WHERE id = $1
+	AND (user_id = $2 OR $2 = '00000000-0000-0000-0000-000000000000')
+	RETURNING user_id
+	`, id, userID).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		// ended before
+		_ = tx.Rollback()
+		return nil, nil
+	}
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return nil, err
+	}
+	var clients []uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+	WITH ended AS (
+		DELETE FROM user_session_client
+		WHERE session_id = $1
+		RETURNING client_id
+	)
+	SELECT coalesce(array_agg(client_id), '{}') FROM ended
+	`, id).Scan(pq.Array(&clients))
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return nil, err
+	}
+	cmds := []string{
+		`DELETE FROM token
+		WHERE refresh_token_id::text IN (
+			SELECT id FROM refresh_token
+			WHERE session_id = $1::text
+			AND NOT 'offline_access' = ANY(scopes)
+		)`,
+		`DELETE FROM refresh_token
+		WHERE session_id = $1::text
+		AND NOT 'offline_access' = ANY(scopes)`,
+	}
+	for _, cmd := range cmds {
+		_, err = tx.ExecContext(ctx, cmd, id)
+		if err != nil {
+			logrus.Error(err)
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO backchannel_logout (
+		client_id,
+		user_id,
+		session_id
+	)
+	SELECT
+		id, $2, $1
+	FROM
+		client
+	WHERE
+		id = ANY($3)
+	AND backchannel_logout_uri <> ''
+	`, id, owner, pq.Array(clients))
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return clients, nil
+}
+
+// BackchannelLogout is a logout token to post to a client
+type BackchannelLogout struct {
+	ID        uuid.UUID
+	ClientID  uuid.UUID
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	// Attempts counts the posts, this one included
+	Attempts int
+	// URI is the backchannel_logout_uri of the client
+	URI string
+	// Registered is set for clients of dynamic client registration,
+	// their URI must not be in the network of the server
+	Registered bool
+}
+
+// DueBackchannelLogouts claims the logout tokens to post now, a token not
+// deleted after the post is posted again after the next interval
+func (s *Storage) DueBackchannelLogouts(ctx context.Context, limit int) ([]*BackchannelLogout, error) {
+	cmd := `
+	WITH due AS (
+		SELECT id FROM backchannel_logout
+		WHERE next_attempt_time <= now()
+		ORDER BY next_attempt_time
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE backchannel_logout l
+	SET attempts = l.attempts + 1,
+		next_attempt_time = now() + interval '30 seconds' * power(2, l.attempts)
+	FROM due, client c
+	WHERE l.id = due.id
+	AND c.id = l.client_id
+	RETURNING
+		l.id,
+		l.client_id,
+		l.user_id,
+		l.session_id,
+		l.attempts,
+		c.backchannel_logout_uri,
+		c.registration_token_hash <> ''
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, limit)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var logouts []*BackchannelLogout
+	for rows.Next() {
+		l := &BackchannelLogout{}
+		err := rows.Scan(
+			&l.ID,
+			&l.ClientID,
+			&l.UserID,
+			&l.SessionID,
+			&l.Attempts,
+			&l.URI,
+			&l.Registered,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		logouts = append(logouts, l)
+	}
+	return logouts, rows.Err()
+}
+
+// DeleteBackchannelLogout drops a logout token the client accepted or which ran out of attempts
+func (s *Storage) DeleteBackchannelLogout(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `
+	DELETE FROM backchannel_logout
+	WHERE id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// SignLogoutToken signs the logout token of a session for the client
+// (OpenID Connect back-channel logout 1.0)
+func (s *Storage) SignLogoutToken(ctx context.Context, issuer string, l *BackchannelLogout) (string, error) {
+	now := time.Now()
+	return s.signJWT("logout+jwt", map[string]any{
+		"iss":          issuer,
+		"sub":          l.UserID.String(),
+		"aud":          l.ClientID.String(),
+		"iat":          now.Unix(),
+		"exp":          now.Add(logoutTokenLifetime).Unix(),
+		"jti":          uuid.NewString(),
+		ClaimSessionID: l.SessionID.String(),
+		"events": map[string]any{
+			EventBackchannelLogout: map[string]any{},
+		},
+	})
+}
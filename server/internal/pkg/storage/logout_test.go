@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/zitadel/oidc/v3/pkg/op"
+)
+
+// clientDefaults are the columns of a client without logout uris
+var clientDefaults = map[string]driver.Value{
+	"secret":                                     "",
+	"redirect_uris":                              "{}",
+	"application_type":                           int64(0),
+	"auth_method":                                "client_secret_basic",
+	"response_types":                             "{}",
+	"grant_types":                                "{}",
+	"access_token_type":                          int64(0),
+	"dev_mode":                                   false,
+	"id_token_user_info_claims_assertion":        false,
+	"clock_skew":                                 "00:00:00",
+	"post_logout_redirect_uri_globs":             "{}",
+	"redirect_uri_globs":                         "{}",
+	"name":                                       "",
+	"access_token_lifetime":                      "00:00:00",
+	"id_token_lifetime":                          "00:00:00",
+	"refresh_token_lifetime":                     "00:00:00",
+	"refresh_token_idle_lifetime":                "00:00:00",
+	"refresh_tokens":                             "",
+	"allowed_scopes":                             "{}",
+	"default_scopes":                             "{}",
+	"audiences":                                  "{}",
+	"disallowed_scopes":                          "",
+	"resources":                                  "{}",
+	"require_pushed_authorization_requests":      false,
+	"response_modes":                             "{}",
+	"authorization_encrypted_response_alg":       "",
+	"authorization_encrypted_response_enc":       "",
+	"jwks":                                       "",
+	"dpop_bound_access_tokens":                   false,
+	"tls_client_auth_subject_dn":                 "",
+	"tls_client_auth_san_dns":                    "",
+	"tls_client_auth_san_uri":                    "",
+	"tls_client_auth_san_ip":                     "",
+	"tls_client_auth_san_email":                  "",
+	"tls_client_certificate_bound_access_tokens": false,
+	"backchannel_token_delivery_mode":            "",
+	"backchannel_client_notification_endpoint":   "",
+	"frontchannel_logout_uri":                    "",
+	"frontchannel_logout_session_required":       false,
+	"backchannel_logout_uri":                     "",
+	"backchannel_logout_session_required":        false,
+	"registration_token_hash <> ''":              false,
+}
+
+// clientRow answers the clientColumns of a client
+func clientRow(t *testing.T, columns map[string]driver.Value) []driver.Value {
+	var row []driver.Value
+	for _, name := range strings.Split(clientColumns, ",") {
+		name = strings.TrimSpace(name)
+		v, ok := columns[name]
+		if !ok {
+			v, ok = clientDefaults[name]
+		}
+		if !ok {
+			t.Fatalf("no value for the client column %s", name)
+		}
+		row = append(row, v)
+	}
+	return row
+}
+
+func TestTerminateSessionFromRequest(t *testing.T) {
+	issuer := "https://op.example.com"
+	session, owner, namespace := uuid.New(), uuid.New(), uuid.New()
+	sessionRequired, plain, backchannel := uuid.New(), uuid.New(), uuid.New()
+	clients := map[string]map[string]driver.Value{
+		sessionRequired.String(): {
+			"frontchannel_logout_uri":              "https://a.example.com/logout?app=a",
+			"frontchannel_logout_session_required": true,
+		},
+		plain.String(): {
+			"frontchannel_logout_uri": "https://b.example.com/logout",
+		},
+		backchannel.String(): {
+			"backchannel_logout_uri": "https://c.example.com/logout",
+		},
+	}
+
+	var inserted []driver.Value
+	db := &fakeDB{}
+	db.on("DELETE FROM user_session_client", func(args []driver.Value) [][]driver.Value {
+		return [][]driver.Value{{"{" + sessionRequired.String() + "," + plain.String() + "," + backchannel.String() + "}"}}
+	})
+	db.on("DELETE FROM user_session", func(args []driver.Value) [][]driver.Value {
+		if args[0] != session.String() {
+			return nil
+		}
+		return [][]driver.Value{{owner.String()}}
+	})
+	db.on("DELETE FROM token", func(args []driver.Value) [][]driver.Value { return nil })
+	db.on("DELETE FROM refresh_token", func(args []driver.Value) [][]driver.Value { return nil })
+	db.on("INSERT INTO backchannel_logout", func(args []driver.Value) [][]driver.Value {
+		inserted = args
+		return [][]driver.Value{{}}
+	})
+	db.on("dev_mode", func(args []driver.Value) [][]driver.Value {
+		columns, ok := clients[args[0].(string)]
+		if !ok {
+			return nil
+		}
+		columns["id"] = args[0]
+		columns["user_namespace_id"] = namespace.String()
+		return [][]driver.Value{clientRow(t, columns)}
+	})
+	db.on("token_policy", func(args []driver.Value) [][]driver.Value { return nil })
+	db.on("array_agg(name", func(args []driver.Value) [][]driver.Value { return [][]driver.Value{{"{}"}} })
+	s := db.storage()
+
+	ctx, ended := WithEndedSession(op.ContextWithIssuer(context.Background(), issuer), session)
+	redirect, err := s.TerminateSessionFromRequest(ctx, &op.EndSessionRequest{RedirectURI: "https://a.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirect != "https://a.example.com/" || ended.ID != session {
+		t.Errorf("got redirect %s, ended %s", redirect, ended.ID)
+	}
+
+	// the clients with a frontchannel uri are rendered, with iss and sid if they require them
+	if len(ended.Frontchannel) != 2 {
+		t.Fatalf("frontchannel uris: %v", ended.Frontchannel)
+	}
+	u, err := url.Parse(ended.Frontchannel[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if u.Host != "a.example.com" || query.Get("app") != "a" || query.Get("iss") != issuer || query.Get(ClaimSessionID) != session.String() {
+		t.Errorf("frontchannel uri with session: %s", ended.Frontchannel[0])
+	}
+	if ended.Frontchannel[1] != "https://b.example.com/logout" {
+		t.Errorf("frontchannel uri: %s", ended.Frontchannel[1])
+	}
+
+	// the logout tokens of the session are queued for the clients of the session
+	if len(inserted) != 3 || inserted[0] != session.String() || inserted[1] != owner.String() {
+		t.Fatalf("backchannel logouts: %v", inserted)
+	}
+	for _, id := range []uuid.UUID{sessionRequired, plain, backchannel} {
+		if !strings.Contains(inserted[2].(string), id.String()) {
+			t.Errorf("no backchannel logout for the client %s: %v", id, inserted[2])
+		}
+	}
+
+	// a session ends once
+	ctx, ended = WithEndedSession(op.ContextWithIssuer(context.Background(), issuer), uuid.New())
+	if _, err := s.TerminateSessionFromRequest(ctx, &op.EndSessionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ended.Frontchannel) != 0 {
+		t.Errorf("frontchannel uris of an ended session: %v", ended.Frontchannel)
+	}
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// the client authentication methods of mutual TLS (RFC 8705)
+const (
+	AuthMethodTLSClientAuth           oidc.AuthMethod = "tls_client_auth"
+	AuthMethodSelfSignedTLSClientAuth oidc.AuthMethod = "self_signed_tls_client_auth"
+)
+
+// TLSClientAuth is the certificate a tls_client_auth client authenticates
+// with, the certificate of a trusted CA with the subject DN or one of the SANs
+type TLSClientAuth struct {
+	SubjectDN string
+	SANDNS    string
+	SANURI    string
+	SANIP     string
+	SANEmail  string
+}
+
+// matches tells if the certificate is the one of the client, the registered
+// value which is not empty must match
+func (t *TLSClientAuth) matches(cert *x509.Certificate) bool {
+	switch {
+	case t.SubjectDN != "":
+		return cert.Subject.String() == t.SubjectDN
+	case t.SANDNS != "":
+		return contains(cert.DNSNames, t.SANDNS)
+	case t.SANURI != "":
+		for _, u := range cert.URIs {
+			if u.String() == t.SANURI {
+				return true
+			}
+		}
+	case t.SANIP != "":
+		ip := net.ParseIP(t.SANIP)
+		for _, a := range cert.IPAddresses {
+			if a.Equal(ip) {
+				return true
+			}
+		}
+	case t.SANEmail != "":
+		return contains(cert.EmailAddresses, t.SANEmail)
+	}
+	return false
+}
+
+// clientCertificate is the certificate of the TLS connection of a request
+type clientCertificate struct {
+	cert *x509.Certificate
+	// verified tells if the chain of the certificate verifies with the trusted CAs
+	verified bool
+}
+
+type clientCertificateKey struct{}
+
+// WithClientCertificate passes the client certificate of the connection to the
+// storage, the tls_client_auth clients authenticate with it and access tokens
+// may be bound to it
+func WithClientCertificate(ctx context.Context, cert *x509.Certificate, verified bool) context.Context {
+	return context.WithValue(ctx, clientCertificateKey{}, &clientCertificate{cert: cert, verified: verified})
+}
+
+func clientCertificateOf(ctx context.Context) *clientCertificate {
+	c, _ := ctx.Value(clientCertificateKey{}).(*clientCertificate)
+	return c
+}
+
+// CertificateThumbprint is the x5t#S256 of the certificate
+func CertificateThumbprint(cert *x509.Certificate) string {
+	h := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// certificateThumbprintOf is the x5t#S256 of the certificate of the request, empty if it has none
+func certificateThumbprintOf(ctx context.Context) string {
+	c := clientCertificateOf(ctx)
+	if c == nil {
+		return ""
+	}
+	return CertificateThumbprint(c.cert)
+}
+
+// authenticate checks the secret of the client, or the certificate of the
+// request for the mutual TLS methods
+func (c *Client) authenticate(ctx context.Context, secret string) error {
+	switch c.AuthMethod() {
+	case AuthMethodTLSClientAuth:
+		cert := clientCertificateOf(ctx)
+		if cert == nil || !cert.verified || !c.tlsClientAuth.matches(cert.cert) {
+			return errors.New("invalid client certificate")
+		}
+		return nil
+	case AuthMethodSelfSignedTLSClientAuth:
+		cert := clientCertificateOf(ctx)
+		if cert == nil || !c.hasKey(cert.cert.PublicKey) {
+			return errors.New("invalid client certificate")
+		}
+		return nil
+	}
+	// for this example we directly check the secret
+	// obviously you would not have the secret in plain text, but rather hashed and salted (e.g. using bcrypt)
+	if c.secret != secret {
+		return errors.New("invalid secret")
+	}
+	return nil
+}
+
+// hasKey tells if the key is one of the jwks of the client
+func (c *Client) hasKey(key crypto.PublicKey) bool {
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal([]byte(c.jwks), &keys); err != nil {
+		return false
+	}
+	for _, k := range keys.Keys {
+		pub, ok := k.Key.(interface{ Equal(crypto.PublicKey) bool })
+		if ok && pub.Equal(key) {
+			return true
+		}
+	}
+	return false
+}
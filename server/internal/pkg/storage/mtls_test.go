@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+// testCertificate is a self-signed client certificate with the names of the tests
+func testCertificate(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, _ := url.Parse("spiffe://example.com/agent")
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(1),
+		Subject:        pkix.Name{CommonName: "agent", Organization: []string{"Example"}},
+		DNSNames:       []string{"agent.example.com"},
+		URIs:           []*url.URL{uri},
+		IPAddresses:    []net.IP{net.ParseIP("192.0.2.7")},
+		EmailAddresses: []string{"agent@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestTLSClientAuthMatches(t *testing.T) {
+	cert, _ := testCertificate(t)
+	tests := []struct {
+		auth  TLSClientAuth
+		match bool
+	}{
+		{TLSClientAuth{SubjectDN: "CN=agent,O=Example"}, true},
+		{TLSClientAuth{SubjectDN: "CN=agent"}, false},
+		{TLSClientAuth{SANDNS: "agent.example.com"}, true},
+		{TLSClientAuth{SANDNS: "other.example.com"}, false},
+		{TLSClientAuth{SANURI: "spiffe://example.com/agent"}, true},
+		{TLSClientAuth{SANURI: "spiffe://example.com/other"}, false},
+		{TLSClientAuth{SANIP: "192.0.2.7"}, true},
+		{TLSClientAuth{SANIP: "192.0.2.8"}, false},
+		{TLSClientAuth{SANEmail: "agent@example.com"}, true},
+		{TLSClientAuth{SANEmail: "other@example.com"}, false},
+		// a client without a registered name matches no certificate
+		{TLSClientAuth{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.auth.matches(cert); got != tt.match {
+			t.Errorf("%+v: got %v, want %v", tt.auth, got, tt.match)
+		}
+	}
+}
+
+func TestAuthenticateCertificate(t *testing.T) {
+	cert, key := testCertificate(t)
+	other, otherKey := testCertificate(t)
+	jwks := func(keys ...*ecdsa.PrivateKey) string {
+		var set jose.JSONWebKeySet
+		for _, k := range keys {
+			set.Keys = append(set.Keys, jose.JSONWebKey{Key: &k.PublicKey})
+		}
+		data, err := json.Marshal(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	tlsClient := &Client{authMethod: AuthMethodTLSClientAuth, tlsClientAuth: TLSClientAuth{SANDNS: "agent.example.com"}}
+	selfSigned := &Client{authMethod: AuthMethodSelfSignedTLSClientAuth, jwks: jwks(otherKey, key)}
+
+	tests := []struct {
+		name   string
+		client *Client
+		ctx    context.Context
+		ok     bool
+	}{
+		{"tls_client_auth", tlsClient, WithClientCertificate(context.Background(), cert, true), true},
+		// the certificate must chain to a trusted CA
+		{"tls_client_auth unverified", tlsClient, WithClientCertificate(context.Background(), cert, false), false},
+		{"tls_client_auth without certificate", tlsClient, context.Background(), false},
+		{"self_signed_tls_client_auth", selfSigned, WithClientCertificate(context.Background(), cert, false), true},
+		{"self_signed_tls_client_auth other key", &Client{authMethod: AuthMethodSelfSignedTLSClientAuth, jwks: jwks(otherKey)}, WithClientCertificate(context.Background(), cert, false), false},
+		{"self_signed_tls_client_auth without certificate", selfSigned, context.Background(), false},
+		{"self_signed_tls_client_auth without jwks", &Client{authMethod: AuthMethodSelfSignedTLSClientAuth}, WithClientCertificate(context.Background(), other, false), false},
+	}
+	for _, tt := range tests {
+		if err := tt.client.authenticate(tt.ctx, ""); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+}
@@ -47,11 +47,15 @@ func authRequestToInternal(authReq *oidc.AuthRequest, userID string) *AuthReques
 
 // RefreshTokenRequestFromBusiness will simply wrap the storage RefreshToken to implement the op.RefreshTokenRequest interface
 func RefreshTokenRequestFromBusiness(token *RefreshToken) op.RefreshTokenRequest {
-	return &RefreshTokenRequest{token}
+	return &RefreshTokenRequest{RefreshToken: token}
 }
 
 type RefreshTokenRequest struct {
 	*RefreshToken
+	// narrowed are the resources of the token request, a subset of the audience
+	narrowed []string
+	// narrowedDetails are the authorization details of the token request, a subset of the granted ones
+	narrowedDetails AuthorizationDetails
 }
 
 func (r *RefreshTokenRequest) GetAMR() []string {
@@ -59,9 +63,19 @@ func (r *RefreshTokenRequest) GetAMR() []string {
 }
 
 func (r *RefreshTokenRequest) GetAudience() []string {
+	if len(r.narrowed) > 0 {
+		return append([]string{r.ApplicationID.String()}, r.narrowed...)
+	}
 	return r.Audience
 }
 
+func (r *RefreshTokenRequest) GetAuthorizationDetails() AuthorizationDetails {
+	if len(r.narrowedDetails) > 0 {
+		return r.narrowedDetails
+	}
+	return r.AuthorizationDetails
+}
+
 func (r *RefreshTokenRequest) GetAuthTime() time.Time {
 	return r.AuthTime
 }
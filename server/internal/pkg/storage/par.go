@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+const (
+	// RequestURIPrefix starts the request_uri of pushed authorization requests (RFC 9126)
+	RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+	// PushedAuthRequestLifetime is how long clients have to use a request_uri
+	PushedAuthRequestLifetime = 60 * time.Second
+)
+
+var ErrPushedAuthRequestNotFound = errors.New("the request_uri is invalid or expired")
+
+type pushedAuthRequestKey struct{}
+
+// WithPushedAuthRequest marks the authorization request as one the client pushed
+func WithPushedAuthRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pushedAuthRequestKey{}, true)
+}
+
+func isPushedAuthRequest(ctx context.Context) bool {
+	pushed, _ := ctx.Value(pushedAuthRequestKey{}).(bool)
+	return pushed
+}
+
+// PushAuthRequest stores a validated authorization request of an authenticated
+// client until the client passes the returned request_uri to the authorization endpoint,
+// with its resource and authorization_details parameters
+func (s *Storage) PushAuthRequest(ctx context.Context, authReq *oidc.AuthRequest, resources []string, authorizationDetails string) (string, error) {
+	client, err := s.GetClient(ctx, authReq.ClientID)
+	if err != nil {
+		return "", err
+	}
+	authReq.Scopes, err = client.CheckScopes(authReq.Scopes)
+	if err != nil {
+		return "", err
+	}
+	err = client.checkResources(resources)
+	if err != nil {
+		return "", err
+	}
+	err = client.CheckResponseMode(authReq.ResponseMode)
+	if err != nil {
+		return "", err
+	}
+	details, err := s.parseAuthorizationDetails(ctx, client.userNamespaceID, authorizationDetails)
+	if err != nil {
+		return "", err
+	}
+
+	// the unused requests of before are gone for good
+	_, err = s.db.ExecContext(ctx, `
+	DELETE FROM auth_request
+	WHERE request_uri <> ''
+	AND creation_date < $1
+	`, time.Now().Add(-PushedAuthRequestLifetime))
+	if err != nil {
+		logrus.Error(err)
+		return "", err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		logrus.Error(err)
+		return "", err
+	}
+	request := &AuthRequest{
+		AuthReq:      *authReq,
+		CreationDate: time.Now(),
+		Resources:    resources,
+		RequestURI:   RequestURIPrefix + token,
+
+		AuthorizationDetails: details,
+	}
+	_, err = s.StoreAuthRequest(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	return request.RequestURI, nil
+}
+
+// PushedAuthRequest returns the pushed authorization request of the client with
+// its resource and authorization_details parameters, a request_uri is used only
+// once and only by its client, other clients can not burn it
+func (s *Storage) PushedAuthRequest(ctx context.Context, requestURI, clientID string) (*oidc.AuthRequest, []string, string, error) {
+	var (
+		creationDate time.Time
+		content      string
+		resources    []string
+		details      string
+	)
+	err := s.db.QueryRowContext(ctx, `
+	DELETE FROM auth_request
+	WHERE request_uri = $1
+	AND content::jsonb ->> 'client_id' = $2
+	RETURNING creation_date, content, resources, authorization_details
+	`, requestURI, clientID).Scan(&creationDate, &content, pq.Array(&resources), &details)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, "", ErrPushedAuthRequestNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, "", err
+	}
+	var authReq oidc.AuthRequest
+	err = json.Unmarshal([]byte(content), &authReq)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, "", err
+	}
+	if time.Since(creationDate) > PushedAuthRequestLifetime {
+		return nil, nil, "", ErrPushedAuthRequestNotFound
+	}
+	return &authReq, resources, details, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+type pushedRow struct {
+	creationDate time.Time
+	content      string
+}
+
+// pushedAuthRequestDB keeps the pushed rows of auth_request by request_uri
+func pushedAuthRequestDB(rows map[string]pushedRow) *fakeDB {
+	db := &fakeDB{}
+	db.on("DELETE FROM auth_request", func(args []driver.Value) [][]driver.Value {
+		row, ok := rows[args[0].(string)]
+		if !ok {
+			return nil
+		}
+		var content struct {
+			ClientID string `json:"client_id"`
+		}
+		if json.Unmarshal([]byte(row.content), &content) != nil || content.ClientID != args[1] {
+			return nil
+		}
+		delete(rows, args[0].(string))
+		return [][]driver.Value{{row.creationDate, row.content, "{https://api.example.com}", ""}}
+	})
+	return db
+}
+
+func pushedContent(t *testing.T, clientID string) string {
+	content, err := json.Marshal(&oidc.AuthRequest{ClientID: clientID, RedirectURI: "https://app.example.com/cb", State: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestPushedAuthRequest(t *testing.T) {
+	ctx := context.Background()
+	uri := RequestURIPrefix + "pushed"
+	rows := map[string]pushedRow{uri: {time.Now(), pushedContent(t, "web")}}
+	s := pushedAuthRequestDB(rows).storage()
+
+	// another client can neither use nor burn the request_uri
+	if _, _, _, err := s.PushedAuthRequest(ctx, uri, "other"); err != ErrPushedAuthRequestNotFound {
+		t.Fatalf("other client: got %v, want %v", err, ErrPushedAuthRequestNotFound)
+	}
+	authReq, resources, _, err := s.PushedAuthRequest(ctx, uri, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authReq.ClientID != "web" || authReq.State != "s" || len(resources) != 1 {
+		t.Errorf("got %+v with resources %v", authReq, resources)
+	}
+	// a request_uri is used once
+	if _, _, _, err := s.PushedAuthRequest(ctx, uri, "web"); err != ErrPushedAuthRequestNotFound {
+		t.Errorf("second use: got %v, want %v", err, ErrPushedAuthRequestNotFound)
+	}
+}
+
+func TestPushedAuthRequestExpired(t *testing.T) {
+	ctx := context.Background()
+	uri := RequestURIPrefix + "old"
+	rows := map[string]pushedRow{uri: {time.Now().Add(-PushedAuthRequestLifetime - time.Second), pushedContent(t, "web")}}
+	s := pushedAuthRequestDB(rows).storage()
+
+	if _, _, _, err := s.PushedAuthRequest(ctx, uri, "web"); err != ErrPushedAuthRequestNotFound {
+		t.Errorf("got %v, want %v", err, ErrPushedAuthRequestNotFound)
+	}
+	if _, ok := rows[uri]; ok {
+		t.Error("the expired request was kept")
+	}
+}
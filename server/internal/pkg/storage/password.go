@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+const day = 24 * time.Hour
+
+// GetPasswordPolicy returns the policy of a namespace, or the default policy
+// if the namespace did not configure one
+func (s *Storage) GetPasswordPolicy(ctx context.Context, namespace uuid.UUID) (*password.Policy, error) {
+	cmd := `
+	SELECT
+		min_length,
+		require_upper,
+		require_lower,
+		require_digit,
+		require_symbol,
+		max_age_days,
+		history,
+		check_breached
+	FROM
+		password_policy
+	WHERE
+		namespace_id = $1
+	`
+	var (
+		p          password.Policy
+		maxAgeDays int
+	)
+	err := s.db.QueryRowContext(ctx, cmd, namespace).Scan(
+		&p.MinLength,
+		&p.RequireUpper,
+		&p.RequireLower,
+		&p.RequireDigit,
+		&p.RequireSymbol,
+		&maxAgeDays,
+		&p.History,
+		&p.CheckBreached,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		p = password.DefaultPolicy
+		return &p, nil
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	p.MaxAge = time.Duration(maxAgeDays) * day
+	return &p, nil
+}
+
+func (s *Storage) SetPasswordPolicy(ctx context.Context, namespace uuid.UUID, p *password.Policy) error {
+	return s.TXSetPasswordPolicy(ctx, s.db, namespace, p)
+}
+
+// TXSetPasswordPolicy is SetPasswordPolicy within a transaction
+func (s *Storage) TXSetPasswordPolicy(ctx context.Context, tx qrm.DB, namespace uuid.UUID, p *password.Policy) error {
+	cmd := `
+	INSERT INTO password_policy (
+		namespace_id,
+		min_length,
+		require_upper,
+		require_lower,
+		require_digit,
+		require_symbol,
+		max_age_days,
+		history,
+		check_breached
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9
+	) ON CONFLICT (namespace_id) DO UPDATE SET
+		min_length=EXCLUDED.min_length,
+		require_upper=EXCLUDED.require_upper,
+		require_lower=EXCLUDED.require_lower,
+		require_digit=EXCLUDED.require_digit,
+		require_symbol=EXCLUDED.require_symbol,
+		max_age_days=EXCLUDED.max_age_days,
+		history=EXCLUDED.history,
+		check_breached=EXCLUDED.check_breached
+	`
+	_, err := tx.ExecContext(ctx, cmd,
+		namespace,
+		p.MinLength,
+		p.RequireUpper,
+		p.RequireLower,
+		p.RequireDigit,
+		p.RequireSymbol,
+		int(p.MaxAge/day),
+		p.History,
+		p.CheckBreached,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// ValidatePassword checks a candidate against the policy of the namespace.
+// For existing users it also rejects the current and the last reused passwords,
+// pass uuid.Nil for users that do not exist yet.
+func (s *Storage) ValidatePassword(ctx context.Context, namespace, userID uuid.UUID, candidate string) error {
+	policy, err := s.GetPasswordPolicy(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return s.validatePassword(ctx, policy, userID, candidate)
+}
+
+func (s *Storage) validatePassword(ctx context.Context, policy *password.Policy, userID uuid.UUID, candidate string) error {
+	err := policy.Validate(candidate, s.BreachedPasswords)
+	if err != nil {
+		return err
+	}
+	if userID == uuid.Nil || policy.History <= 0 {
+		return nil
+	}
+
+	// the current password is the newest of the history
+	cmd := `
+	(SELECT password FROM "user" WHERE id = $1)
+	UNION ALL
+	(SELECT
+		password
+	FROM
+		password_history
+	WHERE
+		user_id = $1
+	ORDER BY create_time DESC
+	LIMIT $2)
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, userID, policy.History-1)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		err := rows.Scan(&hash)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		if hash == "" {
+			continue
+		}
+		match, err := password.ComparePasswordAndHash(candidate, hash)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+		if match {
+			return password.ErrReused
+		}
+	}
+	return rows.Err()
+}
+
+// SetUserPassword validates the new password against the policy of the user's
+// namespace, stores its hash and keeps the old one in the history.
+// Admins, self service and imports all set passwords through it.
+func (s *Storage) SetUserPassword(ctx context.Context, userID uuid.UUID, newPassword string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	err = s.TXSetUserPassword(ctx, tx, userID, newPassword)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// TXSetUserPassword is SetUserPassword within a transaction
+func (s *Storage) TXSetUserPassword(ctx context.Context, tx *sql.Tx, userID uuid.UUID, newPassword string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if user.DirectoryID != uuid.Nil {
+		return ErrDirectoryUser
+	}
+	policy, err := s.GetPasswordPolicy(ctx, user.NamespaceID)
+	if err != nil {
+		return err
+	}
+	err = s.validatePassword(ctx, policy, userID, newPassword)
+	if err != nil {
+		return err
+	}
+	hash, err := password.CreateHash(newPassword)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	if user.Password != "" {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO password_history (
+			user_id,
+			password,
+			create_time
+		) VALUES (
+			$1, $2, $3
+		)`, userID, user.Password, user.PasswordChangeTime)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+	}
+	// the history only needs as many entries as the policy checks
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM password_history
+	WHERE user_id = $1
+	AND id NOT IN (
+		SELECT id FROM password_history
+		WHERE user_id = $1
+		ORDER BY create_time DESC
+		LIMIT $2
+	)`, userID, policy.History)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	UPDATE "user"
+	SET password=$1,
+		password_change_time=now(),
+		updated_at=now()
+	WHERE
+		id=$2
+	`, hash, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// ChangePassword is the self service change of a signed in user
+func (s *Storage) ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	match, err := password.ComparePasswordAndHash(oldPassword, user.Password)
+	if err != nil || !match {
+		return errors.New("current password wrong")
+	}
+	return s.SetUserPassword(ctx, userID, newPassword)
+}
+
+// ChangeExpiredPassword replaces the expired password of a user during the login
+// and then logs in with the new one, like CheckUsernamePassword
+func (s *Storage) ChangeExpiredPassword(username, oldPassword, newPassword, reqid, remoteIP string) error {
+	err := s.CheckUsernamePassword(username, oldPassword, reqid, remoteIP)
+	if err == nil {
+		// not expired (anymore), the user is logged in already
+		return nil
+	}
+	if !errors.Is(err, password.ErrExpired) {
+		return err
+	}
+
+	request, err := s.GetAuthRequestByUUID(context.TODO(), uuid.MustParse(reqid))
+	if err != nil {
+		logrus.Error(err)
+		return errors.New("request not found")
+	}
+	us, err := s.GetUserByUsername(context.TODO(), username, uuid.MustParse(request.GetClientID()))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	err = s.SetUserPassword(context.TODO(), us.ID, newPassword)
+	if err != nil {
+		return err
+	}
+	return s.CheckUsernamePassword(username, newPassword, reqid, remoteIP)
+}
+
+// RehashPassword replaces an outdated hash after a successful login with a
+// hash of the current algorithm and params. The password itself did not change,
+// so neither its age nor the history are touched.
+func (s *Storage) RehashPassword(ctx context.Context, userID uuid.UUID, plain string) error {
+	hash, err := password.CreateHash(plain)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	cmd := `
+	UPDATE "user"
+	SET password=$1
+	WHERE
+		id=$2
+	`
+	_, err = s.db.ExecContext(ctx, cmd, hash, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidResetToken = errors.New("the reset link is invalid or expired")
+
+// CreatePasswordReset starts a reset of the user's password, the returned id
+// is part of the link sent to the user
+func (s *Storage) CreatePasswordReset(ctx context.Context, userID uuid.UUID, life time.Duration) (uuid.UUID, error) {
+	cmd := `
+	INSERT INTO password_reset (
+		user_id,
+		expiration
+	) VALUES (
+		$1, $2
+	) RETURNING id
+	`
+	var id uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, userID, time.Now().Add(life)).Scan(&id)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// PasswordResetUser returns the user of a pending reset
+func (s *Storage) PasswordResetUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	cmd := `
+	SELECT
+		user_id
+	FROM
+		password_reset
+	WHERE
+		id = $1
+	AND expiration > now()
+	`
+	var userID uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, id).Scan(&userID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, ErrInvalidResetToken
+	}
+	return s.GetUserByID(ctx, userID)
+}
+
+// ResetPassword completes a reset: the new password must follow the policy,
+// the reset can be used only once and the user's refresh tokens are revoked
+func (s *Storage) ResetPassword(ctx context.Context, id uuid.UUID, newPassword string) error {
+	user, err := s.PasswordResetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.ValidatePassword(ctx, user.NamespaceID, user.ID, newPassword)
+	if err != nil {
+		return err
+	}
+
+	// all pending resets of the user end with this one, they stay
+	// usable if the password cannot be set
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	cmd := `
+	DELETE FROM password_reset
+	WHERE user_id = (
+		SELECT user_id FROM password_reset
+		WHERE id = $1
+		AND expiration > now()
+	)
+	`
+	res, err := tx.ExecContext(ctx, cmd, id)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// used concurrently
+		_ = tx.Rollback()
+		return ErrInvalidResetToken
+	}
+
+	err = s.TXSetUserPassword(ctx, tx, user.ID, newPassword)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return s.RevokeUserRefreshTokens(ctx, user.ID)
+}
+
+// RevokeUserRefreshTokens removes the refresh tokens of a user of all clients,
+// and the access tokens issued with them
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM token
+	WHERE refresh_token_id::text IN (
+		SELECT id FROM refresh_token
+		WHERE user_id = $1
+	)`, userID.String())
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM refresh_token
+	WHERE user_id = $1
+	`, userID.String())
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for k, t := range s.refreshTokens {
+		if t.UserID == userID {
+			delete(s.refreshTokens, k)
+		}
+	}
+	return nil
+}
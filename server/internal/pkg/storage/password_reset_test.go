@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var userColumn = regexp.MustCompile(`AS "user\.(\w+)"`)
+
+// userRow answers the columns of a jet select of users,
+// the columns not kept in User get the defaults of the table
+func userRow(query string, u *User) []driver.Value {
+	values := map[string]driver.Value{
+		"id":                    u.ID.String(),
+		"username":              u.Username,
+		"password":              u.Password,
+		"given_name":            u.FirstName,
+		"family_name":           u.LastName,
+		"email":                 u.Email,
+		"email_verified":        u.EmailVerified,
+		"phone_number":          u.Phone,
+		"phone_number_verified": u.PhoneVerified,
+		"locale":                "en",
+		"namespace_id":          u.NamespaceID.String(),
+		"password_change_time":  u.PasswordChangeTime,
+		"is_admin":              u.IsAdmin,
+		"status":                "active",
+		"groups":                "{}",
+		"directory_id":          nil,
+	}
+	var row []driver.Value
+	for _, m := range userColumn.FindAllStringSubmatch(query, -1) {
+		v, ok := values[m[1]]
+		switch {
+		case ok:
+		case strings.HasSuffix(m[1], "_time"):
+			v = time.Now()
+		default:
+			v = ""
+		}
+		row = append(row, v)
+	}
+	return row
+}
+
+type passwordResetRow struct {
+	userID     string
+	expiration time.Time
+}
+
+// passwordResetDB keeps the resets of one user with the default password policy
+func passwordResetDB(user *User) *fakeDB {
+	resets := map[string]*passwordResetRow{}
+	db := &fakeDB{}
+	db.on("INSERT INTO password_reset", func(args []driver.Value) [][]driver.Value {
+		id := uuid.NewString()
+		resets[id] = &passwordResetRow{args[0].(string), args[1].(time.Time)}
+		return [][]driver.Value{{id}}
+	})
+	db.on("DELETE FROM password_reset", func(args []driver.Value) [][]driver.Value {
+		reset, ok := resets[args[0].(string)]
+		if !ok || !reset.expiration.After(time.Now()) {
+			return nil
+		}
+		var deleted [][]driver.Value
+		for id, r := range resets {
+			if r.userID == reset.userID {
+				delete(resets, id)
+				deleted = append(deleted, []driver.Value{})
+			}
+		}
+		return deleted
+	})
+	db.on("password_reset", func(args []driver.Value) [][]driver.Value {
+		reset, ok := resets[args[0].(string)]
+		if !ok || !reset.expiration.After(time.Now()) {
+			return nil
+		}
+		return [][]driver.Value{{reset.userID}}
+	})
+	db.onQuery(`FROM public."user"`, func(query string, args []driver.Value) [][]driver.Value {
+		return [][]driver.Value{userRow(query, user)}
+	})
+	db.on("UNION ALL", func(args []driver.Value) [][]driver.Value {
+		return [][]driver.Value{{user.Password}}
+	})
+	db.on(`UPDATE "user"`, func(args []driver.Value) [][]driver.Value {
+		user.Password = args[0].(string)
+		return [][]driver.Value{{}}
+	})
+	for _, statement := range []string{"password_policy", "password_history", "DELETE FROM token", "DELETE FROM refresh_token"} {
+		db.on(statement, func(args []driver.Value) [][]driver.Value { return nil })
+	}
+	return db
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: uuid.New(), Username: "alice", NamespaceID: uuid.New()}
+	s := passwordResetDB(user).storage()
+
+	first, err := s.CreatePasswordReset(ctx, user.ID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.CreatePasswordReset(ctx, user.ID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PasswordResetUser(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetPassword(ctx, first, "Correct-Horse-42"); err != nil {
+		t.Fatal(err)
+	}
+	if user.Password == "" {
+		t.Fatal("the password was not set")
+	}
+	// the link is used once, and ends the other links of the user
+	if err := s.ResetPassword(ctx, first, "Battery-Staple-42"); err != ErrInvalidResetToken {
+		t.Errorf("second use: got %v, want %v", err, ErrInvalidResetToken)
+	}
+	if _, err := s.PasswordResetUser(ctx, second); err != ErrInvalidResetToken {
+		t.Errorf("other link: got %v, want %v", err, ErrInvalidResetToken)
+	}
+}
+
+func TestResetPasswordExpired(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: uuid.New(), Username: "bob", NamespaceID: uuid.New()}
+	s := passwordResetDB(user).storage()
+
+	id, err := s.CreatePasswordReset(ctx, user.ID, -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ResetPassword(ctx, id, "Correct-Horse-42"); err != ErrInvalidResetToken {
+		t.Errorf("got %v, want %v", err, ErrInvalidResetToken)
+	}
+	if user.Password != "" {
+		t.Error("the password was set with an expired link")
+	}
+}
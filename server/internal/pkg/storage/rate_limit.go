@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IncrRateLimit counts a request of the key in the current window,
+// it shares the counters of ratelimit between several nodes
+func (s *Storage) IncrRateLimit(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	cmd := `
+	INSERT INTO rate_limit_counter (
+		key,
+		count,
+		reset_time
+	) VALUES (
+		$1, 1, now() + make_interval(secs => $2)
+	) ON CONFLICT (key) DO UPDATE SET
+		count=CASE
+			WHEN rate_limit_counter.reset_time <= now() THEN 1
+			ELSE rate_limit_counter.count + 1
+		END,
+		reset_time=CASE
+			WHEN rate_limit_counter.reset_time <= now() THEN EXCLUDED.reset_time
+			ELSE rate_limit_counter.reset_time
+		END
+	RETURNING count, reset_time
+	`
+	var (
+		count int64
+		reset time.Time
+	)
+	err := s.db.QueryRowContext(ctx, cmd, key, window.Seconds()).Scan(&count, &reset)
+	if err != nil {
+		logrus.Error(err)
+		return 0, time.Time{}, err
+	}
+	return count, reset, nil
+}
+
+// DeleteExpiredRateLimits removes the counters of past windows
+func (s *Storage) DeleteExpiredRateLimits(ctx context.Context) error {
+	cmd := `
+	DELETE FROM rate_limit_counter
+	WHERE reset_time <= now()
+	`
+	_, err := s.db.ExecContext(ctx, cmd)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
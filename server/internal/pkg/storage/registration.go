@@ -0,0 +1,423 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+// status of users
+const (
+	UserStatusActive = "active"
+	// self registered users wait for an admin to approve them
+	UserStatusPendingApproval = "pending_approval"
+	// users deactivated by provisioning can not log in
+	UserStatusDisabled = "disabled"
+)
+
+// profile fields a registration policy can require
+var RegistrationFields = []string{"email", "phone_number", "given_name", "family_name", "nickname"}
+
+var (
+	ErrUsernameTaken        = errors.New("the username is already taken")
+	ErrRegistrationDisabled = errors.New("registration is disabled")
+	ErrUserDisabled         = errors.New("the account is disabled")
+	ErrPendingApproval      = errors.New("the account waits for approval by an administrator")
+	ErrEmailNotVerified     = errors.New("confirm your email address first, we sent you a link")
+)
+
+// RegistrationPolicy controls the self registration of a namespace
+type RegistrationPolicy struct {
+	Enabled                  bool
+	RequiredFields           []string
+	EmailAllowDomains        []string
+	EmailDenyDomains         []string
+	RequireEmailVerification bool
+	RequireApproval          bool
+	// EmailVerificationTime is when RequireEmailVerification was turned on,
+	// users created before it can still log in without a verified email
+	EmailVerificationTime time.Time
+}
+
+// Requires tells if a new user must fill in the field,
+// the email is required to verify it
+func (p *RegistrationPolicy) Requires(field string) bool {
+	if field == "email" && p.RequireEmailVerification {
+		return true
+	}
+	for _, f := range p.RequiredFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEmail rejects addresses of denied domains, or of domains not allowed
+func (p *RegistrationPolicy) CheckEmail(email string) error {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return errors.New("invalid email address")
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range p.EmailDenyDomains {
+		if strings.EqualFold(d, domain) {
+			return errors.New("email addresses of " + domain + " can not register")
+		}
+	}
+	if len(p.EmailAllowDomains) == 0 {
+		return nil
+	}
+	for _, d := range p.EmailAllowDomains {
+		if strings.EqualFold(d, domain) {
+			return nil
+		}
+	}
+	return errors.New("email addresses of " + domain + " can not register")
+}
+
+// GetRegistrationPolicy returns the registration policy of a namespace,
+// registration is disabled if the namespace did not configure it
+func (s *Storage) GetRegistrationPolicy(ctx context.Context, namespace uuid.UUID) (*RegistrationPolicy, error) {
+	cmd := `
+	SELECT
+		enabled,
+		required_fields,
+		email_allow_domains,
+		email_deny_domains,
+		require_email_verification,
+		require_approval,
+		email_verification_time
+	FROM
+		registration_policy
+	WHERE
+		namespace_id = $1
+	`
+	p := &RegistrationPolicy{}
+	err := s.db.QueryRowContext(ctx, cmd, namespace).Scan(
+		&p.Enabled,
+		pq.Array(&p.RequiredFields),
+		pq.Array(&p.EmailAllowDomains),
+		pq.Array(&p.EmailDenyDomains),
+		&p.RequireEmailVerification,
+		&p.RequireApproval,
+		&p.EmailVerificationTime,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &RegistrationPolicy{}, nil
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// ClientRegistrationPolicy returns the registration policy of the namespace of the client's users
+func (s *Storage) ClientRegistrationPolicy(ctx context.Context, clientID uuid.UUID) (*RegistrationPolicy, error) {
+	client, err := s.GetClientByUUID(ctx, clientID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return s.GetRegistrationPolicy(ctx, client.userNamespaceID)
+}
+
+func (s *Storage) SetRegistrationPolicy(ctx context.Context, namespace uuid.UUID, p *RegistrationPolicy) error {
+	return s.TXSetRegistrationPolicy(ctx, s.db, namespace, p)
+}
+
+// TXSetRegistrationPolicy is SetRegistrationPolicy within a transaction
+func (s *Storage) TXSetRegistrationPolicy(ctx context.Context, tx qrm.DB, namespace uuid.UUID, p *RegistrationPolicy) error {
+	cmd := `
+	INSERT INTO registration_policy (
+		namespace_id,
+		enabled,
+		required_fields,
+		email_allow_domains,
+		email_deny_domains,
+		require_email_verification,
+		require_approval
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	) ON CONFLICT (namespace_id) DO UPDATE SET
+		enabled=EXCLUDED.enabled,
+		required_fields=EXCLUDED.required_fields,
+		email_allow_domains=EXCLUDED.email_allow_domains,
+		email_deny_domains=EXCLUDED.email_deny_domains,
+		require_email_verification=EXCLUDED.require_email_verification,
+		require_approval=EXCLUDED.require_approval,
+		email_verification_time=CASE
+			WHEN registration_policy.require_email_verification THEN registration_policy.email_verification_time
+			ELSE now()
+		END
+	`
+	_, err := tx.ExecContext(ctx, cmd,
+		namespace,
+		p.Enabled,
+		pq.Array(p.RequiredFields),
+		pq.Array(p.EmailAllowDomains),
+		pq.Array(p.EmailDenyDomains),
+		p.RequireEmailVerification,
+		p.RequireApproval,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// CreateUser adds a user to a namespace, the password must follow the policy of the namespace.
+// Users with an empty password can not sign in with a password, e.g. users of upstream providers.
+func (s *Storage) CreateUser(ctx context.Context, u *User, plainPassword string) (uuid.UUID, error) {
+	return s.TXCreateUser(ctx, s.db, u, plainPassword)
+}
+
+// TXCreateUser is CreateUser within a transaction
+func (s *Storage) TXCreateUser(ctx context.Context, tx qrm.DB, u *User, plainPassword string) (uuid.UUID, error) {
+	var hash string
+	if plainPassword != "" {
+		err := s.ValidatePassword(ctx, u.NamespaceID, uuid.Nil, plainPassword)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		hash, err = password.CreateHash(plainPassword)
+		if err != nil {
+			logrus.Error(err)
+			return uuid.Nil, err
+		}
+	}
+	status := u.Status
+	if status == "" {
+		status = UserStatusActive
+	}
+
+	cmd := `
+	INSERT INTO "user" (
+		username,
+		password,
+		given_name,
+		family_name,
+		nickname,
+		email,
+		email_verified,
+		phone_number,
+		locale,
+		namespace_id,
+		status,
+		directory_id,
+		groups
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+	) ON CONFLICT (namespace_id, username) DO NOTHING
+	RETURNING id
+	`
+	rows, err := tx.QueryContext(ctx, cmd,
+		u.Username,
+		hash,
+		u.FirstName,
+		u.LastName,
+		u.Nickname,
+		u.Email,
+		u.EmailVerified,
+		u.Phone,
+		u.PreferredLanguage.String(),
+		u.NamespaceID,
+		status,
+		uuid.NullUUID{UUID: u.DirectoryID, Valid: u.DirectoryID != uuid.Nil},
+		pq.Array(nonNilStrings(u.Groups)),
+	)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			logrus.Error(err)
+			return uuid.Nil, err
+		}
+		return uuid.Nil, ErrUsernameTaken
+	}
+	var id uuid.UUID
+	err = rows.Scan(&id)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// RegisterUser is the self registration of a user in the namespace of a client,
+// the registration policy of the namespace decides the status of the new user
+func (s *Storage) RegisterUser(ctx context.Context, clientID uuid.UUID, u *User, plainPassword string) (uuid.UUID, error) {
+	client, err := s.GetClientByUUID(ctx, clientID)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	policy, err := s.GetRegistrationPolicy(ctx, client.userNamespaceID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !policy.Enabled {
+		return uuid.Nil, ErrRegistrationDisabled
+	}
+	values := map[string]string{
+		"email":        u.Email,
+		"phone_number": u.Phone,
+		"given_name":   u.FirstName,
+		"family_name":  u.LastName,
+		"nickname":     u.Nickname,
+	}
+	for _, f := range RegistrationFields {
+		if policy.Requires(f) && values[f] == "" {
+			return uuid.Nil, errors.New(f + " is required")
+		}
+	}
+	if u.Username == "" {
+		return uuid.Nil, errors.New("username is required")
+	}
+	if plainPassword == "" {
+		return uuid.Nil, errors.New("password is required")
+	}
+	if u.Email != "" {
+		err = policy.CheckEmail(u.Email)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	u.NamespaceID = client.userNamespaceID
+	u.EmailVerified = false
+	u.Status = UserStatusActive
+	if policy.RequireApproval {
+		u.Status = UserStatusPendingApproval
+	}
+	return s.CreateUser(ctx, u, plainPassword)
+}
+
+// checkRegistrationDone stops the login of users the registration policy
+// does not let in yet
+func (s *Storage) checkRegistrationDone(ctx context.Context, u *User) error {
+	if u.Status == UserStatusPendingApproval {
+		return ErrPendingApproval
+	}
+	if u.Status == UserStatusDisabled {
+		return ErrUserDisabled
+	}
+	if u.EmailVerified {
+		return nil
+	}
+	policy, err := s.GetRegistrationPolicy(ctx, u.NamespaceID)
+	if err != nil {
+		return err
+	}
+	if !policy.RequireEmailVerification || u.Email == "" {
+		return nil
+	}
+	if u.CreateTime.Before(policy.EmailVerificationTime) {
+		// registered before the verification was required
+		return nil
+	}
+	return ErrEmailNotVerified
+}
+
+// ListPendingUsers returns the users of a namespace waiting for approval
+func (s *Storage) ListPendingUsers(ctx context.Context, namespace uuid.UUID) ([]*User, error) {
+	cmd := `
+	SELECT
+		id,
+		username,
+		given_name,
+		family_name,
+		nickname,
+		email,
+		email_verified,
+		phone_number,
+		phone_number_verified
+	FROM
+		"user"
+	WHERE
+		namespace_id = $1
+	AND status = $2
+	ORDER BY updated_at
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace, UserStatusPendingApproval)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var users []*User
+	for rows.Next() {
+		u := &User{
+			NamespaceID: namespace,
+			Status:      UserStatusPendingApproval,
+		}
+		err := rows.Scan(
+			&u.ID,
+			&u.Username,
+			&u.FirstName,
+			&u.LastName,
+			&u.Nickname,
+			&u.Email,
+			&u.EmailVerified,
+			&u.Phone,
+			&u.PhoneVerified,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// ApproveUser lets a pending user login
+func (s *Storage) ApproveUser(ctx context.Context, userID uuid.UUID) error {
+	cmd := `
+	UPDATE "user"
+	SET status = $1,
+		updated_at = now()
+	WHERE id = $2
+	AND status = $3
+	`
+	res, err := s.db.ExecContext(ctx, cmd, UserStatusActive, userID, UserStatusPendingApproval)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("no pending registration of the user")
+	}
+	return nil
+}
+
+// RejectUser removes a pending user
+func (s *Storage) RejectUser(ctx context.Context, userID uuid.UUID) error {
+	cmd := `
+	DELETE FROM "user"
+	WHERE id = $1
+	AND status = $2
+	`
+	res, err := s.db.ExecContext(ctx, cmd, userID, UserStatusPendingApproval)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("no pending registration of the user")
+	}
+	return nil
+}
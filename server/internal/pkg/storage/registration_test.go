@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRegistrationPolicyRequires(t *testing.T) {
+	p := &RegistrationPolicy{RequiredFields: []string{"given_name"}}
+	if !p.Requires("given_name") || p.Requires("email") {
+		t.Errorf("requires given_name %v, email %v", p.Requires("given_name"), p.Requires("email"))
+	}
+	// the address is needed to verify it
+	p.RequireEmailVerification = true
+	if !p.Requires("email") {
+		t.Error("email is not required with the verification")
+	}
+}
+
+func TestRegistrationPolicyCheckEmail(t *testing.T) {
+	tests := []struct {
+		allow, deny []string
+		email       string
+		ok          bool
+	}{
+		{nil, nil, "alice@example.com", true},
+		{nil, nil, "alice", false},
+		{nil, nil, "@example.com", false},
+		{nil, nil, "alice@", false},
+		{nil, []string{"mailinator.com"}, "alice@Mailinator.com", false},
+		{[]string{"example.com"}, nil, "alice@EXAMPLE.com", true},
+		{[]string{"example.com"}, nil, "alice@example.org", false},
+		// the domain is what follows the last @
+		{[]string{"example.com"}, nil, "alice@example.org@example.com", true},
+		{[]string{"example.com"}, []string{"example.com"}, "alice@example.com", false},
+	}
+	for _, tt := range tests {
+		p := &RegistrationPolicy{EmailAllowDomains: tt.allow, EmailDenyDomains: tt.deny}
+		err := p.CheckEmail(tt.email)
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckEmail(%q) with allow %v, deny %v: %v", tt.email, tt.allow, tt.deny, err)
+		}
+	}
+}
+
+// registrationPolicyDB answers the registration policy of every namespace
+func registrationPolicyDB(p *RegistrationPolicy) *fakeDB {
+	db := &fakeDB{}
+	db.on("registration_policy", func(args []driver.Value) [][]driver.Value {
+		if p == nil {
+			return nil
+		}
+		return [][]driver.Value{{
+			p.Enabled, "{}", "{}", "{}",
+			p.RequireEmailVerification, p.RequireApproval, p.EmailVerificationTime,
+		}}
+	})
+	return db
+}
+
+func TestCheckRegistrationDone(t *testing.T) {
+	ctx := context.Background()
+	required := time.Now().Add(-time.Hour)
+	policy := &RegistrationPolicy{Enabled: true, RequireEmailVerification: true, EmailVerificationTime: required}
+	tests := []struct {
+		name   string
+		policy *RegistrationPolicy
+		user   User
+		want   error
+	}{
+		{"pending", nil, User{Status: UserStatusPendingApproval}, ErrPendingApproval},
+		{"no policy", nil, User{Status: UserStatusActive, Email: "a@example.com", CreateTime: time.Now()}, nil},
+		{"unverified", policy, User{Status: UserStatusActive, Email: "a@example.com", CreateTime: time.Now()}, ErrEmailNotVerified},
+		{"verified", policy, User{Status: UserStatusActive, Email: "a@example.com", EmailVerified: true, CreateTime: time.Now()}, nil},
+		{"no email", policy, User{Status: UserStatusActive, CreateTime: time.Now()}, nil},
+		// users of the time before the verification was required
+		{"older", policy, User{Status: UserStatusActive, Email: "a@example.com", CreateTime: required.Add(-time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		s := registrationPolicyDB(tt.policy).storage()
+		tt.user.NamespaceID = uuid.New()
+		if err := s.checkRegistrationDone(ctx, &tt.user); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestApproveUser(t *testing.T) {
+	ctx := context.Background()
+	status := map[string]string{}
+	db := &fakeDB{}
+	db.on(`UPDATE "user"`, func(args []driver.Value) [][]driver.Value {
+		id := args[1].(string)
+		if status[id] != args[2] {
+			return nil
+		}
+		status[id] = args[0].(string)
+		return [][]driver.Value{{}}
+	})
+	db.on(`DELETE FROM "user"`, func(args []driver.Value) [][]driver.Value {
+		id := args[0].(string)
+		if status[id] != args[1] {
+			return nil
+		}
+		delete(status, id)
+		return [][]driver.Value{{}}
+	})
+	s := db.storage()
+
+	approved, rejected, active := uuid.New(), uuid.New(), uuid.New()
+	status[approved.String()] = UserStatusPendingApproval
+	status[rejected.String()] = UserStatusPendingApproval
+	status[active.String()] = UserStatusActive
+
+	if err := s.ApproveUser(ctx, approved); err != nil {
+		t.Fatal(err)
+	}
+	if status[approved.String()] != UserStatusActive {
+		t.Errorf("approved user is %s", status[approved.String()])
+	}
+	if err := s.ApproveUser(ctx, approved); err == nil {
+		t.Error("approved twice")
+	}
+	if err := s.RejectUser(ctx, rejected); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := status[rejected.String()]; ok {
+		t.Error("the rejected user was kept")
+	}
+	// only pending users are approved or rejected
+	if err := s.RejectUser(ctx, active); err == nil {
+		t.Error("an active user was rejected")
+	}
+	if err := s.ApproveUser(ctx, uuid.New()); err == nil {
+		t.Error("an unknown user was approved")
+	}
+}
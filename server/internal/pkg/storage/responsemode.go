@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// the response modes besides query and fragment, the jwt ones are JARM
+const (
+	ResponseModeFormPost    oidc.ResponseMode = "form_post"
+	ResponseModeJWT         oidc.ResponseMode = "jwt"
+	ResponseModeQueryJWT    oidc.ResponseMode = "query.jwt"
+	ResponseModeFragmentJWT oidc.ResponseMode = "fragment.jwt"
+	ResponseModeFormPostJWT oidc.ResponseMode = "form_post.jwt"
+)
+
+// authResponseLifetime is how long jwt authorization responses are valid
+const authResponseLifetime = 10 * time.Minute
+
+// defaultResponseModes are allowed for clients which don't list theirs
+var defaultResponseModes = map[oidc.ResponseMode]bool{
+	oidc.ResponseModeQuery:    true,
+	oidc.ResponseModeFragment: true,
+	ResponseModeFormPost:      true,
+}
+
+// CheckResponseMode tells if the client may request the response mode, empty is the default of the response type
+func (c *Client) CheckResponseMode(mode oidc.ResponseMode) error {
+	if mode == "" {
+		return nil
+	}
+	if len(c.responseModes) == 0 && defaultResponseModes[mode] {
+		return nil
+	}
+	for _, m := range c.responseModes {
+		if oidc.ResponseMode(m) == mode {
+			return nil
+		}
+	}
+	return oidc.ErrInvalidRequest().WithDescription(fmt.Sprintf("the client may not request the response_mode %s", mode))
+}
+
+// AuthResponse is what the authorization response of a request needs besides
+// the parameters the library redirects with
+type AuthResponse struct {
+	Mode         oidc.ResponseMode
+	ResponseType oidc.ResponseType
+	ClientID     string
+	RedirectURI  string
+}
+
+type authResponseKey struct{}
+
+// WithAuthResponse lets the storage tell the response mode of the authorization
+// request it creates or loads for the callback
+func WithAuthResponse(ctx context.Context) (context.Context, *AuthResponse) {
+	r := &AuthResponse{}
+	return context.WithValue(ctx, authResponseKey{}, r), r
+}
+
+func setAuthResponse(ctx context.Context, a *AuthRequest) {
+	r, ok := ctx.Value(authResponseKey{}).(*AuthResponse)
+	if !ok {
+		return
+	}
+	*r = AuthResponse{
+		Mode:         a.AuthReq.ResponseMode,
+		ResponseType: a.AuthReq.ResponseType,
+		ClientID:     a.AuthReq.ClientID,
+		RedirectURI:  a.AuthReq.RedirectURI,
+	}
+}
+
+// SignAuthResponse signs the parameters of an authorization response for the
+// client (JARM), and encrypts the JWT to a key of the client if it chose an encryption
+func (s *Storage) SignAuthResponse(ctx context.Context, issuer, clientID string, params url.Values) (string, error) {
+	client, err := s.GetClient(ctx, clientID)
+	if err != nil {
+		return "", err
+	}
+	claims := map[string]any{
+		"iss": issuer,
+		"aud": clientID,
+		"exp": time.Now().Add(authResponseLifetime).Unix(),
+	}
+	for k := range params {
+		claims[k] = params.Get(k)
+	}
+	token, err := s.signJWT("JWT", claims)
+	if err != nil {
+		return "", err
+	}
+	if client.authorizationEncryptedResponseAlg == "" {
+		return token, nil
+	}
+	return client.encryptAuthResponse(token)
+}
+
+// signJWT signs the claims with the signing key of the provider
+func (s *Storage) signJWT(typ string, claims map[string]any) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: s.signingKey.algorithm, Key: s.signingKey.key},
+		(&jose.SignerOptions{}).WithType(jose.ContentType(typ)).WithHeader("kid", s.signingKey.id),
+	)
+	if err != nil {
+		return "", err
+	}
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return signed.CompactSerialize()
+}
+
+// encryptAuthResponse nests the signed response in a JWE for the client
+func (c *Client) encryptAuthResponse(token string) (string, error) {
+	var keys jose.JSONWebKeySet
+	err := json.Unmarshal([]byte(c.jwks), &keys)
+	if err != nil {
+		return "", err
+	}
+	var key *jose.JSONWebKey
+	for i := range keys.Keys {
+		if keys.Keys[i].Use != "sig" && keys.Keys[i].IsPublic() {
+			key = &keys.Keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return "", errors.New("the client has no encryption key")
+	}
+	enc := jose.ContentEncryption(c.authorizationEncryptedResponseEnc)
+	if enc == "" {
+		enc = jose.A128CBC_HS256
+	}
+	encrypter, err := jose.NewEncrypter(enc, jose.Recipient{
+		Algorithm: jose.KeyAlgorithm(c.authorizationEncryptedResponseAlg),
+		Key:       key.Key,
+		KeyID:     key.KeyID,
+	}, (&jose.EncrypterOptions{}).WithContentType("JWT").WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := encrypter.Encrypt([]byte(token))
+	if err != nil {
+		return "", err
+	}
+	return encrypted.CompactSerialize()
+}
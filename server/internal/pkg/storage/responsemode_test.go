@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestSignJWT(t *testing.T) {
+	key := testRSAKey(t)
+	s := &Storage{signingKey: signingKey{id: "key-1", algorithm: jose.RS256, key: key}}
+
+	token, err := s.signJWT("JWT", map[string]any{"iss": "https://op.example.com", "code": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := jose.ParseSigned(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := signed.Signatures[0].Header
+	if header.KeyID != "key-1" || header.Algorithm != string(jose.RS256) || header.ExtraHeaders[jose.HeaderType] != "JWT" {
+		t.Errorf("header %+v", header)
+	}
+	payload, err := signed.Verify(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims["iss"] != "https://op.example.com" || claims["code"] != "abc" {
+		t.Errorf("claims %v", claims)
+	}
+	if _, err := signed.Verify(&testRSAKey(t).PublicKey); err == nil {
+		t.Error("verified with another key")
+	}
+}
+
+func TestEncryptAuthResponse(t *testing.T) {
+	sig, enc := testRSAKey(t), testRSAKey(t)
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		// the signing key of the client is not used for the encryption
+		{Key: &sig.PublicKey, KeyID: "sig", Use: "sig"},
+		{Key: &enc.PublicKey, KeyID: "enc", Use: "enc"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{
+		jwks:                              string(jwks),
+		authorizationEncryptedResponseAlg: string(jose.RSA_OAEP_256),
+		authorizationEncryptedResponseEnc: string(jose.A256GCM),
+	}
+
+	encrypted, err := c.encryptAuthResponse("signed.response.token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwe, err := jose.ParseEncrypted(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwe.Header.KeyID != "enc" || jwe.Header.Algorithm != string(jose.RSA_OAEP_256) || jwe.Header.ExtraHeaders[jose.HeaderContentType] != "JWT" {
+		t.Errorf("header %+v", jwe.Header)
+	}
+	plain, err := jwe.Decrypt(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != "signed.response.token" {
+		t.Errorf("decrypted %q", plain)
+	}
+
+	// without an encryption key there is nothing to encrypt to
+	jwks, _ = json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &sig.PublicKey, KeyID: "sig", Use: "sig"}}})
+	c.jwks = string(jwks)
+	if _, err := c.encryptAuthResponse("signed.response.token"); err == nil {
+		t.Error("encrypted without an encryption key")
+	}
+}
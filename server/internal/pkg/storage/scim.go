@@ -0,0 +1,925 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/scim"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+var ErrSCIMTokenNotFound = errors.New("scim token not found")
+
+// SCIMToken is a provisioning token of a client, only its hash is stored
+type SCIMToken struct {
+	ID           uuid.UUID
+	ClientID     uuid.UUID
+	Description  string
+	CreateTime   time.Time
+	LastUsedTime time.Time
+}
+
+// hashToken is what is stored of the bearer tokens the server issues
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSCIMToken issues a token provisioning the user namespace of the client,
+// the token is returned only here
+func (s *Storage) CreateSCIMToken(ctx context.Context, clientID uuid.UUID, description string) (uuid.UUID, string, error) {
+	_, err := s.GetClientByUUID(ctx, clientID)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, "", err
+	}
+	token, err := newToken()
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, "", err
+	}
+	cmd := `
+	INSERT INTO scim_token (
+		client_id,
+		token_hash,
+		description
+	) VALUES (
+		$1, $2, $3
+	) RETURNING id
+	`
+	var id uuid.UUID
+	err = s.db.QueryRowContext(ctx, cmd, clientID, hashToken(token), description).Scan(&id)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, "", err
+	}
+	return id, token, nil
+}
+
+func (s *Storage) ListSCIMTokens(ctx context.Context, clientID uuid.UUID) ([]*SCIMToken, error) {
+	cmd := `
+	SELECT
+		id,
+		client_id,
+		description,
+		create_time,
+		last_used_time
+	FROM
+		scim_token
+	WHERE
+		client_id = $1
+	ORDER BY create_time
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, clientID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var tokens []*SCIMToken
+	for rows.Next() {
+		t := &SCIMToken{}
+		var lastUsed sql.NullTime
+		err := rows.Scan(&t.ID, &t.ClientID, &t.Description, &t.CreateTime, &lastUsed)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		t.LastUsedTime = lastUsed.Time
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *Storage) DeleteSCIMToken(ctx context.Context, clientID, id uuid.UUID) error {
+	cmd := `
+	DELETE FROM scim_token
+	WHERE client_id = $1
+	AND id = $2
+	`
+	res, err := s.db.ExecContext(ctx, cmd, clientID, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSCIMTokenNotFound
+	}
+	return nil
+}
+
+// ProvisioningNamespace implements scim.Backend
+func (s *Storage) ProvisioningNamespace(ctx context.Context, token string) (uuid.UUID, error) {
+	cmd := `
+	UPDATE scim_token
+	SET last_used_time = now()
+	FROM client
+	WHERE client.id = scim_token.client_id
+	AND scim_token.token_hash = $1
+	RETURNING client.user_namespace_id
+	`
+	var namespace uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, hashToken(token)).Scan(&namespace)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrSCIMTokenNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return namespace, nil
+}
+
+const scimUserColumns = `
+		id,
+		username,
+		external_id,
+		given_name,
+		family_name,
+		middle_name,
+		nickname,
+		profile,
+		locale,
+		zoneinfo,
+		status,
+		email,
+		phone_number,
+		create_time,
+		updated_at
+`
+
+func scanSCIMUser(row interface{ Scan(...any) error }) (*scim.User, error) {
+	var (
+		id                uuid.UUID
+		status            string
+		email, phone      string
+		created, modified time.Time
+	)
+	u := &scim.User{Schemas: []string{scim.SchemaUser}, Name: &scim.Name{}}
+	err := row.Scan(
+		&id,
+		&u.UserName,
+		&u.ExternalID,
+		&u.Name.GivenName,
+		&u.Name.FamilyName,
+		&u.Name.MiddleName,
+		&u.NickName,
+		&u.ProfileURL,
+		&u.Locale,
+		&u.Timezone,
+		&status,
+		&email,
+		&phone,
+		&created,
+		&modified,
+	)
+	if err != nil {
+		return nil, err
+	}
+	u.ID = id.String()
+	u.Name.Formatted = strings.Join(strings.Fields(u.Name.GivenName+" "+u.Name.MiddleName+" "+u.Name.FamilyName), " ")
+	u.DisplayName = u.Name.Formatted
+	if u.DisplayName == "" {
+		u.DisplayName = u.UserName
+	}
+	active := status == UserStatusActive
+	u.Active = &active
+	if email != "" {
+		u.Emails = []scim.MultiValued{{Value: email, Type: "work", Primary: true}}
+	}
+	if phone != "" {
+		u.PhoneNumbers = []scim.MultiValued{{Value: phone, Type: "work", Primary: true}}
+	}
+	u.Meta = &scim.Meta{ResourceType: "User", Created: created, LastModified: modified}
+	return u, nil
+}
+
+// scimUserGroups fills the groups of the users
+func (s *Storage) scimUserGroups(ctx context.Context, users []*scim.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]string, len(users))
+	byID := map[string]*scim.User{}
+	for i, u := range users {
+		ids[i] = u.ID
+		byID[u.ID] = u
+	}
+	cmd := `
+	SELECT
+		user_group_member.user_id,
+		user_group.id,
+		user_group.display_name
+	FROM
+		user_group_member
+	JOIN user_group ON user_group.id = user_group_member.group_id
+	WHERE
+		user_group_member.user_id = ANY($1::uuid[])
+	ORDER BY user_group.display_name
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, pq.Array(ids))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, groupID uuid.UUID
+		var name string
+		err := rows.Scan(&userID, &groupID, &name)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		u := byID[userID.String()]
+		u.Groups = append(u.Groups, scim.Reference{Value: groupID.String(), Display: name, Type: "direct"})
+	}
+	return rows.Err()
+}
+
+// scimUserFilters are the attributes of users a SCIM filter can use
+var scimUserFilters = map[string]scim.Column{
+	"id":                   {Expr: "id::text", Type: scim.ColumnCaseExact},
+	"externalid":           {Expr: "external_id", Type: scim.ColumnCaseExact},
+	"username":             {Expr: "username"},
+	"name.givenname":       {Expr: "given_name"},
+	"name.familyname":      {Expr: "family_name"},
+	"name.middlename":      {Expr: "middle_name"},
+	"name.formatted":       {Expr: scimFormattedName},
+	"displayname":          {Expr: "coalesce(nullif(" + scimFormattedName + ", ''), username)"},
+	"nickname":             {Expr: "nickname"},
+	"profileurl":           {Expr: "profile"},
+	"locale":               {Expr: "locale"},
+	"timezone":             {Expr: "zoneinfo"},
+	"active":               {Expr: "status = 'active'", Type: scim.ColumnBool},
+	"emails.value":         {Expr: "array_remove(ARRAY[email], '')", Multi: true},
+	"emails.type":          {Expr: "CASE WHEN email <> '' THEN ARRAY['work'] ELSE '{}' END", Multi: true},
+	"emails.primary":       {Expr: "CASE WHEN email <> '' THEN ARRAY[true] ELSE '{}' END", Type: scim.ColumnBool, Multi: true},
+	"phonenumbers.value":   {Expr: "array_remove(ARRAY[phone_number], '')", Multi: true},
+	"phonenumbers.type":    {Expr: "CASE WHEN phone_number <> '' THEN ARRAY['work'] ELSE '{}' END", Multi: true},
+	"phonenumbers.primary": {Expr: "CASE WHEN phone_number <> '' THEN ARRAY[true] ELSE '{}' END", Type: scim.ColumnBool, Multi: true},
+	"groups.value": {
+		Expr: `ARRAY(SELECT group_id::text FROM user_group_member WHERE user_id = "user".id)`,
+		Type: scim.ColumnCaseExact, Multi: true,
+	},
+	"groups.display": {
+		Expr:  `ARRAY(SELECT user_group.display_name FROM user_group_member JOIN user_group ON user_group.id = user_group_member.group_id WHERE user_group_member.user_id = "user".id)`,
+		Multi: true,
+	},
+	"meta.created":      {Expr: "create_time", Type: scim.ColumnTime},
+	"meta.lastmodified": {Expr: "updated_at", Type: scim.ColumnTime},
+}
+
+// scimFormattedName is the name.formatted of scanSCIMUser
+const scimFormattedName = "concat_ws(' ', nullif(given_name, ''), nullif(middle_name, ''), nullif(family_name, ''))"
+
+// scimWhere is the condition of a list of the namespace with the filter of the query,
+// and its arguments
+func scimWhere(namespace uuid.UUID, q *scim.Query, columns map[string]scim.Column) (string, []any, error) {
+	args := []any{namespace}
+	where := "namespace_id = $1"
+	if q.Filter != nil {
+		c, err := scim.SQL(q.Filter, columns, &args)
+		if err != nil {
+			return "", nil, err
+		}
+		where += " AND " + c
+	}
+	return where, args, nil
+}
+
+// ListSCIMUsers implements scim.Backend
+func (s *Storage) ListSCIMUsers(ctx context.Context, namespace uuid.UUID, q *scim.Query) ([]*scim.User, int, error) {
+	where, args, err := scimWhere(namespace, q, scimUserFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	err = s.db.QueryRowContext(ctx, `
+	SELECT
+		count(*)
+	FROM
+		"user"
+	WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, err
+	}
+	cmd := `
+	SELECT` + scimUserColumns + `
+	FROM
+		"user"
+	WHERE ` + where + `
+	ORDER BY create_time, username
+	LIMIT ` + strconv.Itoa(q.Count) + `
+	OFFSET ` + strconv.Itoa(q.StartIndex-1)
+	rows, err := s.db.QueryContext(ctx, cmd, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var users []*scim.User
+	for rows.Next() {
+		u, err := scanSCIMUser(rows)
+		if err != nil {
+			logrus.Error(err)
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err)
+		return nil, 0, err
+	}
+	return users, total, s.scimUserGroups(ctx, users)
+}
+
+// GetSCIMUser implements scim.Backend
+func (s *Storage) GetSCIMUser(ctx context.Context, namespace uuid.UUID, id string) (*scim.User, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, scim.ErrNotFound
+	}
+	cmd := `
+	SELECT` + scimUserColumns + `
+	FROM
+		"user"
+	WHERE
+		namespace_id = $1
+	AND id = $2
+	`
+	u, err := scanSCIMUser(s.db.QueryRowContext(ctx, cmd, namespace, uid))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, scim.ErrNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return u, s.scimUserGroups(ctx, []*scim.User{u})
+}
+
+// scimPasswordError tells the client which rule a password breaks
+func scimPasswordError(err error) error {
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) || errors.Is(err, password.ErrReused) {
+		return &scim.Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: err.Error()}
+	}
+	return err
+}
+
+func scimStatus(u *scim.User) string {
+	if u.Active != nil && !*u.Active {
+		return UserStatusDisabled
+	}
+	return UserStatusActive
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// updateSCIMUser sets the attributes of a SCIM user CreateUser does not know
+func (s *Storage) updateSCIMUser(ctx context.Context, tx qrm.DB, namespace, id uuid.UUID, u *scim.User) (int64, error) {
+	name := u.Name
+	if name == nil {
+		name = &scim.Name{}
+	}
+	cmd := `
+	UPDATE "user"
+	SET username = $3,
+		external_id = $4,
+		given_name = $5,
+		family_name = $6,
+		middle_name = $7,
+		nickname = $8,
+		profile = $9,
+		locale = $10,
+		zoneinfo = $11,
+		email = $12,
+		email_verified = CASE WHEN email = $12 THEN email_verified ELSE $12 <> '' END,
+		phone_number = $13,
+		phone_number_verified = phone_number_verified AND phone_number = $13,
+		status = CASE WHEN status = $14 THEN status
+			WHEN $14 = 'disabled' OR status = 'disabled' THEN $14
+			ELSE status END,
+		updated_at = now()
+	WHERE
+		namespace_id = $1
+	AND id = $2
+	`
+	res, err := tx.ExecContext(ctx, cmd,
+		namespace,
+		id,
+		u.UserName,
+		u.ExternalID,
+		name.GivenName,
+		name.FamilyName,
+		name.MiddleName,
+		u.NickName,
+		u.ProfileURL,
+		u.Locale,
+		u.Timezone,
+		scim.Primary(u.Emails),
+		scim.Primary(u.PhoneNumbers),
+		scimStatus(u),
+	)
+	if isUniqueViolation(err) {
+		return 0, scim.ErrConflict
+	}
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CreateSCIMUser implements scim.Backend, the provisioning client vouches for the email
+func (s *Storage) CreateSCIMUser(ctx context.Context, namespace uuid.UUID, u *scim.User) error {
+	if u.Password != "" {
+		policy, err := s.GetPasswordPolicy(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		err = s.validatePassword(ctx, policy, uuid.Nil, u.Password)
+		if err != nil {
+			return scimPasswordError(err)
+		}
+	}
+	// the user is created with all its attributes or not at all
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	email := scim.Primary(u.Emails)
+	id, err := s.TXCreateUser(ctx, tx, &User{
+		Username:      u.UserName,
+		Email:         email,
+		EmailVerified: email != "",
+		Phone:         scim.Primary(u.PhoneNumbers),
+		NamespaceID:   namespace,
+		Status:        scimStatus(u),
+	}, u.Password)
+	if errors.Is(err, ErrUsernameTaken) {
+		_ = tx.Rollback()
+		return scim.ErrConflict
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = s.updateSCIMUser(ctx, tx, namespace, id, u)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	created, err := s.GetSCIMUser(ctx, namespace, id.String())
+	if err != nil {
+		return err
+	}
+	*u = *created
+	return nil
+}
+
+// ReplaceSCIMUser implements scim.Backend, deactivated users lose their sessions and tokens
+func (s *Storage) ReplaceSCIMUser(ctx context.Context, namespace uuid.UUID, u *scim.User) error {
+	id, err := uuid.Parse(u.ID)
+	if err != nil {
+		return scim.ErrNotFound
+	}
+	n, err := s.updateSCIMUser(ctx, s.db, namespace, id, u)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return scim.ErrNotFound
+	}
+	if u.Password != "" {
+		err = s.SetUserPassword(ctx, id, u.Password)
+		if errors.Is(err, ErrDirectoryUser) {
+			return &scim.Error{Status: http.StatusBadRequest, ScimType: "mutability", Detail: err.Error()}
+		}
+		if err != nil {
+			return scimPasswordError(err)
+		}
+	}
+	if scimStatus(u) == UserStatusDisabled {
+		err = s.RevokeUserRefreshTokens(ctx, id)
+		if err != nil {
+			return err
+		}
+		_, err = s.db.ExecContext(ctx, `
+		DELETE FROM user_session
+		WHERE user_id = $1
+		`, id)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+	}
+	replaced, err := s.GetSCIMUser(ctx, namespace, id.String())
+	if err != nil {
+		return err
+	}
+	*u = *replaced
+	return nil
+}
+
+// DeleteSCIMUser implements scim.Backend
+func (s *Storage) DeleteSCIMUser(ctx context.Context, namespace uuid.UUID, id string) error {
+	u, err := s.GetSCIMUser(ctx, namespace, id)
+	if err != nil {
+		return err
+	}
+	return s.DeleteUser(ctx, uuid.MustParse(u.ID))
+}
+
+// scimGroupMembers fills the members of the groups
+func (s *Storage) scimGroupMembers(ctx context.Context, groups []*scim.Group) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	ids := make([]string, len(groups))
+	byID := map[string]*scim.Group{}
+	for i, g := range groups {
+		ids[i] = g.ID
+		byID[g.ID] = g
+	}
+	cmd := `
+	SELECT
+		user_group_member.group_id,
+		"user".id,
+		"user".username
+	FROM
+		user_group_member
+	JOIN "user" ON "user".id = user_group_member.user_id
+	WHERE
+		user_group_member.group_id = ANY($1::uuid[])
+	ORDER BY "user".username
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, pq.Array(ids))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var groupID, userID uuid.UUID
+		var username string
+		err := rows.Scan(&groupID, &userID, &username)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		g := byID[groupID.String()]
+		g.Members = append(g.Members, scim.Reference{Value: userID.String(), Display: username, Type: "User"})
+	}
+	return rows.Err()
+}
+
+func scanSCIMGroup(row interface{ Scan(...any) error }) (*scim.Group, error) {
+	var id uuid.UUID
+	g := &scim.Group{Schemas: []string{scim.SchemaGroup}, Meta: &scim.Meta{ResourceType: "Group"}}
+	err := row.Scan(&id, &g.DisplayName, &g.ExternalID, &g.Meta.Created, &g.Meta.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	g.ID = id.String()
+	return g, nil
+}
+
+// scimGroupFilters are the attributes of groups a SCIM filter can use
+var scimGroupFilters = map[string]scim.Column{
+	"id":          {Expr: "id::text", Type: scim.ColumnCaseExact},
+	"externalid":  {Expr: "external_id", Type: scim.ColumnCaseExact},
+	"displayname": {Expr: "display_name"},
+	"members.value": {
+		Expr: `ARRAY(SELECT user_id::text FROM user_group_member WHERE group_id = user_group.id)`,
+		Type: scim.ColumnCaseExact, Multi: true,
+	},
+	"members.display": {
+		Expr:  `ARRAY(SELECT "user".username FROM user_group_member JOIN "user" ON "user".id = user_group_member.user_id WHERE user_group_member.group_id = user_group.id)`,
+		Multi: true,
+	},
+	"meta.created":      {Expr: "create_time", Type: scim.ColumnTime},
+	"meta.lastmodified": {Expr: "update_time", Type: scim.ColumnTime},
+}
+
+// ListSCIMGroups implements scim.Backend
+func (s *Storage) ListSCIMGroups(ctx context.Context, namespace uuid.UUID, q *scim.Query) ([]*scim.Group, int, error) {
+	where, args, err := scimWhere(namespace, q, scimGroupFilters)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	err = s.db.QueryRowContext(ctx, `
+	SELECT
+		count(*)
+	FROM
+		user_group
+	WHERE `+where, args...).Scan(&total)
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, err
+	}
+	cmd := `
+	SELECT
+		id,
+		display_name,
+		external_id,
+		create_time,
+		update_time
+	FROM
+		user_group
+	WHERE ` + where + `
+	ORDER BY display_name
+	LIMIT ` + strconv.Itoa(q.Count) + `
+	OFFSET ` + strconv.Itoa(q.StartIndex-1)
+	rows, err := s.db.QueryContext(ctx, cmd, args...)
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var groups []*scim.Group
+	for rows.Next() {
+		g, err := scanSCIMGroup(rows)
+		if err != nil {
+			logrus.Error(err)
+			return nil, 0, err
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err)
+		return nil, 0, err
+	}
+	return groups, total, s.scimGroupMembers(ctx, groups)
+}
+
+// GetSCIMGroup implements scim.Backend
+func (s *Storage) GetSCIMGroup(ctx context.Context, namespace uuid.UUID, id string) (*scim.Group, error) {
+	gid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, scim.ErrNotFound
+	}
+	cmd := `
+	SELECT
+		id,
+		display_name,
+		external_id,
+		create_time,
+		update_time
+	FROM
+		user_group
+	WHERE
+		namespace_id = $1
+	AND id = $2
+	`
+	g, err := scanSCIMGroup(s.db.QueryRowContext(ctx, cmd, namespace, gid))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, scim.ErrNotFound
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return g, s.scimGroupMembers(ctx, []*scim.Group{g})
+}
+
+// setSCIMGroupMembers replaces the members, they must be users of the namespace
+func setSCIMGroupMembers(ctx context.Context, tx *sql.Tx, namespace, id uuid.UUID, members []scim.Reference) error {
+	_, err := tx.ExecContext(ctx, `
+	DELETE FROM user_group_member
+	WHERE group_id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	userIDs := map[uuid.UUID]bool{}
+	var ids []string
+	for _, m := range members {
+		uid, err := uuid.Parse(m.Value)
+		if err != nil {
+			return &scim.Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "unknown member " + m.Value}
+		}
+		if !userIDs[uid] {
+			userIDs[uid] = true
+			ids = append(ids, uid.String())
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO user_group_member (
+		group_id,
+		user_id
+	)
+	SELECT
+		$1, id
+	FROM
+		"user"
+	WHERE
+		id = ANY($2::uuid[])
+	AND namespace_id = $3
+	`, id, pq.Array(ids), namespace)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n != int64(len(ids)) {
+		return &scim.Error{Status: http.StatusBadRequest, ScimType: "invalidValue", Detail: "members must be users of the namespace"}
+	}
+	return nil
+}
+
+// CreateSCIMGroup implements scim.Backend
+func (s *Storage) CreateSCIMGroup(ctx context.Context, namespace uuid.UUID, g *scim.Group) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+	INSERT INTO user_group (
+		namespace_id,
+		display_name,
+		external_id
+	) VALUES (
+		$1, $2, $3
+	) RETURNING id
+	`, namespace, g.DisplayName, g.ExternalID).Scan(&id)
+	if isUniqueViolation(err) {
+		_ = tx.Rollback()
+		return scim.ErrConflict
+	}
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	err = setSCIMGroupMembers(ctx, tx, namespace, id, g.Members)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	created, err := s.GetSCIMGroup(ctx, namespace, id.String())
+	if err != nil {
+		return err
+	}
+	*g = *created
+	return nil
+}
+
+// ReplaceSCIMGroup implements scim.Backend
+func (s *Storage) ReplaceSCIMGroup(ctx context.Context, namespace uuid.UUID, g *scim.Group) error {
+	id, err := uuid.Parse(g.ID)
+	if err != nil {
+		return scim.ErrNotFound
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+	UPDATE user_group
+	SET display_name = $3,
+		external_id = $4,
+		update_time = now()
+	WHERE namespace_id = $1
+	AND id = $2
+	`, namespace, id, g.DisplayName, g.ExternalID)
+	if isUniqueViolation(err) {
+		_ = tx.Rollback()
+		return scim.ErrConflict
+	}
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = tx.Rollback()
+		return scim.ErrNotFound
+	}
+	err = setSCIMGroupMembers(ctx, tx, namespace, id, g.Members)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	replaced, err := s.GetSCIMGroup(ctx, namespace, id.String())
+	if err != nil {
+		return err
+	}
+	*g = *replaced
+	return nil
+}
+
+// DeleteSCIMGroup implements scim.Backend
+func (s *Storage) DeleteSCIMGroup(ctx context.Context, namespace uuid.UUID, id string) error {
+	g, err := s.GetSCIMGroup(ctx, namespace, id)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM user_group_member
+	WHERE group_id = $1
+	`, g.ID)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM user_group
+	WHERE id = $1
+	`, g.ID)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// UserGroupNames returns the directory groups of the user and the names of
+// the groups it is a member of
+func (s *Storage) UserGroupNames(ctx context.Context, user *User) ([]string, error) {
+	names := append([]string{}, user.Groups...)
+	cmd := `
+	SELECT
+		user_group.display_name
+	FROM
+		user_group_member
+	JOIN user_group ON user_group.id = user_group_member.group_id
+	WHERE
+		user_group_member.user_id = $1
+	ORDER BY user_group.display_name
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, user.ID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	seen := map[string]bool{}
+	for _, n := range names {
+		seen[n] = true
+	}
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, rows.Err()
+}
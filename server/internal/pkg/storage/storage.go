@@ -18,6 +18,8 @@ import (
 	sqldblogger "github.com/simukti/sqldb-logger"
 	"github.com/simukti/sqldb-logger/logadapter/logrusadapter"
 	log "github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/internal/pkg/directory"
+	"github.com/zltl/xoidc/server/internal/pkg/dpop"
 	"github.com/zltl/xoidc/server/pkg/password"
 
 	"github.com/zitadel/oidc/v3/pkg/oidc"
@@ -53,6 +55,9 @@ type Storage struct {
 	PGPassword string
 	PGDBName   string
 
+	// BreachedPasswords are rejected by password policies with CheckBreached
+	BreachedPasswords *password.BreachedList
+
 	db *sql.DB
 }
 
@@ -136,6 +141,7 @@ func (s *Storage) Open() error {
 				oidc.GrantTypeClientCredentials,
 			},
 			accessTokenType: op.AccessTokenTypeBearer,
+			tokens:          DefaultTokenPolicy,
 		},
 	}
 
@@ -154,7 +160,10 @@ func (s *Storage) GetClient(ctx context.Context, id string) (*Client, error) {
 }
 
 // CheckUsernamePassword implements the `authenticate` interface of the login
-func (s *Storage) CheckUsernamePassword(username, passwordInput, reqid string) error {
+//
+// failed attempts are counted per account and per remote ip, see lockout.go.
+// unknown users and wrong passwords return the same error after the same time.
+func (s *Storage) CheckUsernamePassword(username, passwordInput, reqid, remoteIP string) error {
 	log.Tracef("CheckUsernamePassword: username=%s", username)
 
 	requid, err := uuid.Parse(reqid)
@@ -172,30 +181,101 @@ func (s *Storage) CheckUsernamePassword(username, passwordInput, reqid string) e
 	clientIDStr := request.GetClientID()
 	clientID := uuid.MustParse(clientIDStr)
 
-	us, err := s.GetUserByUsername(context.TODO(), username, clientID)
+	client, err := s.GetClientByUUID(context.TODO(), clientID)
+	if err != nil {
+		log.Errorf("GetClientByUUID: %v", err)
+		return err
+	}
+	accountKey := lockKey{accountLockKey(client.userNamespaceID, username), accountFreeFailures}
+	ipKey := lockKey{ipLockKey(remoteIP), ipFreeFailures}
+	err = s.acquireLoginAttempt(context.TODO(), accountKey, ipKey)
 	if err != nil {
+		return err
+	}
+	// known and unknown users, local and directory users take the same time
+	defer waitLogin(time.Now())
+
+	passHash := dummyPasswordHash()
+	us, err := s.GetUserByUsername(context.TODO(), username, clientID)
+	if err == nil {
+		passHash = us.Password
+	} else if !errors.Is(err, sql.ErrNoRows) {
 		log.Errorf("QueryPassword: %v", err)
+		_ = s.releaseLoginAttempt(context.TODO(), accountKey, ipKey)
 		return err
 	}
-	passHash := us.Password
-	match, err := password.ComparePasswordAndHash(passwordInput, passHash)
+	var match, fromDirectory bool
+	if us != nil && us.DirectoryID != uuid.Nil {
+		// directory users never have a local password
+		us, err = s.directoryLogin(context.TODO(), us, client.userNamespaceID, username, passwordInput)
+		match, fromDirectory = err == nil, true
+	} else {
+		match, err = password.ComparePasswordAndHash(passwordInput, passHash)
+		if err != nil {
+			log.Errorf("ComparePasswordAndHash: %v", err)
+			match = false
+		}
+		if us == nil {
+			us, err = s.directoryLogin(context.TODO(), nil, client.userNamespaceID, username, passwordInput)
+			match, fromDirectory = err == nil, true
+		}
+	}
+	if fromDirectory && err != nil && !errors.Is(err, errDirectoryLoginFailed) && !errors.Is(err, directory.ErrUserNotFound) {
+		// an unreachable directory is no failed login
+		_ = s.releaseLoginAttempt(context.TODO(), accountKey, ipKey)
+		return err
+	}
+	if us == nil || !match {
+		// acquireLoginAttempt counted the failure
+		return ErrLoginFailed
+	}
+
+	err = s.ResetLoginFailures(context.TODO(), accountKey.key)
+	if err != nil {
+		return err
+	}
+	err = s.releaseLoginAttempt(context.TODO(), ipKey)
 	if err != nil {
-		log.Errorf("ComparePasswordAndHash: %v", err)
 		return err
 	}
-	if match {
-		request.UserID = us.ID
-		request.IsDone = true
 
-		err = s.UpdateAuthRequest(context.Background(), request)
+	if !fromDirectory && password.NeedsRehash(us.Password) {
+		// a failed rehash must not fail the login, the old hash still works
+		err = s.RehashPassword(context.TODO(), us.ID, passwordInput)
 		if err != nil {
-			log.Errorf("UpdateAuthRequest: %v", err)
-			return err
+			log.Errorf("RehashPassword: %v", err)
 		}
-		return nil
 	}
 
-	return fmt.Errorf("username or password wrong")
+	policy, err := s.GetPasswordPolicy(context.TODO(), us.NamespaceID)
+	if err != nil {
+		return err
+	}
+	if !fromDirectory && policy.Expired(us.PasswordChangeTime) {
+		return password.ErrExpired
+	}
+	err = s.checkRegistrationDone(context.TODO(), us)
+	if err != nil {
+		return err
+	}
+	request.UserID = us.ID
+	request.AMR = []string{AMRPassword}
+	request.AuthTime = time.Now()
+
+	// users with a registered passkey must present it as second factor
+	passkeys, err := s.CountPasskeyCredentials(context.TODO(), us.ID)
+	if err != nil {
+		log.Errorf("CountPasskeyCredentials: %v", err)
+		return err
+	}
+	request.IsDone = passkeys == 0
+
+	err = s.UpdateAuthRequest(context.Background(), request)
+	if err != nil {
+		log.Errorf("UpdateAuthRequest: %v", err)
+		return err
+	}
+	return nil
 }
 
 // CreateAuthRequest implements the op.Storage interface
@@ -211,9 +291,38 @@ func (s *Storage) CreateAuthRequest(ctx context.Context, authReq *oidc.AuthReque
 		userID = "00000000-0000-0000-0000-000000000000"
 	}
 
+	client, err := s.GetClient(ctx, authReq.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.requirePushedAuthRequests && !isPushedAuthRequest(ctx) {
+		return nil, oidc.ErrInvalidRequest().WithDescription("the client must push its authorization requests")
+	}
+	authReq.Scopes, err = client.CheckScopes(authReq.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	err = client.CheckResponseMode(authReq.ResponseMode)
+	if err != nil {
+		return nil, err
+	}
+
 	log.Info("CreateAuthRequest, userID=", userID)
 	// typically, you'll fill your storage / storage model with the information of the passed object
 	request := authRequestToInternal(authReq, userID)
+	request.Audience = client.audiences
+	if req := resourceRequestOf(ctx); req != nil {
+		err = client.checkResources(req.resources)
+		if err != nil {
+			return nil, err
+		}
+		request.Resources = req.resources
+	}
+	// the user approves the authorization details on the consent screen
+	request.AuthorizationDetails, err = s.requestedAuthorizationDetails(ctx, client.userNamespaceID)
+	if err != nil {
+		return nil, err
+	}
 
 	log.Infof("request: %+v", request)
 	rid, err := s.StoreAuthRequest(context.TODO(), request)
@@ -222,6 +331,7 @@ func (s *Storage) CreateAuthRequest(ctx context.Context, authReq *oidc.AuthReque
 		return nil, err
 	}
 	request.ID = rid
+	setAuthResponse(ctx, request)
 
 	return request, nil
 }
@@ -234,6 +344,7 @@ func (s *Storage) AuthRequestByUID(ctx context.Context, id uuid.UUID) (op.AuthRe
 		log.Error(err)
 		return nil, fmt.Errorf("request not found")
 	}
+	setAuthResponse(ctx, request)
 	return request, nil
 }
 
@@ -260,7 +371,22 @@ func (s *Storage) AuthRequestByCode(ctx context.Context, code string) (op.AuthRe
 	if err != nil {
 		return nil, fmt.Errorf("code invalid or expired")
 	}
-	return s.AuthRequestByUID(ctx, requestID)
+	request, err := s.GetAuthRequestByUUID(ctx, requestID)
+	if err != nil {
+		log.Error(err)
+		return nil, fmt.Errorf("request not found")
+	}
+	// the token request may narrow the access token to some of the resources
+	request.narrowed, err = narrowResources(ctx, request.grantedAudience())
+	if err != nil {
+		return nil, err
+	}
+	// and to some of the authorization details
+	request.narrowedDetails, err = narrowAuthorizationDetails(ctx, request.AuthorizationDetails)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
 }
 
 // SaveAuthCode implements the op.Storage interface
@@ -316,12 +442,24 @@ func (s *Storage) CreateAccessToken(ctx context.Context, request op.TokenRequest
 		applicationID = req.GetClientID()
 	case op.TokenExchangeRequest:
 		applicationID = req.GetClientID()
+	case *clientCredentialsRequest:
+		applicationID = req.GetClientID()
+	case *BackchannelAuthRequest:
+		applicationID = req.GetClientID()
 	}
 
-	token, err := s.accessToken(applicationID, "", request.GetSubject(), request.GetAudience(), request.GetScopes())
+	policy := s.clientTokenPolicy(ctx, applicationID)
+	binding, err := s.bindTokens(ctx, applicationID)
 	if err != nil {
 		return "", time.Time{}, err
 	}
+	details := authorizationDetailsOf(request)
+	token, err := s.accessToken(applicationID, "", request.GetSubject(), request.GetAudience(), request.GetScopes(), policy.AccessTokenLifetime, binding, details)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	issueAuthorizationDetails(ctx, details)
+	s.recordConsentOfRequest(ctx, request)
 	return token.ID.String(), token.Expiration, nil
 }
 
@@ -335,28 +473,45 @@ func (s *Storage) CreateAccessAndRefreshTokens(ctx context.Context, request op.T
 
 	// get the information depending on the request type / implementation
 	applicationID, authTime, amr := getInfoFromRequest(request)
+	policy := s.clientTokenPolicy(ctx, applicationID)
+	binding, err := s.bindTokens(ctx, applicationID)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	details := authorizationDetailsOf(request)
+	issueAuthorizationDetails(ctx, details)
 
 	// if currentRefreshToken is empty (Code Flow) we will have to create a new refresh token
 	if currentRefreshToken == "" {
+		// clients without refresh tokens only get the access token
+		if !policy.AllowsRefreshToken(request.GetScopes()) {
+			accessToken, err := s.accessToken(applicationID, "", request.GetSubject(), request.GetAudience(), request.GetScopes(), policy.AccessTokenLifetime, binding, details)
+			if err != nil {
+				return "", "", time.Time{}, err
+			}
+			s.recordConsentOfRequest(ctx, request)
+			return accessToken.ID.String(), "", accessToken.Expiration, nil
+		}
 		refreshTokenID := uuid.NewString()
-		accessToken, err := s.accessToken(applicationID, refreshTokenID, request.GetSubject(), request.GetAudience(), request.GetScopes())
+		accessToken, err := s.accessToken(applicationID, refreshTokenID, request.GetSubject(), request.GetAudience(), request.GetScopes(), policy.AccessTokenLifetime, binding, details)
 		if err != nil {
 			return "", "", time.Time{}, err
 		}
-		refreshToken, err := s.createRefreshToken(accessToken, amr, authTime)
+		refreshToken, err := s.createRefreshToken(accessToken, grantedAudience(request), grantedAuthorizationDetails(request), amr, authTime, sessionOf(request), &policy, binding.refreshToken)
 		if err != nil {
 			return "", "", time.Time{}, err
 		}
+		s.recordConsentOfRequest(ctx, request)
 		return accessToken.ID.String(), refreshToken, accessToken.Expiration, nil
 	}
 
 	// if we get here, the currentRefreshToken was not empty, so the call is a refresh token request
 	// we therefore will have to check the currentRefreshToken and renew the refresh token
-	refreshToken, refreshTokenID, err := s.renewRefreshToken(currentRefreshToken)
+	refreshToken, refreshTokenID, err := s.renewRefreshToken(currentRefreshToken, &policy)
 	if err != nil {
 		return "", "", time.Time{}, err
 	}
-	accessToken, err := s.accessToken(applicationID, refreshTokenID, request.GetSubject(), request.GetAudience(), request.GetScopes())
+	accessToken, err := s.accessToken(applicationID, refreshTokenID, request.GetSubject(), request.GetAudience(), request.GetScopes(), policy.AccessTokenLifetime, binding, details)
 	if err != nil {
 		return "", "", time.Time{}, err
 	}
@@ -366,14 +521,22 @@ func (s *Storage) CreateAccessAndRefreshTokens(ctx context.Context, request op.T
 func (s *Storage) exchangeRefreshToken(ctx context.Context, request op.TokenExchangeRequest) (accessTokenID string, newRefreshToken string, expiration time.Time, err error) {
 	applicationID := request.GetClientID()
 	authTime := request.GetAuthTime()
+	policy := s.clientTokenPolicy(ctx, applicationID)
+	if !policy.AllowsRefreshToken(request.GetScopes()) {
+		return "", "", time.Time{}, oidc.ErrInvalidRequest().WithDescription("the client does not get refresh tokens")
+	}
+	binding, err := s.bindTokens(ctx, applicationID)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
 
 	refreshTokenID := uuid.NewString()
-	accessToken, err := s.accessToken(applicationID, refreshTokenID, request.GetSubject(), request.GetAudience(), request.GetScopes())
+	accessToken, err := s.accessToken(applicationID, refreshTokenID, request.GetSubject(), request.GetAudience(), request.GetScopes(), policy.AccessTokenLifetime, binding, nil)
 	if err != nil {
 		return "", "", time.Time{}, err
 	}
 
-	refreshToken, err := s.createRefreshToken(accessToken, nil, authTime)
+	refreshToken, err := s.createRefreshToken(accessToken, request.GetAudience(), nil, nil, authTime, "", &policy, binding.refreshToken)
 	if err != nil {
 		return "", "", time.Time{}, err
 	}
@@ -384,13 +547,41 @@ func (s *Storage) exchangeRefreshToken(ctx context.Context, request op.TokenExch
 // TokenRequestByRefreshToken implements the op.Storage interface
 // it will be called after parsing and validation of the refresh token request
 func (s *Storage) TokenRequestByRefreshToken(ctx context.Context, refreshToken string) (op.RefreshTokenRequest, error) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-	token, ok := s.refreshTokens[refreshToken]
-	if !ok {
-		return nil, fmt.Errorf("invalid refresh_token")
+	token, err := s.activeRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	// refresh tokens of public clients are bound to the key of their first DPoP proof
+	if err := checkBinding(ctx, token.JKT, ""); err != nil {
+		return nil, err
 	}
-	return RefreshTokenRequestFromBusiness(token), nil
+	// the client may have lost its refresh tokens since the token was issued
+	policy := s.clientTokenPolicy(ctx, token.ApplicationID.String())
+	if !policy.AllowsRefreshToken(token.Scopes) {
+		return nil, op.ErrInvalidRefreshToken
+	}
+	narrowed, err := narrowResources(ctx, token.Audience)
+	if err != nil {
+		return nil, err
+	}
+	narrowedDetails, err := narrowAuthorizationDetails(ctx, token.AuthorizationDetails)
+	if err != nil {
+		return nil, err
+	}
+	return &RefreshTokenRequest{RefreshToken: token, narrowed: narrowed, narrowedDetails: narrowedDetails}, nil
+}
+
+// activeRefreshToken returns the refresh token if it did not expire
+func (s *Storage) activeRefreshToken(ctx context.Context, refreshToken string) (*RefreshToken, error) {
+	id, err := uuid.Parse(refreshToken)
+	if err != nil {
+		return nil, op.ErrInvalidRefreshToken
+	}
+	token, err := s.QueryRefreshToken(ctx, id)
+	if err != nil || time.Now().After(token.Expiration) {
+		return nil, op.ErrInvalidRefreshToken
+	}
+	return &token, nil
 }
 
 // TerminateSession implements the op.Storage interface
@@ -436,9 +627,9 @@ func (s *Storage) TerminateSession(ctx context.Context, userID string, clientID
 // GetRefreshTokenInfo looks up a refresh token and returns the token id and user id.
 // If given something that is not a refresh token, it must return error.
 func (s *Storage) GetRefreshTokenInfo(ctx context.Context, clientID string, token string) (userID string, tokenID string, err error) {
-	refreshToken, ok := s.refreshTokens[token]
-	if !ok {
-		return "", "", op.ErrInvalidRefreshToken
+	refreshToken, err := s.activeRefreshToken(ctx, token)
+	if err != nil {
+		return "", "", err
 	}
 	return refreshToken.UserID.String(), refreshToken.ID.String(), nil
 }
@@ -520,11 +711,14 @@ func (s *Storage) GetClientByClientID(ctx context.Context, clientID string) (op.
 	if err != nil {
 		return nil, err
 	}
+	// the access token format may depend on the resources of the token request
+	client.resourceRequest = resourceRequestOf(ctx)
 	return RedirectGlobsClient(client), nil
 }
 
 // AuthorizeClientIDSecret implements the op.Storage interface
-// it will be called for validating the client_id, client_secret on token or introspection requests
+// it will be called for validating the client_id, client_secret on token or introspection requests,
+// clients of the mutual TLS methods authenticate with the certificate of the request instead
 func (s *Storage) AuthorizeClientIDSecret(ctx context.Context, clientID, clientSecret string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -533,12 +727,7 @@ func (s *Storage) AuthorizeClientIDSecret(ctx context.Context, clientID, clientS
 	if err != nil {
 		return err
 	}
-	// for this example we directly check the secret
-	// obviously you would not have the secret in plain text, but rather hashed and salted (e.g. using bcrypt)
-	if client.secret != clientSecret {
-		return fmt.Errorf("invalid secret")
-	}
-	return nil
+	return client.authenticate(ctx, clientSecret)
 }
 
 // SetUserinfoFromScopes implements the op.Storage interface.
@@ -551,7 +740,18 @@ func (s *Storage) SetUserinfoFromScopes(ctx context.Context, userinfo *oidc.User
 // next major release, it will be required for op.Storage.
 // It will be called for the creation of an id_token, so we'll just pass it to the private function without any further check
 func (s *Storage) SetUserinfoFromRequest(ctx context.Context, userinfo *oidc.UserInfo, token op.IDTokenRequest, scopes []string) error {
-	return s.setUserinfo(ctx, userinfo, token.GetSubject(), token.GetClientID(), scopes)
+	err := s.setUserinfo(ctx, userinfo, token.GetSubject(), token.GetClientID(), scopes)
+	if err != nil {
+		return err
+	}
+	if req, ok := token.(*BackchannelAuthRequest); ok {
+		return s.setBackchannelClaims(ctx, userinfo, req)
+	}
+	// the id tokens of browser logins name the session, logout requests and tokens refer to it
+	if sid := sessionOf(token); sid != "" {
+		userinfo.AppendClaims(ClaimSessionID, sid)
+	}
+	return nil
 }
 
 // SetUserinfoFromToken implements the op.Storage interface
@@ -565,6 +765,10 @@ func (s *Storage) SetUserinfoFromToken(ctx context.Context, userinfo *oidc.UserI
 	if err != nil {
 		return fmt.Errorf("token is invalid or has expired")
 	}
+	// bound tokens are only accepted with a DPoP proof of their key or with their certificate
+	if err := checkBinding(ctx, token.JKT, token.X5TS256); err != nil {
+		return err
+	}
 	// the userinfo endpoint should support CORS. If it's not possible to specify a specific origin in the CORS handler,
 	// and you have to specify a wildcard (*) origin, then you could also check here if the origin which called the userinfo endpoint here directly
 	// note that the origin can be empty (if called by a web client)
@@ -593,8 +797,9 @@ func (s *Storage) SetIntrospectionFromToken(ctx context.Context, introspection *
 	if err != nil {
 		return fmt.Errorf("token is invalid or has expired")
 	}
-	// check if the client is part of the requested audience
-	for _, aud := range token.Audience {
+	// check if the client is part of the requested audience, or the client the
+	// token was issued to, tokens narrowed to resources don't list it
+	for _, aud := range append(token.Audience, token.ApplicationID.String()) {
 		if aud == clientID {
 			// the introspection response only has to return a boolean (active) if the token is active
 			// this will automatically be done by the library if you don't return an error
@@ -611,6 +816,17 @@ func (s *Storage) SetIntrospectionFromToken(ctx context.Context, introspection *
 			introspection.Scope = token.Scopes
 			//...and the client the token was issued to
 			introspection.ClientID = token.ApplicationID.String()
+			//...and the key or certificate of bound tokens
+			if token.JKT != "" {
+				introspection.TokenType = dpop.TokenType
+			}
+			if cnf := confirmation(token.JKT, token.X5TS256); cnf != nil {
+				introspection.Claims = appendClaim(introspection.Claims, "cnf", cnf)
+			}
+			//...and what the token authorizes beyond the scopes (RFC 9396)
+			if len(token.AuthorizationDetails) > 0 {
+				introspection.Claims = appendClaim(introspection.Claims, ClaimAuthorizationDetails, token.AuthorizationDetails)
+			}
 			return nil
 		}
 	}
@@ -630,6 +846,18 @@ func (s *Storage) getPrivateClaimsFromScopes(ctx context.Context, userID, client
 			claims = appendClaim(claims, CustomClaim, customClaim(clientID))
 		}
 	}
+	// bound JWT access tokens carry the thumbprint of the DPoP key or the certificate
+	binding, err := s.bindTokens(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if cnf := confirmation(binding.jkt, binding.x5t); cnf != nil {
+		claims = appendClaim(claims, "cnf", cnf)
+	}
+	// and the authorization details the token was issued for
+	if details := IssuedAuthorizationDetails(ctx); len(details) > 0 {
+		claims = appendClaim(claims, ClaimAuthorizationDetails, details)
+	}
 	return claims, nil
 }
 
@@ -670,10 +898,14 @@ func (s *Storage) Health(ctx context.Context) error {
 	return nil
 }
 
-// createRefreshToken will store a refresh_token in-memory based on the provided information
-func (s *Storage) createRefreshToken(accessToken *Token, amr []string, authTime time.Time) (string, error) {
+// createRefreshToken will store a refresh_token based on the provided information,
+// it expires by the lifetimes of the client's policy and keeps the audience and the
+// authorization details and the session of the grant, bound refresh tokens take the DPoP key of the access token
+func (s *Storage) createRefreshToken(accessToken *Token, audience []string, details AuthorizationDetails, amr []string, authTime time.Time, sessionID string, policy *TokenPolicy, bound bool) (string, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
+	now := time.Now()
+	absolute := now.Add(policy.RefreshTokenLifetime)
 	token := &RefreshToken{
 		ID:            accessToken.RefreshTokenID,
 		Token:         accessToken.RefreshTokenID.String(),
@@ -681,16 +913,24 @@ func (s *Storage) createRefreshToken(accessToken *Token, amr []string, authTime
 		AMR:           amr,
 		ApplicationID: accessToken.ApplicationID,
 		UserID:        accessToken.Subject,
-		Audience:      accessToken.Audience,
-		Expiration:    time.Now().Add(5 * time.Hour),
+		Audience:      audience,
+		Expiration:    policy.RefreshTokenExpiration(now, absolute),
 		Scopes:        accessToken.Scopes,
+
+		AbsoluteExpiration:   absolute,
+		AuthorizationDetails: details,
+		SessionID:            sessionID,
+	}
+	if bound {
+		token.JKT = accessToken.JKT
 	}
 	s.StoreRefreshToken(context.TODO(), token)
 	return token.Token, nil
 }
 
 // renewRefreshToken checks the provided refresh_token and creates a new one based on the current
-func (s *Storage) renewRefreshToken(currentRefreshToken string) (string, string, error) {
+// the renewed token keeps the absolute expiration, its idle expiration starts over
+func (s *Storage) renewRefreshToken(currentRefreshToken string, policy *TokenPolicy) (string, string, error) {
 	curtokid, err := uuid.Parse(currentRefreshToken)
 	if err != nil {
 		return "", "", fmt.Errorf("invalid refresh token")
@@ -707,13 +947,15 @@ func (s *Storage) renewRefreshToken(currentRefreshToken string) (string, string,
 	token := uuid.New()
 	refreshToken.Token = token.String()
 	refreshToken.ID = token
+	refreshToken.Expiration = policy.RefreshTokenExpiration(time.Now(), refreshToken.AbsoluteExpiration)
 
 	s.StoreRefreshToken(context.TODO(), &refreshToken)
 	return token.String(), refreshToken.ID.String(), nil
 }
 
-// accessToken will store an access_token in-memory based on the provided information
-func (s *Storage) accessToken(applicationID, refreshTokenID, subject string, audience, scopes []string) (*Token, error) {
+// accessToken will store an access_token in-memory based on the provided information,
+// the token is bound to the DPoP key and the certificate of the binding
+func (s *Storage) accessToken(applicationID, refreshTokenID, subject string, audience, scopes []string, lifetime time.Duration, binding tokenBinding, details AuthorizationDetails) (*Token, error) {
 	apid, _ := uuid.Parse(applicationID)
 	refid, _ := uuid.Parse(refreshTokenID)
 	sub, _ := uuid.Parse(subject)
@@ -724,8 +966,12 @@ func (s *Storage) accessToken(applicationID, refreshTokenID, subject string, aud
 		RefreshTokenID: refid,
 		Subject:        sub,
 		Audience:       audience,
-		Expiration:     time.Now().Add(5 * time.Minute),
+		Expiration:     time.Now().Add(lifetime),
 		Scopes:         scopes,
+		JKT:            binding.jkt,
+		X5TS256:        binding.x5t,
+
+		AuthorizationDetails: details,
 	}
 	s.SaveToken(context.Background(), token)
 
@@ -763,6 +1009,12 @@ func (s *Storage) setUserinfo(ctx context.Context, userInfo *oidc.UserInfo, user
 		case oidc.ScopePhone:
 			userInfo.PhoneNumber = user.Phone
 			userInfo.PhoneNumberVerified = user.PhoneVerified
+		case ScopeGroups:
+			groups, err := s.UserGroupNames(ctx, user)
+			if err != nil {
+				return err
+			}
+			userInfo.AppendClaims(ClaimGroups, groups)
 		case CustomScope:
 			// you can also have a custom scope and assert public or custom claims based on that
 			userInfo.AppendClaims(CustomClaim, customClaim(clientID))
@@ -797,8 +1049,16 @@ func (s *Storage) ValidateTokenExchangeRequest(ctx context.Context, request op.T
 		return errors.New("user doesn't have impersonation permission")
 	}
 
+	client, err := s.GetClient(ctx, request.GetClientID())
+	if err != nil {
+		return err
+	}
+	scopes, err := client.CheckScopes(request.GetScopes())
+	if err != nil {
+		return err
+	}
 	allowedScopes := make([]string, 0)
-	for _, scope := range request.GetScopes() {
+	for _, scope := range scopes {
 		if scope == oidc.ScopeAddress {
 			continue
 		}
@@ -885,9 +1145,21 @@ func getInfoFromRequest(req op.TokenRequest) (clientID string, authTime time.Tim
 	if ok {
 		return refreshReq.ApplicationID.String(), refreshReq.AuthTime, refreshReq.AMR
 	}
+	backchannelReq, ok := req.(*BackchannelAuthRequest) // CIBA
+	if ok {
+		return backchannelReq.GetClientID(), backchannelReq.AuthTime, backchannelReq.AMR
+	}
 	return "", time.Time{}, nil
 }
 
+// grantedAudience is the audience the refresh token of the request keeps
+func grantedAudience(req op.TokenRequest) []string {
+	if authReq, ok := req.(*AuthRequest); ok {
+		return authReq.grantedAudience()
+	}
+	return req.GetAudience()
+}
+
 // customClaim demonstrates how to return custom claims based on provided information
 func customClaim(clientID string) map[string]interface{} {
 	return map[string]interface{}{
@@ -1025,7 +1297,15 @@ func (s *Storage) ClientCredentials(ctx context.Context, clientID, clientSecret
 
 	client, ok := s.serviceUsers[clientID]
 	if !ok {
-		return nil, errors.New("wrong service user or password")
+		// registered clients with the grant, like machine clients with certificates
+		client, err := s.GetClient(ctx, clientID)
+		if err != nil {
+			return nil, errors.New("wrong service user or password")
+		}
+		if err := client.authenticate(ctx, clientSecret); err != nil {
+			return nil, err
+		}
+		return client, nil
 	}
 	if client.secret != clientSecret {
 		return nil, errors.New("wrong service user or password")
@@ -1037,7 +1317,23 @@ func (s *Storage) ClientCredentials(ctx context.Context, clientID, clientSecret
 func (s *Storage) ClientCredentialsTokenRequest(ctx context.Context, clientID string, scopes []string) (op.TokenRequest, error) {
 	client, ok := s.serviceUsers[clientID]
 	if !ok {
-		return nil, errors.New("wrong service user or password")
+		c, err := s.GetClient(ctx, clientID)
+		if err != nil {
+			return nil, errors.New("wrong service user or password")
+		}
+		// there is no user to consent, the client gets the details it requests
+		details, err := s.requestedAuthorizationDetails(ctx, c.userNamespaceID)
+		if err != nil {
+			return nil, err
+		}
+		return &clientCredentialsRequest{
+			JWTTokenRequest: &oidc.JWTTokenRequest{
+				Subject:  clientID,
+				Audience: []string{clientID},
+				Scopes:   scopes,
+			},
+			details: details,
+		}, nil
 	}
 
 	return &oidc.JWTTokenRequest{
@@ -1046,3 +1342,18 @@ func (s *Storage) ClientCredentialsTokenRequest(ctx context.Context, clientID st
 		Scopes:   scopes,
 	}, nil
 }
+
+// clientCredentialsRequest is the client_credentials token request of a
+// registered client, the client is the subject
+type clientCredentialsRequest struct {
+	*oidc.JWTTokenRequest
+	details AuthorizationDetails
+}
+
+func (r *clientCredentialsRequest) GetClientID() string {
+	return r.Subject
+}
+
+func (r *clientCredentialsRequest) GetAuthorizationDetails() AuthorizationDetails {
+	return r.details
+}
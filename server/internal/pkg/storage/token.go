@@ -20,6 +20,12 @@ type Token struct {
 	Audience       []string
 	Expiration     time.Time
 	Scopes         []string
+	// JKT is the thumbprint of the DPoP key the token is bound to, empty for bearer tokens
+	JKT string
+	// X5TS256 is the thumbprint of the client certificate the token is bound to, empty if not bound
+	X5TS256 string
+	// AuthorizationDetails are the authorization details the token was issued for
+	AuthorizationDetails AuthorizationDetails
 }
 
 type RefreshToken struct {
@@ -32,6 +38,15 @@ type RefreshToken struct {
 	ApplicationID uuid.UUID
 	Expiration    time.Time
 	Scopes        []string
+	// AbsoluteExpiration is the expiration of the first token of the chain,
+	// Expiration is earlier if the client has an idle lifetime
+	AbsoluteExpiration time.Time
+	// JKT is the thumbprint of the DPoP key the token is bound to, empty if not bound
+	JKT string
+	// AuthorizationDetails are the authorization details of the grant
+	AuthorizationDetails AuthorizationDetails
+	// SessionID is the user_session of the grant, the sid of its id tokens, empty for none
+	SessionID string
 }
 
 func (s *Storage) SaveToken(ctx context.Context, token *Token) error {
@@ -44,6 +59,9 @@ func (s *Storage) SaveToken(ctx context.Context, token *Token) error {
 		tb.Audience,
 		tb.Expiration,
 		tb.Scopes,
+		tb.Jkt,
+		tb.X5tS256,
+		tb.AuthorizationDetails,
 	).VALUES(
 		token.ID,
 		token.ApplicationID,
@@ -52,6 +70,9 @@ func (s *Storage) SaveToken(ctx context.Context, token *Token) error {
 		pq.Array(token.Audience),
 		token.Expiration,
 		pq.Array(token.Scopes),
+		token.JKT,
+		token.X5TS256,
+		token.AuthorizationDetails.String(),
 	)
 	cmd, args := stmt.Sql()
 	_, err := s.db.ExecContext(ctx, cmd, args...)
@@ -74,6 +95,10 @@ func (s *Storage) StoreRefreshToken(ctx context.Context, reftok *RefreshToken) e
 		tb.ApplicationID,
 		tb.Expiration,
 		tb.Scopes,
+		tb.AbsoluteExpiration,
+		tb.Jkt,
+		tb.AuthorizationDetails,
+		tb.SessionID,
 	).VALUES(
 		reftok.ID,
 		reftok.Token,
@@ -84,6 +109,10 @@ func (s *Storage) StoreRefreshToken(ctx context.Context, reftok *RefreshToken) e
 		reftok.ApplicationID,
 		reftok.Expiration,
 		pq.Array(reftok.Scopes),
+		reftok.AbsoluteExpiration,
+		reftok.JKT,
+		reftok.AuthorizationDetails.String(),
+		reftok.SessionID,
 	)
 	cmd, args := stmt.Sql()
 	_, err := s.db.ExecContext(ctx, cmd, args...)
@@ -103,11 +132,17 @@ func (s *Storage) QueryToken(ctx context.Context, id uuid.UUID) (Token, error) {
 			refresh_token_id,
 			audience,
 			expiration,
-			scopes
+			scopes,
+			jkt,
+			x5t_s256,
+			authorization_details
 		FROM token
 		WHERE id = $1
 	`
-	var token Token
+	var (
+		token   Token
+		details string
+	)
 	err := s.db.QueryRowContext(ctx, cmd, id).Scan(
 		&token.ID,
 		&token.ApplicationID,
@@ -116,11 +151,19 @@ func (s *Storage) QueryToken(ctx context.Context, id uuid.UUID) (Token, error) {
 		pq.Array(&token.Audience),
 		&token.Expiration,
 		pq.Array(&token.Scopes),
+		&token.JKT,
+		&token.X5TS256,
+		&details,
 	)
 	if err != nil {
 		logrus.Error(err)
 		return Token{}, err
 	}
+	token.AuthorizationDetails, err = ParseAuthorizationDetails(details)
+	if err != nil {
+		logrus.Error(err)
+		return Token{}, err
+	}
 	return token, nil
 }
 
@@ -200,11 +243,18 @@ func (s *Storage) QueryRefreshToken(ctx context.Context, id uuid.UUID) (RefreshT
 			user_id,
 			application_id,
 			expiration,
-			scopes
+			scopes,
+			absolute_expiration,
+			jkt,
+			authorization_details,
+			session_id
 		FROM refresh_token
 		WHERE id = $1
 	`
-	var token RefreshToken
+	var (
+		token   RefreshToken
+		details string
+	)
 	err := s.db.QueryRowContext(ctx, cmd, id).Scan(
 		&token.ID,
 		&token.Token,
@@ -215,11 +265,20 @@ func (s *Storage) QueryRefreshToken(ctx context.Context, id uuid.UUID) (RefreshT
 		&token.ApplicationID,
 		&token.Expiration,
 		pq.Array(&token.Scopes),
+		&token.AbsoluteExpiration,
+		&token.JKT,
+		&details,
+		&token.SessionID,
 	)
 	if err != nil {
 		logrus.Error(err)
 		return RefreshToken{}, err
 	}
+	token.AuthorizationDetails, err = ParseAuthorizationDetails(details)
+	if err != nil {
+		logrus.Error(err)
+		return RefreshToken{}, err
+	}
 	return token, nil
 }
 
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sanyokbig/pqinterval"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+// whether clients get refresh tokens
+const (
+	RefreshTokensEnabled  = "enabled"
+	RefreshTokensDisabled = "disabled"
+	// RefreshTokensOfflineAccess issues refresh tokens only to requests of the offline_access scope
+	RefreshTokensOfflineAccess = "offline_access"
+)
+
+// TokenPolicy is the lifetimes of the tokens of a namespace's clients. The
+// policy of a client only has the settings it overrides, zero values inherit.
+type TokenPolicy struct {
+	AccessTokenLifetime  time.Duration
+	IDTokenLifetime      time.Duration
+	RefreshTokenLifetime time.Duration
+	// RefreshTokenIdleLifetime expires refresh tokens unused for so long, 0 never
+	RefreshTokenIdleLifetime time.Duration
+	RefreshTokens            string
+}
+
+// DefaultTokenPolicy applies to namespaces without a policy
+var DefaultTokenPolicy = TokenPolicy{
+	AccessTokenLifetime:  5 * time.Minute,
+	IDTokenLifetime:      1 * time.Hour,
+	RefreshTokenLifetime: 5 * time.Hour,
+	RefreshTokens:        RefreshTokensEnabled,
+}
+
+func (p *TokenPolicy) Validate() error {
+	if p.AccessTokenLifetime < 0 || p.IDTokenLifetime < 0 || p.RefreshTokenLifetime < 0 || p.RefreshTokenIdleLifetime < 0 {
+		return errors.New("token lifetimes must not be negative")
+	}
+	switch p.RefreshTokens {
+	case "", RefreshTokensEnabled, RefreshTokensDisabled, RefreshTokensOfflineAccess:
+		return nil
+	}
+	return fmt.Errorf("refresh_tokens must be %s, %s or %s", RefreshTokensEnabled, RefreshTokensDisabled, RefreshTokensOfflineAccess)
+}
+
+// Override returns the policy with the settings the client policy c overrides
+func (p TokenPolicy) Override(c TokenPolicy) TokenPolicy {
+	if c.AccessTokenLifetime != 0 {
+		p.AccessTokenLifetime = c.AccessTokenLifetime
+	}
+	if c.IDTokenLifetime != 0 {
+		p.IDTokenLifetime = c.IDTokenLifetime
+	}
+	if c.RefreshTokenLifetime != 0 {
+		p.RefreshTokenLifetime = c.RefreshTokenLifetime
+	}
+	if c.RefreshTokenIdleLifetime != 0 {
+		p.RefreshTokenIdleLifetime = c.RefreshTokenIdleLifetime
+	}
+	if c.RefreshTokens != "" {
+		p.RefreshTokens = c.RefreshTokens
+	}
+	return p
+}
+
+// AllowsRefreshToken tells if requests of the scopes get refresh tokens
+func (p *TokenPolicy) AllowsRefreshToken(scopes []string) bool {
+	switch p.RefreshTokens {
+	case RefreshTokensDisabled:
+		return false
+	case RefreshTokensOfflineAccess:
+		for _, s := range scopes {
+			if s == oidc.ScopeOfflineAccess {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// RefreshTokenExpiration is when a refresh token used at now expires, absolute
+// is the expiration of the first token of the chain
+func (p *TokenPolicy) RefreshTokenExpiration(now, absolute time.Time) time.Time {
+	if p.RefreshTokenIdleLifetime == 0 {
+		return absolute
+	}
+	if idle := now.Add(p.RefreshTokenIdleLifetime); idle.Before(absolute) {
+		return idle
+	}
+	return absolute
+}
+
+func durations(intervals []pqinterval.Interval, ds ...*time.Duration) error {
+	for i, d := range ds {
+		v, err := intervals[i].Duration()
+		if err != nil {
+			return err
+		}
+		*d = v
+	}
+	return nil
+}
+
+// GetTokenPolicy returns the token policy of a namespace, or the default policy
+// if the namespace did not configure one
+func (s *Storage) GetTokenPolicy(ctx context.Context, namespace uuid.UUID) (*TokenPolicy, error) {
+	cmd := `
+	SELECT
+		access_token_lifetime,
+		id_token_lifetime,
+		refresh_token_lifetime,
+		refresh_token_idle_lifetime,
+		refresh_tokens
+	FROM
+		token_policy
+	WHERE
+		namespace_id = $1
+	`
+	var (
+		p         TokenPolicy
+		intervals = make([]pqinterval.Interval, 4)
+	)
+	err := s.db.QueryRowContext(ctx, cmd, namespace).Scan(
+		&intervals[0],
+		&intervals[1],
+		&intervals[2],
+		&intervals[3],
+		&p.RefreshTokens,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		p = DefaultTokenPolicy
+		return &p, nil
+	}
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	err = durations(intervals, &p.AccessTokenLifetime, &p.IDTokenLifetime, &p.RefreshTokenLifetime, &p.RefreshTokenIdleLifetime)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetTokenPolicy sets the token policy of a namespace, zero values are the defaults
+func (s *Storage) SetTokenPolicy(ctx context.Context, namespace uuid.UUID, p *TokenPolicy) error {
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+	v := DefaultTokenPolicy.Override(*p)
+	cmd := `
+	INSERT INTO token_policy (
+		namespace_id,
+		access_token_lifetime,
+		id_token_lifetime,
+		refresh_token_lifetime,
+		refresh_token_idle_lifetime,
+		refresh_tokens
+	) VALUES (
+		$1,
+		$2 * interval '1 microsecond',
+		$3 * interval '1 microsecond',
+		$4 * interval '1 microsecond',
+		$5 * interval '1 microsecond',
+		$6
+	) ON CONFLICT (namespace_id) DO UPDATE SET
+		access_token_lifetime=EXCLUDED.access_token_lifetime,
+		id_token_lifetime=EXCLUDED.id_token_lifetime,
+		refresh_token_lifetime=EXCLUDED.refresh_token_lifetime,
+		refresh_token_idle_lifetime=EXCLUDED.refresh_token_idle_lifetime,
+		refresh_tokens=EXCLUDED.refresh_tokens
+	`
+	_, err = s.db.ExecContext(ctx, cmd,
+		namespace,
+		v.AccessTokenLifetime.Microseconds(),
+		v.IDTokenLifetime.Microseconds(),
+		v.RefreshTokenLifetime.Microseconds(),
+		v.RefreshTokenIdleLifetime.Microseconds(),
+		v.RefreshTokens,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// clientTokenPolicy returns the effective token policy of a client, the
+// default policy for clients which are not in the database like service users
+func (s *Storage) clientTokenPolicy(ctx context.Context, clientID string) TokenPolicy {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return DefaultTokenPolicy
+	}
+	client, err := s.GetClientByUUID(ctx, id)
+	if err != nil {
+		return DefaultTokenPolicy
+	}
+	return client.tokens
+}
@@ -0,0 +1,715 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sanyokbig/pqinterval"
+	"github.com/sirupsen/logrus"
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"github.com/zltl/xoidc/server/internal/pkg/transfer"
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+var userStatuses = map[string]bool{
+	UserStatusActive:          true,
+	UserStatusPendingApproval: true,
+	UserStatusDisabled:        true,
+}
+
+// ImportUsers upserts the users of a CSV or JSON Lines file into a namespace, keyed on the username.
+// A row that fails is reported and skipped, the other rows are imported.
+// A dry run reports the same and rolls everything back.
+func (s *Storage) ImportUsers(ctx context.Context, namespace uuid.UUID, r io.Reader, format string, dryRun bool) (*transfer.Result, error) {
+	policy, err := s.GetPasswordPolicy(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	result := &transfer.Result{DryRun: dryRun}
+	err = transfer.ReadUsers(r, format, func(line int, u *transfer.User, err error) error {
+		if err != nil {
+			result.Add(line, "", "", err)
+			return nil
+		}
+		// a failed row must not abort the transaction of the others
+		_, err = tx.ExecContext(ctx, `SAVEPOINT import_row`)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		action, err := s.importUser(ctx, tx, policy, namespace, u)
+		if err != nil {
+			_, rerr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`)
+			if rerr != nil {
+				logrus.Error(rerr)
+				return rerr
+			}
+		} else {
+			_, rerr := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`)
+			if rerr != nil {
+				logrus.Error(rerr)
+				return rerr
+			}
+		}
+		result.Add(line, u.Username, action, err)
+		return nil
+	})
+	if err != nil || dryRun {
+		_ = tx.Rollback()
+		return result, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Storage) importUser(ctx context.Context, tx *sql.Tx, policy *password.Policy, namespace uuid.UUID, u *transfer.User) (string, error) {
+	err := u.Validate()
+	if err != nil {
+		return "", err
+	}
+	if u.Status != "" && !userStatuses[u.Status] {
+		return "", fmt.Errorf("unknown status %q", u.Status)
+	}
+	hash := u.PasswordHash
+	if u.Password != "" {
+		// the history of an existing user applies to the new password
+		var userID uuid.UUID
+		err = tx.QueryRowContext(ctx, `SELECT id FROM "user" WHERE namespace_id = $1 AND username = $2`,
+			namespace, u.Username).Scan(&userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logrus.Error(err)
+			return "", err
+		}
+		err = s.validatePassword(ctx, policy, userID, u.Password)
+		if err != nil {
+			return "", err
+		}
+		hash, err = password.CreateHash(u.Password)
+		if err != nil {
+			logrus.Error(err)
+			return "", err
+		}
+	}
+
+	// an empty password or status keeps the one of an existing user,
+	// the passwords of directory users are checked by the directory
+	cmd := `
+	INSERT INTO "user" (
+		namespace_id,
+		username,
+		password,
+		given_name,
+		family_name,
+		middle_name,
+		nickname,
+		email,
+		email_verified,
+		phone_number,
+		phone_number_verified,
+		locale,
+		zoneinfo,
+		status,
+		external_id
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, COALESCE(NULLIF($14, ''), 'active'), $15
+	) ON CONFLICT (namespace_id, username) DO UPDATE
+	SET password = CASE WHEN EXCLUDED.password = '' THEN "user".password ELSE EXCLUDED.password END,
+		password_change_time = CASE WHEN EXCLUDED.password IN ('', "user".password) THEN "user".password_change_time ELSE now() END,
+		given_name = EXCLUDED.given_name,
+		family_name = EXCLUDED.family_name,
+		middle_name = EXCLUDED.middle_name,
+		nickname = EXCLUDED.nickname,
+		email = EXCLUDED.email,
+		email_verified = EXCLUDED.email_verified,
+		phone_number = EXCLUDED.phone_number,
+		phone_number_verified = EXCLUDED.phone_number_verified,
+		locale = EXCLUDED.locale,
+		zoneinfo = EXCLUDED.zoneinfo,
+		status = CASE WHEN $14 = '' THEN "user".status ELSE EXCLUDED.status END,
+		external_id = EXCLUDED.external_id,
+		updated_at = now()
+	WHERE "user".directory_id IS NULL OR EXCLUDED.password = ''
+	RETURNING id, xmax = 0
+	`
+	var (
+		id       uuid.UUID
+		inserted bool
+	)
+	err = tx.QueryRowContext(ctx, cmd,
+		namespace,
+		u.Username,
+		hash,
+		u.GivenName,
+		u.FamilyName,
+		u.MiddleName,
+		u.Nickname,
+		u.Email,
+		u.EmailVerified,
+		u.PhoneNumber,
+		u.PhoneNumberVerified,
+		u.Locale,
+		u.Zoneinfo,
+		u.Status,
+		u.ExternalID,
+	).Scan(&id, &inserted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrDirectoryUser
+	}
+	if err != nil {
+		logrus.Error(err)
+		return "", err
+	}
+
+	if u.Groups != nil {
+		err = setUserGroups(ctx, tx, namespace, id, u.Groups)
+		if err != nil {
+			return "", err
+		}
+	}
+	if inserted {
+		return transfer.ActionCreate, nil
+	}
+	return transfer.ActionUpdate, nil
+}
+
+// setUserGroups replaces the memberships of a user, missing groups are created
+func setUserGroups(ctx context.Context, tx *sql.Tx, namespace, userID uuid.UUID, groups []string) error {
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO user_group (
+		namespace_id,
+		display_name
+	)
+	SELECT
+		$1, unnest($2::text[])
+	ON CONFLICT (namespace_id, display_name) DO NOTHING
+	`, namespace, pq.Array(groups))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM user_group_member
+	WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO user_group_member (
+		group_id,
+		user_id
+	)
+	SELECT
+		id, $1
+	FROM
+		user_group
+	WHERE
+		namespace_id = $2
+	AND display_name = ANY($3::text[])
+	`, userID, namespace, pq.Array(groups))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// ExportUsers writes the users of a namespace, their password hashes only if asked for
+func (s *Storage) ExportUsers(ctx context.Context, namespace uuid.UUID, w transfer.UserWriter, withPasswordHashes bool) error {
+	cmd := `
+	SELECT
+		username,
+		password,
+		given_name,
+		family_name,
+		middle_name,
+		nickname,
+		email,
+		email_verified,
+		phone_number,
+		phone_number_verified,
+		locale,
+		zoneinfo,
+		status,
+		external_id,
+		ARRAY(
+			SELECT
+				user_group.display_name
+			FROM
+				user_group_member
+			JOIN user_group ON user_group.id = user_group_member.group_id
+			WHERE
+				user_group_member.user_id = "user".id
+			ORDER BY user_group.display_name
+		)
+	FROM
+		"user"
+	WHERE
+		namespace_id = $1
+	ORDER BY username
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		u := &transfer.User{}
+		err := rows.Scan(
+			&u.Username,
+			&u.PasswordHash,
+			&u.GivenName,
+			&u.FamilyName,
+			&u.MiddleName,
+			&u.Nickname,
+			&u.Email,
+			&u.EmailVerified,
+			&u.PhoneNumber,
+			&u.PhoneNumberVerified,
+			&u.Locale,
+			&u.Zoneinfo,
+			&u.Status,
+			&u.ExternalID,
+			pq.Array(&u.Groups),
+		)
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+		if !withPasswordHashes {
+			u.PasswordHash = ""
+		}
+		err = w.Write(u)
+		if err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return w.Flush()
+}
+
+// ImportClients upserts clients, keyed on their id, or on their name in the user namespace.
+// A dry run reports the same and rolls everything back.
+func (s *Storage) ImportClients(ctx context.Context, clients []*clientmeta.Client, dryRun bool) (*transfer.Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	result := &transfer.Result{DryRun: dryRun}
+	for i, c := range clients {
+		_, err = tx.ExecContext(ctx, `SAVEPOINT import_row`)
+		if err != nil {
+			logrus.Error(err)
+			_ = tx.Rollback()
+			return nil, err
+		}
+		action, err := importClient(ctx, tx, c)
+		savepoint := `RELEASE SAVEPOINT import_row`
+		if err != nil {
+			savepoint = `ROLLBACK TO SAVEPOINT import_row`
+		}
+		_, rerr := tx.ExecContext(ctx, savepoint)
+		if rerr != nil {
+			logrus.Error(rerr)
+			_ = tx.Rollback()
+			return nil, rerr
+		}
+		result.Add(i+1, c.Key(), action, err)
+	}
+	if dryRun {
+		_ = tx.Rollback()
+		return result, nil
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func importClient(ctx context.Context, tx *sql.Tx, c *clientmeta.Client) (string, error) {
+	err := c.Validate()
+	if err != nil {
+		return "", err
+	}
+	namespace := uuid.MustParse(c.UserNamespaceID)
+
+	var id uuid.UUID
+	if c.ID != "" {
+		id = uuid.MustParse(c.ID)
+		err = tx.QueryRowContext(ctx, `SELECT id FROM client WHERE id = $1`, id).Scan(&id)
+	} else {
+		id, err = clientByName(ctx, tx, namespace, c.Name)
+	}
+	exists := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logrus.Error(err)
+		return "", err
+	}
+	if !exists && c.Secret == "" && c.AuthMethod != "none" {
+		return "", errors.New("secret is required for new clients")
+	}
+
+	disallowedScopes := c.DisallowedScopes
+	if disallowedScopes == "" {
+		disallowedScopes = DisallowedScopesDownscope
+	}
+	args := []any{
+		c.Secret,
+		pq.Array(nonNilStrings(c.RedirectURIs)),
+		int(c.ApplicationType),
+		c.AuthMethod,
+		pq.Array(nonNilStrings(c.ResponseTypes)),
+		pq.Array(nonNilStrings(c.GrantTypes)),
+		int(c.AccessTokenType),
+		c.DevMode,
+		c.IDTokenUserinfoClaimsAssertion,
+		c.ClockSkewDuration().Microseconds(),
+		pq.Array(nonNilStrings(c.PostLogoutRedirectURIGlobs)),
+		pq.Array(nonNilStrings(c.RedirectURIGlobs)),
+		namespace,
+		c.Name,
+		clientmeta.Duration(c.AccessTokenLifetime).Microseconds(),
+		clientmeta.Duration(c.IDTokenLifetime).Microseconds(),
+		clientmeta.Duration(c.RefreshTokenLifetime).Microseconds(),
+		clientmeta.Duration(c.RefreshTokenIdleLifetime).Microseconds(),
+		c.RefreshTokens,
+		pq.Array(nonNilStrings(c.AllowedScopes)),
+		pq.Array(nonNilStrings(c.DefaultScopes)),
+		pq.Array(nonNilStrings(c.Audiences)),
+		disallowedScopes,
+		pq.Array(nonNilStrings(c.Resources)),
+		c.RequirePushedAuthorizationRequests,
+		pq.Array(nonNilStrings(c.ResponseModes)),
+		c.AuthorizationEncryptedResponseAlg,
+		c.AuthorizationEncryptedResponseEnc,
+		c.JWKS,
+		c.DPoPBoundAccessTokens,
+		c.TLSClientAuthSubjectDN,
+		c.TLSClientAuthSANDNS,
+		c.TLSClientAuthSANURI,
+		c.TLSClientAuthSANIP,
+		c.TLSClientAuthSANEmail,
+		c.TLSClientCertificateBoundAccessTokens,
+		c.BackchannelTokenDeliveryMode,
+		c.BackchannelClientNotificationEndpoint,
+		c.FrontchannelLogoutURI,
+		c.FrontchannelLogoutSessionRequired,
+		c.BackchannelLogoutURI,
+		c.BackchannelLogoutSessionRequired,
+	}
+	if exists {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE client
+		SET secret = CASE WHEN $1 = '' THEN secret ELSE $1 END,
+			redirect_uris = $2,
+			application_type = $3,
+			auth_method = $4,
+			response_types = $5,
+			grant_types = $6,
+			access_token_type = $7,
+			dev_mode = $8,
+			id_token_user_info_claims_assertion = $9,
+			clock_skew = $10 * interval '1 microsecond',
+			post_logout_redirect_uri_globs = $11,
+			redirect_uri_globs = $12,
+			user_namespace_id = $13,
+			name = $14,
+			access_token_lifetime = $15 * interval '1 microsecond',
+			id_token_lifetime = $16 * interval '1 microsecond',
+			refresh_token_lifetime = $17 * interval '1 microsecond',
+			refresh_token_idle_lifetime = $18 * interval '1 microsecond',
+			refresh_tokens = $19,
+			allowed_scopes = $20,
+			default_scopes = $21,
+			audiences = $22,
+			disallowed_scopes = $23,
+			resources = $24,
+			require_pushed_authorization_requests = $25,
+			response_modes = $26,
+			authorization_encrypted_response_alg = $27,
+			authorization_encrypted_response_enc = $28,
+			jwks = $29,
+			dpop_bound_access_tokens = $30,
+			tls_client_auth_subject_dn = $31,
+			tls_client_auth_san_dns = $32,
+			tls_client_auth_san_uri = $33,
+			tls_client_auth_san_ip = $34,
+			tls_client_auth_san_email = $35,
+			tls_client_certificate_bound_access_tokens = $36,
+			backchannel_token_delivery_mode = $37,
+			backchannel_client_notification_endpoint = $38,
+			frontchannel_logout_uri = $39,
+			frontchannel_logout_session_required = $40,
+			backchannel_logout_uri = $41,
+			backchannel_logout_session_required = $42
+		WHERE id = $43
+		`, append(args, id)...)
+		if err != nil {
+			logrus.Error(err)
+			return "", err
+		}
+		return transfer.ActionUpdate, nil
+	}
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO client (
+		secret,
+		redirect_uris,
+		application_type,
+		auth_method,
+		response_types,
+		grant_types,
+		access_token_type,
+		dev_mode,
+		id_token_user_info_claims_assertion,
+		clock_skew,
+		post_logout_redirect_uri_globs,
+		redirect_uri_globs,
+		user_namespace_id,
+		name,
+		access_token_lifetime,
+		id_token_lifetime,
+		refresh_token_lifetime,
+		refresh_token_idle_lifetime,
+		refresh_tokens,
+		allowed_scopes,
+		default_scopes,
+		audiences,
+		disallowed_scopes,
+		resources,
+		require_pushed_authorization_requests,
+		response_modes,
+		authorization_encrypted_response_alg,
+		authorization_encrypted_response_enc,
+		jwks,
+		dpop_bound_access_tokens,
+		tls_client_auth_subject_dn,
+		tls_client_auth_san_dns,
+		tls_client_auth_san_uri,
+		tls_client_auth_san_ip,
+		tls_client_auth_san_email,
+		tls_client_certificate_bound_access_tokens,
+		backchannel_token_delivery_mode,
+		backchannel_client_notification_endpoint,
+		frontchannel_logout_uri,
+		frontchannel_logout_session_required,
+		backchannel_logout_uri,
+		backchannel_logout_session_required,
+		id
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10 * interval '1 microsecond', $11, $12, $13, $14,
+		$15 * interval '1 microsecond', $16 * interval '1 microsecond', $17 * interval '1 microsecond', $18 * interval '1 microsecond',
+		$19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
+		$31, $32, $33, $34, $35, $36, $37, $38, $39, $40, $41, $42, $43
+	)
+	`, append(args, id)...)
+	if err != nil {
+		logrus.Error(err)
+		return "", err
+	}
+	c.ID = id.String()
+	return transfer.ActionCreate, nil
+}
+
+// clientByName finds the client of an import without id, names are not unique but the key must be
+func clientByName(ctx context.Context, tx *sql.Tx, namespace uuid.UUID, name string) (uuid.UUID, error) {
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		id
+	FROM
+		client
+	WHERE
+		user_namespace_id = $1
+	AND name = $2
+	LIMIT 2
+	`, namespace, name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		err := rows.Scan(&id)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, err
+	}
+	switch len(ids) {
+	case 0:
+		return uuid.Nil, sql.ErrNoRows
+	case 1:
+		return ids[0], nil
+	}
+	return uuid.Nil, fmt.Errorf("several clients are named %q, give the id", name)
+}
+
+// ExportClients returns the clients of a user namespace, of all namespaces for uuid.Nil.
+// Their secrets are only exported if asked for.
+func (s *Storage) ExportClients(ctx context.Context, namespace uuid.UUID, withSecrets bool) ([]*clientmeta.Client, error) {
+	cmd := `
+	SELECT
+		id,
+		name,
+		secret,
+		user_namespace_id,
+		redirect_uris,
+		post_logout_redirect_uri_globs,
+		redirect_uri_globs,
+		application_type,
+		auth_method,
+		response_types,
+		grant_types,
+		access_token_type,
+		dev_mode,
+		id_token_user_info_claims_assertion,
+		clock_skew,
+		access_token_lifetime,
+		id_token_lifetime,
+		refresh_token_lifetime,
+		refresh_token_idle_lifetime,
+		refresh_tokens,
+		allowed_scopes,
+		default_scopes,
+		audiences,
+		disallowed_scopes,
+		resources,
+		require_pushed_authorization_requests,
+		response_modes,
+		authorization_encrypted_response_alg,
+		authorization_encrypted_response_enc,
+		jwks,
+		dpop_bound_access_tokens,
+		tls_client_auth_subject_dn,
+		tls_client_auth_san_dns,
+		tls_client_auth_san_uri,
+		tls_client_auth_san_ip,
+		tls_client_auth_san_email,
+		tls_client_certificate_bound_access_tokens,
+		backchannel_token_delivery_mode,
+		backchannel_client_notification_endpoint,
+		frontchannel_logout_uri,
+		frontchannel_logout_session_required,
+		backchannel_logout_uri,
+		backchannel_logout_session_required
+	FROM
+		client
+	WHERE
+		user_namespace_id = $1 OR $1 = '00000000-0000-0000-0000-000000000000'
+	ORDER BY name, id
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, namespace)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var clients []*clientmeta.Client
+	for rows.Next() {
+		c := &clientmeta.Client{}
+		var (
+			applicationType, accessTokenType int
+			intervals                        = make([]pqinterval.Interval, 5)
+			ds                               = make([]time.Duration, 5)
+		)
+		err := rows.Scan(
+			&c.ID,
+			&c.Name,
+			&c.Secret,
+			&c.UserNamespaceID,
+			pq.Array(&c.RedirectURIs),
+			pq.Array(&c.PostLogoutRedirectURIGlobs),
+			pq.Array(&c.RedirectURIGlobs),
+			&applicationType,
+			&c.AuthMethod,
+			pq.Array(&c.ResponseTypes),
+			pq.Array(&c.GrantTypes),
+			&accessTokenType,
+			&c.DevMode,
+			&c.IDTokenUserinfoClaimsAssertion,
+			&intervals[0],
+			&intervals[1],
+			&intervals[2],
+			&intervals[3],
+			&intervals[4],
+			&c.RefreshTokens,
+			pq.Array(&c.AllowedScopes),
+			pq.Array(&c.DefaultScopes),
+			pq.Array(&c.Audiences),
+			&c.DisallowedScopes,
+			pq.Array(&c.Resources),
+			&c.RequirePushedAuthorizationRequests,
+			pq.Array(&c.ResponseModes),
+			&c.AuthorizationEncryptedResponseAlg,
+			&c.AuthorizationEncryptedResponseEnc,
+			&c.JWKS,
+			&c.DPoPBoundAccessTokens,
+			&c.TLSClientAuthSubjectDN,
+			&c.TLSClientAuthSANDNS,
+			&c.TLSClientAuthSANURI,
+			&c.TLSClientAuthSANIP,
+			&c.TLSClientAuthSANEmail,
+			&c.TLSClientCertificateBoundAccessTokens,
+			&c.BackchannelTokenDeliveryMode,
+			&c.BackchannelClientNotificationEndpoint,
+			&c.FrontchannelLogoutURI,
+			&c.FrontchannelLogoutSessionRequired,
+			&c.BackchannelLogoutURI,
+			&c.BackchannelLogoutSessionRequired,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		err = durations(intervals, &ds[0], &ds[1], &ds[2], &ds[3], &ds[4])
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		c.ClockSkew = clientmeta.FormatDuration(ds[0])
+		c.AccessTokenLifetime = clientmeta.FormatDuration(ds[1])
+		c.IDTokenLifetime = clientmeta.FormatDuration(ds[2])
+		c.RefreshTokenLifetime = clientmeta.FormatDuration(ds[3])
+		c.RefreshTokenIdleLifetime = clientmeta.FormatDuration(ds[4])
+		if c.DisallowedScopes == DisallowedScopesDownscope {
+			c.DisallowedScopes = ""
+		}
+		c.ApplicationType = op.ApplicationType(applicationType)
+		c.AccessTokenType = op.AccessTokenType(accessTokenType)
+		if !withSecrets {
+			c.Secret = ""
+		}
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/rsa"
 	"database/sql"
+	"time"
 
 	. "github.com/go-jet/jet/v2/postgres"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+	"github.com/zltl/xoidc/server/gen/xoidc/public/model"
 	"github.com/zltl/xoidc/server/gen/xoidc/public/table"
 	"golang.org/x/text/language"
 )
@@ -42,6 +45,13 @@ func (s *Storage) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
 		tb.PhoneNumber,
 		tb.PhoneNumberVerified,
 		tb.Locale,
+		tb.NamespaceID,
+		tb.PasswordChangeTime,
+		tb.Status,
+		tb.DirectoryID,
+		tb.Groups,
+		tb.IsAdmin,
+		tb.CreateTime,
 	).WHERE(
 		tb.ID.EQ(UUID(id)),
 	)
@@ -49,6 +59,7 @@ func (s *Storage) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
 	u := &User{}
 
 	var locale string
+	var directoryID uuid.NullUUID
 	err := s.db.QueryRowContext(ctx, cmd, args...).Scan(
 		&u.Username,
 		&u.Password,
@@ -59,11 +70,19 @@ func (s *Storage) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error)
 		&u.Phone,
 		&u.PhoneVerified,
 		&locale,
+		&u.NamespaceID,
+		&u.PasswordChangeTime,
+		&u.Status,
+		&directoryID,
+		pq.Array(&u.Groups),
+		&u.IsAdmin,
+		&u.CreateTime,
 	)
 	if err != nil {
 		return nil, err
 	}
 	u.ID = id
+	u.DirectoryID = directoryID.UUID
 
 	return u, nil
 }
@@ -80,6 +99,13 @@ func (s *Storage) GetUserByUsername(ctx context.Context, name string, clientID u
 		table.User.PhoneNumber,
 		table.User.PhoneNumberVerified,
 		table.User.Locale,
+		table.User.NamespaceID,
+		table.User.PasswordChangeTime,
+		table.User.Status,
+		table.User.DirectoryID,
+		table.User.Groups,
+		table.User.IsAdmin,
+		table.User.CreateTime,
 	).FROM(
 		table.User,
 		table.Client,
@@ -95,6 +121,7 @@ func (s *Storage) GetUserByUsername(ctx context.Context, name string, clientID u
 	u := &User{}
 	logrus.Debugf("args=%+v", args)
 	var locale string
+	var directoryID uuid.NullUUID
 	err := s.db.QueryRowContext(ctx, cmd, args...).Scan(
 		&u.ID,
 		&u.Username,
@@ -106,28 +133,163 @@ func (s *Storage) GetUserByUsername(ctx context.Context, name string, clientID u
 		&u.Phone,
 		&u.PhoneVerified,
 		&locale,
+		&u.NamespaceID,
+		&u.PasswordChangeTime,
+		&u.Status,
+		&directoryID,
+		pq.Array(&u.Groups),
+		&u.IsAdmin,
+		&u.CreateTime,
 	)
 	if err != nil {
 		return nil, err
 	}
+	u.DirectoryID = directoryID.UUID
 
 	return u, nil
 }
 
 type User struct {
-	ID                uuid.UUID
-	Username          string
-	Password          string
-	FirstName         string
-	LastName          string
-	Email             string
-	EmailVerified     bool
-	Phone             string
-	PhoneVerified     bool
-	PreferredLanguage language.Tag
-	IsAdmin           bool
+	ID                 uuid.UUID
+	Username           string
+	Password           string
+	FirstName          string
+	LastName           string
+	Nickname           string
+	Email              string
+	EmailVerified      bool
+	Phone              string
+	PhoneVerified      bool
+	PreferredLanguage  language.Tag
+	IsAdmin            bool
+	NamespaceID        uuid.UUID
+	PasswordChangeTime time.Time
+	Status             string
+	// DirectoryID is the directory checking the password, nil for local users
+	DirectoryID uuid.UUID
+	Groups      []string
+	CreateTime  time.Time
 }
 
 type Service struct {
 	keys map[string]*rsa.PublicKey
 }
+
+// GetUserProfile returns all columns of the user, for the account portal
+func (s *Storage) GetUserProfile(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	tb := table.User
+	stmt := tb.SELECT(
+		tb.AllColumns,
+	).WHERE(
+		tb.ID.EQ(UUID(id)),
+	)
+	var u model.User
+	err := stmt.QueryContext(ctx, s.db, &u)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	return &u, nil
+}
+
+// UpdateUserProfile changes the profile fields a user may edit,
+// username, password and contacts have their own flows
+func (s *Storage) UpdateUserProfile(ctx context.Context, id uuid.UUID, u *model.User) error {
+	tb := table.User
+	stmt := tb.UPDATE(
+		tb.Nickname,
+		tb.GivenName,
+		tb.FamilyName,
+		tb.MiddleName,
+		tb.PreferredUsername,
+		tb.Profile,
+		tb.Picture,
+		tb.Website,
+		tb.Gender,
+		tb.Birthdate,
+		tb.Zoneinfo,
+		tb.Locale,
+		tb.Address,
+		tb.UpdatedAt,
+	).SET(
+		String(u.Nickname),
+		String(u.GivenName),
+		String(u.FamilyName),
+		String(u.MiddleName),
+		String(u.PreferredUsername),
+		String(u.Profile),
+		String(u.Picture),
+		String(u.Website),
+		String(u.Gender),
+		DateT(u.Birthdate),
+		String(u.Zoneinfo),
+		String(u.Locale),
+		String(u.Address),
+		NOW(),
+	).WHERE(
+		tb.ID.EQ(UUID(id)),
+	)
+	_, err := stmt.ExecContext(ctx, s.db)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteUser removes a user with its sessions, tokens, credentials and memberships
+func (s *Storage) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	cmds := []string{
+		`DELETE FROM user_session WHERE user_id = $1`,
+		`DELETE FROM consent WHERE user_id = $1`,
+		`DELETE FROM token WHERE subject = $1`,
+		`DELETE FROM refresh_token WHERE user_id = $1::text`,
+		`DELETE FROM user_identity WHERE user_id = $1`,
+		`DELETE FROM user_group_member WHERE user_id = $1`,
+		`DELETE FROM webauthn_credential WHERE user_id = $1`,
+		`DELETE FROM password_history WHERE user_id = $1`,
+		`DELETE FROM password_reset WHERE user_id = $1`,
+		`DELETE FROM verification WHERE user_id = $1`,
+		`DELETE FROM auth_request WHERE user_id = $1`,
+		`DELETE FROM backchannel_auth_request WHERE user_id = $1`,
+		`DELETE FROM user_session_client WHERE user_id = $1`,
+		`DELETE FROM backchannel_logout WHERE user_id = $1`,
+	}
+	for _, cmd := range cmds {
+		_, err = tx.ExecContext(ctx, cmd, id)
+		if err != nil {
+			logrus.Error(err)
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM "user" WHERE id = $1`, id)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		_ = tx.Rollback()
+		return sql.ErrNoRows
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for k, t := range s.refreshTokens {
+		if t.UserID == id {
+			delete(s.refreshTokens, k)
+		}
+	}
+	return nil
+}
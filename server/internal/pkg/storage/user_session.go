@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// a browser stays signed in this long without a new login
+const UserSessionLife = 14 * 24 * time.Hour
+
+var ErrSessionNotFound = errors.New("the session ended, please sign in again")
+
+// UserSession is the sign in of a user with a browser
+type UserSession struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	UserAgent    string
+	RemoteIP     string
+	AMR          []string
+	CreateTime   time.Time
+	LastSeenTime time.Time
+	Expiration   time.Time
+}
+
+// CreateUserSession starts the session of a browser after a completed login
+func (s *Storage) CreateUserSession(ctx context.Context, userID uuid.UUID, userAgent, remoteIP string, amr []string) (uuid.UUID, error) {
+	if len(userAgent) > 500 {
+		userAgent = userAgent[:500]
+	}
+	cmd := `
+	INSERT INTO user_session (
+		user_id,
+		user_agent,
+		remote_ip,
+		amr,
+		expiration
+	) VALUES (
+		$1, $2, $3, $4, $5
+	) RETURNING id
+	`
+	var id uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, userID, userAgent, remoteIP, pq.Array(amr), time.Now().Add(UserSessionLife)).Scan(&id)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// TouchUserSession returns the user of a session that did not end,
+// and remembers when the session was used
+func (s *Storage) TouchUserSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	cmd := `
+	UPDATE user_session
+	SET last_seen_time = now()
+	WHERE id = $1
+	AND expiration > now()
+	RETURNING user_id
+	`
+	var userID uuid.UUID
+	err := s.db.QueryRowContext(ctx, cmd, id).Scan(&userID)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, ErrSessionNotFound
+	}
+	return userID, nil
+}
+
+// ListUserSessions returns the sessions of a user, the last used first
+func (s *Storage) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*UserSession, error) {
+	cmd := `
+	SELECT
+		id,
+		user_agent,
+		remote_ip,
+		amr,
+		create_time,
+		last_seen_time,
+		expiration
+	FROM
+		user_session
+	WHERE
+		user_id = $1
+	AND expiration > now()
+	ORDER BY last_seen_time DESC
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, userID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+	var sessions []*UserSession
+	for rows.Next() {
+		us := &UserSession{UserID: userID}
+		err := rows.Scan(
+			&us.ID,
+			&us.UserAgent,
+			&us.RemoteIP,
+			pq.Array(&us.AMR),
+			&us.CreateTime,
+			&us.LastSeenTime,
+			&us.Expiration,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		sessions = append(sessions, us)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteUserSession signs out a browser of the user, the clients of the
+// session are told with logout tokens
+func (s *Storage) DeleteUserSession(ctx context.Context, userID, id uuid.UUID) error {
+	_, err := s.endUserSession(ctx, userID, id)
+	return err
+}
+
+func (s *Storage) DeleteExpiredUserSessions(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+	DELETE FROM user_session
+	WHERE expiration < now()
+	`)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+	DELETE FROM user_session_client
+	WHERE session_id NOT IN (SELECT id FROM user_session)
+	`)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
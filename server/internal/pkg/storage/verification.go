@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// kinds of contact verifications
+const (
+	VerifyEmail = "email"
+	VerifyPhone = "phone"
+)
+
+// a wrong code can be entered this often before a new one must be requested
+const maxVerificationAttempts = 5
+
+var ErrInvalidVerification = errors.New("the verification is invalid or expired")
+
+func hashVerificationCode(id uuid.UUID, code string) string {
+	sum := sha256.Sum256(append(id[:], code...))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateVerification starts the verification of an address of the user,
+// it replaces a pending verification of the same kind.
+// code is the one time code for phones, and empty for email links.
+func (s *Storage) CreateVerification(ctx context.Context, userID uuid.UUID, kind, address, code string, life time.Duration) (uuid.UUID, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM verification
+	WHERE user_id = $1
+	AND kind = $2
+	`, userID, kind)
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	id := uuid.New()
+	var codeHash string
+	if code != "" {
+		codeHash = hashVerificationCode(id, code)
+	}
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO verification (
+		id,
+		user_id,
+		kind,
+		address,
+		code,
+		expiration
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	)`, id, userID, kind, address, codeHash, time.Now().Add(life))
+	if err != nil {
+		logrus.Error(err)
+		_ = tx.Rollback()
+		return uuid.Nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		logrus.Error(err)
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// ConfirmEmailVerification marks the email address verified, if the link belongs
+// to a pending verification and the address was not changed since
+func (s *Storage) ConfirmEmailVerification(ctx context.Context, id uuid.UUID) error {
+	cmd := `
+	DELETE FROM verification
+	WHERE id = $1
+	AND kind = $2
+	AND expiration > now()
+	RETURNING user_id, address
+	`
+	var (
+		userID  uuid.UUID
+		address string
+	)
+	err := s.db.QueryRowContext(ctx, cmd, id, VerifyEmail).Scan(&userID, &address)
+	if err != nil {
+		logrus.Error(err)
+		return ErrInvalidVerification
+	}
+	return s.markVerified(ctx, userID, VerifyEmail, address)
+}
+
+// ConfirmPhoneVerification checks the one time code sent to the user's phone,
+// every guess is counted before the code is compared
+func (s *Storage) ConfirmPhoneVerification(ctx context.Context, userID uuid.UUID, code string) error {
+	cmd := `
+	UPDATE verification
+	SET attempts = attempts + 1
+	WHERE
+		user_id = $1
+	AND kind = $2
+	AND expiration > now()
+	RETURNING
+		id,
+		address,
+		code,
+		attempts
+	`
+	var (
+		id       uuid.UUID
+		address  string
+		codeHash string
+		attempts int
+	)
+	err := s.db.QueryRowContext(ctx, cmd, userID, VerifyPhone).Scan(&id, &address, &codeHash, &attempts)
+	if err != nil {
+		logrus.Error(err)
+		return ErrInvalidVerification
+	}
+	if attempts > maxVerificationAttempts {
+		return errors.New("too many wrong codes, request a new one")
+	}
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(hashVerificationCode(id, code))) != 1 {
+		return errors.New("the code is wrong")
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+	DELETE FROM verification
+	WHERE id = $1
+	`, id)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInvalidVerification
+	}
+	return s.markVerified(ctx, userID, VerifyPhone, address)
+}
+
+func (s *Storage) markVerified(ctx context.Context, userID uuid.UUID, kind, address string) error {
+	cmd := `
+	UPDATE "user"
+	SET email_verified = true,
+		updated_at = now()
+	WHERE id = $1
+	AND email = $2
+	`
+	if kind == VerifyPhone {
+		cmd = `
+		UPDATE "user"
+		SET phone_number_verified = true,
+			updated_at = now()
+		WHERE id = $1
+		AND phone_number = $2
+		`
+	}
+	res, err := s.db.ExecContext(ctx, cmd, userID, address)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("the address changed since the verification was sent")
+	}
+	return nil
+}
+
+// SetUserEmail changes the email address, a new address is not verified
+func (s *Storage) SetUserEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	cmd := `
+	UPDATE "user"
+	SET email = $1,
+		email_verified = email_verified AND email = $1,
+		updated_at = now()
+	WHERE id = $2
+	`
+	_, err := s.db.ExecContext(ctx, cmd, email, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// SetUserPhone changes the phone number, a new number is not verified
+func (s *Storage) SetUserPhone(ctx context.Context, userID uuid.UUID, phone string) error {
+	cmd := `
+	UPDATE "user"
+	SET phone_number = $1,
+		phone_number_verified = phone_number_verified AND phone_number = $1,
+		updated_at = now()
+	WHERE id = $2
+	`
+	_, err := s.db.ExecContext(ctx, cmd, phone, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// SetUserVerified lets admins mark the contacts of a user verified or not,
+// nil leaves the flag as it is
+func (s *Storage) SetUserVerified(ctx context.Context, userID uuid.UUID, emailVerified, phoneVerified *bool) error {
+	cmd := `
+	UPDATE "user"
+	SET email_verified = COALESCE($1, email_verified),
+		phone_number_verified = COALESCE($2, phone_number_verified),
+		updated_at = now()
+	WHERE id = $3
+	`
+	res, err := s.db.ExecContext(ctx, cmd, emailVerified, phoneVerified, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
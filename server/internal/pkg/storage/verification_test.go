@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type verificationRow struct {
+	id, userID, kind, address, code string
+	attempts                        int64
+	expiration                      time.Time
+}
+
+// verificationDB keeps the rows of verification and the phone number of users
+func verificationDB(phones map[string]string) (*fakeDB, map[string]*verificationRow) {
+	rows := map[string]*verificationRow{}
+	db := &fakeDB{}
+	db.on("INSERT INTO verification", func(args []driver.Value) [][]driver.Value {
+		rows[args[0].(string)] = &verificationRow{
+			id:         args[0].(string),
+			userID:     args[1].(string),
+			kind:       args[2].(string),
+			address:    args[3].(string),
+			code:       args[4].(string),
+			expiration: args[5].(time.Time),
+		}
+		return [][]driver.Value{{}}
+	})
+	db.on("UPDATE verification", func(args []driver.Value) [][]driver.Value {
+		var out [][]driver.Value
+		for _, row := range rows {
+			if row.userID == args[0] && row.kind == args[1] && row.expiration.After(time.Now()) {
+				row.attempts++
+				out = append(out, []driver.Value{row.id, row.address, row.code, row.attempts})
+			}
+		}
+		return out
+	})
+	db.on("DELETE FROM verification", func(args []driver.Value) [][]driver.Value {
+		var out [][]driver.Value
+		for id, row := range rows {
+			// by id, or all of a user and kind
+			if id == args[0] || len(args) == 2 && row.userID == args[0] && row.kind == args[1] {
+				delete(rows, id)
+				out = append(out, []driver.Value{})
+			}
+		}
+		return out
+	})
+	db.on(`UPDATE "user"`, func(args []driver.Value) [][]driver.Value {
+		if phones[args[0].(string)] != args[1] {
+			return nil
+		}
+		return [][]driver.Value{{}}
+	})
+	return db, rows
+}
+
+func TestConfirmPhoneVerification(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	db, _ := verificationDB(map[string]string{userID.String(): "+15550100"})
+	s := db.storage()
+
+	_, err := s.CreateVerification(ctx, userID, VerifyPhone, "+15550100", "123456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ConfirmPhoneVerification(ctx, userID, "654321"); err == nil {
+		t.Fatal("a wrong code was accepted")
+	}
+	if err := s.ConfirmPhoneVerification(ctx, userID, "123456"); err != nil {
+		t.Fatal(err)
+	}
+	// the code is used once
+	if err := s.ConfirmPhoneVerification(ctx, userID, "123456"); err != ErrInvalidVerification {
+		t.Errorf("second use: got %v, want %v", err, ErrInvalidVerification)
+	}
+}
+
+func TestConfirmPhoneVerificationAttempts(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	db, rows := verificationDB(map[string]string{userID.String(): "+15550100"})
+	s := db.storage()
+
+	_, err := s.CreateVerification(ctx, userID, VerifyPhone, "+15550100", "123456", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxVerificationAttempts; i++ {
+		if err := s.ConfirmPhoneVerification(ctx, userID, "000000"); err == nil {
+			t.Fatal("a wrong code was accepted")
+		}
+	}
+	// the right code comes too late
+	if err := s.ConfirmPhoneVerification(ctx, userID, "123456"); err == nil {
+		t.Fatal("the code was accepted after the wrong attempts")
+	}
+	for _, row := range rows {
+		if row.attempts != maxVerificationAttempts+1 {
+			t.Errorf("%d attempts counted, want %d", row.attempts, maxVerificationAttempts+1)
+		}
+	}
+
+	// a new code starts over
+	_, err = s.CreateVerification(ctx, userID, VerifyPhone, "+15550100", "111111", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ConfirmPhoneVerification(ctx, userID, "111111"); err != nil {
+		t.Errorf("the new code: %v", err)
+	}
+}
+
+func TestConfirmPhoneVerificationExpired(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	db, _ := verificationDB(map[string]string{userID.String(): "+15550100"})
+	s := db.storage()
+
+	_, err := s.CreateVerification(ctx, userID, VerifyPhone, "+15550100", "123456", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ConfirmPhoneVerification(ctx, userID, "123456"); err != ErrInvalidVerification {
+		t.Errorf("got %v, want %v", err, ErrInvalidVerification)
+	}
+}
@@ -0,0 +1,406 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// amr values of RFC 8176 reported for password and passkey logins
+const (
+	AMRPassword       = "pwd"
+	AMRHardwareKey    = "hwk"
+	AMRSoftwareKey    = "swk"
+	AMRMultipleFactor = "mfa"
+)
+
+// a registration or assertion ceremony must be finished within this time
+const webauthnSessionLife = 5 * time.Minute
+
+// PasskeyCredential is a WebAuthn credential registered by a user
+type PasskeyCredential struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Name         string
+	Credential   webauthn.Credential
+	CreateTime   time.Time
+	LastUsedTime time.Time
+}
+
+// AMR returns the authentication method reference of the credential:
+// passkeys that can be synced between devices live in software, the others in hardware.
+func (c *PasskeyCredential) AMR() string {
+	return PasskeyAMR(&c.Credential)
+}
+
+// PasskeyAMR returns hwk for device bound credentials and swk for synced ones
+func PasskeyAMR(cred *webauthn.Credential) string {
+	if cred.Flags.BackupEligible {
+		return AMRSoftwareKey
+	}
+	return AMRHardwareKey
+}
+
+// PasskeyUser implements webauthn.User for a stored user and its credentials
+type PasskeyUser struct {
+	*User
+	Credentials []PasskeyCredential
+}
+
+// WebAuthnID returns the user handle, which is the binary user id
+func (u *PasskeyUser) WebAuthnID() []byte {
+	return u.ID[:]
+}
+
+func (u *PasskeyUser) WebAuthnName() string {
+	return u.Username
+}
+
+func (u *PasskeyUser) WebAuthnDisplayName() string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		return u.Username
+	}
+	return name
+}
+
+func (u *PasskeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *PasskeyUser) WebAuthnCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, 0, len(u.Credentials))
+	for _, c := range u.Credentials {
+		creds = append(creds, c.Credential)
+	}
+	return creds
+}
+
+// CredentialDescriptors lists the registered credentials, used to exclude
+// them on registration and to restrict a second factor assertion to them
+func (u *PasskeyUser) CredentialDescriptors() []protocol.CredentialDescriptor {
+	ds := make([]protocol.CredentialDescriptor, 0, len(u.Credentials))
+	for _, c := range u.Credentials {
+		ds = append(ds, c.Credential.Descriptor())
+	}
+	return ds
+}
+
+func (s *Storage) GetPasskeyUser(ctx context.Context, userID uuid.UUID) (*PasskeyUser, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	creds, err := s.ListPasskeyCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyUser{
+		User:        user,
+		Credentials: creds,
+	}, nil
+}
+
+// GetPasskeyUserByHandle loads the user of a discoverable credential,
+// the user handle is what WebAuthnID returned on registration
+func (s *Storage) GetPasskeyUserByHandle(ctx context.Context, userHandle []byte) (*PasskeyUser, error) {
+	userID, err := uuid.FromBytes(userHandle)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPasskeyUser(ctx, userID)
+}
+
+func (s *Storage) ListPasskeyCredentials(ctx context.Context, userID uuid.UUID) ([]PasskeyCredential, error) {
+	cmd := `
+	SELECT
+		id,
+		user_id,
+		name,
+		credential_id,
+		public_key,
+		attestation_type,
+		transports,
+		aaguid,
+		sign_count,
+		clone_warning,
+		attachment,
+		user_verified,
+		backup_eligible,
+		backup_state,
+		create_time,
+		last_used_time
+	FROM
+		webauthn_credential
+	WHERE
+		user_id = $1
+	ORDER BY create_time
+	`
+	rows, err := s.db.QueryContext(ctx, cmd, userID)
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []PasskeyCredential
+	for rows.Next() {
+		var (
+			c          PasskeyCredential
+			transports []string
+			signCount  int64
+			attachment string
+		)
+		err := rows.Scan(
+			&c.ID,
+			&c.UserID,
+			&c.Name,
+			&c.Credential.ID,
+			&c.Credential.PublicKey,
+			&c.Credential.AttestationType,
+			pq.Array(&transports),
+			&c.Credential.Authenticator.AAGUID,
+			&signCount,
+			&c.Credential.Authenticator.CloneWarning,
+			&attachment,
+			&c.Credential.Flags.UserVerified,
+			&c.Credential.Flags.BackupEligible,
+			&c.Credential.Flags.BackupState,
+			&c.CreateTime,
+			&c.LastUsedTime,
+		)
+		if err != nil {
+			logrus.Error(err)
+			return nil, err
+		}
+		for _, t := range transports {
+			c.Credential.Transport = append(c.Credential.Transport, protocol.AuthenticatorTransport(t))
+		}
+		c.Credential.Authenticator.SignCount = uint32(signCount)
+		c.Credential.Authenticator.Attachment = protocol.AuthenticatorAttachment(attachment)
+		c.Credential.Flags.UserPresent = true
+		creds = append(creds, c)
+	}
+	return creds, rows.Err()
+}
+
+func (s *Storage) CountPasskeyCredentials(ctx context.Context, userID uuid.UUID) (int64, error) {
+	cmd := `
+	SELECT
+		count(*)
+	FROM
+		webauthn_credential
+	WHERE
+		user_id = $1
+	`
+	var total int64
+	err := s.db.QueryRowContext(ctx, cmd, userID).Scan(&total)
+	if err != nil {
+		logrus.Error(err)
+		return 0, err
+	}
+	return total, nil
+}
+
+func (s *Storage) StorePasskeyCredential(ctx context.Context, userID uuid.UUID, name string, cred *webauthn.Credential) error {
+	cmd := `
+	INSERT INTO webauthn_credential (
+		user_id,
+		name,
+		credential_id,
+		public_key,
+		attestation_type,
+		transports,
+		aaguid,
+		sign_count,
+		attachment,
+		user_verified,
+		backup_eligible,
+		backup_state
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+	)
+	`
+	transports := make([]string, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transports = append(transports, string(t))
+	}
+	_, err := s.db.ExecContext(ctx, cmd,
+		userID,
+		name,
+		cred.ID,
+		cred.PublicKey,
+		cred.AttestationType,
+		pq.Array(transports),
+		cred.Authenticator.AAGUID,
+		int64(cred.Authenticator.SignCount),
+		string(cred.Authenticator.Attachment),
+		cred.Flags.UserVerified,
+		cred.Flags.BackupEligible,
+		cred.Flags.BackupState,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// UpdatePasskeyCredentialUsage stores the sign count and flags of a credential
+// of the user after a successful assertion
+func (s *Storage) UpdatePasskeyCredentialUsage(ctx context.Context, userID uuid.UUID, cred *webauthn.Credential) error {
+	cmd := `
+	UPDATE webauthn_credential
+	SET sign_count=$1,
+		clone_warning=$2,
+		backup_state=$3,
+		last_used_time=now()
+	WHERE
+		credential_id=$4
+	AND user_id=$5
+	`
+	_, err := s.db.ExecContext(ctx, cmd,
+		int64(cred.Authenticator.SignCount),
+		cred.Authenticator.CloneWarning,
+		cred.Flags.BackupState,
+		cred.ID,
+		userID,
+	)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) DeletePasskeyCredential(ctx context.Context, userID, id uuid.UUID) error {
+	cmd := `
+	DELETE FROM webauthn_credential
+	WHERE id=$1
+	AND user_id=$2
+	`
+	_, err := s.db.ExecContext(ctx, cmd, id, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// StoreWebAuthnSession keeps the state of a running registration or assertion ceremony
+func (s *Storage) StoreWebAuthnSession(ctx context.Context, id string, session *webauthn.SessionData) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	cmd := `
+	INSERT INTO webauthn_session (
+		id,
+		data,
+		expiration
+	) VALUES (
+		$1, $2, $3
+	) ON CONFLICT (id) DO UPDATE SET
+		data=EXCLUDED.data,
+		expiration=EXCLUDED.expiration
+	`
+	_, err = s.db.ExecContext(ctx, cmd, id, string(data), time.Now().Add(webauthnSessionLife))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// TakeWebAuthnSession returns and removes a ceremony state, so every challenge can be answered only once
+func (s *Storage) TakeWebAuthnSession(ctx context.Context, id string) (*webauthn.SessionData, error) {
+	cmd := `
+	DELETE FROM webauthn_session
+	WHERE id=$1
+	RETURNING data, expiration
+	`
+	var (
+		data       string
+		expiration time.Time
+	)
+	err := s.db.QueryRowContext(ctx, cmd, id).Scan(&data, &expiration)
+	if err != nil {
+		logrus.Error(err)
+		return nil, errors.New("passkey ceremony not found")
+	}
+	if time.Now().After(expiration) {
+		return nil, errors.New("passkey ceremony expired")
+	}
+	session := &webauthn.SessionData{}
+	err = json.Unmarshal([]byte(data), session)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// PendingSecondFactor returns the user of an auth request whose password was
+// verified but which still waits for a passkey assertion
+func (s *Storage) PendingSecondFactor(ctx context.Context, id string) (string, bool, error) {
+	reqid, err := uuid.Parse(id)
+	if err != nil {
+		return "", false, err
+	}
+	request, err := s.GetAuthRequestByUUID(ctx, reqid)
+	if err != nil {
+		return "", false, errors.New("request not found")
+	}
+	if request.IsDone || request.UserID == uuid.Nil {
+		return "", false, nil
+	}
+	for _, m := range request.AMR {
+		if m == AMRPassword {
+			return request.UserID.String(), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// CompleteAuthRequest marks the auth request as authenticated by the user with the given methods
+func (s *Storage) CompleteAuthRequest(ctx context.Context, id string, userID uuid.UUID, amr []string) error {
+	reqid, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	request, err := s.GetAuthRequestByUUID(ctx, reqid)
+	if err != nil {
+		return errors.New("request not found")
+	}
+	// passkey logins skip the password check, not the status of the account
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	client, err := s.GetClientByUUID(ctx, uuid.MustParse(request.GetClientID()))
+	if err != nil {
+		logrus.Error(err)
+		return err
+	}
+	if user.NamespaceID != client.userNamespaceID {
+		return errors.New("the user does not belong to the client")
+	}
+	err = s.checkRegistrationDone(ctx, user)
+	if err != nil {
+		return err
+	}
+	request.UserID = userID
+	request.AMR = amr
+	request.IsDone = true
+	request.AuthTime = time.Now()
+	return s.UpdateAuthRequest(ctx, request)
+}
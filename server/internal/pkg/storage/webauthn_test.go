@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+// webauthnSessionDB keeps the rows of webauthn_session as if they were stored
+// the given time ago
+func webauthnSessionDB(ago time.Duration) *fakeDB {
+	sessions := map[string][]driver.Value{}
+	db := &fakeDB{}
+	db.on("INSERT INTO webauthn_session", func(args []driver.Value) [][]driver.Value {
+		sessions[args[0].(string)] = []driver.Value{args[1], args[2].(time.Time).Add(-ago)}
+		return [][]driver.Value{{}}
+	})
+	db.on("DELETE FROM webauthn_session", func(args []driver.Value) [][]driver.Value {
+		row, ok := sessions[args[0].(string)]
+		if !ok {
+			return nil
+		}
+		delete(sessions, args[0].(string))
+		return [][]driver.Value{row}
+	})
+	return db
+}
+
+func TestWebAuthnSession(t *testing.T) {
+	ctx := context.Background()
+	s := webauthnSessionDB(0).storage()
+	session := &webauthn.SessionData{Challenge: "challenge"}
+
+	if err := s.StoreWebAuthnSession(ctx, "login:1", session); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.TakeWebAuthnSession(ctx, "login:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Challenge != session.Challenge {
+		t.Errorf("challenge %q, want %q", got.Challenge, session.Challenge)
+	}
+	// a challenge is answered once
+	if _, err := s.TakeWebAuthnSession(ctx, "login:1"); err == nil {
+		t.Error("the ceremony was taken twice")
+	}
+	if _, err := s.TakeWebAuthnSession(ctx, "login:2"); err == nil {
+		t.Error("an unknown ceremony was taken")
+	}
+}
+
+func TestWebAuthnSessionExpired(t *testing.T) {
+	ctx := context.Background()
+	s := webauthnSessionDB(webauthnSessionLife + time.Second).storage()
+
+	if err := s.StoreWebAuthnSession(ctx, "register:1", &webauthn.SessionData{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TakeWebAuthnSession(ctx, "register:1"); err == nil {
+		t.Error("an expired ceremony was taken")
+	}
+	// the expired ceremony is removed as well
+	if _, err := s.TakeWebAuthnSession(ctx, "register:1"); err == nil {
+		t.Error("an expired ceremony was taken twice")
+	}
+}
+
+func TestUpdatePasskeyCredentialUsage(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.New()
+	credID := []byte("credential")
+	signCount := int64(1)
+	db := &fakeDB{}
+	db.on("UPDATE webauthn_credential", func(args []driver.Value) [][]driver.Value {
+		if !bytes.Equal(args[3].([]byte), credID) || args[4] != owner.String() {
+			return nil
+		}
+		signCount = args[0].(int64)
+		return [][]driver.Value{{}}
+	})
+	s := db.storage()
+
+	cred := &webauthn.Credential{ID: credID}
+	cred.Authenticator.SignCount = 7
+	// the credential of another user is left alone
+	if err := s.UpdatePasskeyCredentialUsage(ctx, uuid.New(), cred); err != nil {
+		t.Fatal(err)
+	}
+	if signCount != 1 {
+		t.Fatalf("the sign count of another user was set to %d", signCount)
+	}
+	if err := s.UpdatePasskeyCredentialUsage(ctx, owner, cred); err != nil {
+		t.Fatal(err)
+	}
+	if signCount != 7 {
+		t.Errorf("sign count %d, want 7", signCount)
+	}
+}
@@ -0,0 +1,53 @@
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadClients reads a JSON or YAML list of clients
+func ReadClients(r io.Reader, format string) ([]*clientmeta.Client, error) {
+	var clients []*clientmeta.Client
+	switch format {
+	case FormatJSON:
+		d := json.NewDecoder(r)
+		d.DisallowUnknownFields()
+		if err := d.Decode(&clients); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		d := yaml.NewDecoder(r)
+		d.KnownFields(true)
+		if err := d.Decode(&clients); err != nil && err != io.EOF {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("clients can not be read from %q files", format)
+	}
+	return clients, nil
+}
+
+// WriteClients writes a list of clients ReadClients reads back
+func WriteClients(w io.Writer, format string, clients []*clientmeta.Client) error {
+	if clients == nil {
+		clients = []*clientmeta.Client{}
+	}
+	switch format {
+	case FormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(clients)
+	case FormatYAML:
+		e := yaml.NewEncoder(w)
+		e.SetIndent(2)
+		if err := e.Encode(clients); err != nil {
+			return err
+		}
+		return e.Close()
+	}
+	return fmt.Errorf("clients can not be written as %q files", format)
+}
@@ -0,0 +1,82 @@
+// Package transfer reads and writes the files of bulk imports and exports,
+// users as CSV or JSON Lines and clients as JSON or YAML
+package transfer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// FormatOf guesses the format of a file by its extension
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// ContentType of the files of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatYAML:
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionError  = "error"
+)
+
+// Row is the outcome of one user or client of an import
+type Row struct {
+	// Line of the file, or the position in the list of clients
+	Line   int    `json:"line"`
+	Key    string `json:"key"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Result of an import, a dry run reports the same but changes nothing
+type Result struct {
+	DryRun  bool  `json:"dry_run"`
+	Created int   `json:"created"`
+	Updated int   `json:"updated"`
+	Failed  int   `json:"failed"`
+	Rows    []Row `json:"rows"`
+}
+
+func (r *Result) Add(line int, key, action string, err error) {
+	row := Row{Line: line, Key: key, Action: action}
+	switch {
+	case err != nil:
+		row.Action = ActionError
+		row.Error = err.Error()
+		r.Failed++
+	case action == ActionCreate:
+		r.Created++
+	case action == ActionUpdate:
+		r.Updated++
+	}
+	r.Rows = append(r.Rows, row)
+}
@@ -0,0 +1,159 @@
+package transfer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zitadel/oidc/v3/pkg/op"
+	"github.com/zltl/xoidc/server/internal/pkg/clientmeta"
+)
+
+type readRow struct {
+	line int
+	user *User
+	err  error
+}
+
+func readAll(t *testing.T, input, format string) []readRow {
+	t.Helper()
+	var rows []readRow
+	err := ReadUsers(strings.NewReader(input), format, func(line int, u *User, err error) error {
+		rows = append(rows, readRow{line, u, err})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestReadUsersCSV(t *testing.T) {
+	input := "Username,email,email_verified,groups,password_hash\n" +
+		"ann,ann@example.com,true,admins; staff,$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy\n" +
+		"bob,bob@example.com,maybe,,\n" +
+		"cid,,,\n" +
+		"dan,,false,,md5$abc\n"
+	rows := readAll(t, input, FormatCSV)
+	if len(rows) != 4 {
+		t.Fatalf("%d rows", len(rows))
+	}
+	ann := rows[0].user
+	if rows[0].err != nil || rows[0].line != 2 || ann.Username != "ann" || !ann.EmailVerified ||
+		!reflect.DeepEqual(ann.Groups, []string{"admins", "staff"}) || ann.Validate() != nil {
+		t.Errorf("ann: %+v %v", ann, rows[0].err)
+	}
+	if rows[1].err == nil || rows[1].line != 3 {
+		t.Errorf("bob: bad boolean accepted")
+	}
+	if rows[2].err == nil {
+		t.Errorf("cid: missing field accepted")
+	}
+	if rows[3].err != nil || rows[3].user.Validate() == nil {
+		t.Errorf("dan: unknown hash accepted")
+	}
+
+	err := ReadUsers(strings.NewReader("email\nann@example.com\n"), FormatCSV, func(int, *User, error) error { return nil })
+	if err == nil {
+		t.Error("header without username accepted")
+	}
+}
+
+func TestReadUsersJSONL(t *testing.T) {
+	input := `{"username": "ann", "groups": []}` + "\n\n" +
+		`{"username": "bob", "unknown": 1}` + "\n" +
+		`{"username": "cid", "password": "x", "password_hash": "y"}` + "\n"
+	rows := readAll(t, input, FormatJSONL)
+	if len(rows) != 3 {
+		t.Fatalf("%d rows", len(rows))
+	}
+	if rows[0].err != nil || rows[0].user.Groups == nil {
+		t.Errorf("ann: an empty list of groups must clear the memberships: %+v", rows[0].user)
+	}
+	if rows[1].err == nil || rows[1].line != 3 {
+		t.Errorf("bob: unknown field accepted")
+	}
+	if rows[2].err != nil || rows[2].user.Validate() == nil {
+		t.Errorf("cid: password and hash accepted")
+	}
+}
+
+func TestUsersRoundTrip(t *testing.T) {
+	users := []*User{
+		{Username: "ann", Email: "ann@example.com", EmailVerified: true, Status: "active", Groups: []string{"a", "b"}},
+		{Username: "bob", PhoneNumber: "+100", PasswordHash: "pbkdf2_sha256$1000$salt$key", Groups: []string{}},
+	}
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		var buf bytes.Buffer
+		w, err := NewUserWriter(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range users {
+			if err := w.Write(u); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		rows := readAll(t, buf.String(), format)
+		if len(rows) != len(users) {
+			t.Fatalf("%s: %d rows", format, len(rows))
+		}
+		for i, row := range rows {
+			got := row.user
+			if format == FormatJSONL && len(users[i].Groups) == 0 {
+				// omitted, which keeps the memberships
+				got.Groups = []string{}
+			}
+			if row.err != nil || !reflect.DeepEqual(got, users[i]) {
+				t.Errorf("%s: got %+v, want %+v (%v)", format, got, users[i], row.err)
+			}
+		}
+	}
+}
+
+func TestClientsRoundTrip(t *testing.T) {
+	clients := []*clientmeta.Client{{
+		ID:              "b0f3f0d5-6f6e-4d45-9b5c-0a1c0d0a6f10",
+		Name:            "web",
+		UserNamespaceID: "00000000-0000-0000-0000-000000000000",
+		RedirectURIs:    []string{"https://app.example.com/callback"},
+		ApplicationType: op.ApplicationTypeNative,
+		AuthMethod:      "none",
+		ResponseTypes:   []string{"code"},
+		GrantTypes:      []string{"authorization_code", "refresh_token"},
+		AccessTokenType: op.AccessTokenTypeJWT,
+		ClockSkew:       "5s",
+
+		AccessTokenLifetime:      "10m",
+		RefreshTokenIdleLifetime: "720h",
+		RefreshTokens:            "offline_access",
+		AllowedScopes:            []string{"api:*"},
+		Audiences:                []string{"https://api.example.com"},
+		DisallowedScopes:         "reject",
+	}}
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		var buf bytes.Buffer
+		if err := WriteClients(&buf, format, clients); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "native") {
+			t.Errorf("%s: application type is not written by name:\n%s", format, buf.String())
+		}
+		got, err := ReadClients(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, clients) || got[0].Validate() != nil {
+			t.Errorf("%s: got %+v", format, got[0])
+		}
+	}
+
+	_, err := ReadClients(strings.NewReader("- name: web\n  secrett: x\n"), FormatYAML)
+	if err == nil {
+		t.Error("unknown field accepted")
+	}
+}
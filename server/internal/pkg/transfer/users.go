@@ -0,0 +1,307 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/zltl/xoidc/server/pkg/password"
+)
+
+// User is a user of an import or export, keyed on its username in the namespace
+type User struct {
+	Username string `json:"username" yaml:"username"`
+	// Password is a plain password checked against the policy of the namespace
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	// PasswordHash is an argon2id, bcrypt or PBKDF2 hash stored as is
+	PasswordHash        string `json:"password_hash,omitempty" yaml:"password_hash,omitempty"`
+	GivenName           string `json:"given_name,omitempty" yaml:"given_name,omitempty"`
+	FamilyName          string `json:"family_name,omitempty" yaml:"family_name,omitempty"`
+	MiddleName          string `json:"middle_name,omitempty" yaml:"middle_name,omitempty"`
+	Nickname            string `json:"nickname,omitempty" yaml:"nickname,omitempty"`
+	Email               string `json:"email,omitempty" yaml:"email,omitempty"`
+	EmailVerified       bool   `json:"email_verified,omitempty" yaml:"email_verified,omitempty"`
+	PhoneNumber         string `json:"phone_number,omitempty" yaml:"phone_number,omitempty"`
+	PhoneNumberVerified bool   `json:"phone_number_verified,omitempty" yaml:"phone_number_verified,omitempty"`
+	Locale              string `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Zoneinfo            string `json:"zoneinfo,omitempty" yaml:"zoneinfo,omitempty"`
+	// Status keeps the status of existing users if empty
+	Status     string `json:"status,omitempty" yaml:"status,omitempty"`
+	ExternalID string `json:"external_id,omitempty" yaml:"external_id,omitempty"`
+	// Groups are the names of the groups of the user, nil keeps the memberships
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+func (u *User) Validate() error {
+	if u.Username == "" {
+		return errors.New("username is required")
+	}
+	if u.Password != "" && u.PasswordHash != "" {
+		return errors.New("password and password_hash are exclusive")
+	}
+	if u.PasswordHash != "" && !password.Supported(u.PasswordHash) {
+		return password.ErrUnknownHash
+	}
+	return nil
+}
+
+// the columns of CSV files, the header names them in any order
+var userColumns = []string{
+	"username",
+	"password",
+	"password_hash",
+	"given_name",
+	"family_name",
+	"middle_name",
+	"nickname",
+	"email",
+	"email_verified",
+	"phone_number",
+	"phone_number_verified",
+	"locale",
+	"zoneinfo",
+	"status",
+	"external_id",
+	"groups",
+}
+
+// groups of a CSV cell are separated by this
+const groupSeparator = ";"
+
+func (u *User) field(column string) *string {
+	switch column {
+	case "username":
+		return &u.Username
+	case "password":
+		return &u.Password
+	case "password_hash":
+		return &u.PasswordHash
+	case "given_name":
+		return &u.GivenName
+	case "family_name":
+		return &u.FamilyName
+	case "middle_name":
+		return &u.MiddleName
+	case "nickname":
+		return &u.Nickname
+	case "email":
+		return &u.Email
+	case "phone_number":
+		return &u.PhoneNumber
+	case "locale":
+		return &u.Locale
+	case "zoneinfo":
+		return &u.Zoneinfo
+	case "status":
+		return &u.Status
+	case "external_id":
+		return &u.ExternalID
+	}
+	return nil
+}
+
+func (u *User) setColumn(column, value string) error {
+	switch column {
+	case "email_verified", "phone_number_verified":
+		b := false
+		if value != "" {
+			var err error
+			b, err = strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a boolean", column, value)
+			}
+		}
+		if column == "email_verified" {
+			u.EmailVerified = b
+		} else {
+			u.PhoneNumberVerified = b
+		}
+	case "groups":
+		u.Groups = []string{}
+		for _, g := range strings.Split(value, groupSeparator) {
+			if g = strings.TrimSpace(g); g != "" {
+				u.Groups = append(u.Groups, g)
+			}
+		}
+	default:
+		*u.field(column) = value
+	}
+	return nil
+}
+
+func (u *User) column(column string) string {
+	switch column {
+	case "email_verified":
+		return strconv.FormatBool(u.EmailVerified)
+	case "phone_number_verified":
+		return strconv.FormatBool(u.PhoneNumberVerified)
+	case "groups":
+		return strings.Join(u.Groups, groupSeparator)
+	}
+	return *u.field(column)
+}
+
+// ReadUsers calls fn with the users of a CSV or JSON Lines file in order.
+// Rows that can not be parsed are passed with their error, fn decides to go on
+// or to stop by returning an error.
+func ReadUsers(r io.Reader, format string, fn func(line int, u *User, err error) error) error {
+	switch format {
+	case FormatCSV:
+		return readUsersCSV(r, fn)
+	case FormatJSONL:
+		return readUsersJSONL(r, fn)
+	}
+	return fmt.Errorf("users can not be read from %q files", format)
+}
+
+func readUsersCSV(r io.Reader, fn func(line int, u *User, err error) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("header: %w", err)
+	}
+	known := map[string]bool{}
+	for _, c := range userColumns {
+		known[c] = true
+	}
+	hasUsername := false
+	for i, c := range header {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !known[c] {
+			return fmt.Errorf("header: unknown column %q", c)
+		}
+		hasUsername = hasUsername || c == "username"
+		header[i] = c
+	}
+	if !hasUsername {
+		return errors.New("header: the username column is required")
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(parseErr.StartLine, nil, parseErr.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(header) {
+			err = fmt.Errorf("%d fields, the header has %d", len(record), len(header))
+			if err := fn(line, nil, err); err != nil {
+				return err
+			}
+			continue
+		}
+		u := &User{}
+		for i, c := range header {
+			err = u.setColumn(c, record[i])
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			u = nil
+		}
+		if err := fn(line, u, err); err != nil {
+			return err
+		}
+	}
+}
+
+// lines of JSON Lines files may be this long
+const maxLine = 1 << 20
+
+func readUsersJSONL(r io.Reader, fn func(line int, u *User, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for line := 1; scanner.Scan(); line++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		u := &User{}
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err := d.Decode(u)
+		if err != nil {
+			u = nil
+		}
+		if err := fn(line, u, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// UserWriter writes the users of an export
+type UserWriter interface {
+	Write(u *User) error
+	// Flush ends the file
+	Flush() error
+}
+
+func NewUserWriter(w io.Writer, format string) (UserWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvUserWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		return &jsonlUserWriter{e: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("users can not be written as %q files", format)
+}
+
+type csvUserWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvUserWriter) Write(u *User) error {
+	if !c.header {
+		c.header = true
+		err := c.w.Write(userColumns)
+		if err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(userColumns))
+	for i, column := range userColumns {
+		record[i] = u.column(column)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvUserWriter) Flush() error {
+	if !c.header {
+		c.header = true
+		_ = c.w.Write(userColumns)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlUserWriter struct {
+	e *json.Encoder
+}
+
+func (j *jsonlUserWriter) Write(u *User) error {
+	return j.e.Encode(u)
+}
+
+func (j *jsonlUserWriter) Flush() error {
+	return nil
+}
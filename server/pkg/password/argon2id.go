@@ -1,11 +1,15 @@
 package password
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/alexedwards/argon2id"
 )
 
 // https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html
 
+// DefaultParams are used by CreateHash, hashes with weaker params are rehashed on login
 var DefaultParams = &argon2id.Params{
 	Memory:      19 * 1024,
 	Iterations:  2,
@@ -14,12 +18,49 @@ var DefaultParams = &argon2id.Params{
 	KeyLength:   32,
 }
 
-// argon2id
-func ComparePasswordAndHash(password, hash string) (match bool, err error) {
-	return argon2id.ComparePasswordAndHash(password, hash)
+// ParseParams reads argon2id params in the form of the hash, "m=19456,t=2,p=1".
+// Salt and key length keep the values of DefaultParams.
+func ParseParams(s string) (*argon2id.Params, error) {
+	p := *DefaultParams
+	_, err := fmt.Sscanf(strings.TrimSpace(s), "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
+	if err != nil {
+		return nil, fmt.Errorf("invalid argon2id params %q: %w", s, err)
+	}
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return nil, fmt.Errorf("invalid argon2id params %q: values must be positive", s)
+	}
+	return &p, nil
 }
 
 // argon2id
 func CreateHash(password string) (hash string, err error) {
 	return argon2id.CreateHash(password, DefaultParams)
 }
+
+type argon2idHasher struct{}
+
+func (argon2idHasher) Name() string {
+	return "argon2id"
+}
+
+func (argon2idHasher) Detect(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (argon2idHasher) Compare(password, hash string) (bool, error) {
+	return argon2id.ComparePasswordAndHash(password, hash)
+}
+
+func (argon2idHasher) Outdated(hash string) bool {
+	p, salt, key, err := argon2id.DecodeHash(hash)
+	if err != nil {
+		return true
+	}
+	// more lanes are not weaker, but a changed parallelism is rehashed
+	// like any other changed param
+	return p.Memory < DefaultParams.Memory ||
+		p.Iterations < DefaultParams.Iterations ||
+		p.Parallelism != DefaultParams.Parallelism ||
+		uint32(len(salt)) < DefaultParams.SaltLength ||
+		uint32(len(key)) < DefaultParams.KeyLength
+}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptHasher checks the $2a$, $2b$ and $2y$ hashes of legacy systems
+type bcryptHasher struct{}
+
+func (bcryptHasher) Name() string {
+	return "bcrypt"
+}
+
+func (bcryptHasher) Detect(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+func (bcryptHasher) Compare(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (bcryptHasher) Outdated(hash string) bool {
+	return true
+}
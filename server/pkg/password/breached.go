@@ -0,0 +1,68 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedList is a local set of known breached passwords
+type BreachedList struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+// LoadBreachedList reads a file with one entry per line. An entry is either a
+// plain password or a SHA-1 hash in hex, optionally followed by ":<count>" as
+// in the downloads of haveibeenpwned.com.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBreachedList(f)
+}
+
+func ReadBreachedList(r io.Reader) (*BreachedList, error) {
+	l := &BreachedList{
+		hashes: make(map[[sha1.Size]byte]struct{}),
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entry, _, _ := strings.Cut(line, ":")
+		var sum [sha1.Size]byte
+		if b, err := hex.DecodeString(entry); err == nil && len(b) == sha1.Size {
+			copy(sum[:], b)
+		} else {
+			sum = sha1.Sum([]byte(line))
+		}
+		l.hashes[sum] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Contains reports if the password is in the list, a nil list contains nothing
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.hashes[sha1.Sum([]byte(password))]
+	return ok
+}
+
+func (l *BreachedList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.hashes)
+}
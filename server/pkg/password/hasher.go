@@ -0,0 +1,76 @@
+package password
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrUnknownHash is returned for hashes no registered hasher understands
+var ErrUnknownHash = errors.New("password hash algorithm not supported")
+
+// Hasher verifies password hashes of one algorithm
+type Hasher interface {
+	// Name of the algorithm, e.g. argon2id
+	Name() string
+	// Detect reports if the hash was created by this algorithm, usually by its prefix
+	Detect(hash string) bool
+	Compare(password, hash string) (match bool, err error)
+	// Outdated reports if the hash should be replaced by a hash of the current algorithm and params
+	Outdated(hash string) bool
+}
+
+var (
+	hashersLock sync.RWMutex
+	hashers     []Hasher
+)
+
+// RegisterHasher adds a hasher, e.g. for the hashes of a legacy system.
+// Hashes are checked by the first hasher detecting them.
+func RegisterHasher(h Hasher) {
+	hashersLock.Lock()
+	defer hashersLock.Unlock()
+	hashers = append(hashers, h)
+}
+
+func init() {
+	RegisterHasher(argon2idHasher{})
+	RegisterHasher(bcryptHasher{})
+	RegisterHasher(pbkdf2Hasher{})
+}
+
+func detect(hash string) (Hasher, error) {
+	hashersLock.RLock()
+	defer hashersLock.RUnlock()
+	for _, h := range hashers {
+		if h.Detect(hash) {
+			return h, nil
+		}
+	}
+	return nil, ErrUnknownHash
+}
+
+// ComparePasswordAndHash checks the password with the algorithm of the hash
+func ComparePasswordAndHash(password, hash string) (match bool, err error) {
+	h, err := detect(hash)
+	if err != nil {
+		return false, err
+	}
+	return h.Compare(password, hash)
+}
+
+// NeedsRehash reports if a hash should be replaced after a successful login,
+// because it is of another algorithm or weaker params than CreateHash uses
+func NeedsRehash(hash string) bool {
+	h, err := detect(hash)
+	if err != nil {
+		return true
+	}
+	return h.Outdated(hash)
+}
+
+// Supported reports if a hash of another system can be stored as is,
+// e.g. for imports of argon2id, bcrypt or PBKDF2 hashes
+func Supported(hash string) bool {
+	_, err := detect(hash)
+	return err == nil
+}
@@ -0,0 +1,80 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/alexedwards/argon2id"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCompareLegacyHashes(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := []string{
+		string(bcryptHash),
+		"pbkdf2_sha256$1000$seasalt123$KuEnssc6S4MzVSS8Tu48m1RDSrTAn7j3CfgvvjkvfWA=",
+		"$pbkdf2-sha256$1000$IbJoChuO2v02vMClh312lw$z0RMOXk5zFjtAgFySgnEWZLt55o0y46IuiMmYMrL3rM",
+	}
+	for _, hash := range hashes {
+		match, err := ComparePasswordAndHash("correct horse", hash)
+		if err != nil || !match {
+			t.Errorf("%s: got match=%v err=%v", hash, match, err)
+		}
+		match, err = ComparePasswordAndHash("wrong horse", hash)
+		if err != nil || match {
+			t.Errorf("%s: wrong password got match=%v err=%v", hash, match, err)
+		}
+		if !NeedsRehash(hash) {
+			t.Errorf("%s: legacy hash not outdated", hash)
+		}
+	}
+
+	if _, err := ComparePasswordAndHash("x", "md5$abc"); err != ErrUnknownHash {
+		t.Errorf("unknown hash: got %v", err)
+	}
+}
+
+func TestNeedsRehashArgon2id(t *testing.T) {
+	current, err := CreateHash("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NeedsRehash(current) {
+		t.Error("hash with current params is outdated")
+	}
+
+	weak := *DefaultParams
+	weak.Iterations = 1
+	old, err := argon2id.CreateHash("123456", &weak)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NeedsRehash(old) {
+		t.Error("hash with weaker params is not outdated")
+	}
+
+	other := *DefaultParams
+	other.Parallelism = DefaultParams.Parallelism + 1
+	old, err = argon2id.CreateHash("123456", &other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NeedsRehash(old) {
+		t.Error("hash with other parallelism is not outdated")
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	p, err := ParseParams("m=65536,t=3,p=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Memory != 65536 || p.Iterations != 3 || p.Parallelism != 4 || p.KeyLength != DefaultParams.KeyLength {
+		t.Errorf("got %+v", p)
+	}
+	if _, err := ParseParams("m=0,t=1,p=1"); err == nil {
+		t.Error("zero memory accepted")
+	}
+}
@@ -0,0 +1,74 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var errInvalidPBKDF2Hash = errors.New("pbkdf2: hash is not in the correct format")
+
+// pbkdf2Hasher checks PBKDF2-SHA256 hashes of legacy systems in the formats
+//
+//	pbkdf2_sha256$<iterations>$<salt>$<base64 key>       (Django)
+//	$pbkdf2-sha256$<iterations>$<ab64 salt>$<ab64 key>   (passlib)
+type pbkdf2Hasher struct{}
+
+func (pbkdf2Hasher) Name() string {
+	return "pbkdf2-sha256"
+}
+
+func (pbkdf2Hasher) Detect(hash string) bool {
+	return strings.HasPrefix(hash, "pbkdf2_sha256$") ||
+		strings.HasPrefix(hash, "$pbkdf2-sha256$")
+}
+
+func (pbkdf2Hasher) Compare(password, hash string) (bool, error) {
+	iterations, salt, key, err := decodePBKDF2(hash)
+	if err != nil {
+		return false, err
+	}
+	other := pbkdf2.Key([]byte(password), salt, iterations, len(key), sha256.New)
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (pbkdf2Hasher) Outdated(hash string) bool {
+	return true
+}
+
+func decodePBKDF2(hash string) (iterations int, salt, key []byte, err error) {
+	var parts []string
+	// passlib uses an adapted base64 with '.' instead of '+' and no padding
+	ab64 := base64.RawStdEncoding
+	if strings.HasPrefix(hash, "$") {
+		parts = strings.Split(strings.TrimPrefix(hash, "$"), "$")
+		if len(parts) != 4 {
+			return 0, nil, nil, errInvalidPBKDF2Hash
+		}
+		salt, err = ab64.DecodeString(strings.ReplaceAll(parts[2], ".", "+"))
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		key, err = ab64.DecodeString(strings.ReplaceAll(parts[3], ".", "+"))
+	} else {
+		parts = strings.Split(hash, "$")
+		if len(parts) != 4 {
+			return 0, nil, nil, errInvalidPBKDF2Hash
+		}
+		salt = []byte(parts[2])
+		key, err = base64.StdEncoding.DecodeString(parts[3])
+	}
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	iterations, err = strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 || len(key) == 0 {
+		return 0, nil, nil, errInvalidPBKDF2Hash
+	}
+	return iterations, salt, key, nil
+}
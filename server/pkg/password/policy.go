@@ -0,0 +1,91 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Policy is what a namespace requires from the passwords of its users
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MaxAge forces a change of older passwords, 0 never expires
+	MaxAge time.Duration
+	// History is the number of last passwords which must not be reused
+	History int
+	// CheckBreached rejects passwords of the breached password list
+	CheckBreached bool
+}
+
+// DefaultPolicy applies to namespaces without an own policy
+var DefaultPolicy = Policy{
+	MinLength:     8,
+	CheckBreached: true,
+}
+
+var (
+	ErrReused  = errors.New("password was used recently, choose another one")
+	ErrExpired = errors.New("password expired, choose a new one")
+)
+
+// PolicyError lists every rule a candidate password breaks
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// Validate checks the candidate against the rules that do not need the password history
+func (p *Policy) Validate(candidate string, breached *BreachedList) error {
+	var violations []string
+	if len([]rune(candidate)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range candidate {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "password must contain an upper case letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "password must contain a lower case letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "password must contain a symbol")
+	}
+	if p.CheckBreached && breached.Contains(candidate) {
+		violations = append(violations, "password appears in a list of breached passwords")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Expired reports if a password changed at the given time must be changed now
+func (p *Policy) Expired(changed time.Time) bool {
+	return p.MaxAge > 0 && time.Since(changed) > p.MaxAge
+}
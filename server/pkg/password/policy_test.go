@@ -0,0 +1,68 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	breached, err := ReadBreachedList(strings.NewReader(
+		"Password1!\n" +
+			// sha1 of "123456" in the haveibeenpwned format
+			"7C4A8D09CA3762AF61E59520943DC26494F8941B:37359195\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Policy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		CheckBreached: true,
+	}
+
+	tests := []struct {
+		candidate  string
+		violations int
+	}{
+		{"Correct-Horse-9", 0},
+		{"short", 4},
+		{"alllowercase", 3},
+		{"Password1!", 1},
+		{"123456", 5},
+	}
+	for _, tt := range tests {
+		err := p.Validate(tt.candidate, breached)
+		var perr *PolicyError
+		if tt.violations == 0 {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.candidate, err)
+			}
+			continue
+		}
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: got %v, want a PolicyError", tt.candidate, err)
+			continue
+		}
+		if len(perr.Violations) != tt.violations {
+			t.Errorf("%q: got violations %q, want %d", tt.candidate, perr.Violations, tt.violations)
+		}
+	}
+}
+
+func TestPolicyExpired(t *testing.T) {
+	p := Policy{MaxAge: time.Hour}
+	if p.Expired(time.Now()) {
+		t.Error("fresh password expired")
+	}
+	if !p.Expired(time.Now().Add(-2 * time.Hour)) {
+		t.Error("old password not expired")
+	}
+	if (&Policy{}).Expired(time.Time{}) {
+		t.Error("password expired without max age")
+	}
+}
@@ -20,6 +20,44 @@ SET default_tablespace = '';
 
 SET default_table_access_method = heap;
 
+--
+-- Name: api_resource; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.api_resource (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    namespace_id uuid NOT NULL,
+    identifier text DEFAULT ''::text NOT NULL,
+    name character varying(200) DEFAULT ''::character varying NOT NULL,
+    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    access_token_type integer DEFAULT 0 NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.api_resource OWNER TO postgres;
+
+--
+-- Name: COLUMN api_resource.identifier; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.api_resource.identifier IS 'absolute uri clients pass as resource parameter, the audience of the tokens for the api';
+
+
+--
+-- Name: COLUMN api_resource.scopes; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.api_resource.scopes IS 'scopes of the api, clients which may request the api may request them';
+
+
+--
+-- Name: COLUMN api_resource.access_token_type; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.api_resource.access_token_type IS 'preferred format of access tokens for the api, 0 bearer, 1 jwt';
+
+
 --
 -- Name: auth_request; Type: TABLE; Schema: public; Owner: postgres
 --
@@ -31,12 +69,173 @@ CREATE TABLE public.auth_request (
     auth_time timestamp with time zone DEFAULT now() NOT NULL,
     content text DEFAULT ''::text NOT NULL,
     namespace_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
-    user_id uuid NOT NULL
+    user_id uuid NOT NULL,
+    amr character varying(20)[] DEFAULT '{}'::character varying[] NOT NULL,
+    audience text[] DEFAULT '{}'::text[] NOT NULL,
+    resources text[] DEFAULT '{}'::text[] NOT NULL,
+    request_uri character varying(200) DEFAULT ''::character varying NOT NULL,
+    authorization_details text DEFAULT ''::text NOT NULL,
+    details_approved boolean DEFAULT false NOT NULL,
+    session_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL
 );
 
 
 ALTER TABLE public.auth_request OWNER TO postgres;
 
+--
+-- Name: COLUMN auth_request.session_id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.auth_request.session_id IS 'user_session of the login, the sid of the id tokens';
+
+
+--
+-- Name: COLUMN auth_request.details_approved; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.auth_request.details_approved IS 'the user approved the authorization_details on the consent screen';
+
+
+--
+-- Name: COLUMN auth_request.authorization_details; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.auth_request.authorization_details IS 'authorization_details of the request as JSON array, empty for none';
+
+
+--
+-- Name: COLUMN auth_request.request_uri; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.auth_request.request_uri IS 'request_uri of a pushed authorization request (RFC 9126), empty for others';
+
+
+--
+-- Name: COLUMN auth_request.resources; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.auth_request.resources IS 'resource parameters of the request, the audience of its tokens if not empty';
+
+
+--
+-- Name: COLUMN auth_request.audience; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.auth_request.audience IS 'audiences of the tokens besides the client';
+
+
+--
+-- Name: authorization_details_type; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.authorization_details_type (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    namespace_id uuid NOT NULL,
+    type character varying(200) DEFAULT ''::character varying NOT NULL,
+    description text DEFAULT ''::text NOT NULL,
+    schema text DEFAULT '{}'::text NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.authorization_details_type OWNER TO postgres;
+
+--
+-- Name: COLUMN authorization_details_type.type; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.authorization_details_type.type IS 'the type field of authorization_details entries (RFC 9396)';
+
+
+--
+-- Name: COLUMN authorization_details_type.schema; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.authorization_details_type.schema IS 'JSON schema the entries of the type must match';
+
+
+--
+-- Name: backchannel_auth_request; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.backchannel_auth_request (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    client_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    binding_message character varying(200) DEFAULT ''::character varying NOT NULL,
+    client_notification_token character varying(1024) DEFAULT ''::character varying NOT NULL,
+    status character varying(20) DEFAULT 'pending'::character varying NOT NULL,
+    amr character varying(20)[] DEFAULT '{}'::character varying[] NOT NULL,
+    auth_time timestamp with time zone DEFAULT now() NOT NULL,
+    poll_interval integer DEFAULT 5 NOT NULL,
+    last_poll_time timestamp with time zone DEFAULT '1970-01-01 00:00:00+00'::timestamp with time zone NOT NULL,
+    expiration timestamp with time zone NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.backchannel_auth_request OWNER TO postgres;
+
+--
+-- Name: COLUMN backchannel_auth_request.id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.backchannel_auth_request.id IS 'the auth_req_id of the client initiated backchannel authentication request';
+
+
+--
+-- Name: COLUMN backchannel_auth_request.status; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.backchannel_auth_request.status IS 'pending, approved, denied or issued';
+
+
+--
+-- Name: COLUMN backchannel_auth_request.client_notification_token; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.backchannel_auth_request.client_notification_token IS 'bearer token of the ping and push callbacks to the client';
+
+
+--
+-- Name: COLUMN backchannel_auth_request.poll_interval; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.backchannel_auth_request.poll_interval IS 'seconds the client waits between token requests';
+
+
+--
+-- Name: backchannel_logout; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.backchannel_logout (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    client_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    session_id uuid NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    next_attempt_time timestamp with time zone DEFAULT now() NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.backchannel_logout OWNER TO postgres;
+
+--
+-- Name: COLUMN backchannel_logout.id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.backchannel_logout.id IS 'logout token to post to the backchannel_logout_uri of the client, retried until it is accepted';
+
+
+--
+-- Name: COLUMN backchannel_logout.next_attempt_time; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.backchannel_logout.next_attempt_time IS 'when the token is posted next, moves back after each failed attempt';
+
+
 --
 -- Name: client; Type: TABLE; Schema: public; Owner: postgres
 --
@@ -56,216 +255,1750 @@ CREATE TABLE public.client (
     redirect_uri_globs text[] DEFAULT '{}'::text[] NOT NULL,
     user_namespace_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
     grant_types character varying[] DEFAULT '{}'::character varying[] NOT NULL,
-    name character varying(200) DEFAULT ''::character varying NOT NULL
+    name character varying(200) DEFAULT ''::character varying NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    registration_token_hash character varying(100) DEFAULT ''::character varying NOT NULL,
+    registration_metadata jsonb DEFAULT '{}'::jsonb NOT NULL,
+    access_token_lifetime interval(6) DEFAULT '00:00:00'::interval(6) NOT NULL,
+    id_token_lifetime interval(6) DEFAULT '00:00:00'::interval(6) NOT NULL,
+    refresh_token_lifetime interval(6) DEFAULT '00:00:00'::interval(6) NOT NULL,
+    refresh_token_idle_lifetime interval(6) DEFAULT '00:00:00'::interval(6) NOT NULL,
+    refresh_tokens character varying(40) DEFAULT ''::character varying NOT NULL,
+    allowed_scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    default_scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    audiences text[] DEFAULT '{}'::text[] NOT NULL,
+    disallowed_scopes character varying(40) DEFAULT 'downscope'::character varying NOT NULL,
+    resources text[] DEFAULT '{}'::text[] NOT NULL,
+    require_pushed_authorization_requests boolean DEFAULT false NOT NULL,
+    response_modes character varying(40)[] DEFAULT '{}'::character varying[] NOT NULL,
+    authorization_encrypted_response_alg character varying(40) DEFAULT ''::character varying NOT NULL,
+    authorization_encrypted_response_enc character varying(40) DEFAULT ''::character varying NOT NULL,
+    jwks text DEFAULT ''::text NOT NULL,
+    dpop_bound_access_tokens boolean DEFAULT false NOT NULL,
+    tls_client_auth_subject_dn character varying(500) DEFAULT ''::character varying NOT NULL,
+    tls_client_auth_san_dns character varying(500) DEFAULT ''::character varying NOT NULL,
+    tls_client_auth_san_uri character varying(500) DEFAULT ''::character varying NOT NULL,
+    tls_client_auth_san_ip character varying(500) DEFAULT ''::character varying NOT NULL,
+    tls_client_auth_san_email character varying(500) DEFAULT ''::character varying NOT NULL,
+    tls_client_certificate_bound_access_tokens boolean DEFAULT false NOT NULL,
+    backchannel_token_delivery_mode character varying(10) DEFAULT ''::character varying NOT NULL,
+    backchannel_client_notification_endpoint character varying(500) DEFAULT ''::character varying NOT NULL,
+    frontchannel_logout_uri character varying(500) DEFAULT ''::character varying NOT NULL,
+    frontchannel_logout_session_required boolean DEFAULT false NOT NULL,
+    backchannel_logout_uri character varying(500) DEFAULT ''::character varying NOT NULL,
+    backchannel_logout_session_required boolean DEFAULT false NOT NULL
 );
 
 
 ALTER TABLE public.client OWNER TO postgres;
 
 --
--- Name: COLUMN client.application_type; Type: COMMENT; Schema: public; Owner: postgres
+-- Name: COLUMN client.backchannel_logout_session_required; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COMMENT ON COLUMN public.client.application_type IS '0: web
-1: user_agent
-2: native';
+COMMENT ON COLUMN public.client.backchannel_logout_session_required IS 'the logout tokens must have the sid claim';
 
 
 --
--- Name: COLUMN client.auth_method; Type: COMMENT; Schema: public; Owner: postgres
+-- Name: COLUMN client.backchannel_logout_uri; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COMMENT ON COLUMN public.client.auth_method IS 'client_secret_basic
-client_secret_post
-none
-private_key_jwt';
+COMMENT ON COLUMN public.client.backchannel_logout_uri IS 'logout tokens are posted here when the user signs out, empty for none';
 
 
 --
--- Name: COLUMN client.access_token_type; Type: COMMENT; Schema: public; Owner: postgres
+-- Name: COLUMN client.frontchannel_logout_session_required; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COMMENT ON COLUMN public.client.access_token_type IS '0: bearer
-1:jwt';
+COMMENT ON COLUMN public.client.frontchannel_logout_session_required IS 'the frontchannel_logout_uri gets the iss and sid parameters';
 
 
 --
--- Name: code_request_id; Type: TABLE; Schema: public; Owner: postgres
+-- Name: COLUMN client.frontchannel_logout_uri; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-CREATE TABLE public.code_request_id (
-    code character varying(256) NOT NULL,
-    request_id uuid NOT NULL,
-    create_time timestamp(3) with time zone DEFAULT now() NOT NULL
-);
+COMMENT ON COLUMN public.client.frontchannel_logout_uri IS 'rendered in an iframe when the user signs out, empty for none';
 
 
-ALTER TABLE public.code_request_id OWNER TO postgres;
+--
+-- Name: COLUMN client.backchannel_client_notification_endpoint; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.backchannel_client_notification_endpoint IS 'ciba: where ping and push callbacks go';
+
 
 --
--- Name: refresh_token; Type: TABLE; Schema: public; Owner: postgres
+-- Name: COLUMN client.backchannel_token_delivery_mode; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-CREATE TABLE public.refresh_token (
-    id character varying(200) DEFAULT ''::character varying NOT NULL,
-    token text DEFAULT ''::text NOT NULL,
-    auth_time timestamp(3) without time zone DEFAULT now() NOT NULL,
-    amr character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
-    audience character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
-    user_id character varying(200) DEFAULT ''::character varying NOT NULL,
-    application_id character varying(200) DEFAULT ''::character varying NOT NULL,
-    expiration timestamp(3) without time zone DEFAULT now() NOT NULL,
-    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL
-);
+COMMENT ON COLUMN public.client.backchannel_token_delivery_mode IS 'ciba: poll, ping or push, empty if the client does not use ciba';
 
 
-ALTER TABLE public.refresh_token OWNER TO postgres;
+--
+-- Name: COLUMN client.tls_client_certificate_bound_access_tokens; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.tls_client_certificate_bound_access_tokens IS 'access tokens are bound to the certificate the client authenticated with';
+
 
 --
--- Name: token; Type: TABLE; Schema: public; Owner: postgres
+-- Name: COLUMN client.tls_client_auth_san_email; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-CREATE TABLE public.token (
-    id character varying(200) DEFAULT ''::character varying NOT NULL,
-    audience character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
-    expiration timestamp(3) without time zone DEFAULT now() NOT NULL,
-    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
-    application_id uuid DEFAULT gen_random_uuid() NOT NULL,
-    subject uuid DEFAULT gen_random_uuid() NOT NULL,
-    refresh_token_id uuid DEFAULT gen_random_uuid() NOT NULL
-);
+COMMENT ON COLUMN public.client.tls_client_auth_san_email IS 'tls_client_auth: an rfc822Name SAN of the certificate of the client';
 
 
-ALTER TABLE public.token OWNER TO postgres;
+--
+-- Name: COLUMN client.tls_client_auth_san_ip; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.tls_client_auth_san_ip IS 'tls_client_auth: an iPAddress SAN of the certificate of the client';
+
 
 --
--- Name: user; Type: TABLE; Schema: public; Owner: postgres
+-- Name: COLUMN client.tls_client_auth_san_uri; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-CREATE TABLE public."user" (
-    username character varying(200) DEFAULT ''::character varying NOT NULL,
-    password text DEFAULT ''::text NOT NULL,
-    nickname character varying(200) DEFAULT ''::character varying NOT NULL,
-    given_name character varying(200) DEFAULT ''::character varying NOT NULL,
-    family_name character varying(200) DEFAULT ''::character varying NOT NULL,
-    middle_name character varying(200) DEFAULT ''::character varying NOT NULL,
-    preferred_username character varying(200) DEFAULT ''::character varying NOT NULL,
-    profile text DEFAULT ''::text NOT NULL,
-    picture text DEFAULT ''::text NOT NULL,
-    website text DEFAULT ''::text NOT NULL,
-    email character varying(200) DEFAULT ''::character varying NOT NULL,
-    email_verified boolean DEFAULT false NOT NULL,
-    gender character varying(40) DEFAULT ''::character varying NOT NULL,
-    birthdate date DEFAULT now() NOT NULL,
-    zoneinfo character varying(40) DEFAULT ''::character varying NOT NULL,
-    locale character varying(60) DEFAULT ''::character varying NOT NULL,
-    phone_number character varying(100) DEFAULT ''::character varying NOT NULL,
-    phone_number_verified boolean DEFAULT false NOT NULL,
-    address character varying(200) DEFAULT ''::character varying NOT NULL,
-    updated_at timestamp with time zone DEFAULT now() NOT NULL,
-    namespace_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
-    id uuid DEFAULT gen_random_uuid() NOT NULL
-);
+COMMENT ON COLUMN public.client.tls_client_auth_san_uri IS 'tls_client_auth: a uniformResourceIdentifier SAN of the certificate of the client';
 
 
-ALTER TABLE public."user" OWNER TO postgres;
+--
+-- Name: COLUMN client.tls_client_auth_san_dns; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.tls_client_auth_san_dns IS 'tls_client_auth: a dNSName SAN of the certificate of the client';
+
 
 --
--- Data for Name: auth_request; Type: TABLE DATA; Schema: public; Owner: postgres
+-- Name: COLUMN client.tls_client_auth_subject_dn; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COPY public.auth_request (id, creation_date, done, auth_time, content, namespace_id, user_id) FROM stdin;
-30fe0ae9-d940-4d2a-a4d8-8c539622104e	2023-11-26 07:06:05.95332+00	f	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"64a85d42-e863-4407-a923-5af760bec3a2","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	00000000-0000-0000-0000-000000000000
-5f141e2c-4bfb-449f-b082-21752c4080f9	2023-12-02 09:36:45.410367+00	t	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"e37f7a11-c7a7-47b5-85d9-adcde28bd31a","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f
-7537efeb-31d8-41f6-a92f-c9f1567cc347	2023-11-26 06:53:34.610723+00	t	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"e0dc027f-7422-4ce0-94c6-482015208e83","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f
-f8e80ace-06e0-4b73-9a20-e7f873a588f7	2023-12-02 11:20:43.741457+00	t	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"3324b9ad-bafc-4880-b294-f797dd706b8d","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f
-22a55ec0-0171-44f8-85b5-99bdfcfc9318	2023-12-02 09:45:47.652939+00	f	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"4d678a56-c938-425f-9a50-3287e8728ee5","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	00000000-0000-0000-0000-000000000000
-\.
+COMMENT ON COLUMN public.client.tls_client_auth_subject_dn IS 'tls_client_auth: the subject DN of the certificate of the client';
 
 
 --
--- Data for Name: client; Type: TABLE DATA; Schema: public; Owner: postgres
+-- Name: COLUMN client.dpop_bound_access_tokens; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COPY public.client (id, secret, redirect_uris, application_type, auth_method, response_types, access_token_type, dev_mode, id_token_user_info_claims_assertion, clock_skew, post_logout_redirect_uri_globs, redirect_uri_globs, user_namespace_id, grant_types, name) FROM stdin;
-674fc25c-7772-45e3-835d-3b77b16a2937	123456	{custom://auth/callback,http://localhost:9999/auth/callback,http://localhost/auth/callback}	0	client_secret_basic	{code}	0	t	t	01:05:00	{}	{}	00000000-0000-0000-0000-000000000000	{authorization_code,refresh_token,urn:ietf:params:oauth:grant-type:token-exchange}	
-\.
+COMMENT ON COLUMN public.client.dpop_bound_access_tokens IS 'the client must send DPoP proofs, its access tokens are always DPoP bound';
 
 
 --
--- Data for Name: code_request_id; Type: TABLE DATA; Schema: public; Owner: postgres
+-- Name: COLUMN client.jwks; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COPY public.code_request_id (code, request_id, create_time) FROM stdin;
-s2o3ld5MBb9AaB3vKlWPDo9EnfoGwgqMgHhwEnzCovgpZ9rqgQMFhr44W4Hh-lOFsiVfUw	5f141e2c-4bfb-449f-b082-21752c4080f9	2023-12-02 09:36:50.965+00
-r2dx_0-YIsxVCD7uNisPnKbQtMCfzu89tqIGLLu2QsdnQaGq0qDs0WDWJCoQKpUORGcPNg	f8e80ace-06e0-4b73-9a20-e7f873a588f7	2023-12-02 11:20:52.255+00
-\.
+COMMENT ON COLUMN public.client.jwks IS 'public keys of the client as json web key set';
 
 
 --
--- Data for Name: refresh_token; Type: TABLE DATA; Schema: public; Owner: postgres
+-- Name: COLUMN client.authorization_encrypted_response_enc; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COPY public.refresh_token (id, token, auth_time, amr, audience, user_id, application_id, expiration, scopes) FROM stdin;
-\.
+COMMENT ON COLUMN public.client.authorization_encrypted_response_enc IS 'content encryption of encrypted jwt authorization responses, empty for A128CBC-HS256';
 
 
 --
--- Data for Name: token; Type: TABLE DATA; Schema: public; Owner: postgres
+-- Name: COLUMN client.authorization_encrypted_response_alg; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COPY public.token (id, audience, expiration, scopes, application_id, subject, refresh_token_id) FROM stdin;
-78488baa-54f7-464e-ac64-a953d5cb182c	{674fc25c-7772-45e3-835d-3b77b16a2937}	2023-12-02 19:28:54.796	{openid,profile}	674fc25c-7772-45e3-835d-3b77b16a2937	744d9044-f29d-42e8-a65e-e6c52398fa1f	00000000-0000-0000-0000-000000000000
-\.
+COMMENT ON COLUMN public.client.authorization_encrypted_response_alg IS 'key management algorithm of encrypted jwt authorization responses, empty only signs them';
 
 
 --
--- Data for Name: user; Type: TABLE DATA; Schema: public; Owner: postgres
+-- Name: COLUMN client.response_modes; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-COPY public."user" (username, password, nickname, given_name, family_name, middle_name, preferred_username, profile, picture, website, email, email_verified, gender, birthdate, zoneinfo, locale, phone_number, phone_number_verified, address, updated_at, namespace_id, id) FROM stdin;
-test	$argon2id$v=19$m=19456,t=2,p=1$Z0CCH0FfcFXsHnxDTfvXXQ$KqH1dzTda/0Mrj63scfybiTVGCjHxjmZHTfwMpRyOSc	test	test	test	test	test				test@email.com	f		2023-08-13				f		2023-08-13 10:33:13.160209+00	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f
-\.
+COMMENT ON COLUMN public.client.response_modes IS 'response modes the client may request, empty allows query, fragment and form_post';
 
 
 --
--- Name: auth_request auth_request_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+-- Name: COLUMN client.require_pushed_authorization_requests; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-ALTER TABLE ONLY public.auth_request
-    ADD CONSTRAINT auth_request_pkey PRIMARY KEY (id);
+COMMENT ON COLUMN public.client.require_pushed_authorization_requests IS 'the client must push its authorization requests to /par';
 
 
 --
--- Name: client client_new_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+-- Name: COLUMN client.resources; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-ALTER TABLE ONLY public.client
-    ADD CONSTRAINT client_new_pkey PRIMARY KEY (id_token_user_info_claims_assertion);
+COMMENT ON COLUMN public.client.resources IS 'identifiers of the api resources the client may request with the resource parameter';
 
 
 --
--- Name: code_request_id code_request_id_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+-- Name: COLUMN client.disallowed_scopes; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-ALTER TABLE ONLY public.code_request_id
-    ADD CONSTRAINT code_request_id_pkey PRIMARY KEY (code, request_id);
+COMMENT ON COLUMN public.client.disallowed_scopes IS 'downscope drops the scopes the client may not request, reject fails the request';
 
 
 --
--- Name: refresh_token refresh_token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+-- Name: COLUMN client.audiences; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-ALTER TABLE ONLY public.refresh_token
-    ADD CONSTRAINT refresh_token_pkey PRIMARY KEY (id);
+COMMENT ON COLUMN public.client.audiences IS 'audiences (APIs) the tokens of the client are for besides the client itself';
 
 
 --
--- Name: token token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+-- Name: COLUMN client.default_scopes; Type: COMMENT; Schema: public; Owner: postgres
 --
 
-ALTER TABLE ONLY public.token
-    ADD CONSTRAINT token_pkey PRIMARY KEY (id);
+COMMENT ON COLUMN public.client.default_scopes IS 'scopes added to every authorization request of the client';
+
+
+--
+-- Name: COLUMN client.allowed_scopes; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.allowed_scopes IS 'globs of the scopes besides openid, profile, email, phone, address and offline_access the client may request, empty allows the scopes of the user namespace';
+
+
+--
+-- Name: COLUMN client.refresh_tokens; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.refresh_tokens IS 'enabled, disabled, or offline_access to issue refresh tokens only for that scope; empty uses the token policy of the user namespace';
+
+
+--
+-- Name: COLUMN client.refresh_token_idle_lifetime; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.refresh_token_idle_lifetime IS 'refresh tokens unused for so long expire, 0 uses the token policy of the user namespace';
+
+
+--
+-- Name: COLUMN client.refresh_token_lifetime; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.refresh_token_lifetime IS 'absolute lifetime of refresh tokens, 0 uses the token policy of the user namespace';
+
+
+--
+-- Name: COLUMN client.id_token_lifetime; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.id_token_lifetime IS 'lifetime of id tokens, 0 uses the token policy of the user namespace';
+
+
+--
+-- Name: COLUMN client.access_token_lifetime; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.access_token_lifetime IS 'lifetime of access tokens, 0 uses the token policy of the user namespace';
+
+
+--
+-- Name: COLUMN client.registration_metadata; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.registration_metadata IS 'metadata of dynamically registered clients without an own column, like logo_uri or contacts';
+
+
+--
+-- Name: COLUMN client.registration_token_hash; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.registration_token_hash IS 'sha256 of the registration access token of dynamically registered clients';
+
+
+--
+-- Name: COLUMN client.application_type; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.application_type IS '0: web
+1: user_agent
+2: native';
+
+
+--
+-- Name: COLUMN client.auth_method; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.auth_method IS 'client_secret_basic
+client_secret_post
+none
+private_key_jwt';
+
+
+--
+-- Name: COLUMN client.access_token_type; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client.access_token_type IS '0: bearer
+1:jwt';
+
+
+--
+-- Name: client_registration_policy; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.client_registration_policy (
+    namespace_id uuid NOT NULL,
+    enabled boolean DEFAULT false NOT NULL,
+    require_initial_access_token boolean DEFAULT true NOT NULL,
+    require_software_statement boolean DEFAULT false NOT NULL,
+    software_statement_jwks text DEFAULT ''::text NOT NULL,
+    grant_types character varying[] DEFAULT '{}'::character varying[] NOT NULL,
+    redirect_uri_globs text[] DEFAULT '{}'::text[] NOT NULL
+);
+
+
+ALTER TABLE public.client_registration_policy OWNER TO postgres;
+
+--
+-- Name: COLUMN client_registration_policy.software_statement_jwks; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client_registration_policy.software_statement_jwks IS 'JSON web key set of the issuers of accepted software statements';
+
+
+--
+-- Name: COLUMN client_registration_policy.grant_types; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client_registration_policy.grant_types IS 'if not empty, registered clients may only use these grant types';
+
+
+--
+-- Name: COLUMN client_registration_policy.redirect_uri_globs; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.client_registration_policy.redirect_uri_globs IS 'if not empty, redirect uris of registered clients must match one of these';
+
+
+--
+-- Name: code_request_id; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.code_request_id (
+    code character varying(256) NOT NULL,
+    request_id uuid NOT NULL,
+    create_time timestamp(3) with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.code_request_id OWNER TO postgres;
+
+--
+-- Name: consent; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.consent (
+    user_id uuid NOT NULL,
+    client_id uuid NOT NULL,
+    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    update_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.consent OWNER TO postgres;
+
+--
+-- Name: COLUMN consent.scopes; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.consent.scopes IS 'all scopes the user granted to the client so far';
+
+
+--
+-- Name: directory; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.directory (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    namespace_id uuid NOT NULL,
+    name character varying(200) DEFAULT ''::character varying NOT NULL,
+    kind character varying(40) DEFAULT 'ldap'::character varying NOT NULL,
+    priority integer DEFAULT 0 NOT NULL,
+    url text DEFAULT ''::text NOT NULL,
+    start_tls boolean DEFAULT false NOT NULL,
+    insecure_skip_verify boolean DEFAULT false NOT NULL,
+    root_ca text DEFAULT ''::text NOT NULL,
+    bind_dn text DEFAULT ''::text NOT NULL,
+    bind_password text DEFAULT ''::text NOT NULL,
+    base_dn text DEFAULT ''::text NOT NULL,
+    user_filter text DEFAULT ''::text NOT NULL,
+    group_base_dn text DEFAULT ''::text NOT NULL,
+    group_filter text DEFAULT ''::text NOT NULL,
+    group_name_attribute character varying(100) DEFAULT ''::character varying NOT NULL,
+    attribute_mapping text DEFAULT ''::text NOT NULL,
+    sync_users boolean DEFAULT false NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.directory OWNER TO postgres;
+
+--
+-- Name: COLUMN directory.kind; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.directory.kind IS 'ldap or active_directory';
+
+
+--
+-- Name: COLUMN directory.attribute_mapping; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.directory.attribute_mapping IS 'JSON object of claims to directory attributes, empty for the defaults of the kind';
+
+
+--
+-- Name: COLUMN directory.sync_users; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.directory.sync_users IS 'create unknown users at their first login and update their profile at every login';
+
+
+--
+-- Name: dpop_proof; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.dpop_proof (
+    jti text NOT NULL,
+    expire_time timestamp with time zone NOT NULL
+);
+
+
+ALTER TABLE public.dpop_proof OWNER TO postgres;
+
+--
+-- Name: COLUMN dpop_proof.jti; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.dpop_proof.jti IS 'the jkt and jti of a used proof';
+
+
+--
+-- Name: COLUMN dpop_proof.expire_time; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.dpop_proof.expire_time IS 'the proof is not accepted anymore afterwards';
+
+
+--
+-- Name: identity_provider; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.identity_provider (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    namespace_id uuid NOT NULL,
+    name character varying(200) DEFAULT ''::character varying NOT NULL,
+    issuer text DEFAULT ''::text NOT NULL,
+    client_id character varying(500) DEFAULT ''::character varying NOT NULL,
+    client_secret character varying(500) DEFAULT ''::character varying NOT NULL,
+    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    claim_mapping text DEFAULT ''::text NOT NULL,
+    jit_provisioning boolean DEFAULT false NOT NULL,
+    link_by_email boolean DEFAULT false NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.identity_provider OWNER TO postgres;
+
+--
+-- Name: COLUMN identity_provider.claim_mapping; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.identity_provider.claim_mapping IS 'JSON object of user fields to templates of upstream claims, empty for the standard claims';
+
+
+--
+-- Name: initial_access_token; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.initial_access_token (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    namespace_id uuid NOT NULL,
+    token_hash character varying(100) DEFAULT ''::character varying NOT NULL,
+    description character varying(200) DEFAULT ''::character varying NOT NULL,
+    expire_time timestamp with time zone,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    last_used_time timestamp with time zone
+);
+
+
+ALTER TABLE public.initial_access_token OWNER TO postgres;
+
+--
+-- Name: login_failure; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.login_failure (
+    key character varying(300) NOT NULL,
+    failures integer DEFAULT 0 NOT NULL,
+    last_failure_time timestamp with time zone DEFAULT now() NOT NULL,
+    locked_until timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.login_failure OWNER TO postgres;
+
+--
+-- Name: COLUMN login_failure.key; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.login_failure.key IS 'user:<namespace>:<username> or ip:<address>';
+
+
+--
+-- Name: COLUMN login_failure.locked_until; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.login_failure.locked_until IS 'no login attempt is checked before this time';
+
+
+--
+-- Name: namespace; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.namespace (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    name character varying(200) DEFAULT ''::character varying NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.namespace OWNER TO postgres;
+
+--
+-- Name: password_history; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.password_history (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    password text NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.password_history OWNER TO postgres;
+
+--
+-- Name: password_policy; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.password_policy (
+    namespace_id uuid NOT NULL,
+    min_length integer DEFAULT 8 NOT NULL,
+    require_upper boolean DEFAULT false NOT NULL,
+    require_lower boolean DEFAULT false NOT NULL,
+    require_digit boolean DEFAULT false NOT NULL,
+    require_symbol boolean DEFAULT false NOT NULL,
+    max_age_days integer DEFAULT 0 NOT NULL,
+    history integer DEFAULT 0 NOT NULL,
+    check_breached boolean DEFAULT true NOT NULL
+);
+
+
+ALTER TABLE public.password_policy OWNER TO postgres;
+
+--
+-- Name: COLUMN password_policy.max_age_days; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.password_policy.max_age_days IS 'passwords older than this must be changed, 0 never expires';
+
+
+--
+-- Name: COLUMN password_policy.history; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.password_policy.history IS 'number of last passwords that must not be reused';
+
+
+--
+-- Name: password_reset; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.password_reset (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    expiration timestamp with time zone NOT NULL
+);
+
+
+ALTER TABLE public.password_reset OWNER TO postgres;
+
+--
+-- Name: rate_limit_counter; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.rate_limit_counter (
+    key character varying(300) NOT NULL,
+    count bigint DEFAULT 0 NOT NULL,
+    reset_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.rate_limit_counter OWNER TO postgres;
+
+--
+-- Name: COLUMN rate_limit_counter.reset_time; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.rate_limit_counter.reset_time IS 'end of the current window, the count restarts afterwards';
+
+
+--
+-- Name: refresh_token; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.refresh_token (
+    id character varying(200) DEFAULT ''::character varying NOT NULL,
+    token text DEFAULT ''::text NOT NULL,
+    auth_time timestamp(3) without time zone DEFAULT now() NOT NULL,
+    amr character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    audience character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    user_id character varying(200) DEFAULT ''::character varying NOT NULL,
+    application_id character varying(200) DEFAULT ''::character varying NOT NULL,
+    expiration timestamp(3) without time zone DEFAULT now() NOT NULL,
+    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    absolute_expiration timestamp(3) without time zone DEFAULT now() NOT NULL,
+    jkt character varying(100) DEFAULT ''::character varying NOT NULL,
+    authorization_details text DEFAULT ''::text NOT NULL,
+    session_id character varying(200) DEFAULT ''::character varying NOT NULL
+);
+
+
+ALTER TABLE public.refresh_token OWNER TO postgres;
+
+--
+-- Name: COLUMN refresh_token.session_id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.refresh_token.session_id IS 'user_session of the grant, the sid of its id tokens, empty for none';
+
+
+--
+-- Name: COLUMN refresh_token.authorization_details; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.refresh_token.authorization_details IS 'authorization_details of the grant as JSON array, empty for none';
+
+
+--
+-- Name: COLUMN refresh_token.jkt; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.refresh_token.jkt IS 'JWK thumbprint of the DPoP key the token is bound to, empty if not bound';
+
+
+--
+-- Name: COLUMN refresh_token.absolute_expiration; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.refresh_token.absolute_expiration IS 'expiration of the refresh token chain, renewals keep it while expiration moves with the idle lifetime';
+
+
+--
+-- Name: registration_policy; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.registration_policy (
+    namespace_id uuid NOT NULL,
+    enabled boolean DEFAULT false NOT NULL,
+    required_fields character varying(40)[] DEFAULT '{}'::character varying[] NOT NULL,
+    email_allow_domains character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    email_deny_domains character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    require_email_verification boolean DEFAULT false NOT NULL,
+    require_approval boolean DEFAULT false NOT NULL,
+    email_verification_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.registration_policy OWNER TO postgres;
+
+--
+-- Name: COLUMN registration_policy.email_verification_time; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.registration_policy.email_verification_time IS 'when require_email_verification was turned on, users created before need not verify their email';
+
+
+--
+-- Name: COLUMN registration_policy.required_fields; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.registration_policy.required_fields IS 'profile fields a new user must fill in: email, phone_number, given_name, family_name, nickname';
+
+
+--
+-- Name: COLUMN registration_policy.email_allow_domains; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.registration_policy.email_allow_domains IS 'if not empty, only emails of these domains may register';
+
+
+--
+-- Name: scim_token; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.scim_token (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    client_id uuid NOT NULL,
+    token_hash character varying(100) DEFAULT ''::character varying NOT NULL,
+    description character varying(200) DEFAULT ''::character varying NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    last_used_time timestamp with time zone
+);
+
+
+ALTER TABLE public.scim_token OWNER TO postgres;
+
+--
+-- Name: COLUMN scim_token.token_hash; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.scim_token.token_hash IS 'hex SHA-256 of the bearer token, the token itself is shown once';
+
+
+--
+-- Name: scope; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.scope (
+    namespace_id uuid NOT NULL,
+    name character varying(200) NOT NULL,
+    description text DEFAULT ''::text NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.scope OWNER TO postgres;
+
+--
+-- Name: COLUMN scope.name; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.scope.name IS 'scope value clients of the namespace may request';
+
+
+--
+-- Name: token; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.token (
+    id character varying(200) DEFAULT ''::character varying NOT NULL,
+    audience character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    expiration timestamp(3) without time zone DEFAULT now() NOT NULL,
+    scopes character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    application_id uuid DEFAULT gen_random_uuid() NOT NULL,
+    subject uuid DEFAULT gen_random_uuid() NOT NULL,
+    refresh_token_id uuid DEFAULT gen_random_uuid() NOT NULL,
+    jkt character varying(100) DEFAULT ''::character varying NOT NULL,
+    x5t_s256 character varying(100) DEFAULT ''::character varying NOT NULL,
+    authorization_details text DEFAULT ''::text NOT NULL
+);
+
+
+ALTER TABLE public.token OWNER TO postgres;
+
+--
+-- Name: COLUMN token.authorization_details; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.token.authorization_details IS 'authorization_details the token was issued for as JSON array, empty for none';
+
+
+--
+-- Name: COLUMN token.x5t_s256; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.token.x5t_s256 IS 'SHA-256 thumbprint of the client certificate the token is bound to, empty if not bound';
+
+
+--
+-- Name: COLUMN token.jkt; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.token.jkt IS 'JWK thumbprint of the DPoP key the token is bound to, empty for bearer tokens';
+
+
+--
+-- Name: token_policy; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.token_policy (
+    namespace_id uuid NOT NULL,
+    access_token_lifetime interval(6) DEFAULT '00:05:00'::interval(6) NOT NULL,
+    id_token_lifetime interval(6) DEFAULT '01:00:00'::interval(6) NOT NULL,
+    refresh_token_lifetime interval(6) DEFAULT '05:00:00'::interval(6) NOT NULL,
+    refresh_token_idle_lifetime interval(6) DEFAULT '00:00:00'::interval(6) NOT NULL,
+    refresh_tokens character varying(40) DEFAULT 'enabled'::character varying NOT NULL
+);
+
+
+ALTER TABLE public.token_policy OWNER TO postgres;
+
+--
+-- Name: COLUMN token_policy.refresh_token_idle_lifetime; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.token_policy.refresh_token_idle_lifetime IS 'refresh tokens unused for so long expire, 0 only the absolute lifetime applies';
+
+
+--
+-- Name: COLUMN token_policy.refresh_tokens; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.token_policy.refresh_tokens IS 'enabled, disabled, or offline_access to issue refresh tokens only for that scope';
+
+
+--
+-- Name: user; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public."user" (
+    username character varying(200) DEFAULT ''::character varying NOT NULL,
+    password text DEFAULT ''::text NOT NULL,
+    nickname character varying(200) DEFAULT ''::character varying NOT NULL,
+    given_name character varying(200) DEFAULT ''::character varying NOT NULL,
+    family_name character varying(200) DEFAULT ''::character varying NOT NULL,
+    middle_name character varying(200) DEFAULT ''::character varying NOT NULL,
+    preferred_username character varying(200) DEFAULT ''::character varying NOT NULL,
+    profile text DEFAULT ''::text NOT NULL,
+    picture text DEFAULT ''::text NOT NULL,
+    website text DEFAULT ''::text NOT NULL,
+    email character varying(200) DEFAULT ''::character varying NOT NULL,
+    email_verified boolean DEFAULT false NOT NULL,
+    gender character varying(40) DEFAULT ''::character varying NOT NULL,
+    birthdate date DEFAULT now() NOT NULL,
+    zoneinfo character varying(40) DEFAULT ''::character varying NOT NULL,
+    locale character varying(60) DEFAULT ''::character varying NOT NULL,
+    phone_number character varying(100) DEFAULT ''::character varying NOT NULL,
+    phone_number_verified boolean DEFAULT false NOT NULL,
+    address character varying(200) DEFAULT ''::character varying NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    namespace_id uuid DEFAULT '00000000-0000-0000-0000-000000000000'::uuid NOT NULL,
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    password_change_time timestamp with time zone DEFAULT now() NOT NULL,
+    status character varying(20) DEFAULT 'active'::character varying NOT NULL,
+    directory_id uuid,
+    groups character varying(200)[] DEFAULT '{}'::character varying[] NOT NULL,
+    external_id character varying(500) DEFAULT ''::character varying NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    is_admin boolean DEFAULT false NOT NULL
+);
+
+
+ALTER TABLE public."user" OWNER TO postgres;
+
+--
+-- Name: COLUMN user.is_admin; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public."user".is_admin IS 'admin declared by the bootstrap file';
+
+
+--
+-- Name: COLUMN user.external_id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public."user".external_id IS 'id of the user in the provisioning system';
+
+
+--
+-- Name: COLUMN user.directory_id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public."user".directory_id IS 'the directory checking the password of the user, null for local users';
+
+
+--
+-- Name: COLUMN user.status; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public."user".status IS 'active, or pending_approval for self registered users waiting for an admin';
+
+
+--
+-- Name: user_group; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_group (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    namespace_id uuid NOT NULL,
+    display_name character varying(200) DEFAULT ''::character varying NOT NULL,
+    external_id character varying(500) DEFAULT ''::character varying NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    update_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.user_group OWNER TO postgres;
+
+--
+-- Name: COLUMN user_group.display_name; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.user_group.display_name IS 'name of the group in the groups claim';
+
+
+--
+-- Name: user_group_member; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_group_member (
+    group_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.user_group_member OWNER TO postgres;
+
+--
+-- Name: user_identity; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_identity (
+    provider_id uuid NOT NULL,
+    subject character varying(500) DEFAULT ''::character varying NOT NULL,
+    user_id uuid NOT NULL,
+    email character varying(200) DEFAULT ''::character varying NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    last_login_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.user_identity OWNER TO postgres;
+
+--
+-- Name: COLUMN user_identity.subject; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.user_identity.subject IS 'sub claim of the upstream provider';
+
+
+--
+-- Name: user_session; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_session (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    user_agent character varying(500) DEFAULT ''::character varying NOT NULL,
+    remote_ip character varying(100) DEFAULT ''::character varying NOT NULL,
+    amr character varying(40)[] DEFAULT '{}'::character varying[] NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    last_seen_time timestamp with time zone DEFAULT now() NOT NULL,
+    expiration timestamp with time zone NOT NULL
+);
+
+
+ALTER TABLE public.user_session OWNER TO postgres;
+
+--
+-- Name: COLUMN user_session.user_agent; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.user_session.user_agent IS 'browser of the sign in, shown to the user in the account portal';
+
+
+--
+-- Name: user_session_client; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_session_client (
+    session_id uuid NOT NULL,
+    client_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.user_session_client OWNER TO postgres;
+
+--
+-- Name: COLUMN user_session_client.client_id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.user_session_client.client_id IS 'client the user signed in to with the session, told when the session ends';
+
+
+--
+-- Name: verification; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.verification (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    kind character varying(20) NOT NULL,
+    address character varying(200) NOT NULL,
+    code character varying(100) DEFAULT ''::character varying NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    expiration timestamp with time zone NOT NULL
+);
+
+
+ALTER TABLE public.verification OWNER TO postgres;
+
+--
+-- Name: COLUMN verification.kind; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.verification.kind IS 'email or phone';
+
+
+--
+-- Name: COLUMN verification.address; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.verification.address IS 'the email address or phone number to verify, it must still be the current one on confirmation';
+
+
+--
+-- Name: COLUMN verification.code; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.verification.code IS 'sha256 of the one time code sent by sms';
+
+
+--
+-- Name: webauthn_credential; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.webauthn_credential (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    name character varying(200) DEFAULT ''::character varying NOT NULL,
+    credential_id bytea NOT NULL,
+    public_key bytea NOT NULL,
+    attestation_type character varying(100) DEFAULT ''::character varying NOT NULL,
+    transports character varying(40)[] DEFAULT '{}'::character varying[] NOT NULL,
+    aaguid bytea DEFAULT '\x'::bytea NOT NULL,
+    sign_count bigint DEFAULT 0 NOT NULL,
+    clone_warning boolean DEFAULT false NOT NULL,
+    attachment character varying(40) DEFAULT ''::character varying NOT NULL,
+    user_verified boolean DEFAULT false NOT NULL,
+    backup_eligible boolean DEFAULT false NOT NULL,
+    backup_state boolean DEFAULT false NOT NULL,
+    create_time timestamp with time zone DEFAULT now() NOT NULL,
+    last_used_time timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.webauthn_credential OWNER TO postgres;
+
+--
+-- Name: COLUMN webauthn_credential.backup_eligible; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN public.webauthn_credential.backup_eligible IS 'passkeys that may be synced between devices, reported as amr swk instead of hwk';
+
+
+--
+-- Name: webauthn_session; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.webauthn_session (
+    id character varying(200) NOT NULL,
+    data text DEFAULT ''::text NOT NULL,
+    expiration timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.webauthn_session OWNER TO postgres;
+
+--
+-- Data for Name: api_resource; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.api_resource (id, namespace_id, identifier, name, scopes, access_token_type, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: auth_request; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.auth_request (id, creation_date, done, auth_time, content, namespace_id, user_id, amr, audience, resources, request_uri, authorization_details, details_approved, session_id) FROM stdin;
+30fe0ae9-d940-4d2a-a4d8-8c539622104e	2023-11-26 07:06:05.95332+00	f	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"64a85d42-e863-4407-a923-5af760bec3a2","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	00000000-0000-0000-0000-000000000000	{}	{}	{}			f	00000000-0000-0000-0000-000000000000
+5f141e2c-4bfb-449f-b082-21752c4080f9	2023-12-02 09:36:45.410367+00	t	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"e37f7a11-c7a7-47b5-85d9-adcde28bd31a","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f	{}	{}	{}			f	00000000-0000-0000-0000-000000000000
+7537efeb-31d8-41f6-a92f-c9f1567cc347	2023-11-26 06:53:34.610723+00	t	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"e0dc027f-7422-4ce0-94c6-482015208e83","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f	{}	{}	{}			f	00000000-0000-0000-0000-000000000000
+f8e80ace-06e0-4b73-9a20-e7f873a588f7	2023-12-02 11:20:43.741457+00	t	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"3324b9ad-bafc-4880-b294-f797dd706b8d","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f	{}	{}	{}			f	00000000-0000-0000-0000-000000000000
+22a55ec0-0171-44f8-85b5-99bdfcfc9318	2023-12-02 09:45:47.652939+00	f	0001-01-01 00:00:00+00	{"scope":"openid profile","response_type":"code","client_id":"674fc25c-7772-45e3-835d-3b77b16a2937","redirect_uri":"http://localhost:9999/auth/callback","state":"4d678a56-c938-425f-9a50-3287e8728ee5","nonce":"","response_mode":"","display":"","prompt":"Welcome back!","max_age":null,"ui_locales":null,"id_token_hint":"","login_hint":"","acr_values":"","code_challenge":"","code_challenge_method":"","RequestParam":""}	00000000-0000-0000-0000-000000000000	00000000-0000-0000-0000-000000000000	{}	{}	{}			f	00000000-0000-0000-0000-000000000000
+\.
+
+
+--
+-- Data for Name: authorization_details_type; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.authorization_details_type (id, namespace_id, type, description, schema, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: backchannel_auth_request; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.backchannel_auth_request (id, client_id, user_id, scopes, binding_message, client_notification_token, status, amr, auth_time, poll_interval, last_poll_time, expiration, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: backchannel_logout; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.backchannel_logout (id, client_id, user_id, session_id, attempts, next_attempt_time, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: client; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.client (id, secret, redirect_uris, application_type, auth_method, response_types, access_token_type, dev_mode, id_token_user_info_claims_assertion, clock_skew, post_logout_redirect_uri_globs, redirect_uri_globs, user_namespace_id, grant_types, name, create_time, registration_token_hash, registration_metadata, access_token_lifetime, id_token_lifetime, refresh_token_lifetime, refresh_token_idle_lifetime, refresh_tokens, allowed_scopes, default_scopes, audiences, disallowed_scopes, resources, require_pushed_authorization_requests, response_modes, authorization_encrypted_response_alg, authorization_encrypted_response_enc, jwks, dpop_bound_access_tokens, tls_client_auth_subject_dn, tls_client_auth_san_dns, tls_client_auth_san_uri, tls_client_auth_san_ip, tls_client_auth_san_email, tls_client_certificate_bound_access_tokens, backchannel_token_delivery_mode, backchannel_client_notification_endpoint, frontchannel_logout_uri, frontchannel_logout_session_required, backchannel_logout_uri, backchannel_logout_session_required) FROM stdin;
+674fc25c-7772-45e3-835d-3b77b16a2937	123456	{custom://auth/callback,http://localhost:9999/auth/callback,http://localhost/auth/callback}	0	client_secret_basic	{code}	0	t	t	01:05:00	{}	{}	00000000-0000-0000-0000-000000000000	{authorization_code,refresh_token,urn:ietf:params:oauth:grant-type:token-exchange}		2023-11-26 00:00:00+00		{}	00:00:00	00:00:00	00:00:00	00:00:00		{custom_scope,custom_scope:impersonate:*}	{}	{}	downscope	{}	false	{}				f						f				f		f
+\.
+
+
+--
+-- Data for Name: client_registration_policy; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.client_registration_policy (namespace_id, enabled, require_initial_access_token, require_software_statement, software_statement_jwks, grant_types, redirect_uri_globs) FROM stdin;
+\.
+
+
+--
+-- Data for Name: code_request_id; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.code_request_id (code, request_id, create_time) FROM stdin;
+s2o3ld5MBb9AaB3vKlWPDo9EnfoGwgqMgHhwEnzCovgpZ9rqgQMFhr44W4Hh-lOFsiVfUw	5f141e2c-4bfb-449f-b082-21752c4080f9	2023-12-02 09:36:50.965+00
+r2dx_0-YIsxVCD7uNisPnKbQtMCfzu89tqIGLLu2QsdnQaGq0qDs0WDWJCoQKpUORGcPNg	f8e80ace-06e0-4b73-9a20-e7f873a588f7	2023-12-02 11:20:52.255+00
+\.
+
+
+--
+-- Data for Name: consent; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.consent (user_id, client_id, scopes, create_time, update_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: directory; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.directory (id, namespace_id, name, kind, priority, url, start_tls, insecure_skip_verify, root_ca, bind_dn, bind_password, base_dn, user_filter, group_base_dn, group_filter, group_name_attribute, attribute_mapping, sync_users, enabled, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: dpop_proof; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.dpop_proof (jti, expire_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: identity_provider; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.identity_provider (id, namespace_id, name, issuer, client_id, client_secret, scopes, claim_mapping, jit_provisioning, link_by_email, enabled, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: initial_access_token; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.initial_access_token (id, namespace_id, token_hash, description, expire_time, create_time, last_used_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: login_failure; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.login_failure (key, failures, last_failure_time, locked_until) FROM stdin;
+\.
+
+
+--
+-- Data for Name: namespace; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.namespace (id, name, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: password_history; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.password_history (id, user_id, password, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: password_policy; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.password_policy (namespace_id, min_length, require_upper, require_lower, require_digit, require_symbol, max_age_days, history, check_breached) FROM stdin;
+\.
+
+
+--
+-- Data for Name: password_reset; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.password_reset (id, user_id, create_time, expiration) FROM stdin;
+\.
+
+
+--
+-- Data for Name: rate_limit_counter; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.rate_limit_counter (key, count, reset_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: refresh_token; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.refresh_token (id, token, auth_time, amr, audience, user_id, application_id, expiration, scopes, absolute_expiration, jkt, authorization_details, session_id) FROM stdin;
+\.
+
+
+--
+-- Data for Name: registration_policy; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.registration_policy (namespace_id, enabled, required_fields, email_allow_domains, email_deny_domains, require_email_verification, require_approval, email_verification_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: scim_token; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.scim_token (id, client_id, token_hash, description, create_time, last_used_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: scope; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.scope (namespace_id, name, description, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: token; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.token (id, audience, expiration, scopes, application_id, subject, refresh_token_id, jkt, x5t_s256, authorization_details) FROM stdin;
+78488baa-54f7-464e-ac64-a953d5cb182c	{674fc25c-7772-45e3-835d-3b77b16a2937}	2023-12-02 19:28:54.796	{openid,profile}	674fc25c-7772-45e3-835d-3b77b16a2937	744d9044-f29d-42e8-a65e-e6c52398fa1f	00000000-0000-0000-0000-000000000000			
+\.
+
+
+--
+-- Data for Name: token_policy; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.token_policy (namespace_id, access_token_lifetime, id_token_lifetime, refresh_token_lifetime, refresh_token_idle_lifetime, refresh_tokens) FROM stdin;
+\.
+
+
+--
+-- Data for Name: user; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public."user" (username, password, nickname, given_name, family_name, middle_name, preferred_username, profile, picture, website, email, email_verified, gender, birthdate, zoneinfo, locale, phone_number, phone_number_verified, address, updated_at, namespace_id, id, password_change_time, status, directory_id, groups, external_id, create_time, is_admin) FROM stdin;
+test	$argon2id$v=19$m=19456,t=2,p=1$Z0CCH0FfcFXsHnxDTfvXXQ$KqH1dzTda/0Mrj63scfybiTVGCjHxjmZHTfwMpRyOSc	test	test	test	test	test				test@email.com	f		2023-08-13				f		2023-08-13 10:33:13.160209+00	00000000-0000-0000-0000-000000000000	744d9044-f29d-42e8-a65e-e6c52398fa1f	2023-11-26 07:00:00+00	active	\N	{}		2023-08-13 10:33:13.160209+00	f
+\.
+
+
+--
+-- Data for Name: user_group; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.user_group (id, namespace_id, display_name, external_id, create_time, update_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: user_group_member; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.user_group_member (group_id, user_id, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: user_identity; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.user_identity (provider_id, subject, user_id, email, create_time, last_login_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: user_session; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.user_session (id, user_id, user_agent, remote_ip, amr, create_time, last_seen_time, expiration) FROM stdin;
+\.
+
+
+--
+-- Data for Name: user_session_client; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.user_session_client (session_id, client_id, user_id, create_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: verification; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.verification (id, user_id, kind, address, code, attempts, expiration) FROM stdin;
+\.
+
+
+--
+-- Data for Name: webauthn_credential; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.webauthn_credential (id, user_id, name, credential_id, public_key, attestation_type, transports, aaguid, sign_count, clone_warning, attachment, user_verified, backup_eligible, backup_state, create_time, last_used_time) FROM stdin;
+\.
+
+
+--
+-- Data for Name: webauthn_session; Type: TABLE DATA; Schema: public; Owner: postgres
+--
+
+COPY public.webauthn_session (id, data, expiration) FROM stdin;
+\.
+
+
+--
+-- Name: api_resource api_resource_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.api_resource
+    ADD CONSTRAINT api_resource_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: auth_request auth_request_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.auth_request
+    ADD CONSTRAINT auth_request_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: authorization_details_type authorization_details_type_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.authorization_details_type
+    ADD CONSTRAINT authorization_details_type_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: backchannel_auth_request backchannel_auth_request_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.backchannel_auth_request
+    ADD CONSTRAINT backchannel_auth_request_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: backchannel_logout backchannel_logout_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.backchannel_logout
+    ADD CONSTRAINT backchannel_logout_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: client client_new_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.client
+    ADD CONSTRAINT client_new_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: client_registration_policy client_registration_policy_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.client_registration_policy
+    ADD CONSTRAINT client_registration_policy_pkey PRIMARY KEY (namespace_id);
+
+
+--
+-- Name: code_request_id code_request_id_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.code_request_id
+    ADD CONSTRAINT code_request_id_pkey PRIMARY KEY (code, request_id);
+
+
+--
+-- Name: consent consent_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.consent
+    ADD CONSTRAINT consent_pkey PRIMARY KEY (user_id, client_id);
+
+
+--
+-- Name: directory directory_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.directory
+    ADD CONSTRAINT directory_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: dpop_proof dpop_proof_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.dpop_proof
+    ADD CONSTRAINT dpop_proof_pkey PRIMARY KEY (jti);
+
+
+--
+-- Name: identity_provider identity_provider_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.identity_provider
+    ADD CONSTRAINT identity_provider_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: initial_access_token initial_access_token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.initial_access_token
+    ADD CONSTRAINT initial_access_token_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: login_failure login_failure_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.login_failure
+    ADD CONSTRAINT login_failure_pkey PRIMARY KEY (key);
+
+
+--
+-- Name: namespace namespace_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.namespace
+    ADD CONSTRAINT namespace_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: password_history password_history_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.password_history
+    ADD CONSTRAINT password_history_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: password_policy password_policy_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.password_policy
+    ADD CONSTRAINT password_policy_pkey PRIMARY KEY (namespace_id);
+
+
+--
+-- Name: password_reset password_reset_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.password_reset
+    ADD CONSTRAINT password_reset_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: rate_limit_counter rate_limit_counter_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.rate_limit_counter
+    ADD CONSTRAINT rate_limit_counter_pkey PRIMARY KEY (key);
+
+
+--
+-- Name: refresh_token refresh_token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.refresh_token
+    ADD CONSTRAINT refresh_token_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: registration_policy registration_policy_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.registration_policy
+    ADD CONSTRAINT registration_policy_pkey PRIMARY KEY (namespace_id);
+
+
+--
+-- Name: scim_token scim_token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.scim_token
+    ADD CONSTRAINT scim_token_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: scope scope_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.scope
+    ADD CONSTRAINT scope_pkey PRIMARY KEY (namespace_id, name);
+
+
+--
+-- Name: token token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.token
+    ADD CONSTRAINT token_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: token_policy token_policy_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.token_policy
+    ADD CONSTRAINT token_policy_pkey PRIMARY KEY (namespace_id);
+
+
+--
+-- Name: user_group user_group_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_group
+    ADD CONSTRAINT user_group_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: user_group_member user_group_member_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_group_member
+    ADD CONSTRAINT user_group_member_pkey PRIMARY KEY (group_id, user_id);
+
+
+--
+-- Name: user_identity user_identity_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_identity
+    ADD CONSTRAINT user_identity_pkey PRIMARY KEY (provider_id, subject);
+
+
+--
+-- Name: user_session user_session_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_session
+    ADD CONSTRAINT user_session_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: user_session_client user_session_client_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_session_client
+    ADD CONSTRAINT user_session_client_pkey PRIMARY KEY (session_id, client_id);
+
+
+--
+-- Name: verification verification_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.verification
+    ADD CONSTRAINT verification_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: webauthn_credential webauthn_credential_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.webauthn_credential
+    ADD CONSTRAINT webauthn_credential_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: webauthn_session webauthn_session_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.webauthn_session
+    ADD CONSTRAINT webauthn_session_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: api_resource_identifier_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX api_resource_identifier_idx ON public.api_resource USING btree (namespace_id, identifier);
+
+
+--
+-- Name: auth_request_request_uri_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX auth_request_request_uri_idx ON public.auth_request USING btree (request_uri) WHERE ((request_uri)::text <> ''::text);
+
+
+--
+-- Name: authorization_details_type_type_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX authorization_details_type_type_idx ON public.authorization_details_type USING btree (namespace_id, type);
+
+
+--
+-- Name: backchannel_logout_next_attempt_time_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX backchannel_logout_next_attempt_time_idx ON public.backchannel_logout USING btree (next_attempt_time);
+
+
+--
+-- Name: directory_namespace_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX directory_namespace_id_idx ON public.directory USING btree (namespace_id);
+
+
+--
+-- Name: dpop_proof_expire_time_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX dpop_proof_expire_time_idx ON public.dpop_proof USING btree (expire_time);
+
+
+--
+-- Name: identity_provider_namespace_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX identity_provider_namespace_id_idx ON public.identity_provider USING btree (namespace_id);
+
+
+--
+-- Name: initial_access_token_token_hash_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX initial_access_token_token_hash_idx ON public.initial_access_token USING btree (token_hash);
+
+
+--
+-- Name: namespace_name_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX namespace_name_idx ON public.namespace USING btree (name);
+
+
+--
+-- Name: password_history_user_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX password_history_user_id_idx ON public.password_history USING btree (user_id, create_time);
+
+
+--
+-- Name: password_reset_user_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX password_reset_user_id_idx ON public.password_reset USING btree (user_id);
+
+
+--
+-- Name: scim_token_token_hash_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX scim_token_token_hash_idx ON public.scim_token USING btree (token_hash);
+
+
+--
+-- Name: user_group_member_user_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX user_group_member_user_id_idx ON public.user_group_member USING btree (user_id);
+
+
+--
+-- Name: user_group_namespace_id_display_name_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX user_group_namespace_id_display_name_idx ON public.user_group USING btree (namespace_id, display_name);
+
+
+--
+-- Name: user_identity_user_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX user_identity_user_id_idx ON public.user_identity USING btree (user_id);
+
+
+--
+-- Name: user_namespace_id_username_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX user_namespace_id_username_idx ON public."user" USING btree (namespace_id, username);
+
+
+--
+-- Name: user_session_client_client_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX user_session_client_client_id_idx ON public.user_session_client USING btree (client_id);
+
+
+--
+-- Name: user_session_user_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX user_session_user_id_idx ON public.user_session USING btree (user_id);
+
+
+--
+-- Name: verification_user_id_kind_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX verification_user_id_kind_idx ON public.verification USING btree (user_id, kind);
+
+
+--
+-- Name: webauthn_credential_user_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX webauthn_credential_user_id_idx ON public.webauthn_credential USING btree (user_id);
+
+
+--
+-- Name: webauthn_credential_credential_id_idx; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE UNIQUE INDEX webauthn_credential_credential_id_idx ON public.webauthn_credential USING btree (credential_id);
 
 
 --